@@ -1,7 +1,5 @@
 package config
 
-import "os"
-
 var WAAPIQRLogin string = "https://api.wa.my.id/api/whatsauth/request"
 
 var WAAPIMessage string = "https://api.wa.my.id/api/v2/send/message/text"
@@ -24,8 +22,4 @@ var WAAPIToken string
 
 var APIGETPDLMS string = "https://pamongdesa.kemendagri.go.id/webservice/public/user/get-by-phone?number="
 
-var APITOKENPD string = os.Getenv("PDTOKEN")
-
-var PRIVATEKEY string = "e4cb06d20bcce42bf4ac16c9b056bfaf1c6a5168c24692b38eb46d551777dc4147db091df55d64499fdf2ca85504ac4d320c4c645c9bef75efac0494314cae94"
-
-var PUBLICKEY string = "47db091df55d64499fdf2ca85504ac4d320c4c645c9bef75efac0494314cae94"
+var MidtransAPI string = "https://api.midtrans.com"
@@ -3,6 +3,8 @@ package config
 import (
 	"log"
 	"os"
+	"sync"
+	"time"
 
 	"github.com/gocroot/helper/at"
 	"github.com/gocroot/helper/atdb"
@@ -10,8 +12,6 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
-var PrivateKey string = os.Getenv("PRKEY")
-
 var AESKey string = os.Getenv("AESKEY")
 
 var IPPort, Net = at.GetAddress()
@@ -20,16 +20,36 @@ var PhoneNumber string = os.Getenv("PHONENUMBER")
 
 var Profile itmodel.Profile
 
+const envReload = time.Minute
+
+var (
+	envMu     sync.Mutex
+	envLoaded time.Time
+)
+
+// SetEnv memuat profile whatsauth, keyring token dan secret. Dipanggil middleware di setiap request,
+// tapi profile hanya dibaca ulang dari Mongo setiap envReload dan nilai lama tetap dipakai jika pembacaan gagal.
 func SetEnv() {
 	if ErrorMongoconn != nil {
 		log.Println(ErrorMongoconn.Error())
 	}
-	Profile, err := atdb.GetOneDoc[itmodel.Profile](Mongoconn, "profile", primitive.M{"phonenumber": PhoneNumber})
-	if err != nil {
-		log.Println(err)
+	envMu.Lock()
+	if time.Since(envLoaded) >= envReload {
+		prof, err := atdb.GetOneDoc[itmodel.Profile](Mongoconn, "profile", primitive.M{"phonenumber": PhoneNumber})
+		if err != nil {
+			log.Println(err)
+		} else {
+			Profile = prof
+			PublicKeyWhatsAuth = prof.PublicKey
+			WAAPIToken = prof.Token
+			envLoaded = time.Now()
+		}
+	}
+	envMu.Unlock()
+	if err := LoadTokenKeys(false); err != nil {
+		log.Println("gagal memuat kunci token: " + err.Error())
+	}
+	if err := LoadSecrets(false); err != nil {
+		log.Println("konfigurasi secret: " + err.Error())
 	}
-	PublicKeyWhatsAuth = Profile.PublicKey
-	WAAPIToken = Profile.Token
 }
-
-var GHAccessToken string = os.Getenv("GH_ACCESS_TOKEN")
@@ -0,0 +1,157 @@
+package config
+
+import (
+	"errors"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gocroot/helper/atdb"
+	"github.com/gocroot/helper/secrets"
+	"github.com/gocroot/helper/session"
+	"github.com/gocroot/helper/watoken"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// TokenKeyring kunci untuk sign dan verifikasi token login, diisi oleh LoadTokenKeys
+var TokenKeyring = watoken.NewKeyring()
+
+func init() {
+	TokenKeyring.SetRevocationList(sessionRevocation{})
+}
+
+// sessionRevocation daftar pencabutan token dari collection session dan revocation
+type sessionRevocation struct{}
+
+func (sessionRevocation) Revoked(id, sessionID string, issuedAt time.Time) (bool, error) {
+	if Mongoconn == nil {
+		return false, ErrorMongoconn
+	}
+	return session.IsRevoked(Mongoconn, id, sessionID, issuedAt)
+}
+
+// TokenKeyCollection collection penyimpanan kunci token yang bisa dirotasi
+const TokenKeyCollection = "tokenkey"
+
+// TokenKey dokumen kunci token di Mongo, hanya yang Active dipakai verifikasi dan satu yang Signing dipakai sign.
+// Private key disimpan terenkripsi AESKEY jika Encrypted, dokumen lama tanpa flag tetap dibaca apa adanya.
+type TokenKey struct {
+	watoken.Key `bson:",inline"`
+	Active      bool      `bson:"active" json:"active"`
+	Signing     bool      `bson:"signing" json:"signing"`
+	Encrypted   bool      `bson:"encrypted,omitempty" json:"encrypted,omitempty"`
+	CreatedAt   time.Time `bson:"createdat" json:"createdat"`
+}
+
+// SealTokenKey mengenkripsi private key dengan skema yang sama dengan secret di Mongo sebelum disimpan
+func SealTokenKey(key TokenKey) (TokenKey, error) {
+	if key.PrivateKey == "" || key.Encrypted {
+		return key, nil
+	}
+	sealed, err := secrets.Encrypt(key.PrivateKey, AESKey)
+	if err != nil {
+		return key, err
+	}
+	key.PrivateKey, key.Encrypted = sealed, true
+	return key, nil
+}
+
+// openTokenKey kebalikan SealTokenKey
+func openTokenKey(key TokenKey) (TokenKey, error) {
+	if !key.Encrypted {
+		return key, nil
+	}
+	plain, err := secrets.Decrypt(key.PrivateKey, AESKey)
+	if err != nil {
+		return key, err
+	}
+	key.PrivateKey, key.Encrypted = plain, false
+	return key, nil
+}
+
+// lama keyring dipakai sebelum dibaca ulang dari Mongo
+const tokenKeyReload = time.Minute
+
+var (
+	tokenKeyMu     sync.Mutex
+	tokenKeyLoaded time.Time
+	tokenKeyStored []TokenKey // hasil terakhir yang berhasil dibaca dari collection tokenkey
+)
+
+// LoadTokenKeys memuat ulang keyring dari env, public key whatsauth dan collection tokenkey.
+// Env TOKEN_KEYS berisi kid:publickey[:privatekey] dipisah koma, TOKEN_SIGNING_KID memaksa signing key tertentu,
+// dan PRKEY lama tetap dipakai dengan kid "prkey". Tanpa force, keyring hanya dibaca ulang setiap tokenKeyReload.
+// Jika collection tokenkey gagal dibaca, kunci dari pembacaan terakhir tetap dipakai dan pembacaan diulang di panggilan berikutnya.
+func LoadTokenKeys(force bool) error {
+	tokenKeyMu.Lock()
+	defer tokenKeyMu.Unlock()
+	if !force && time.Since(tokenKeyLoaded) < tokenKeyReload {
+		return nil
+	}
+	keys, err := envTokenKeys()
+	if err != nil {
+		return err
+	}
+	signing := os.Getenv("TOKEN_SIGNING_KID")
+	if PublicKeyWhatsAuth != "" {
+		keys = append(keys, watoken.Key{ID: "whatsauth", PublicKey: PublicKeyWhatsAuth})
+	}
+	stored, err := atdb.GetAllDoc[[]TokenKey](Mongoconn, TokenKeyCollection, bson.M{"active": true})
+	fresh := err == nil
+	if err != nil {
+		log.Println("gagal membaca tokenkey, memakai kunci sebelumnya: " + err.Error())
+		stored = tokenKeyStored
+	}
+	var latest time.Time
+	mongoSigning := ""
+	for i, key := range stored {
+		if key, err = openTokenKey(key); err != nil {
+			// private key yang tidak bisa dibuka tidak dipakai sign, public key tetap dipakai verifikasi
+			log.Println("private key " + key.ID + " tidak bisa didekripsi: " + err.Error())
+			key.PrivateKey, key.Signing = "", false
+		}
+		stored[i] = key
+		keys = append(keys, key.Key)
+		if key.Signing && key.PrivateKey != "" && !key.CreatedAt.Before(latest) {
+			mongoSigning, latest = key.ID, key.CreatedAt
+		}
+	}
+	if signing == "" {
+		signing = mongoSigning
+	}
+	if signing == "" && os.Getenv("PRKEY") != "" {
+		signing = "prkey"
+	}
+	if err = TokenKeyring.Replace(keys, signing); err != nil {
+		return err
+	}
+	if fresh {
+		tokenKeyStored, tokenKeyLoaded = stored, time.Now()
+	}
+	return nil
+}
+
+// envTokenKeys membaca kunci dari TOKEN_KEYS dan PRKEY
+func envTokenKeys() (keys []watoken.Key, err error) {
+	for _, entry := range strings.Split(os.Getenv("TOKEN_KEYS"), ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		parts := strings.Split(entry, ":")
+		if len(parts) < 2 || len(parts) > 3 {
+			return nil, errors.New("format TOKEN_KEYS harus kid:publickey[:privatekey]")
+		}
+		key := watoken.Key{ID: parts[0], PublicKey: parts[1]}
+		if len(parts) == 3 {
+			key.PrivateKey = parts[2]
+		}
+		keys = append(keys, key)
+	}
+	if prkey := os.Getenv("PRKEY"); prkey != "" {
+		keys = append(keys, watoken.Key{ID: "prkey", PrivateKey: prkey})
+	}
+	return
+}
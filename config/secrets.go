@@ -0,0 +1,93 @@
+package config
+
+import (
+	"context"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/gocroot/helper/secrets"
+)
+
+// nama secret, sama dengan nama env dan _id di collection secrets
+const (
+	SecretTurnstile     = "TURNSTILE_SECRET"
+	SecretLinkedDevice  = "WA_LINKED_DEVICE"
+	SecretGHAccessToken = "GH_ACCESS_TOKEN"
+	SecretGHAuthorName  = "GH_AUTHOR_NAME"
+	SecretGHAuthorEmail = "GH_AUTHOR_EMAIL"
+	SecretMidtransKey   = "MIDTRANS_SERVER_KEY"
+	SecretPDToken       = "PDTOKEN"
+	SecretPaymentFake   = "PAYMENT_FAKE_SECRET"
+	SecretCron          = "CRON_SECRET"
+)
+
+const (
+	secretReload  = time.Minute
+	secretTimeout = 10 * time.Second
+	secretFileEnv = "SECRETS_FILE" // path file NAMA=nilai, misalnya secret yang di-mount dari secret manager
+)
+
+// secretSpecs semua secret aplikasi. MONGOSTRING dan AESKEY tetap dari env karena dipakai untuk membuka provider mongo.
+var secretSpecs = []secrets.Spec{
+	{Name: SecretTurnstile, Required: true, Description: "secret Cloudflare Turnstile untuk captcha login WhatsApp"},
+	{Name: SecretLinkedDevice, Description: "token PASETO device WhatsApp default untuk akun yang daftar lewat form"},
+	{Name: SecretGHAccessToken, Required: true, Description: "token GitHub untuk upload file ke repo"},
+	{Name: SecretGHAuthorName, Required: true, Description: "nama author commit upload GitHub"},
+	{Name: SecretGHAuthorEmail, Required: true, Description: "email author commit upload GitHub"},
+	{Name: SecretMidtransKey, Description: "server key Midtrans, kosong berarti provider tidak aktif"},
+	{Name: SecretPDToken, Description: "token API pamong desa"},
+	{Name: SecretPaymentFake, Description: "secret provider pembayaran fake untuk development"},
+	{Name: SecretCron, Description: "secret header X-Cron-Secret untuk endpoint cron, kosong berarti endpoint cron ditolak"},
+}
+
+// SecretMongo provider collection secrets, juga dipakai untuk menyimpan secret terenkripsi dengan AESKEY
+var SecretMongo = secrets.MongoProvider{DB: Mongoconn, Key: AESKey}
+
+// Secrets snapshot secret dari env, file SECRETS_FILE lalu collection secrets, urut sesuai prioritas
+var Secrets = secrets.NewStore(secretSpecs,
+	secrets.EnvProvider{},
+	secrets.FileProvider{Path: os.Getenv(secretFileEnv)},
+	SecretMongo,
+)
+
+// nilai secret yang dipakai langsung oleh handler, selalu dibaca dari snapshot Secrets yang dijaga mutex
+// supaya reload di LoadSecrets tidak balapan dengan handler yang sedang berjalan
+func TurnstileSecret() string   { return Secrets.Get(SecretTurnstile) }
+func LinkedDeviceToken() string { return Secrets.Get(SecretLinkedDevice) }
+func GHAccessToken() string     { return Secrets.Get(SecretGHAccessToken) }
+func GHAuthorName() string      { return Secrets.Get(SecretGHAuthorName) }
+func GHAuthorEmail() string     { return Secrets.Get(SecretGHAuthorEmail) }
+func MidtransServerKey() string { return Secrets.Get(SecretMidtransKey) }
+func APITOKENPD() string        { return Secrets.Get(SecretPDToken) }
+func PaymentFakeSecret() string { return Secrets.Get(SecretPaymentFake) }
+func CronSecret() string        { return Secrets.Get(SecretCron) }
+
+var (
+	secretMu     sync.Mutex
+	secretLoaded time.Time
+)
+
+// LoadSecrets memuat ulang semua secret dari provider. Tanpa force, secret hanya dibaca ulang setiap secretReload
+// sehingga perubahan di Mongo atau file ikut terpakai tanpa deploy ulang.
+// Error berisi secret wajib yang belum diset atau provider yang gagal, nilai yang berhasil dimuat tetap dipakai.
+func LoadSecrets(force bool) error {
+	secretMu.Lock()
+	defer secretMu.Unlock()
+	if !force && time.Since(secretLoaded) < secretReload {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), secretTimeout)
+	defer cancel()
+	err := Secrets.Load(ctx)
+	secretLoaded = time.Now()
+	return err
+}
+
+func init() {
+	// dimuat sekali saat start supaya secret yang belum diset langsung terlihat di log
+	if err := LoadSecrets(true); err != nil {
+		log.Println("konfigurasi secret: " + err.Error())
+	}
+}
@@ -0,0 +1,27 @@
+package config
+
+import (
+	"sync"
+	"testing"
+)
+
+// dijalankan dengan go test -race, reload secret tidak boleh balapan dengan handler yang membaca secret
+func TestLoadSecretsConcurrentRead(t *testing.T) {
+	t.Setenv(SecretCron, "rahasia-cron")
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			LoadSecrets(true)
+		}()
+		go func() {
+			defer wg.Done()
+			_ = CronSecret() + GHAccessToken() + TurnstileSecret()
+		}()
+	}
+	wg.Wait()
+	if got := CronSecret(); got != "rahasia-cron" {
+		t.Fatalf("CronSecret() = %q", got)
+	}
+}
@@ -0,0 +1,232 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/gocroot/config"
+	"github.com/gocroot/helper/at"
+	"github.com/gocroot/helper/atdb"
+	"github.com/gocroot/helper/router"
+	"github.com/gocroot/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// GetAddresses daftar buku alamat milik user dari token, alamat default paling atas
+func GetAddresses(respw http.ResponseWriter, req *http.Request) {
+	payload, err := decodeLoginToken(req)
+	if err != nil {
+		writeTokenError(respw, err)
+		return
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "default", Value: -1}, {Key: "_id", Value: -1}})
+	cur, err := config.Mongoconn.Collection("address").Find(context.TODO(), bson.M{"user_id": payload.Id}, opts)
+	if err != nil {
+		at.WriteError(respw, at.NewError(at.CodeInternal, "Data alamat tidak bisa diambil", err.Error()))
+		return
+	}
+	addresses := []model.Address{}
+	if err = cur.All(context.TODO(), &addresses); err != nil {
+		at.WriteError(respw, at.NewError(at.CodeInternal, "Data alamat tidak bisa dibaca", err.Error()))
+		return
+	}
+	at.WriteJSON(respw, http.StatusOK, addresses)
+}
+
+// PostAddress menambah alamat ke buku alamat, alamat pertama otomatis jadi default
+func PostAddress(respw http.ResponseWriter, req *http.Request) {
+	payload, err := decodeLoginToken(req)
+	if err != nil {
+		writeTokenError(respw, err)
+		return
+	}
+	var addr model.Address
+	if err = json.NewDecoder(req.Body).Decode(&addr); err != nil {
+		at.WriteError(respw, at.NewError(at.CodeInvalidBody, "Body tidak valid", err.Error()))
+		return
+	}
+	if err = validateAddress(&addr); err != nil {
+		at.WriteError(respw, at.NewError(at.CodeBadRequest, "Alamat tidak valid", err.Error()))
+		return
+	}
+	count, err := atdb.GetCountDoc(config.Mongoconn, "address", bson.M{"user_id": payload.Id})
+	if err != nil {
+		at.WriteError(respw, at.NewError(at.CodeInternal, "Data alamat tidak bisa diambil", err.Error()))
+		return
+	}
+	addr.ID = primitive.NilObjectID
+	addr.UserID = payload.Id
+	addr.Default = addr.Default || count == 0
+	addr.CreatedAt = time.Now()
+	addr.UpdatedAt = addr.CreatedAt
+	addr.ID, err = atdb.InsertOneDoc(config.Mongoconn, "address", addr)
+	if err != nil {
+		at.WriteError(respw, at.NewError(at.CodeInternal, "Gagal Insert Database", err.Error()))
+		return
+	}
+	if addr.Default {
+		if err = setDefaultAddress(addr); err != nil {
+			at.WriteError(respw, at.NewError(at.CodeInternal, "Gagal mengubah alamat default", err.Error()))
+			return
+		}
+	}
+	at.WriteJSON(respw, http.StatusOK, addr)
+}
+
+// PutAddress mengubah alamat milik user, default true menjadikannya alamat default
+func PutAddress(respw http.ResponseWriter, req *http.Request) {
+	payload, err := decodeLoginToken(req)
+	if err != nil {
+		writeTokenError(respw, err)
+		return
+	}
+	existing, ok := getOwnedAddress(respw, router.Param(req, "id"), payload.Id)
+	if !ok {
+		return
+	}
+	var addr model.Address
+	if err = json.NewDecoder(req.Body).Decode(&addr); err != nil {
+		at.WriteError(respw, at.NewError(at.CodeInvalidBody, "Body tidak valid", err.Error()))
+		return
+	}
+	if err = validateAddress(&addr); err != nil {
+		at.WriteError(respw, at.NewError(at.CodeBadRequest, "Alamat tidak valid", err.Error()))
+		return
+	}
+	addr.ID = existing.ID
+	addr.UserID = existing.UserID
+	addr.CreatedAt = existing.CreatedAt
+	addr.UpdatedAt = time.Now()
+	// alamat default hanya bisa dipindah ke alamat lain, tidak bisa dilepas begitu saja
+	addr.Default = addr.Default || existing.Default
+	if _, err = atdb.ReplaceOneDoc(config.Mongoconn, "address", bson.M{"_id": addr.ID}, addr); err != nil {
+		at.WriteError(respw, at.NewError(at.CodeInternal, "Gagal memperbarui database", err.Error()))
+		return
+	}
+	if addr.Default && !existing.Default {
+		if err = setDefaultAddress(addr); err != nil {
+			at.WriteError(respw, at.NewError(at.CodeInternal, "Gagal mengubah alamat default", err.Error()))
+			return
+		}
+	}
+	at.WriteJSON(respw, http.StatusOK, addr)
+}
+
+// DeleteAddress menghapus alamat milik user, jika alamat default yang dihapus alamat terbaru jadi default
+func DeleteAddress(respw http.ResponseWriter, req *http.Request) {
+	var respn model.Response
+	payload, err := decodeLoginToken(req)
+	if err != nil {
+		writeTokenError(respw, err)
+		return
+	}
+	addr, ok := getOwnedAddress(respw, router.Param(req, "id"), payload.Id)
+	if !ok {
+		return
+	}
+	if _, err = atdb.DeleteOneDoc(config.Mongoconn, "address", bson.M{"_id": addr.ID}); err != nil {
+		at.WriteError(respw, at.NewError(at.CodeInternal, "Gagal menghapus alamat", err.Error()))
+		return
+	}
+	if addr.Default {
+		next, err := atdb.GetOneLatestDoc[model.Address](config.Mongoconn, "address", bson.M{"user_id": payload.Id})
+		if err == nil {
+			setDefaultAddress(next)
+		}
+	}
+	respn.Status = "Success"
+	respn.Response = "Alamat berhasil dihapus"
+	at.WriteJSON(respw, http.StatusOK, respn)
+}
+
+// getOwnedAddress mengambil alamat berdasarkan id dan memastikan milik user tersebut
+func getOwnedAddress(respw http.ResponseWriter, id, userID string) (addr model.Address, ok bool) {
+	objectId, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		at.WriteError(respw, at.NewError(at.CodeBadRequest, "ObjectID Tidak Valid", err.Error()))
+		return
+	}
+	addr, err = atdb.GetOneDoc[model.Address](config.Mongoconn, "address", bson.M{"_id": objectId, "user_id": userID})
+	if err != nil {
+		at.WriteError(respw, at.NewError(at.CodeNotFound, "Data alamat tidak di temukan", err.Error()))
+		return
+	}
+	return addr, true
+}
+
+// getCheckoutAddress mengambil alamat untuk checkout, jika id kosong dipakai alamat default user
+func getCheckoutAddress(addressID primitive.ObjectID, userID string) (addr model.Address, err error) {
+	filter := bson.M{"user_id": userID, "default": true}
+	if !addressID.IsZero() {
+		filter = bson.M{"user_id": userID, "_id": addressID}
+	}
+	addr, err = atdb.GetOneDoc[model.Address](config.Mongoconn, "address", filter)
+	if err != nil {
+		err = errors.New("alamat pengiriman tidak ditemukan di buku alamat")
+	}
+	return
+}
+
+// setDefaultAddress menjadikan alamat tersebut satu-satunya alamat default milik user
+func setDefaultAddress(addr model.Address) (err error) {
+	col := config.Mongoconn.Collection("address")
+	_, err = col.UpdateMany(context.TODO(), bson.M{"user_id": addr.UserID, "_id": bson.M{"$ne": addr.ID}}, bson.M{"$set": bson.M{"default": false}})
+	if err != nil {
+		return
+	}
+	_, err = col.UpdateOne(context.TODO(), bson.M{"_id": addr.ID}, bson.M{"$set": bson.M{"default": true}})
+	return
+}
+
+// validateAddress memastikan field wajib terisi dan province, city (dan kecamatan/desa jika diisi) ada di region database geo.
+// Nama wilayah disamakan dengan penulisan di collection region.
+func validateAddress(addr *model.Address) error {
+	if addr.Penerima == "" || addr.PhoneNumber == "" || addr.Street == "" || addr.Province == "" || addr.City == "" {
+		return errors.New("penerima, phonenumber, street, province dan city wajib diisi")
+	}
+	filter := bson.M{
+		"province": exactInsensitive(addr.Province),
+		"district": exactInsensitive(addr.City),
+	}
+	if addr.SubDistrict != "" {
+		filter["sub_district"] = exactInsensitive(addr.SubDistrict)
+	}
+	if addr.Village != "" {
+		filter["village"] = exactInsensitive(addr.Village)
+	}
+	opts := options.FindOne().SetProjection(bson.M{"border": 0})
+	var region model.Region
+	err := config.MongoconnGeo.Collection("region").FindOne(context.TODO(), filter, opts).Decode(&region)
+	if err != nil {
+		return errors.New("wilayah " + addr.City + ", " + addr.Province + " tidak ditemukan di data region")
+	}
+	addr.Province = region.Province
+	addr.City = region.District
+	if addr.SubDistrict != "" {
+		addr.SubDistrict = region.SubDistrict
+	}
+	if addr.Village != "" {
+		addr.Village = region.Village
+	}
+	// koordinat dipakai untuk ongkir per km, jadi harus berada di wilayah alamat
+	if addr.Longitude != 0 || addr.Latitude != 0 {
+		inside, err := regionContains(addressRegionFilter(*addr), addr.Longitude, addr.Latitude)
+		if err != nil {
+			return errors.New("koordinat alamat tidak bisa dicek: " + err.Error())
+		}
+		if !inside {
+			return errors.New("koordinat alamat tidak berada di wilayah " + addr.City + ", " + addr.Province)
+		}
+	}
+	return nil
+}
+
+func exactInsensitive(s string) bson.M {
+	return bson.M{"$regex": "^" + regexp.QuoteMeta(s) + "$", "$options": "i"}
+}
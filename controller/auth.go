@@ -3,6 +3,8 @@ package controller
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"log"
 	"net/http"
 	"net/url"
 	"regexp"
@@ -12,7 +14,8 @@ import (
 	"github.com/gocroot/helper/at"
 	"github.com/gocroot/helper/atdb"
 	"github.com/gocroot/helper/auth"
-	"github.com/gocroot/helper/watoken"
+	"github.com/gocroot/helper/authtoken"
+	"github.com/gocroot/helper/identity"
 	"github.com/gocroot/model"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -21,41 +24,30 @@ import (
 )
 
 func RegisterGmailAuth(w http.ResponseWriter, r *http.Request) {
-	logintoken, err := watoken.Decode(config.PublicKeyWhatsAuth, at.GetLoginFromHeader(r))
+	logintoken, err := config.TokenKeyring.Decode(at.GetLoginFromHeader(r))
 	if err != nil {
-		var respn model.Response
-		respn.Status = "Error : Token Tidak Valid "
-		respn.Info = at.GetSecretFromHeader(r)
-		respn.Location = "Decode Token Error: " + at.GetLoginFromHeader(r)
-		respn.Response = err.Error()
-		at.WriteJSON(w, http.StatusForbidden, respn)
+		at.WriteError(w, at.NewError(at.CodeInvalidToken, "Token Tidak Valid", err.Error()))
 		return
 	}
 	var request struct {
 		Token string `json:"token"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"message": "Invalid request"})
+		at.WriteError(w, at.NewError(at.CodeInvalidBody, "", err.Error()))
 		return
 	}
 
 	// Ambil kredensial dari database
 	creds, err := atdb.GetOneDoc[auth.GoogleCredential](config.Mongoconn, "credentials", bson.M{})
 	if err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadGateway)
-		json.NewEncoder(w).Encode(map[string]string{"message": "Database Connection Problem: Unable to fetch credentials"})
+		at.WriteError(w, at.NewError(at.CodeInternal, "Kredensial Google tidak bisa diambil", err.Error()))
 		return
 	}
 
 	// Verifikasi ID token menggunakan client_id
 	payload, err := auth.VerifyIDToken(request.Token, creds.ClientID)
 	if err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusUnauthorized)
-		json.NewEncoder(w).Encode(map[string]string{"message": "Invalid token: Token verification failed"})
+		at.WriteError(w, at.NewError(at.CodeInvalidToken, "Token Google tidak valid", err.Error()))
 		return
 	}
 
@@ -65,6 +57,13 @@ func RegisterGmailAuth(w http.ResponseWriter, r *http.Request) {
 		Email:                payload.Claims["email"].(string),
 		GoogleProfilePicture: payload.Claims["picture"].(string),
 	}
+	googleID := identity.Identity{Type: identity.Google, Subject: payload.Subject, LinkedAt: time.Now()}
+	// akun google yang sudah tertaut ke akun lain harus digabung lewat /auth/identity
+	if owner, err := userByIdentity(identity.Google, payload.Subject); err == nil && owner.PhoneNumber != logintoken.Id {
+		at.WriteError(w, at.NewError(at.CodeConflict, "Akun Google sudah tertaut ke akun lain", "Gunakan penautan identitas dengan merge"))
+		return
+	}
+	userInfo.Identities = []identity.Identity{googleID}
 
 	// Simpan atau perbarui informasi pengguna di database
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
@@ -79,9 +78,7 @@ func RegisterGmailAuth(w http.ResponseWriter, r *http.Request) {
 		// User does not exist or exists but has no phone number, insert into db
 		id, err := atdb.InsertOneDoc(config.Mongoconn, "user", userInfo)
 		if err != nil {
-			w.Header().Set("Content-Type", "application/json")
-			w.WriteHeader(http.StatusBadGateway)
-			json.NewEncoder(w).Encode(map[string]string{"message": "Database Connection Problem: Unable to fetch credentials"})
+			at.WriteError(w, at.NewError(at.CodeInternal, "Gagal menyimpan user", err.Error()))
 			return
 		}
 		response := map[string]interface{}{
@@ -96,11 +93,10 @@ func RegisterGmailAuth(w http.ResponseWriter, r *http.Request) {
 	} else if existingUser.PhoneNumber != "" {
 		existingUser.Email = userInfo.Email
 		existingUser.GoogleProfilePicture = userInfo.GoogleProfilePicture
+		existingUser.Identities, _ = identity.Add(existingUser.Identities, googleID)
 		_, err := atdb.ReplaceOneDoc(config.Mongoconn, "user", bson.M{"_id": existingUser.ID}, existingUser)
 		if err != nil {
-			w.Header().Set("Content-Type", "application/json")
-			w.WriteHeader(http.StatusBadGateway)
-			json.NewEncoder(w).Encode(map[string]string{"message": "Database Connection Problem: Unable to update user"})
+			at.WriteError(w, at.NewError(at.CodeInternal, "Gagal memperbarui user", err.Error()))
 			return
 		}
 		response := map[string]interface{}{
@@ -120,9 +116,7 @@ func RegisterGmailAuth(w http.ResponseWriter, r *http.Request) {
 	opts := options.Update().SetUpsert(true)
 	_, err = collection.UpdateOne(ctx, filter, update, opts)
 	if err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{"message": "Failed to save user info: Database update failed"})
+		at.WriteError(w, at.NewError(at.CodeInternal, "Gagal menyimpan data user", err.Error()))
 		return
 	}
 
@@ -139,27 +133,21 @@ func Auth(w http.ResponseWriter, r *http.Request) {
 		Token string `json:"token"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"message": "Invalid request"})
+		at.WriteError(w, at.NewError(at.CodeInvalidBody, "", err.Error()))
 		return
 	}
 
 	// Ambil kredensial dari database
 	creds, err := atdb.GetOneDoc[auth.GoogleCredential](config.Mongoconn, "credentials", bson.M{})
 	if err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadGateway)
-		json.NewEncoder(w).Encode(map[string]string{"message": "Database Connection Problem: Unable to fetch credentials"})
+		at.WriteError(w, at.NewError(at.CodeInternal, "Kredensial Google tidak bisa diambil", err.Error()))
 		return
 	}
 
 	// Verifikasi ID token menggunakan client_id
 	payload, err := auth.VerifyIDToken(request.Token, creds.ClientID)
 	if err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusUnauthorized)
-		json.NewEncoder(w).Encode(map[string]string{"message": "Invalid token: Token verification failed"})
+		at.WriteError(w, at.NewError(at.CodeInvalidToken, "Token Google tidak valid", err.Error()))
 		return
 	}
 
@@ -176,8 +164,23 @@ func Auth(w http.ResponseWriter, r *http.Request) {
 	collection := config.Mongoconn.Collection("user")
 	filter := bson.M{"email": userInfo.Email}
 
-	var existingUser model.Userdomyikado
-	err = collection.FindOne(ctx, filter).Decode(&existingUser)
+	// cari dari akun google yang sudah tertaut, lalu dari email yang sudah diverifikasi google
+	existingUser, err := userByIdentity(identity.Google, payload.Subject)
+	if verified, _ := payload.Claims["email_verified"].(bool); err != nil && verified {
+		if email, nerr := identity.Normalize(identity.Email, userInfo.Email); nerr == nil {
+			existingUser, err = userByIdentity(identity.Email, email)
+		}
+		if err == nil && !canAutoLinkGoogle(existingUser) {
+			// email akun form belum diverifikasi, bisa jadi didaftarkan orang lain dengan email korban
+			err = errors.New("email akun belum diverifikasi, tautkan google lewat /identity")
+		}
+		if err == nil && !identity.Contains(existingUser.Identities, identity.Google, payload.Subject) {
+			googleID := identity.Identity{Type: identity.Google, Subject: payload.Subject, LinkedAt: time.Now()}
+			if _, lerr := collection.UpdateOne(ctx, bson.M{"_id": existingUser.ID}, bson.M{"$push": bson.M{"identities": googleID}}); lerr != nil {
+				log.Println("gagal menautkan akun google: " + lerr.Error())
+			}
+		}
+	}
 	if err != nil || existingUser.PhoneNumber == "" {
 		// User does not exist or exists but has no phone number, request QR scan
 		response := map[string]interface{}{
@@ -190,17 +193,20 @@ func Auth(w http.ResponseWriter, r *http.Request) {
 		json.NewEncoder(w).Encode(response)
 		return
 	} else if existingUser.PhoneNumber != "" {
-		token, err := watoken.EncodeforHours(existingUser.PhoneNumber, existingUser.Name, config.PrivateKey, 18) // Generating a token for 18 hours
+		if requireTOTP(w, existingUser.PhoneNumber, existingUser.Name) {
+			return
+		}
+		tokens, err := issueSession(r, existingUser.PhoneNumber, existingUser.Name) // token akses pendek dan refresh token
 		if err != nil {
-			w.Header().Set("Content-Type", "application/json")
-			w.WriteHeader(http.StatusInternalServerError)
-			json.NewEncoder(w).Encode(map[string]string{"message": "Token generation failed"})
+			at.WriteError(w, at.NewError(at.CodeInternal, "Gagal membuat token", err.Error()))
 			return
 		}
 		response := map[string]interface{}{
-			"message": "Authenticated successfully",
-			"user":    userInfo,
-			"token":   token,
+			"message":       "Authenticated successfully",
+			"user":          userInfo,
+			"token":         tokens.AccessToken,
+			"refresh_token": tokens.RefreshToken,
+			"expires_in":    tokens.ExpiresIn,
 		}
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusOK)
@@ -214,9 +220,7 @@ func Auth(w http.ResponseWriter, r *http.Request) {
 	opts := options.Update().SetUpsert(true)
 	_, err = collection.UpdateOne(ctx, filter, update, opts)
 	if err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{"message": "Failed to save user info: Database update failed"})
+		at.WriteError(w, at.NewError(at.CodeInternal, "Gagal menyimpan data user", err.Error()))
 		return
 	}
 
@@ -234,22 +238,21 @@ func GeneratePasswordHandler(respw http.ResponseWriter, r *http.Request) {
 		Captcha     string `json:"captcha"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
-		var respn model.Response
-		respn.Status = "Invalid Request"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, at.NewError(at.CodeInvalidBody, "", err.Error()))
 		return
 	}
 	// Validate CAPTCHA
+	turnstileSecret := config.TurnstileSecret()
+	if turnstileSecret == "" {
+		at.WriteError(respw, at.NewError(at.CodeUnavailable, "Captcha belum dikonfigurasi", "secret "+config.SecretTurnstile+" belum diset"))
+		return
+	}
 	captchaResponse, err := http.PostForm("https://challenges.cloudflare.com/turnstile/v0/siteverify", url.Values{
-		"secret":   {"0x4AAAAAAAfj2NjfaHRBhkd2VjcfmRe5gvI"},
+		"secret":   {turnstileSecret},
 		"response": {request.Captcha},
 	})
 	if err != nil {
-		var respn model.Response
-		respn.Status = "Failed to verify captcha"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusServiceUnavailable, respn)
+		at.WriteError(respw, at.NewError(at.CodeUnavailable, "Captcha tidak bisa diverifikasi", err.Error()))
 		return
 	}
 	defer captchaResponse.Body.Close()
@@ -258,58 +261,44 @@ func GeneratePasswordHandler(respw http.ResponseWriter, r *http.Request) {
 		Success bool `json:"success"`
 	}
 	if err := json.NewDecoder(captchaResponse.Body).Decode(&captchaResult); err != nil {
-		var respn model.Response
-		respn.Status = "Failed to decode captcha response"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusInternalServerError, respn)
+		at.WriteError(respw, at.NewError(at.CodeUpstream, "Respon captcha tidak bisa dibaca", err.Error()))
 		return
 	}
 	if !captchaResult.Success {
-		var respn model.Response
-		respn.Status = "Unauthorized"
-		respn.Response = "Invalid captcha"
-		at.WriteJSON(respw, http.StatusUnauthorized, respn)
+		at.WriteError(respw, at.NewError(at.CodeUnauthorized, "", "Invalid captcha"))
+		return
+	}
+	// Rate limit dihitung setelah captcha lolos, per nomor tujuan dan per IP,
+	// supaya request tanpa captcha dari orang lain tidak menghabiskan jatah OTP pemilik nomor
+	if !limitAuth(respw, r, otpRule, request.PhoneNumber) {
 		return
 	}
 
 	// Validate phone number
 	re := regexp.MustCompile(`^62\d{9,15}$`)
 	if !re.MatchString(request.PhoneNumber) {
-		var respn model.Response
-		respn.Status = "Bad Request"
-		respn.Response = "Invalid phone number format"
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, at.NewError(at.CodeBadRequest, "Nomor telepon tidak valid", "Invalid phone number format"))
 		return
 	}
 
-	// Check if phone number exists in the 'user' collection
-	userFilter := bson.M{"phonenumber": request.PhoneNumber}
-	_, err = atdb.GetOneDoc[model.Userdomyikado](config.Mongoconn, "user", userFilter)
+	// Check if phone number exists in the 'user' collection, termasuk nomor yang ditautkan
+	_, err = userByIdentity(identity.Phone, request.PhoneNumber)
 	if err != nil {
-		var respn model.Response
-		respn.Status = "Unauthorized"
-		respn.Response = "Phone number not registered"
-		at.WriteJSON(respw, http.StatusUnauthorized, respn)
+		at.WriteError(respw, at.NewError(at.CodeUnauthorized, "", "Phone number not registered"))
 		return
 	}
 
 	// Generate random password
 	randomPassword, err := auth.GenerateRandomPassword(12)
 	if err != nil {
-		var respn model.Response
-		respn.Status = "Failed to generate password"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusInternalServerError, respn)
+		at.WriteError(respw, at.NewError(at.CodeInternal, "Gagal membuat password", err.Error()))
 		return
 	}
 
 	// Hash the password
 	hashedPassword, err := auth.HashPassword(randomPassword)
 	if err != nil {
-		var respn model.Response
-		respn.Status = "Failed to hash password"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusInternalServerError, respn)
+		at.WriteError(respw, at.NewError(at.CodeInternal, "Gagal hash password", err.Error()))
 		return
 	}
 
@@ -327,10 +316,7 @@ func GeneratePasswordHandler(respw http.ResponseWriter, r *http.Request) {
 		}
 		_, err = atdb.InsertOneDoc(config.Mongoconn, "stp", newUser)
 		if err != nil {
-			var respn model.Response
-			respn.Status = "Failed to insert new user"
-			respn.Response = err.Error()
-			at.WriteJSON(respw, http.StatusNotModified, respn)
+			at.WriteError(respw, at.NewError(at.CodeInternal, "Gagal menyimpan user baru", err.Error()))
 			return
 		}
 		responseMessage = "New user created and password generated successfully"
@@ -343,10 +329,7 @@ func GeneratePasswordHandler(respw http.ResponseWriter, r *http.Request) {
 		}
 		_, err = atdb.UpdateOneDoc(config.Mongoconn, "stp", stpFilter, stpUpdate)
 		if err != nil {
-			var respn model.Response
-			respn.Status = "Failed to update user"
-			respn.Response = err.Error()
-			at.WriteJSON(respw, http.StatusInternalServerError, respn)
+			at.WriteError(respw, at.NewError(at.CodeInternal, "Gagal memperbarui user", err.Error()))
 			return
 		}
 		responseMessage = "User info updated and password generated successfully"
@@ -363,30 +346,18 @@ func GeneratePasswordHandler(respw http.ResponseWriter, r *http.Request) {
 	auth.SendWhatsAppPassword(respw, request.PhoneNumber, randomPassword)
 }
 
-var (
-	rl = auth.NewRateLimiter(1, 5) // 1 request per second, burst of 5
-)
-
 func VerifyPasswordHandler(respw http.ResponseWriter, r *http.Request) {
 	var request struct {
 		PhoneNumber string `json:"phonenumber"`
 		Password    string `json:"password"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
-		var respn model.Response
-		respn.Status = "Invalid Request"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, at.NewError(at.CodeInvalidBody, "", err.Error()))
 		return
 	}
 
-	// Implementasi rate limiting
-	limiter := rl.GetLimiter(request.PhoneNumber)
-	if !limiter.Allow() {
-		var respn model.Response
-		respn.Status = "Too Many Requests"
-		respn.Response = "Please try again later."
-		at.WriteJSON(respw, http.StatusTooManyRequests, respn)
+	// Implementasi rate limiting dan kunci login di Mongo
+	if !limitAuth(respw, r, loginRule, request.PhoneNumber) {
 		return
 	}
 
@@ -394,55 +365,47 @@ func VerifyPasswordHandler(respw http.ResponseWriter, r *http.Request) {
 	userFilter := bson.M{"phonenumber": request.PhoneNumber}
 	user, err := atdb.GetOneDoc[model.Stp](config.Mongoconn, "stp", userFilter)
 	if err != nil {
-		var respn model.Response
-		respn.Status = "Failed to verify password"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusUnauthorized, respn)
+		at.WriteError(respw, at.NewError(at.CodeUnauthorized, "Password tidak bisa diverifikasi", err.Error()))
 		return
 	}
 
 	// Verify password and expiry
 	if time.Now().After(user.CreatedAt.Add(4 * time.Minute)) {
-		var respn model.Response
-		respn.Status = "Unauthorized"
-		respn.Response = "Password Expired"
-		at.WriteJSON(respw, http.StatusUnauthorized, respn)
+		at.WriteError(respw, at.NewError(at.CodeExpired, "Password sudah kedaluwarsa", "Password Expired"))
 		return
 	}
 
 	err = bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(request.Password))
 	if err != nil {
-		var respn model.Response
-		respn.Status = "Failed to verify password"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusUnauthorized, respn)
+		authFailed(r, request.PhoneNumber)
+		at.WriteError(respw, at.NewError(at.CodeUnauthorized, "Password tidak bisa diverifikasi", err.Error()))
 		return
 	}
+	authSucceeded(r, request.PhoneNumber)
 
-	// Find user in the 'user' collection
-	myiUserFilter := bson.M{"phonenumber": request.PhoneNumber}
-	existingUser, err := atdb.GetOneDoc[model.Userdomyikado](config.Mongoconn, "user", myiUserFilter)
+	// Find user in the 'user' collection, nomor tautan login sebagai akun pemiliknya
+	existingUser, err := userByIdentity(identity.Phone, request.PhoneNumber)
 	if err != nil {
-		var respn model.Response
-		respn.Status = "Unauthorized"
-		respn.Response = "Phone number not registered"
-		at.WriteJSON(respw, http.StatusUnauthorized, respn)
+		at.WriteError(respw, at.NewError(at.CodeUnauthorized, "", "Phone number not registered"))
 		return
 	}
 
-	token, err := watoken.EncodeforHours(existingUser.PhoneNumber, existingUser.Name, config.PrivateKey, 18)
+	// akun dengan TOTP aktif harus melewati faktor kedua dulu
+	if requireTOTP(respw, existingUser.PhoneNumber, existingUser.Name) {
+		return
+	}
+	tokens, err := issueSession(r, existingUser.PhoneNumber, existingUser.Name)
 	if err != nil {
-		var respn model.Response
-		respn.Status = "Failed to give the token"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusInternalServerError, respn)
+		at.WriteError(respw, at.NewError(at.CodeInternal, "Gagal membuat token", err.Error()))
 		return
 	}
 
 	response := map[string]interface{}{
-		"message": "Authenticated successfully",
-		"token":   token,
-		"name":    existingUser.Name,
+		"message":       "Authenticated successfully",
+		"token":         tokens.AccessToken,
+		"refresh_token": tokens.RefreshToken,
+		"expires_in":    tokens.ExpiresIn,
+		"name":          existingUser.Name,
 	}
 
 	// Respond with success
@@ -454,30 +417,24 @@ func ResendPasswordHandler(respw http.ResponseWriter, r *http.Request) {
 		PhoneNumber string `json:"phonenumber"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
-		var respn model.Response
-		respn.Status = "Invalid Request"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, at.NewError(at.CodeInvalidBody, "", err.Error()))
+		return
+	}
+	if !limitAuth(respw, r, otpRule, request.PhoneNumber) {
 		return
 	}
 
 	// Generate random password
 	randomPassword, err := auth.GenerateRandomPassword(12)
 	if err != nil {
-		var respn model.Response
-		respn.Status = "Failed to generate password"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusInternalServerError, respn)
+		at.WriteError(respw, at.NewError(at.CodeInternal, "Gagal membuat password", err.Error()))
 		return
 	}
 
 	// Hash the password
 	hashedPassword, err := auth.HashPassword(randomPassword)
 	if err != nil {
-		var respn model.Response
-		respn.Status = "Failed to hash password"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusInternalServerError, respn)
+		at.WriteError(respw, at.NewError(at.CodeInternal, "Gagal hash password", err.Error()))
 		return
 	}
 
@@ -494,10 +451,7 @@ func ResendPasswordHandler(respw http.ResponseWriter, r *http.Request) {
 		}
 		_, err = atdb.InsertOneDoc(config.Mongoconn, "stp", newUser)
 		if err != nil {
-			var respn model.Response
-			respn.Status = "Failed to insert new user"
-			respn.Response = err.Error()
-			at.WriteJSON(respw, http.StatusInternalServerError, respn)
+			at.WriteError(respw, at.NewError(at.CodeInternal, "Gagal menyimpan user baru", err.Error()))
 			return
 		}
 		responseMessage := "New user created and password generated successfully"
@@ -513,10 +467,7 @@ func ResendPasswordHandler(respw http.ResponseWriter, r *http.Request) {
 		auth.SendWhatsAppPassword(respw, request.PhoneNumber, randomPassword)
 		return
 	} else if stpErr != nil {
-		var respn model.Response
-		respn.Status = "Failed to fetch user info"
-		respn.Response = stpErr.Error()
-		at.WriteJSON(respw, http.StatusInternalServerError, respn)
+		at.WriteError(respw, at.NewError(at.CodeInternal, "Data user tidak bisa diambil", stpErr.Error()))
 		return
 	}
 
@@ -528,10 +479,7 @@ func ResendPasswordHandler(respw http.ResponseWriter, r *http.Request) {
 	}
 	_, err = atdb.UpdateOneDoc(config.Mongoconn, "stp", stpFilter, stpUpdate)
 	if err != nil {
-		var respn model.Response
-		respn.Status = "Failed to update user"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusInternalServerError, respn)
+		at.WriteError(respw, at.NewError(at.CodeInternal, "Gagal memperbarui user", err.Error()))
 		return
 	}
 	responseMessage := "User info updated and password generated successfully"
@@ -550,63 +498,79 @@ func ResendPasswordHandler(respw http.ResponseWriter, r *http.Request) {
 func RegisterAkun(respw http.ResponseWriter, r *http.Request) {
 	var request model.Userdomyikado
 	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
-		respn := model.Response{
-			Status:   "Invalid Request",
-			Response: err.Error(),
-		}
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, at.NewError(at.CodeInvalidBody, "", err.Error()))
 		return
 	}
 
 	re := regexp.MustCompile(`^62\d{9,15}$`)
 	if !re.MatchString(request.PhoneNumber) {
-		respn := model.Response{
-			Status:   "Bad Request",
-			Response: "Invalid phone number format",
-		}
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, at.NewError(at.CodeBadRequest, "Nomor telepon tidak valid", "Invalid phone number format"))
+		return
+	}
+	if !limitAuth(respw, r, registerRule, request.PhoneNumber) {
+		return
+	}
+	email, err := identity.Normalize(identity.Email, request.Email)
+	if err == nil {
+		err = validatePassword(request.Password)
+	}
+	if err != nil {
+		at.WriteError(respw, at.NewError(at.CodeBadRequest, "Email atau password tidak valid", err.Error()))
 		return
 	}
+	// satu nomor dan satu email hanya untuk satu akun, termasuk yang ditautkan ke akun lain
+	for _, id := range []identity.Identity{{Type: identity.Phone, Subject: request.PhoneNumber}, {Type: identity.Email, Subject: email}} {
+		_, err = userByIdentity(id.Type, id.Subject)
+		if err == nil {
+			at.WriteError(respw, at.NewError(at.CodeConflict, "Akun sudah terdaftar", id.Type+" "+id.Subject+" sudah dipakai akun lain"))
+			return
+		}
+		if !errors.Is(err, mongo.ErrNoDocuments) {
+			at.WriteError(respw, at.NewError(at.CodeInternal, "Gagal memeriksa akun", err.Error()))
+			return
+		}
+	}
 
 	hashedPassword, err := auth.HashPassword(request.Password)
 	if err != nil {
-		respn := model.Response{
-			Status:   "Failed to hash password",
-			Response: err.Error(),
-		}
-		at.WriteJSON(respw, http.StatusInternalServerError, respn)
+		at.WriteError(respw, at.NewError(at.CodeInternal, "Gagal hash password", err.Error()))
 		return
 	}
 
 	newUser := model.Userdomyikado{
-		Name:          request.Name,
-		PhoneNumber:   request.PhoneNumber,
-		Email:         request.Email,
-		Team:          "pd.my.id",
-		Scope:         "dev",
-		LinkedDevice:  "v4.public.eyJhbGlhcyI6IlJvbGx5IE1hdWxhbmEgQXdhbmdnYSIsImV4cCI6IjIwMjktMDgtMDlUMTQ6MzQ6MjlaIiwiaWF0IjoiMjAyNC0wOC0wOVQwODozNDoyOVoiLCJpZCI6IjYyODEzMTIwMDAzMDAiLCJuYmYiOiIyMDI0LTA4LTA5VDA4OjM0OjI5WiJ9FXnQi5vnQ7YXHteepJ14Xcc-wPc0PLQ0n4LSbGFijfdkStVeD6QIDuwQGeaq7xETWmmtFXjfkmmfDG0WHmAlBA",
-		JumlahAntrian: 7,
-		Password:      hashedPassword,
-	}
-
-	_, err = atdb.InsertOneDoc(config.Mongoconn, "user", newUser)
+		Name:              request.Name,
+		PhoneNumber:       request.PhoneNumber,
+		Email:             email,
+		Team:              "pd.my.id",
+		Scope:             "dev",
+		LinkedDevice:      config.LinkedDeviceToken(),
+		JumlahAntrian:     7,
+		Password:          hashedPassword,
+		EmailVerification: model.EmailPending,
+	}
+
+	newUser.ID, err = atdb.InsertOneDoc(config.Mongoconn, "user", newUser)
 	if err != nil {
-		respn := model.Response{
-			Status:   "Failed to insert new user",
-			Response: err.Error(),
-		}
-		at.WriteJSON(respw, http.StatusInternalServerError, respn)
+		at.WriteError(respw, at.NewError(at.CodeInternal, "Gagal menyimpan user baru", err.Error()))
 		return
 	}
 
+	// akun baru harus memverifikasi email sebelum bisa login dengan form
+	verification := "sent"
+	if _, err = issueAuthToken(newUser, authtoken.VerifyEmail, true); err != nil {
+		log.Println("gagal mengirim verifikasi email: " + err.Error())
+		verification = "failed"
+	}
+
 	response := map[string]interface{}{
-		"message":       "New user created successfully",
-		"name":          newUser.Name,
-		"phonenumber":   newUser.PhoneNumber,
-		"email":         newUser.Email,
-		"team":          newUser.Team,
-		"scope":         newUser.Scope,
-		"jumlahAntrian": newUser.JumlahAntrian,
+		"message":            "New user created successfully",
+		"name":               newUser.Name,
+		"phonenumber":        newUser.PhoneNumber,
+		"email":              newUser.Email,
+		"team":               newUser.Team,
+		"scope":              newUser.Scope,
+		"jumlahAntrian":      newUser.JumlahAntrian,
+		"email_verification": verification,
 	}
 
 	at.WriteJSON(respw, http.StatusOK, response)
@@ -616,105 +580,63 @@ func LoginAkunForm(respw http.ResponseWriter, r *http.Request) {
 	var userRequest model.Userdomyikado
 
 	if err := json.NewDecoder(r.Body).Decode(&userRequest); err != nil {
-		response := model.Response{
-			Status:   "Invalid Request",
-			Response: err.Error(),
-		}
-		at.WriteJSON(respw, http.StatusBadRequest, response)
+		at.WriteError(respw, at.NewError(at.CodeInvalidBody, "", err.Error()))
+		return
+	}
+	// email dinormalisasi dulu supaya variasi huruf besar dan spasi memakai kunci rate limit dan lockout yang sama
+	email, err := identity.Normalize(identity.Email, userRequest.Email)
+	if err != nil {
+		at.WriteError(respw, at.NewError(at.CodeNotFound, "Akun tidak ditemukan", err.Error()))
+		return
+	}
+	if !limitAuth(respw, r, loginRule, email) {
 		return
 	}
 
-	var storedUser model.Userdomyikado
-	err := config.Mongoconn.Collection("user").FindOne(context.Background(), bson.M{"email": userRequest.Email}).Decode(&storedUser)
+	storedUser, err := userByIdentity(identity.Email, email)
 	if err != nil {
-		response := model.Response{
-			Status:   "Error: Toko tidak ditemukan",
-			Response: "Error: " + err.Error(),
-		}
-		at.WriteJSON(respw, http.StatusNotFound, response)
+		at.WriteError(respw, at.NewError(at.CodeNotFound, "Akun tidak ditemukan", err.Error()))
 		return
 	}
 
 	err = bcrypt.CompareHashAndPassword([]byte(storedUser.Password), []byte(userRequest.Password))
 	if err != nil {
-		response := model.Response{
-			Status:   "Failed to verify password",
-			Response: "Invalid password",
-		}
-		at.WriteJSON(respw, http.StatusUnauthorized, response)
+		authFailed(r, email)
+		at.WriteError(respw, at.NewError(at.CodeUnauthorized, "Password tidak bisa diverifikasi", "Invalid password"))
+		return
+	}
+	authSucceeded(r, email)
+	if storedUser.EmailVerification == model.EmailPending {
+		at.WriteError(respw, at.NewError(at.CodeEmailUnverified, "Email belum diverifikasi", "Buka token verifikasi yang dikirim ke "+storedUser.Email+" atau minta kirim ulang"))
 		return
 	}
 
-	encryptedToken, err := watoken.EncodeforHours(storedUser.PhoneNumber, storedUser.Name, config.PRIVATEKEY, 18)
+	if requireTOTP(respw, storedUser.PhoneNumber, storedUser.Name) {
+		return
+	}
+	tokens, err := issueSession(r, storedUser.PhoneNumber, storedUser.Name)
 	if err != nil {
-		var respn model.Response
-		respn.Status = "Error: token gagal generate"
-		respn.Response = ", Error: " + err.Error()
-		at.WriteJSON(respw, http.StatusNotFound, respn)
+		at.WriteError(respw, at.NewError(at.CodeInternal, "Gagal membuat token", err.Error()))
 		return
 	}
 
 	response := map[string]interface{}{
-		"message": "Login successful",
-		"name":    storedUser.Name,
-		"email":   storedUser.Email,
-		"phone":   storedUser.PhoneNumber,
-		"team":    storedUser.Team,
-		"scope":   storedUser.Scope,
-		"token":   encryptedToken,
-		"antrian": storedUser.JumlahAntrian,
+		"message":       "Login successful",
+		"name":          storedUser.Name,
+		"email":         storedUser.Email,
+		"phone":         storedUser.PhoneNumber,
+		"team":          storedUser.Team,
+		"scope":         storedUser.Scope,
+		"token":         tokens.AccessToken,
+		"refresh_token": tokens.RefreshToken,
+		"expires_in":    tokens.ExpiresIn,
+		"antrian":       storedUser.JumlahAntrian,
 	}
 
 	at.WriteJSON(respw, http.StatusOK, response)
 }
 
-// func GetMenu(respw http.ResponseWriter, req *http.Request) {
-// 	payload, err := watoken.Decode(config.PublicKeyWhatsAuth, at.GetLoginFromHeader(req))
-
-// 	if err != nil {
-// 		payload, err = watoken.Decode(config.PUBLICKEY, at.GetLoginFromHeader(req))
-
-// 		if err != nil {
-// 			var respn model.Response
-// 			respn.Status = "Error: Token Tidak Valid"
-// 			respn.Info = at.GetSecretFromHeader(req)
-// 			respn.Location = "Decode Token Error"
-// 			respn.Response = err.Error()
-// 			at.WriteJSON(respw, http.StatusForbidden, respn)
-// 			return
-// 		}
-// 	}
-
-// 	var datauser model.Userdomyikado
-// 	err = config.Mongoconn.Collection("user").FindOne(context.Background(), bson.M{"phonenumber": payload.Id}).Decode(&datauser)
-// 	if err != nil {
-// 		var respn model.Response
-// 		respn.Status = "Error: User tidak ditemukan"
-// 		respn.Response = "User with the provided role ID not found"
-// 		at.WriteJSON(respw, http.StatusNotFound, respn)
-// 		return
-// 	}
-
-// 	if datauser.Role == "user" {
-// 		response := map[string]interface{}{
-// 			"message": "Menu for user",
-// 			"menu":    "/user",
-// 		}
-// 		at.WriteJSON(respw, http.StatusOK, response)
-// 		return
-// 	} else if datauser.Role == "admin" {
-// 		response := map[string]interface{}{
-// 			"message": "Menu for admin",
-// 			"menu":    "/admin",
-// 		}
-// 		at.WriteJSON(respw, http.StatusOK, response)
-// 		return
-// 	} else {
-// 		response := map[string]interface{}{
-// 			"message": "Role not recognized",
-// 			"menu":    "/505",
-// 		}
-// 		at.WriteJSON(respw, http.StatusForbidden, response)
-// 		return
-// 	}
-// }
\ No newline at end of file
+// canAutoLinkGoogle akun boleh otomatis ditautkan ke Google dengan email yang sama hanya jika emailnya bukan email form yang belum diverifikasi
+func canAutoLinkGoogle(docuser model.Userdomyikado) bool {
+	return docuser.EmailVerification != model.EmailPending
+}
@@ -0,0 +1,23 @@
+package controller
+
+import (
+	"testing"
+
+	"github.com/gocroot/model"
+)
+
+func TestCanAutoLinkGoogle(t *testing.T) {
+	cases := []struct {
+		status string
+		want   bool
+	}{
+		{"", true}, // akun lama tanpa status verifikasi
+		{model.EmailVerified, true},
+		{model.EmailPending, false}, // email form yang belum diverifikasi bisa milik orang lain
+	}
+	for _, c := range cases {
+		if got := canAutoLinkGoogle(model.Userdomyikado{Email: "korban@example.com", EmailVerification: c.status}); got != c.want {
+			t.Errorf("canAutoLinkGoogle(%q) = %v, want %v", c.status, got, c.want)
+		}
+	}
+}
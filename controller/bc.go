@@ -3,13 +3,13 @@ package controller
 import (
 	"encoding/json"
 	"net/http"
+	"strconv"
 	"strings"
 
 	"github.com/gocroot/config"
 	"github.com/gocroot/helper/at"
 	"github.com/gocroot/helper/atapi"
 	"github.com/gocroot/helper/atdb"
-	"github.com/gocroot/helper/watoken"
 	"github.com/gocroot/model"
 	"github.com/whatsauth/itmodel"
 	"go.mongodb.org/mongo-driver/bson"
@@ -17,96 +17,61 @@ import (
 )
 
 func GetDataSenders(respw http.ResponseWriter, req *http.Request) {
-	payload, err := watoken.Decode(config.PublicKeyWhatsAuth, at.GetLoginFromHeader(req))
+	payload, err := config.TokenKeyring.Decode(at.GetLoginFromHeader(req))
 	if err != nil {
-		var respn model.Response
-		respn.Status = "Error : Token Tidak Valid"
-		respn.Info = at.GetSecretFromHeader(req)
-		respn.Location = "Decode Token Error"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusForbidden, respn)
+		at.WriteError(respw, at.NewError(at.CodeInvalidToken, "Token Tidak Valid", err.Error()))
 		return
 	}
 	_, err = atdb.GetOneDoc[model.Userdomyikado](config.Mongoconn, "user", primitive.M{"phonenumber": payload.Id})
 	if err != nil {
-		var respn model.Response
-		respn.Status = "Error : Data user tidak di temukan"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusNotImplemented, respn)
+		at.WriteError(respw, at.NewError(at.CodeNotFound, "Data user tidak di temukan", err.Error()))
 		return
 	}
 	existingprjs, err := atdb.GetAllDoc[[]model.SenderDasboard](config.Mongoconn, "sender", primitive.M{})
 	if err != nil {
-		var respn model.Response
-		respn.Status = "Error : Data senders tidak di temukan"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusNotFound, respn)
+		at.WriteError(respw, at.NewError(at.CodeNotFound, "Data senders tidak di temukan", err.Error()))
 		return
 	}
 	if len(existingprjs) == 0 {
-		var respn model.Response
-		respn.Status = "Error : Data senders tidak di temukan"
-		respn.Response = "Kakak belum input sender, silahkan input dulu ya"
-		at.WriteJSON(respw, http.StatusNotFound, respn)
+		at.WriteError(respw, at.NewError(at.CodeNotFound, "Data senders tidak di temukan", "Kakak belum input sender, silahkan input dulu ya"))
 		return
 	}
 	at.WriteJSON(respw, http.StatusOK, existingprjs)
 }
 
 func GetDataSendersTerblokir(respw http.ResponseWriter, req *http.Request) {
-	payload, err := watoken.Decode(config.PublicKeyWhatsAuth, at.GetLoginFromHeader(req))
+	payload, err := config.TokenKeyring.Decode(at.GetLoginFromHeader(req))
 	if err != nil {
-		var respn model.Response
-		respn.Status = "Error : Token Tidak Valid"
-		respn.Info = at.GetSecretFromHeader(req)
-		respn.Location = "Decode Token Error"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusForbidden, respn)
+		at.WriteError(respw, at.NewError(at.CodeInvalidToken, "Token Tidak Valid", err.Error()))
 		return
 	}
 	_, err = atdb.GetOneDoc[model.Userdomyikado](config.Mongoconn, "user", primitive.M{"phonenumber": payload.Id})
 	if err != nil {
-		var respn model.Response
-		respn.Status = "Error : Data user tidak di temukan"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusNotImplemented, respn)
+		at.WriteError(respw, at.NewError(at.CodeNotFound, "Data user tidak di temukan", err.Error()))
 		return
 	}
 	existingprjs, err := atdb.GetAllDoc[[]model.SenderDasboard](config.Mongoconn, "bin", primitive.M{})
 	if err != nil {
-		var respn model.Response
-		respn.Status = "Error : Data senders tidak di temukan"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusNotFound, respn)
+		at.WriteError(respw, at.NewError(at.CodeNotFound, "Data senders tidak di temukan", err.Error()))
 		return
 	}
 	if len(existingprjs) == 0 {
-		var respn model.Response
-		respn.Status = "Error : Data senders tidak di temukan"
-		respn.Response = "Kakak belum input sender, silahkan input dulu ya"
-		at.WriteJSON(respw, http.StatusNotFound, respn)
+		at.WriteError(respw, at.NewError(at.CodeNotFound, "Data senders tidak di temukan", "Kakak belum input sender, silahkan input dulu ya"))
 		return
 	}
 	at.WriteJSON(respw, http.StatusOK, existingprjs)
 }
 
 func GetRekapBlast(respw http.ResponseWriter, req *http.Request) {
-	var respn model.Response
-	payload, err := watoken.Decode(config.PublicKeyWhatsAuth, at.GetLoginFromHeader(req))
+	payload, err := config.TokenKeyring.Decode(at.GetLoginFromHeader(req))
 	if err != nil {
-		respn.Status = "Error : Token Tidak Valid"
-		respn.Info = at.GetSecretFromHeader(req)
-		respn.Location = "Decode Token Error"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusForbidden, respn)
+		at.WriteError(respw, at.NewError(at.CodeInvalidToken, "Token Tidak Valid", err.Error()))
 		return
 	}
 	//check eksistensi user
 	docuser, err := atdb.GetOneDoc[model.Userdomyikado](config.Mongoconn, "user", primitive.M{"phonenumber": payload.Id})
 	if err != nil {
-		docuser.PhoneNumber = payload.Id
-		docuser.Name = payload.Alias
-		at.WriteJSON(respw, http.StatusNotFound, docuser)
+		at.WriteError(respw, at.NewError(at.CodeNotFound, "Data user tidak di temukan", err.Error()))
 		return
 	}
 	docuser.Name = payload.Alias
@@ -114,16 +79,12 @@ func GetRekapBlast(respw http.ResponseWriter, req *http.Request) {
 	// Menghitung jumlah dokumen dalam koleksi
 	countqueue, err := atdb.GetCountDoc(config.Mongoconn, "peserta", bson.M{})
 	if err != nil {
-		respn.Status = "Error : penghitungan data queue"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusConflict, respn)
+		at.WriteError(respw, at.NewError(at.CodeInternal, "penghitungan data queue", err.Error()))
 		return
 	}
 	countsent, err := atdb.GetCountDoc(config.Mongoconn, "sent", bson.M{})
 	if err != nil {
-		respn.Status = "Error : penghitungan data sent"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusConflict, respn)
+		at.WriteError(respw, at.NewError(at.CodeInternal, "penghitungan data sent", err.Error()))
 		return
 	}
 
@@ -137,38 +98,26 @@ func GetRekapBlast(respw http.ResponseWriter, req *http.Request) {
 
 // melakukan pendaftaran nomor blast dengan pengecekan apakah suda link device
 func PutNomorBlast(respw http.ResponseWriter, req *http.Request) {
-	payload, err := watoken.Decode(config.PublicKeyWhatsAuth, at.GetLoginFromHeader(req))
+	payload, err := config.TokenKeyring.Decode(at.GetLoginFromHeader(req))
 	if err != nil {
-		var respn model.Response
-		respn.Status = "Error : Token Tidak Valid "
-		respn.Info = at.GetSecretFromHeader(req)
-		respn.Location = "Decode Token Error: " + at.GetLoginFromHeader(req)
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusForbidden, respn)
+		at.WriteError(respw, at.NewError(at.CodeInvalidToken, "Token Tidak Valid", err.Error()))
 		return
 	}
 	var newbot model.SenderDasboard
 	err = json.NewDecoder(req.Body).Decode(&newbot)
 	if err != nil {
-		var respn model.Response
-		respn.Status = "Error : Body tidak valid"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, at.NewError(at.CodeInvalidBody, "Body tidak valid", err.Error()))
 		return
 	}
 	docuser, err := atdb.GetOneDoc[model.Userdomyikado](config.Mongoconn, "user", primitive.M{"phonenumber": payload.Id})
 	if err != nil {
-		docuser.PhoneNumber = payload.Id
-		docuser.Name = payload.Alias
-		at.WriteJSON(respw, http.StatusNotFound, docuser)
+		at.WriteError(respw, at.NewError(at.CodeNotFound, "Data user tidak di temukan", err.Error()))
 		return
 	}
 	docuser.Name = payload.Alias
 	//check apakah user sudah linked device atau belum
 	if docuser.LinkedDevice == "" {
-		var respn model.Response
-		respn.Status = "Error : User belum melakukan linked device"
-		at.WriteJSON(respw, http.StatusExpectationFailed, respn)
+		at.WriteError(respw, at.NewError(at.CodeBadRequest, "User belum melakukan linked device", ""))
 		return
 	}
 	//check validasi nomor inputan dengan mengirimkan pesan
@@ -179,33 +128,32 @@ func PutNomorBlast(respw http.ResponseWriter, req *http.Request) {
 	}
 	httpstatuscode, _, err := atapi.PostStructWithToken[model.Response]("token", config.WAAPIToken, newmsg, config.WAAPIMessage)
 	if httpstatuscode != 200 || err != nil {
-		var respn model.Response
-		respn.Status = "Error : Nomor yang diinputkan tidak valid"
+		detail := "status " + strconv.Itoa(httpstatuscode)
 		if err != nil {
-			respn.Response = err.Error()
+			detail = err.Error()
 		}
-		at.WriteJSON(respw, http.StatusExpectationFailed, respn)
+		at.WriteError(respw, at.NewError(at.CodeUpstream, "Nomor yang diinputkan tidak valid", detail))
 		return
 	}
 	//request linked device nomor yang didaftarkan
-	tokenbotbaru, err := watoken.Encode(newbot.Phonenumber, config.PrivateKey)
+	tokenbotbaru, err := config.TokenKeyring.Encode(newbot.Phonenumber)
 	if err != nil {
-		at.WriteJSON(respw, http.StatusMisdirectedRequest, docuser)
+		at.WriteError(respw, at.NewError(at.CodeInternal, "Gagal membuat token", err.Error()))
 		return
 	}
 	hcode, qrstat, err := atapi.Get[model.QRStatus](config.WAAPIGetDevice + tokenbotbaru)
 	if err != nil {
-		at.WriteJSON(respw, http.StatusMisdirectedRequest, docuser)
+		at.WriteError(respw, at.NewError(at.CodeUpstream, "Status device WhatsApp tidak bisa diambil", err.Error()))
 		return
 	}
 	if hcode != http.StatusOK {
-		at.WriteJSON(respw, http.StatusFailedDependency, docuser)
+		at.WriteError(respw, at.NewError(at.CodeUpstream, "Status device WhatsApp tidak bisa diambil", "status "+strconv.Itoa(hcode)))
 		return
 	}
 	//insert ke coll sender dan profile
 	contohsender, err := atdb.GetOneLatestDoc[itmodel.Profile](config.Mongoconn, "sender", bson.M{})
 	if err != nil {
-		at.WriteJSON(respw, http.StatusFailedDependency, docuser)
+		at.WriteError(respw, at.NewError(at.CodeInternal, "Contoh sender tidak bisa diambil", err.Error()))
 		return
 	}
 	contohsender.Botname = docuser.Name
@@ -217,14 +165,14 @@ func PutNomorBlast(respw http.ResponseWriter, req *http.Request) {
 	baseURL := contohsender.URL[:lastSlashIndex+1]
 	// Gabungkan baseURL dengan phonenumber
 	contohsender.URL = baseURL + newbot.Phonenumber
-	contohsender.Token, err = watoken.EncodeforHours(newbot.Phonenumber, docuser.Name, config.PrivateKey, 43830)
+	contohsender.Token, err = config.TokenKeyring.EncodeforHours(newbot.Phonenumber, docuser.Name, 43830)
 	if err != nil {
-		at.WriteJSON(respw, http.StatusFailedDependency, docuser)
+		at.WriteError(respw, at.NewError(at.CodeInternal, "Gagal membuat token", err.Error()))
 		return
 	}
 	_, err = atdb.InsertOneDoc(config.Mongoconn, "sender", contohsender)
 	if err != nil {
-		at.WriteJSON(respw, http.StatusFailedDependency, docuser)
+		at.WriteError(respw, at.NewError(at.CodeInternal, "Gagal menyimpan sender", err.Error()))
 		return
 	}
 	//daftarkan ke webhook agar bot aktif dan insert kan ke profile
@@ -234,17 +182,16 @@ func PutNomorBlast(respw http.ResponseWriter, req *http.Request) {
 	}
 	httpstatuscode, _, err = atapi.PostStructWithToken[model.Response]("token", contohsender.Token, whdt, config.WAAPIGetToken)
 	if httpstatuscode != 200 || err != nil {
-		var respn model.Response
-		respn.Status = "Error : Gagal mendaftarkan ke webhook"
+		detail := "status " + strconv.Itoa(httpstatuscode)
 		if err != nil {
-			respn.Response = err.Error()
+			detail = err.Error()
 		}
-		at.WriteJSON(respw, http.StatusExpectationFailed, respn)
+		at.WriteError(respw, at.NewError(at.CodeUpstream, "Gagal mendaftarkan ke webhook", detail))
 		return
 	}
 	_, err = atdb.InsertOneDoc(config.Mongoconn, "profile", contohsender)
 	if err != nil {
-		at.WriteJSON(respw, http.StatusFailedDependency, docuser)
+		at.WriteError(respw, at.NewError(at.CodeInternal, "Gagal menyimpan profile", err.Error()))
 		return
 	}
 
@@ -258,12 +205,11 @@ func PutNomorBlast(respw http.ResponseWriter, req *http.Request) {
 		}
 		httpstatuscode, _, err = atapi.PostStructWithToken[model.Response]("token", config.WAAPIToken, newmsg, config.WAAPITextMessage)
 		if httpstatuscode != 200 || err != nil {
-			var respn model.Response
-			respn.Status = "Error : Nomor yang diinputkan tidak valid"
+			detail := "status " + strconv.Itoa(httpstatuscode)
 			if err != nil {
-				respn.Response = err.Error()
+				detail = err.Error()
 			}
-			at.WriteJSON(respw, http.StatusExpectationFailed, respn)
+			at.WriteError(respw, at.NewError(at.CodeUpstream, "Nomor yang diinputkan tidak valid", detail))
 			return
 		}
 	}
@@ -1,36 +1,34 @@
 package controller
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"time"
 
 	"github.com/gocroot/config"
+	"github.com/gocroot/helper/at"
 	"github.com/gocroot/helper/atdb"
+	"github.com/gocroot/helper/router"
+	"github.com/gocroot/helper/watoken"
 	"github.com/gocroot/model"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-// CreateCart - Fungsi untuk membuat cart baru
+// CreateCart - Fungsi untuk membuat cart milik user dari token, jika sudah ada cart yang sama dikembalikan
 func CreateCart(w http.ResponseWriter, r *http.Request) {
-	var cart model.Cart
-
-	// Decode JSON request ke struct Cart
-	decoder := json.NewDecoder(r.Body)
-	if err := decoder.Decode(&cart); err != nil {
-		http.Error(w, "Bad request", http.StatusBadRequest)
+	payload, err := decodeLoginToken(r)
+	if err != nil {
+		writeTokenError(w, err)
 		return
 	}
 
-	// Generate timestamp untuk CreatedAt dan UpdatedAt
-	cart.CreatedAt = time.Now()
-	cart.UpdatedAt = time.Now()
-
-	// Insert Cart ke dalam database
-	insertedID, err := atdb.InsertOneDoc(config.Mongoconn, "cart", cart)
+	cart, err := getOrCreateCart(payload.Id)
 	if err != nil {
-		http.Error(w, "Failed to create cart", http.StatusInternalServerError)
+		at.WriteError(w, at.NewError(at.CodeInternal, "Gagal membuat cart", err.Error()))
 		return
 	}
 
@@ -38,113 +36,111 @@ func CreateCart(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	response := map[string]interface{}{
 		"status":  "success",
-		"cart_id": insertedID,
+		"cart_id": cart.ID,
 	}
 	json.NewEncoder(w).Encode(response)
 }
 
-// GetCart - Fungsi untuk mendapatkan cart berdasarkan user_id
+// GetCart - Fungsi untuk mendapatkan cart berdasarkan user_id, hanya untuk pemilik cart
 func GetCart(w http.ResponseWriter, r *http.Request) {
-	// Mendapatkan user_id dari URL params
-	userID := r.URL.Query().Get("user_id")
-	objectID, err := primitive.ObjectIDFromHex(userID)
+	payload, err := decodeLoginToken(r)
 	if err != nil {
-		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		writeTokenError(w, err)
+		return
+	}
+	// Mendapatkan user_id dari URL params dan harus sama dengan pemilik token
+	userID := router.Param(r, "user_id")
+	if userID != payload.Id {
+		at.WriteError(w, at.NewError(at.CodeForbidden, "Cart bukan milik user ini", ""))
 		return
 	}
-
-	// Mencari cart berdasarkan user_id
-	filter := bson.M{"user_id": objectID}
 
 	// Mendapatkan cart menggunakan GetOneDoc
-	cart, err := atdb.GetOneDoc[model.Cart](config.Mongoconn, "cart", filter)
+	cart, err := atdb.GetOneDoc[model.Cart](config.Mongoconn, "cart", bson.M{"user_id": userID})
 	if err != nil {
-		http.Error(w, "Cart not found", http.StatusNotFound)
+		at.WriteError(w, at.NewError(at.CodeNotFound, "Cart tidak ditemukan", err.Error()))
 		return
 	}
 
-	// Mengirimkan response cart ke client dengan format JSON
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(cart); err != nil {
-		http.Error(w, "Error encoding cart", http.StatusInternalServerError)
-		return
-	}
+	// Mengirimkan response cart beserta harga dan total ke client dengan format JSON
+	writeCartSummary(w, cart)
 }
 
+// AddItemToCart - Fungsi untuk menambahkan item, cart dibuat otomatis saat item pertama ditambahkan
 func AddItemToCart(w http.ResponseWriter, r *http.Request) {
+	payload, err := decodeLoginToken(r)
+	if err != nil {
+		writeTokenError(w, err)
+		return
+	}
 	var cartItem model.CartItem
 
 	// Decode JSON request ke struct CartItem
 	decoder := json.NewDecoder(r.Body)
 	if err := decoder.Decode(&cartItem); err != nil {
-		http.Error(w, "Bad request", http.StatusBadRequest)
+		at.WriteError(w, at.NewError(at.CodeInvalidBody, "", err.Error()))
 		return
 	}
 
-	// Mendapatkan user_id dari URL params
-	userID := r.URL.Query().Get("user_id")
-	objectID, err := primitive.ObjectIDFromHex(userID)
+	if cartItem.Quantity <= 0 {
+		at.WriteError(w, at.NewError(at.CodeValidation, "Jumlah harus lebih dari 0", ""))
+		return
+	}
+	// Produk yang ditambahkan harus ada di collection product
+	_, err = atdb.GetOneDoc[model.Product](config.Mongoconn, model.ProductCollection, bson.M{"_id": cartItem.ProductID})
 	if err != nil {
-		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		at.WriteError(w, at.NewError(at.CodeNotFound, "Produk tidak ditemukan", err.Error()))
 		return
 	}
 
-	// Mencari cart berdasarkan user_id
-	filter := bson.M{"user_id": objectID}
-	cart, err := atdb.GetOneDoc[model.Cart](config.Mongoconn, "cart", filter)
+	cart, err := getOrCreateCart(payload.Id)
 	if err != nil {
-		http.Error(w, "Cart not found", http.StatusNotFound)
+		at.WriteError(w, at.NewError(at.CodeInternal, "Gagal membuat cart", err.Error()))
 		return
 	}
 
-	// Menambahkan item ke dalam cart
-	cart.Items = append(cart.Items, cartItem)
-	cart.UpdatedAt = time.Now()
-
-	// Update cart di database
-	updateFields := bson.M{"items": cart.Items, "updated_at": cart.UpdatedAt}
-	_, err = atdb.UpdateOneDoc(config.Mongoconn, "cart", filter, updateFields)
-	if err != nil {
-		http.Error(w, "Failed to add item to cart", http.StatusInternalServerError)
+	// Menambahkan item ke dalam cart, quantity digabung jika produk sudah ada
+	cart.AddItem(cartItem)
+	if err = saveCartItems(&cart); err != nil {
+		at.WriteError(w, at.NewError(at.CodeInternal, "Gagal menambah item ke cart", err.Error()))
 		return
 	}
 
 	// Mengirimkan response ke client
-	w.Header().Set("Content-Type", "application/json")
-	response := map[string]string{"status": "success", "message": "Item added to cart"}
-	json.NewEncoder(w).Encode(response)
+	writeCartSummary(w, cart)
 }
 
 // UpdateItemInCart - Fungsi untuk memperbarui kuantitas item dalam cart
 func UpdateItemInCart(w http.ResponseWriter, r *http.Request) {
+	payload, err := decodeLoginToken(r)
+	if err != nil {
+		writeTokenError(w, err)
+		return
+	}
 	var cartItem model.CartItem
 
 	// Decode JSON request ke struct CartItem
 	decoder := json.NewDecoder(r.Body)
 	if err := decoder.Decode(&cartItem); err != nil {
-		http.Error(w, "Bad request", http.StatusBadRequest)
+		at.WriteError(w, at.NewError(at.CodeInvalidBody, "", err.Error()))
 		return
 	}
 
-	// Mendapatkan user_id dan product_id dari URL params
-	userID := r.URL.Query().Get("user_id")
-	productID := r.URL.Query().Get("product_id")
-	objectID, err := primitive.ObjectIDFromHex(userID)
-	if err != nil {
-		http.Error(w, "Invalid user ID", http.StatusBadRequest)
-		return
-	}
-	productObjectID, err := primitive.ObjectIDFromHex(productID)
-	if err != nil {
-		http.Error(w, "Invalid product ID", http.StatusBadRequest)
-		return
+	// product_id diambil dari body, jika kosong dari URL params
+	productObjectID := cartItem.ProductID
+	if productObjectID.IsZero() {
+		productObjectID, err = primitive.ObjectIDFromHex(r.URL.Query().Get("product_id"))
+		if err != nil {
+			at.WriteError(w, at.NewError(at.CodeBadRequest, "ID produk tidak valid", err.Error()))
+			return
+		}
 	}
 
-	// Mencari cart berdasarkan user_id
-	filter := bson.M{"user_id": objectID}
+	// Mencari cart berdasarkan user_id dari token
+	filter := bson.M{"user_id": payload.Id}
 	cart, err := atdb.GetOneDoc[model.Cart](config.Mongoconn, "cart", filter)
 	if err != nil {
-		http.Error(w, "Cart not found", http.StatusNotFound)
+		at.WriteError(w, at.NewError(at.CodeNotFound, "Cart tidak ditemukan", err.Error()))
 		return
 	}
 
@@ -159,72 +155,104 @@ func UpdateItemInCart(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if !itemFound {
-		http.Error(w, "Product not found in cart", http.StatusNotFound)
+		at.WriteError(w, at.NewError(at.CodeNotFound, "Produk tidak ada di cart", ""))
 		return
 	}
-
-	// Update waktu terakhir diubah
-	cart.UpdatedAt = time.Now()
+	// Quantity 0 atau kurang berarti item dihapus dari cart
+	if cartItem.Quantity <= 0 {
+		cart.RemoveItem(productObjectID)
+	}
 
 	// Update cart di database
-	updateFields := bson.M{"items": cart.Items, "updated_at": cart.UpdatedAt}
-	_, err = atdb.UpdateOneDoc(config.Mongoconn, "cart", filter, updateFields)
-	if err != nil {
-		http.Error(w, "Failed to update item in cart", http.StatusInternalServerError)
+	if err = saveCartItems(&cart); err != nil {
+		at.WriteError(w, at.NewError(at.CodeInternal, "Gagal memperbarui item cart", err.Error()))
 		return
 	}
 
 	// Mengirimkan response ke client
-	w.Header().Set("Content-Type", "application/json")
-	response := map[string]string{"status": "success", "message": "Item updated in cart"}
-	json.NewEncoder(w).Encode(response)
+	writeCartSummary(w, cart)
 }
 
 // DeleteItemFromCart - Fungsi untuk menghapus item dari cart
 func DeleteItemFromCart(w http.ResponseWriter, r *http.Request) {
-	// Mendapatkan user_id dan product_id dari URL params
-	userID := r.URL.Query().Get("user_id")
-	productID := r.URL.Query().Get("product_id")
-	objectID, err := primitive.ObjectIDFromHex(userID)
+	payload, err := decodeLoginToken(r)
 	if err != nil {
-		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		writeTokenError(w, err)
 		return
 	}
+	// Mendapatkan product_id dari URL params
+	productID := r.URL.Query().Get("product_id")
 	productObjectID, err := primitive.ObjectIDFromHex(productID)
 	if err != nil {
-		http.Error(w, "Invalid product ID", http.StatusBadRequest)
+		at.WriteError(w, at.NewError(at.CodeBadRequest, "ID produk tidak valid", err.Error()))
 		return
 	}
 
-	// Mencari cart berdasarkan user_id
-	filter := bson.M{"user_id": objectID}
+	// Mencari cart berdasarkan user_id dari token
+	filter := bson.M{"user_id": payload.Id}
 	cart, err := atdb.GetOneDoc[model.Cart](config.Mongoconn, "cart", filter)
 	if err != nil {
-		http.Error(w, "Cart not found", http.StatusNotFound)
+		at.WriteError(w, at.NewError(at.CodeNotFound, "Cart tidak ditemukan", err.Error()))
 		return
 	}
 
 	// Menghapus item dari cart
-	var updatedItems []model.CartItem
-	for _, item := range cart.Items {
-		if item.ProductID != productObjectID {
-			updatedItems = append(updatedItems, item)
-		}
+	cart.RemoveItem(productObjectID)
+
+	// Update cart di database
+	if err = saveCartItems(&cart); err != nil {
+		at.WriteError(w, at.NewError(at.CodeInternal, "Gagal menghapus item cart", err.Error()))
+		return
 	}
 
-	cart.Items = updatedItems
-	cart.UpdatedAt = time.Now()
+	// Mengirimkan response ke client
+	writeCartSummary(w, cart)
+}
 
-	// Update cart di database
+// saveCartItems menyimpan items cart dan waktu terakhir diubah
+func saveCartItems(cart *model.Cart) (err error) {
+	cart.UpdatedAt = time.Now()
+	if cart.Items == nil {
+		cart.Items = []model.CartItem{}
+	}
 	updateFields := bson.M{"items": cart.Items, "updated_at": cart.UpdatedAt}
-	_, err = atdb.UpdateOneDoc(config.Mongoconn, "cart", filter, updateFields)
+	_, err = atdb.UpdateOneDoc(config.Mongoconn, "cart", bson.M{"_id": cart.ID}, updateFields)
+	return
+}
+
+// writeCartSummary mengirim cart dengan harga satuan, subtotal per item dan total cart dari collection product
+func writeCartSummary(w http.ResponseWriter, cart model.Cart) {
+	summary, err := cart.Summary(config.Mongoconn.Collection(model.ProductCollection))
 	if err != nil {
-		http.Error(w, "Failed to delete item from cart", http.StatusInternalServerError)
+		at.WriteError(w, at.NewError(at.CodeInternal, "Gagal menghitung total cart", err.Error()))
 		return
 	}
+	at.WriteJSON(w, http.StatusOK, summary)
+}
+
+// getOrCreateCart mengambil cart aktif milik user, dibuat dengan upsert supaya satu user hanya punya satu cart
+func getOrCreateCart(userID string) (cart model.Cart, err error) {
+	now := time.Now()
+	filter := bson.M{"user_id": userID}
+	update := bson.M{"$setOnInsert": bson.M{
+		"user_id":    userID,
+		"created_at": now,
+		"updated_at": now,
+	}}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+	err = config.Mongoconn.Collection("cart").FindOneAndUpdate(context.TODO(), filter, update, opts).Decode(&cart)
+	if mongo.IsDuplicateKeyError(err) {
+		// request lain baru saja membuat cart user ini, ulangi untuk mengambil cart tersebut
+		err = config.Mongoconn.Collection("cart").FindOneAndUpdate(context.TODO(), filter, update, opts).Decode(&cart)
+	}
+	return
+}
 
-	// Mengirimkan response ke client
-	w.Header().Set("Content-Type", "application/json")
-	response := map[string]string{"status": "success", "message": "Item deleted from cart"}
-	json.NewEncoder(w).Encode(response)
+// decodeLoginToken membaca token dari header Login dengan keyring token
+func decodeLoginToken(r *http.Request) (payload watoken.Payload[any], err error) {
+	return config.TokenKeyring.Decode(at.GetLoginFromHeader(r))
+}
+
+func writeTokenError(w http.ResponseWriter, err error) {
+	at.WriteError(w, at.NewError(at.CodeInvalidToken, "Token Tidak Valid", err.Error()))
 }
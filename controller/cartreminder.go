@@ -0,0 +1,145 @@
+package controller
+
+import (
+	"context"
+	"crypto/subtle"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gocroot/config"
+	"github.com/gocroot/helper/at"
+	"github.com/gocroot/helper/atapi"
+	"github.com/gocroot/helper/atdb"
+	"github.com/gocroot/model"
+	"github.com/whatsauth/itmodel"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// lama cart tidak berubah sebelum dikirim pengingat
+	cartIdle = 24 * time.Hour
+	// jarak minimal antar pengingat untuk satu user
+	cartReminderInterval = 72 * time.Hour
+	// jumlah pengingat maksimal sekali jalan
+	cartReminderBatch = 100
+)
+
+// GetCartReminder dipanggil dari cron untuk mengirim pengingat WhatsApp ke pemilik cart yang lama tidak berubah.
+// Request wajib membawa header X-Cron-Secret, jeda idle dan interval tetap dari server supaya tidak bisa dipakai spam.
+func GetCartReminder(respw http.ResponseWriter, req *http.Request) {
+	var resp model.Response
+	if !authorizeCron(respw, req) {
+		return
+	}
+	sent, err := sendCartReminders(cartIdle, cartReminderInterval)
+	if err != nil {
+		at.WriteError(respw, at.NewError(at.CodeInternal, "Pengingat cart gagal", err.Error()))
+		return
+	}
+	resp.Status = "Success"
+	resp.Response = strconv.Itoa(sent) + " pengingat cart terkirim"
+	at.WriteJSON(respw, http.StatusOK, resp)
+}
+
+// authorizeCron mencocokkan header X-Cron-Secret dengan secret CRON_SECRET,
+// jika ditolak response sudah ditulis dan mengembalikan false
+func authorizeCron(respw http.ResponseWriter, req *http.Request) bool {
+	secret := config.CronSecret()
+	if secret == "" {
+		at.WriteError(respw, at.NewError(at.CodeUnavailable, "Cron belum dikonfigurasi", "secret "+config.SecretCron+" belum diset"))
+		return false
+	}
+	if subtle.ConstantTimeCompare([]byte(req.Header.Get("X-Cron-Secret")), []byte(secret)) != 1 {
+		at.WriteError(respw, at.NewError(at.CodeUnauthorized, "", "X-Cron-Secret tidak valid"))
+		return false
+	}
+	return true
+}
+
+// sendCartReminders mencari cart idle milik user yang tidak unsubscribe lalu mengirim daftar itemnya
+func sendCartReminders(idle, interval time.Duration) (sent int, err error) {
+	unsubscribed, err := atdb.GetAllDistinct[string](config.Mongoconn, bson.M{}, "phone_number", "unsubscribe")
+	if err != nil {
+		return
+	}
+	now := time.Now()
+	filter := bson.M{
+		"user_id":    bson.M{"$nin": unsubscribed},
+		"items.0":    bson.M{"$exists": true},
+		"updated_at": bson.M{"$lt": now.Add(-idle)},
+		"$or":        reminderDue(now, interval),
+	}
+	opts := options.Find().SetLimit(cartReminderBatch)
+	cur, err := config.Mongoconn.Collection("cart").Find(context.TODO(), filter, opts)
+	if err != nil {
+		return
+	}
+	var carts []model.Cart
+	if err = cur.All(context.TODO(), &carts); err != nil {
+		return
+	}
+	products := config.Mongoconn.Collection(model.ProductCollection)
+	for _, cart := range carts {
+		// klaim cart dengan update bersyarat supaya cron yang berjalan bersamaan tidak mengirim dua kali
+		claim := bson.M{"_id": cart.ID, "$or": reminderDue(now, interval)}
+		res, err := config.Mongoconn.Collection("cart").UpdateOne(context.TODO(), claim, bson.M{"$set": bson.M{"reminded_at": now}})
+		if err != nil || res.ModifiedCount == 0 {
+			continue
+		}
+		summary, err := cart.Summary(products)
+		if err == nil {
+			summary.Items = summary.Available()
+		}
+		if err == nil && len(summary.Items) > 0 {
+			dt := &itmodel.TextMessage{
+				To:       cart.UserID,
+				IsGroup:  false,
+				Messages: cartReminderMessage(summary),
+			}
+			_, _, err = atapi.PostStructWithToken[itmodel.Response]("Token", config.WAAPIToken, dt, config.WAAPIMessage)
+			if err == nil {
+				sent++
+				continue
+			}
+		}
+		// pengingat tidak terkirim, klaim dikembalikan supaya cart ikut di cron berikutnya
+		unclaimCartReminder(cart)
+	}
+	return
+}
+
+// unclaimCartReminder mengembalikan reminded_at ke nilai sebelum diklaim
+func unclaimCartReminder(cart model.Cart) {
+	update := bson.M{"$unset": bson.M{"reminded_at": ""}}
+	if !cart.RemindedAt.IsZero() {
+		update = bson.M{"$set": bson.M{"reminded_at": cart.RemindedAt}}
+	}
+	if _, err := config.Mongoconn.Collection("cart").UpdateOne(context.TODO(), bson.M{"_id": cart.ID}, update); err != nil {
+		log.Println("gagal mengembalikan klaim pengingat cart " + cart.ID.Hex() + ": " + err.Error())
+	}
+}
+
+// reminderDue cart belum pernah diingatkan, atau sudah berubah sejak pengingat terakhir dan sudah lewat interval
+func reminderDue(now time.Time, interval time.Duration) bson.A {
+	return bson.A{
+		bson.M{"reminded_at": bson.M{"$exists": false}},
+		bson.M{
+			"reminded_at": bson.M{"$lt": now.Add(-interval)},
+			"$expr":       bson.M{"$lt": bson.A{"$reminded_at", "$updated_at"}},
+		},
+	}
+}
+
+func cartReminderMessage(summary model.CartSummary) string {
+	var lines []string
+	for _, item := range summary.Items {
+		lines = append(lines, "- "+item.Name+" x"+strconv.Itoa(item.Quantity)+" Rp "+strconv.FormatInt(item.Subtotal, 10))
+	}
+	return "*Keranjang kamu masih menunggu*\nHai kak, barang berikut masih ada di keranjang:\n" + strings.Join(lines, "\n") +
+		"\nTotal: Rp " + strconv.FormatInt(summary.Total, 10) +
+		"\nYuk selesaikan checkout sebelum stoknya habis.\n\n_Ketik unsubscribe jika tidak ingin menerima pengingat lagi._"
+}
@@ -1,65 +1,286 @@
 package controller
 
 import (
-	// "context"
-	// "fmt"
+	"context"
 	"encoding/json"
+	"errors"
+	"log"
 	"net/http"
-
-	// "time"
+	"strconv"
+	"time"
 
 	"github.com/gocroot/config"
 	"github.com/gocroot/helper/at"
 	"github.com/gocroot/helper/atdb"
-	"github.com/gocroot/helper/watoken"
+	"github.com/gocroot/helper/productevent"
+	"github.com/gocroot/helper/rbac"
+	"github.com/gocroot/helper/router"
+	"github.com/gocroot/helper/voucher"
 	"github.com/gocroot/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-func Createcheckout(respw http.ResponseWriter, req *http.Request) {
-	// Decode token
-	_, err := watoken.Decode(config.PublicKeyWhatsAuth, at.GetLoginFromHeader(req))
+// lama stok ditahan untuk checkout yang belum dibayar
+const reservasiCheckout = 30 * time.Minute
+
+// CheckoutCart mengubah cart milik user menjadi checkout, harga dikunci dari collection product dan stok ditahan sementara
+func CheckoutCart(respw http.ResponseWriter, req *http.Request) {
+	payload, err := decodeLoginToken(req)
 	if err != nil {
-		_, err = watoken.Decode(config.PUBLICKEY, at.GetLoginFromHeader(req))
-		if err != nil {
-			var respn model.Response
-			respn.Status = "Error: Token Tidak Valid"
-			respn.Response = err.Error()
-			at.WriteJSON(respw, http.StatusForbidden, respn)
-			return
-		}
+		writeTokenError(respw, err)
+		return
 	}
-
 	var checkout model.Checkout
 	if err := json.NewDecoder(req.Body).Decode(&checkout); err != nil {
-		respn := model.Response{
-			Status:   "Invalid Request",
-			Response: err.Error(),
+		at.WriteError(respw, at.NewError(at.CodeInvalidBody, "", err.Error()))
+		return
+	}
+	cart, err := atdb.GetOneDoc[model.Cart](config.Mongoconn, "cart", bson.M{"user_id": payload.Id})
+	if err != nil || len(cart.Items) == 0 {
+		at.WriteError(respw, at.NewError(at.CodeBadRequest, "Cart kosong", "Tidak ada item di cart untuk di checkout"))
+		return
+	}
+	addr, err := getCheckoutAddress(checkout.AddressID, payload.Id)
+	if err != nil {
+		at.WriteError(respw, at.NewError(at.CodeBadRequest, "Alamat tidak valid", err.Error()))
+		return
+	}
+	summary, err := cart.Summary(config.Mongoconn.Collection(model.ProductCollection))
+	if err != nil {
+		at.WriteError(respw, at.NewError(at.CodeNotFound, "Produk di cart tidak ditemukan", err.Error()))
+		return
+	}
+	if len(summary.Available()) != len(summary.Items) {
+		at.WriteError(respw, at.NewError(at.CodeConflict, "Produk di cart sudah tidak tersedia", "Hapus produk yang ditandai unavailable dari cart"))
+		return
+	}
+	// lapak diambil dari produk di cart, bukan dari body, supaya pembeli tidak bisa menahan stok atas nama lapak lain
+	namalapak, err := cartLapak(summary.Items)
+	if err != nil {
+		at.WriteError(respw, at.NewError(at.CodeValidation, "Cart tidak bisa di checkout", err.Error()))
+		return
+	}
+	if checkout.NamaLapak != "" && checkout.NamaLapak != namalapak {
+		at.WriteError(respw, at.NewError(at.CodeValidation, "Lapak tidak sesuai isi cart", "Produk di cart berasal dari lapak "+namalapak))
+		return
+	}
+	checkout.NamaLapak = namalapak
+	// ongkir dihitung dari titik asal lapak ke alamat pengiriman
+	quote, err := shippingQuote(checkout.NamaLapak, addr)
+	if err != nil {
+		at.WriteError(respw, at.NewError(at.CodeValidation, "Ongkir tidak bisa dihitung", err.Error()))
+		return
+	}
+	// voucher dihitung dari harga yang sudah dikunci di summary
+	var breakdown voucher.Breakdown
+	var v voucher.Voucher
+	if checkout.VoucherCode != "" {
+		breakdown, v, err = applyVoucher(checkout.VoucherCode, summary.Items, payload.Id)
+		if err != nil {
+			at.WriteError(respw, at.NewError(at.CodeValidation, "Voucher tidak bisa dipakai", err.Error()))
+			return
 		}
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+	}
+	if err = reserveStock(summary.Items); err != nil {
+		at.WriteError(respw, at.NewError(at.CodeConflict, "Stok tidak cukup", err.Error()))
 		return
 	}
 
+	now := time.Now()
 	newCheckout := model.Checkout{
-		Address:       checkout.Address,
-		Product:       checkout.Product,
+		ID:            primitive.NewObjectID(),
+		PhoneNumber:   payload.Id,
+		NamaLapak:     checkout.NamaLapak,
+		Address:       addr.String(),
+		AddressID:     addr.ID,
+		Shipping:      &addr,
+		Items:         summary.Items,
 		PaymentMethod: checkout.PaymentMethod,
-		TotalPrice:    checkout.TotalPrice,
+		Subtotal:      float64(summary.Total),
+		ShippingFee:   float64(quote.Fee),
+		TotalPrice:    float64(summary.Total + quote.Fee),
+		Status:        model.CheckoutPending,
+		CreatedAt:     now,
+		ReservedUntil: now.Add(reservasiCheckout),
+	}
+	if checkout.VoucherCode != "" {
+		if err = redeemVoucher(v, payload.Id, newCheckout.ID, breakdown.Discount); err != nil {
+			releaseStock(summary.Items)
+			at.WriteError(respw, at.NewError(at.CodeConflict, "Voucher tidak bisa dipakai", err.Error()))
+			return
+		}
+		newCheckout.VoucherCode = v.Code
+		newCheckout.Discount = float64(breakdown.Discount)
+		newCheckout.TotalPrice = float64(breakdown.Total + quote.Fee)
 	}
-
 	_, err = atdb.InsertOneDoc(config.Mongoconn, "checkout", newCheckout)
 	if err != nil {
-		respn := model.Response{
-			Status:   "Failed to insert new user",
-			Response: err.Error(),
+		releaseStock(summary.Items)
+		releaseVoucher(newCheckout.ID)
+		at.WriteError(respw, at.NewError(at.CodeInternal, "Gagal Insert Database", err.Error()))
+		return
+	}
+	// kosongkan cart setelah checkout berhasil, checkout dan reservasi stok sudah tersimpan
+	// sehingga kegagalan di sini hanya dicatat supaya client tidak mengulang checkout dan menahan stok dua kali
+	cart.Items = nil
+	if err = saveCartItems(&cart); err != nil {
+		log.Println("gagal mengosongkan cart " + payload.Id + " setelah checkout " + newCheckout.ID.Hex() + ": " + err.Error())
+	}
+	at.WriteJSON(respw, http.StatusOK, newCheckout)
+}
+
+// cartLapak lapak tunggal dari item cart, satu checkout hanya untuk satu lapak
+func cartLapak(items []model.CartLine) (string, error) {
+	var namalapak string
+	for _, item := range items {
+		switch {
+		case item.NamaLapak == "":
+			return "", errors.New("produk " + item.Name + " tidak punya lapak")
+		case namalapak == "":
+			namalapak = item.NamaLapak
+		case item.NamaLapak != namalapak:
+			return "", errors.New("cart berisi produk dari lapak " + namalapak + " dan " + item.NamaLapak + ", checkout per lapak")
 		}
-		at.WriteJSON(respw, http.StatusInternalServerError, respn)
+	}
+	return namalapak, nil
+}
+
+// ReleaseExpiredCheckout dipanggil dari cron untuk melepas stok checkout yang tidak dibayar tepat waktu
+func ReleaseExpiredCheckout(respw http.ResponseWriter, req *http.Request) {
+	var resp model.Response
+	count, err := releaseExpiredCheckouts()
+	if err != nil {
+		at.WriteError(respw, at.NewError(at.CodeInternal, "", err.Error()))
+		return
+	}
+	resp.Response = strconv.Itoa(count) + " checkout kedaluwarsa"
+	at.WriteJSON(respw, http.StatusOK, resp)
+}
+
+// PutCheckoutShipped owner lapak menandai checkout lunas sudah dikirim
+func PutCheckoutShipped(respw http.ResponseWriter, req *http.Request) {
+	checkout, ok := getCheckoutParam(respw, req)
+	if !ok {
+		return
+	}
+	if err := checkLapakAccess(req, rbac.LapakManage, checkout.NamaLapak); err != nil {
+		writeForbidden(respw, err)
+		return
+	}
+	moveCheckoutStatus(respw, checkout, model.CheckoutPaid, model.CheckoutShipped, "shippedat")
+}
+
+// PutCheckoutDone pembeli mengonfirmasi barang dari checkout miliknya sudah diterima, setelah itu produknya bisa diulas
+func PutCheckoutDone(respw http.ResponseWriter, req *http.Request) {
+	payload, err := decodeLoginToken(req)
+	if err != nil {
+		writeTokenError(respw, err)
+		return
+	}
+	checkout, ok := getCheckoutParam(respw, req)
+	if !ok {
+		return
+	}
+	if checkout.PhoneNumber != payload.Id {
+		at.WriteError(respw, at.NewError(at.CodeForbidden, "User bukan pemilik checkout", "User bukan pembeli dari checkout ini"))
+		return
+	}
+	moveCheckoutStatus(respw, checkout, model.CheckoutShipped, model.CheckoutDone, "doneat")
+}
+
+// getCheckoutParam mengambil checkout dari param url checkoutid
+func getCheckoutParam(respw http.ResponseWriter, req *http.Request) (checkout model.Checkout, ok bool) {
+	objectId, err := primitive.ObjectIDFromHex(router.Param(req, "checkoutid"))
+	if err != nil {
+		at.WriteError(respw, at.NewError(at.CodeBadRequest, "ObjectID Tidak Valid", err.Error()))
+		return
+	}
+	checkout, err = atdb.GetOneDoc[model.Checkout](config.Mongoconn, "checkout", bson.M{"_id": objectId})
+	if err != nil {
+		at.WriteError(respw, at.NewError(at.CodeNotFound, "Data checkout tidak di temukan", err.Error()))
+		return
+	}
+	return checkout, true
+}
+
+// moveCheckoutStatus memindahkan status checkout dengan update bersyarat status asal, lalu mengirim checkout terbaru
+func moveCheckoutStatus(respw http.ResponseWriter, checkout model.Checkout, from, to, timeField string) {
+	var updated model.Checkout
+	err := config.Mongoconn.Collection("checkout").FindOneAndUpdate(context.TODO(),
+		bson.M{"_id": checkout.ID, "status": from},
+		bson.M{"$set": bson.M{"status": to, timeField: time.Now()}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&updated)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		at.WriteError(respw, at.NewError(at.CodeConflict, "Status tidak bisa diubah", "Status "+checkout.Status+" tidak bisa diubah menjadi "+to))
+		return
+	}
+	if err != nil {
+		at.WriteError(respw, at.NewError(at.CodeInternal, "Gagal memperbarui checkout", err.Error()))
 		return
 	}
+	at.WriteJSON(respw, http.StatusOK, updated)
+}
+
+// reserveStock mengurangi stok setiap produk secara atomik, jika salah satu gagal stok yang sudah dikurangi dikembalikan
+func reserveStock(items []model.CartLine) (err error) {
+	products := config.Mongoconn.Collection(model.ProductCollection)
+	for i, item := range items {
+		filter := bson.M{"_id": item.ProductID, "stock": bson.M{"$gte": item.Quantity}}
+		update := bson.M{"$inc": bson.M{"stock": -item.Quantity}}
+		var res *mongo.UpdateResult
+		res, err = products.UpdateOne(context.TODO(), filter, update)
+		if err == nil && res.ModifiedCount == 0 {
+			err = errors.New("stok " + item.Name + " tidak mencukupi")
+		}
+		if err != nil {
+			releaseStock(items[:i])
+			return
+		}
+	}
+	return
+}
 
-	response := map[string]interface{}{
-		"status":       "success",
-		"message":      "checkout berhasil di buat",
-		"nama_product": newCheckout.Product,
+// releaseStock mengembalikan stok produk yang ditahan, produk yang stoknya kembali dari habis dikabarkan ke wishlist
+func releaseStock(items []model.CartLine) {
+	products := config.Mongoconn.Collection(model.ProductCollection)
+	for _, item := range items {
+		var before model.Product
+		err := products.FindOneAndUpdate(context.TODO(), bson.M{"_id": item.ProductID}, bson.M{"$inc": bson.M{"stock": item.Quantity}}).Decode(&before)
+		if err != nil {
+			log.Println("gagal mengembalikan stok " + item.ProductID.Hex() + ": " + err.Error())
+			continue
+		}
+		after := productSnapshot(before)
+		after.Stock += item.Quantity
+		if change, changed := productevent.Diff(before.ID, productSnapshot(before), after); changed {
+			emitProductChange(change)
+		}
+	}
+}
+
+// releaseExpiredCheckouts menandai checkout pending yang lewat batas waktu sebagai expired lalu mengembalikan stoknya.
+// Status diubah dulu dengan filter pending supaya stok tidak dikembalikan dua kali.
+func releaseExpiredCheckouts() (count int, err error) {
+	filter := bson.M{"status": model.CheckoutPending, "reserveduntil": bson.M{"$lt": time.Now()}}
+	expired, err := atdb.GetAllDoc[[]model.Checkout](config.Mongoconn, "checkout", filter)
+	if err != nil {
+		return
+	}
+	for _, checkout := range expired {
+		res, err := config.Mongoconn.Collection("checkout").UpdateOne(context.TODO(),
+			bson.M{"_id": checkout.ID, "status": model.CheckoutPending},
+			bson.M{"$set": bson.M{"status": model.CheckoutExpired}})
+		if err != nil || res.ModifiedCount == 0 {
+			continue
+		}
+		releaseStock(checkout.Items)
+		if checkout.VoucherCode != "" {
+			releaseVoucher(checkout.ID)
+		}
+		count++
 	}
-	at.WriteJSON(respw, http.StatusOK, response)
+	return
 }
@@ -1 +1,188 @@
 package controller
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"path"
+	"time"
+
+	"github.com/gocroot/config"
+	"github.com/gocroot/helper/at"
+	"github.com/gocroot/helper/atdb"
+	"github.com/gocroot/helper/ghupload"
+	"github.com/gocroot/helper/rbac"
+	"github.com/gocroot/helper/router"
+	"github.com/gocroot/model"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// PostKonfirmasiPembayaran pembeli mengupload bukti bayar (screenshot transfer) untuk checkout miliknya
+func PostKonfirmasiPembayaran(w http.ResponseWriter, r *http.Request) {
+	payload, err := config.TokenKeyring.Decode(at.GetLoginFromHeader(r))
+	if err != nil {
+		at.WriteError(w, at.NewError(at.CodeInvalidToken, "Token Tidak Valid", err.Error()))
+		return
+	}
+	checkoutid := router.Param(r, "checkoutid")
+	objectId, err := primitive.ObjectIDFromHex(checkoutid)
+	if err != nil {
+		at.WriteError(w, at.NewError(at.CodeBadRequest, "ObjectID Tidak Valid", err.Error()))
+		return
+	}
+	checkout, err := atdb.GetOneDoc[model.Checkout](config.Mongoconn, "checkout", primitive.M{"_id": objectId})
+	if err != nil {
+		at.WriteError(w, at.NewError(at.CodeNotFound, "Data checkout tidak di temukan", err.Error()))
+		return
+	}
+	//check apakah dia pembeli
+	if checkout.PhoneNumber != payload.Id {
+		at.WriteError(w, at.NewError(at.CodeForbidden, "User bukan pemilik checkout", "User bukan pembeli dari checkout ini"))
+		return
+	}
+	if checkout.Status == model.CheckoutExpired {
+		at.WriteError(w, at.NewError(at.CodeConflict, "Checkout sudah kedaluwarsa", "Batas waktu pembayaran checkout ini sudah lewat"))
+		return
+	}
+	if checkout.Status != model.CheckoutPending {
+		at.WriteError(w, at.NewError(at.CodeConflict, "Checkout sudah lunas", "Pembayaran checkout ini sudah di approve"))
+		return
+	}
+
+	file, header, err := r.FormFile("buktibayar")
+	if err != nil {
+		at.WriteError(w, at.NewError(at.CodeBadRequest, "File tidak ada", err.Error()))
+		return
+	}
+	defer file.Close()
+	// Read the file content
+	fileContent, err := io.ReadAll(file)
+	if err != nil {
+		at.WriteError(w, at.NewError(at.CodeInternal, "File tidak bisa dibaca", err.Error()))
+		return
+	}
+
+	// Get GitHub credentials and other details from the request or environment variables
+	GitHubAccessToken := config.GHAccessToken()
+	GitHubAuthorName := config.GHAuthorName()
+	GitHubAuthorEmail := config.GHAuthorEmail()
+	githubOrg := "penerbitbukupedia"
+	githubRepo := "pembayaran"
+	pathFile := checkout.ID.Hex() + "/" + ghupload.CalculateHash(fileContent) + path.Ext(header.Filename) // Append the original file extension
+	replace := true
+
+	content, _, err := ghupload.GithubUpload(GitHubAccessToken, GitHubAuthorName, GitHubAuthorEmail, fileContent, githubOrg, githubRepo, pathFile, replace)
+	if err != nil {
+		at.WriteError(w, at.NewError(at.CodeUpstream, "File tidak bisa diupload ke github", err.Error()))
+		return
+	}
+
+	conf := model.Confirmation{
+		CheckoutID:  checkout.ID,
+		PhoneNumber: payload.Id,
+		BuktiBayar:  "https://raw.githubusercontent.com/" + githubOrg + "/" + githubRepo + "/main/" + *content.Content.Path,
+		Status:      model.ConfirmationPending,
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+	}
+	conf.ID, err = atdb.InsertOneDoc(config.Mongoconn, "confirmation", conf)
+	if err != nil {
+		at.WriteError(w, at.NewError(at.CodeInternal, "Gagal Insert Database", err.Error()))
+		return
+	}
+	at.WriteJSON(w, http.StatusOK, conf)
+}
+
+// PutKonfirmasiPembayaran owner lapak meng-approve atau menolak bukti bayar, jika approve kwitansi dikirim ke pembeli
+func PutKonfirmasiPembayaran(respw http.ResponseWriter, req *http.Request) {
+	payload, err := config.TokenKeyring.Decode(at.GetLoginFromHeader(req))
+	if err != nil {
+		at.WriteError(respw, at.NewError(at.CodeInvalidToken, "Token Tidak Valid", err.Error()))
+		return
+	}
+	var confreq model.ConfirmationRequest
+	err = json.NewDecoder(req.Body).Decode(&confreq)
+	if err != nil {
+		at.WriteError(respw, at.NewError(at.CodeInvalidBody, "Body tidak valid", err.Error()))
+		return
+	}
+	objectId, err := primitive.ObjectIDFromHex(confreq.ID)
+	if err != nil {
+		at.WriteError(respw, at.NewError(at.CodeBadRequest, "ObjectID Tidak Valid", err.Error()))
+		return
+	}
+	conf, err := atdb.GetOneDoc[model.Confirmation](config.Mongoconn, "confirmation", primitive.M{"_id": objectId})
+	if err != nil {
+		at.WriteError(respw, at.NewError(at.CodeNotFound, "Data konfirmasi tidak di temukan", err.Error()))
+		return
+	}
+	if conf.Status != model.ConfirmationPending {
+		at.WriteError(respw, at.NewError(at.CodeConflict, "Konfirmasi sudah diproses", "Status konfirmasi sudah "+conf.Status))
+		return
+	}
+	checkout, err := atdb.GetOneDoc[model.Checkout](config.Mongoconn, "checkout", primitive.M{"_id": conf.CheckoutID})
+	if err != nil {
+		at.WriteError(respw, at.NewError(at.CodeNotFound, "Data checkout tidak di temukan", err.Error()))
+		return
+	}
+	prj, err := atdb.GetOneDoc[model.Project](config.Mongoconn, "project", primitive.M{"name": checkout.NamaLapak})
+	if err != nil {
+		at.WriteError(respw, at.NewError(at.CodeNotFound, "Data lapak tidak di temukan", err.Error()))
+		return
+	}
+	//check apakah dia owner lapak
+	if err = authorizeResource(req, rbac.LapakManage, prj.Owner.PhoneNumber); err != nil {
+		writeForbidden(respw, err)
+		return
+	}
+
+	pending := conf
+	conf.ApprovedBy = payload.Id
+	conf.Alasan = confreq.Alasan
+	conf.UpdatedAt = time.Now()
+	if !confreq.Approve {
+		conf.Status = model.ConfirmationRejected
+		// hanya konfirmasi yang masih pending yang bisa ditolak, supaya tidak menimpa approve yang berjalan bersamaan
+		res, err := atdb.ReplaceOneDoc(config.Mongoconn, "confirmation", primitive.M{"_id": conf.ID, "status": model.ConfirmationPending}, conf)
+		if err == nil && res.MatchedCount == 0 {
+			at.WriteError(respw, at.NewError(at.CodeConflict, "Konfirmasi sudah diproses", "Konfirmasi ini sudah diproses request lain"))
+			return
+		}
+		if err != nil {
+			at.WriteError(respw, at.NewError(at.CodeInternal, "Gagal memperbarui database", err.Error()))
+			return
+		}
+		at.WriteJSON(respw, http.StatusOK, conf)
+		return
+	}
+
+	// konfirmasi diklaim dulu dengan filter pending yang sama seperti reject, supaya reject yang berjalan bersamaan tidak tertimpa
+	conf.Status = model.ConfirmationApproved
+	res, err := atdb.ReplaceOneDoc(config.Mongoconn, "confirmation", primitive.M{"_id": conf.ID, "status": model.ConfirmationPending}, conf)
+	if err == nil && res.MatchedCount == 0 {
+		at.WriteError(respw, at.NewError(at.CodeConflict, "Konfirmasi sudah diproses", "Konfirmasi ini sudah diproses request lain"))
+		return
+	}
+	if err != nil {
+		at.WriteError(respw, at.NewError(at.CodeInternal, "Gagal memperbarui database", err.Error()))
+		return
+	}
+	//checkout yang sudah kedaluwarsa stoknya sudah dilepas jadi tidak bisa di approve
+	checkout, err = setCheckoutPaid(checkout)
+	if err != nil {
+		// checkout gagal dilunasi, konfirmasi dikembalikan ke pending supaya bisa ditolak
+		_, uerr := atdb.ReplaceOneDoc(config.Mongoconn, "confirmation", primitive.M{"_id": conf.ID, "status": model.ConfirmationApproved}, pending)
+		if uerr != nil {
+			log.Println("gagal mengembalikan konfirmasi " + conf.ID.Hex() + ": " + uerr.Error())
+		}
+		at.WriteError(respw, at.NewError(at.CodeConflict, "Gagal memperbarui checkout", err.Error()))
+		return
+	}
+	err = SendReceiptWA(checkout, conf)
+	if err != nil {
+		at.WriteError(respw, at.NewError(at.CodeUpstream, "Kwitansi gagal dikirim", err.Error()).WithInfo(checkout.ReceiptNumber))
+		return
+	}
+	at.WriteJSON(respw, http.StatusOK, conf)
+}
@@ -0,0 +1,144 @@
+package controller
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gocroot/config"
+	"github.com/gocroot/helper/at"
+	"github.com/gocroot/helper/dashboard"
+	"github.com/gocroot/helper/rbac"
+	"github.com/gocroot/helper/router"
+)
+
+// GetLapakOrders antrian pesanan lapak dari collection order dan checkout untuk owner lapak.
+// Query: source, status, from, to (YYYY-MM-DD), limit, format=csv
+func GetLapakOrders(respw http.ResponseWriter, req *http.Request) {
+	filter, ok := lapakDashboardFilter(respw, req)
+	if !ok {
+		return
+	}
+	ctx := context.TODO()
+	cur, err := config.Mongoconn.Collection("order").Aggregate(ctx, dashboard.QueuePipeline(filter))
+	if err != nil {
+		writeDashboardError(respw, err)
+		return
+	}
+	defer cur.Close(ctx)
+	rows := []dashboard.Row{}
+	if err = cur.All(ctx, &rows); err != nil {
+		writeDashboardError(respw, err)
+		return
+	}
+	if req.URL.Query().Get("format") == "csv" {
+		writeCSVHeader(respw, "pesanan-"+filter.NamaLapak+".csv")
+		dashboard.WriteQueueCSV(respw, rows)
+		return
+	}
+	at.WriteJSON(respw, http.StatusOK, rows)
+}
+
+// GetLapakSummary ringkasan pendapatan per periode, barang terlaris dan rata-rata nilai pesanan lapak.
+// Query: period (daily, weekly, monthly), source, from, to (YYYY-MM-DD), format=csv
+func GetLapakSummary(respw http.ResponseWriter, req *http.Request) {
+	filter, ok := lapakDashboardFilter(respw, req)
+	if !ok {
+		return
+	}
+	period := req.URL.Query().Get("period")
+	if period == "" {
+		period = dashboard.PeriodDaily
+	}
+	if filter.From.IsZero() && filter.To.IsZero() {
+		filter.From, filter.To = dashboard.DefaultRange(period, time.Now())
+	}
+	pipeline, err := dashboard.SummaryPipeline(filter, period)
+	if err != nil {
+		at.WriteError(respw, at.NewError(at.CodeBadRequest, "Parameter tidak valid", err.Error()))
+		return
+	}
+	ctx := context.TODO()
+	cur, err := config.Mongoconn.Collection("order").Aggregate(ctx, pipeline)
+	if err != nil {
+		writeDashboardError(respw, err)
+		return
+	}
+	defer cur.Close(ctx)
+	var facets []struct {
+		Overall     []dashboard.Summary    `bson:"overall"`
+		Revenue     []dashboard.Bucket     `bson:"revenue"`
+		BestSellers []dashboard.BestSeller `bson:"best_sellers"`
+	}
+	if err = cur.All(ctx, &facets); err != nil {
+		writeDashboardError(respw, err)
+		return
+	}
+	summary := dashboard.Summary{Revenue: []dashboard.Bucket{}, BestSellers: []dashboard.BestSeller{}}
+	if len(facets) > 0 {
+		if len(facets[0].Overall) > 0 {
+			summary = facets[0].Overall[0]
+		}
+		summary.Revenue = facets[0].Revenue
+		summary.BestSellers = facets[0].BestSellers
+	}
+	summary.NamaLapak = filter.NamaLapak
+	summary.Period = period
+	summary.From = filter.From
+	summary.To = filter.To
+	if req.URL.Query().Get("format") == "csv" {
+		writeCSVHeader(respw, "ringkasan-"+filter.NamaLapak+"-"+period+".csv")
+		dashboard.WriteSummaryCSV(respw, summary)
+		return
+	}
+	at.WriteJSON(respw, http.StatusOK, summary)
+}
+
+// lapakDashboardFilter membaca filter dashboard dan memastikan user dari token adalah owner lapak di param url
+func lapakDashboardFilter(respw http.ResponseWriter, req *http.Request) (filter dashboard.Filter, ok bool) {
+	filter.NamaLapak = router.Param(req, "namalapak")
+	if err := checkLapakAccess(req, rbac.LapakReport, filter.NamaLapak); err != nil {
+		writeForbidden(respw, err)
+		return
+	}
+	query := req.URL.Query()
+	filter.Source = query.Get("source")
+	filter.Status = query.Get("status")
+	if filter.Source != "" && filter.Source != dashboard.SourceOrder && filter.Source != dashboard.SourceCheckout {
+		at.WriteError(respw, at.NewError(at.CodeBadRequest, "Parameter tidak valid", "source harus order atau checkout"))
+		return
+	}
+	loc, err := time.LoadLocation(dashboard.TimeZone)
+	if err != nil {
+		loc = time.Local
+	}
+	if from := query.Get("from"); from != "" {
+		if filter.From, err = time.ParseInLocation("2006-01-02", from, loc); err != nil {
+			at.WriteError(respw, at.NewError(at.CodeBadRequest, "Parameter tidak valid", "from harus berformat YYYY-MM-DD"))
+			return
+		}
+	}
+	if to := query.Get("to"); to != "" {
+		if filter.To, err = time.ParseInLocation("2006-01-02", to, loc); err != nil {
+			at.WriteError(respw, at.NewError(at.CodeBadRequest, "Parameter tidak valid", "to harus berformat YYYY-MM-DD"))
+			return
+		}
+		// tanggal to ikut dihitung sampai akhir hari
+		filter.To = filter.To.AddDate(0, 0, 1)
+	}
+	if limit := query.Get("limit"); limit != "" {
+		filter.Limit, _ = strconv.ParseInt(limit, 10, 64)
+	}
+	return filter, true
+}
+
+func writeDashboardError(respw http.ResponseWriter, err error) {
+	at.WriteError(respw, at.NewError(at.CodeInternal, "Data dashboard tidak bisa diambil", err.Error()))
+}
+
+func writeCSVHeader(respw http.ResponseWriter, filename string) {
+	respw.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	respw.Header().Set("Content-Disposition", "attachment; filename=\""+filename+"\"")
+	respw.WriteHeader(http.StatusOK)
+}
@@ -11,37 +11,29 @@ import (
 	"github.com/gocroot/helper/atdb"
 	"github.com/gocroot/helper/dokped"
 	"github.com/gocroot/helper/ghupload"
-	"github.com/gocroot/helper/watoken"
+	"github.com/gocroot/helper/rbac"
+	"github.com/gocroot/helper/router"
 	"github.com/gocroot/model"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 func AksesFileRepoDraft(w http.ResponseWriter, r *http.Request) {
-	var respn model.Response
-	payload, err := watoken.Decode(config.PublicKeyWhatsAuth, at.GetLoginFromHeader(r))
+	payload, err := config.TokenKeyring.Decode(at.GetLoginFromHeader(r))
 	if err != nil {
-		respn.Status = "Error : Token Tidak Valid"
-		respn.Info = at.GetSecretFromHeader(r)
-		respn.Location = "Decode Token Error"
-		respn.Response = err.Error()
-		at.WriteJSON(w, http.StatusForbidden, respn)
+		at.WriteError(w, at.NewError(at.CodeInvalidToken, "Token Tidak Valid", err.Error()))
 		return
 	}
-	docuser, err := atdb.GetOneDoc[model.Userdomyikado](config.Mongoconn, "user", primitive.M{"phonenumber": payload.Id})
+	_, err = atdb.GetOneDoc[model.Userdomyikado](config.Mongoconn, "user", primitive.M{"phonenumber": payload.Id})
 	if err != nil {
-		respn.Status = "Error : Data user tidak di temukan"
-		respn.Response = err.Error()
-		at.WriteJSON(w, http.StatusNotImplemented, respn)
+		at.WriteError(w, at.NewError(at.CodeNotFound, "Data user tidak di temukan", err.Error()))
 		return
 	}
-	pathFileBase64 := at.GetParam(r)
+	pathFileBase64 := router.Param(r, "path")
 	// Decode string dari Base64
 	decoded, err := base64.StdEncoding.DecodeString(pathFileBase64)
 	if err != nil {
-		respn.Status = "Error : decoding base64"
-		respn.Response = err.Error()
-		at.WriteJSON(w, http.StatusNotImplemented, respn)
+		at.WriteError(w, at.NewError(at.CodeBadRequest, "decoding base64", err.Error()))
 		return
 	}
 	pathFile := string(decoded)
@@ -50,170 +42,117 @@ func AksesFileRepoDraft(w http.ResponseWriter, r *http.Request) {
 	//cek apakah user memiliki akses ke project
 	prj, err := atdb.GetOneDoc[model.Project](config.Mongoconn, "project", primitive.M{"name": namaprj})
 	if err != nil {
-		respn.Status = "Error : Data lapak tidak di temukan"
-		respn.Response = err.Error()
-		at.WriteJSON(w, http.StatusNotImplemented, respn)
+		at.WriteError(w, at.NewError(at.CodeNotFound, "Data lapak tidak di temukan", err.Error()))
 		return
 	}
-	//check apakah dia owner
-	if (prj.Owner.PhoneNumber != docuser.PhoneNumber) && (prj.Editor.PhoneNumber != docuser.PhoneNumber) {
-		if !docuser.IsManager { //kalo bukan manager maka ga punya akses dong
-			respn.Status = "Error : User bukan owner project tidak berhak"
-			respn.Response = "User bukan owner dari project ini"
-			at.WriteJSON(w, http.StatusNotImplemented, respn)
-			return
-		}
+	//owner dan editor project boleh akses, manager boleh akses semua project
+	if err = authorizeResource(r, rbac.ProjectDraft, prj.Owner.PhoneNumber, prj.Editor.PhoneNumber); err != nil {
+		writeForbidden(w, err)
+		return
 	}
 
 	githubOrg := "penerbitbukupedia"
 	githubRepo := "draft"
-	filecontent, err := ghupload.GithubGetFile(config.GHAccessToken, githubOrg, githubRepo, pathFile)
+	filecontent, err := ghupload.GithubGetFile(config.GHAccessToken(), githubOrg, githubRepo, pathFile)
 	if err != nil {
-		respn.Status = "Error : Data tidak bisa diambil dari github"
-		respn.Info = githubOrg + "/" + githubRepo
-		respn.Location = pathFile
-		respn.Response = err.Error()
-		at.WriteJSON(w, http.StatusBadRequest, respn)
+		at.WriteError(w, at.NewError(at.CodeUpstream, "Data tidak bisa diambil dari github", err.Error()).WithInfo(githubOrg+"/"+githubRepo))
 		return
 	}
 	at.WriteFile(w, http.StatusOK, filecontent)
 }
 
 func GetFileDraftSPK(w http.ResponseWriter, r *http.Request) {
-	var respn model.Response
-	payload, err := watoken.Decode(config.PublicKeyWhatsAuth, at.GetLoginFromHeader(r))
+	payload, err := config.TokenKeyring.Decode(at.GetLoginFromHeader(r))
 	if err != nil {
-		respn.Status = "Error : Token Tidak Valid"
-		respn.Info = at.GetSecretFromHeader(r)
-		respn.Location = "Decode Token Error"
-		respn.Response = err.Error()
-		at.WriteJSON(w, http.StatusForbidden, respn)
+		at.WriteError(w, at.NewError(at.CodeInvalidToken, "Token Tidak Valid", err.Error()))
 		return
 	}
-	docuser, err := atdb.GetOneDoc[model.Userdomyikado](config.Mongoconn, "user", primitive.M{"phonenumber": payload.Id})
+	_, err = atdb.GetOneDoc[model.Userdomyikado](config.Mongoconn, "user", primitive.M{"phonenumber": payload.Id})
 	if err != nil {
-		respn.Status = "Error : Data user tidak di temukan"
-		respn.Response = err.Error()
-		at.WriteJSON(w, http.StatusNotImplemented, respn)
+		at.WriteError(w, at.NewError(at.CodeNotFound, "Data user tidak di temukan", err.Error()))
 		return
 	}
-	pathFileBase64 := at.GetParam(r)
+	pathFileBase64 := router.Param(r, "namaproject")
 	// Decode string dari Base64
 	decoded, err := base64.StdEncoding.DecodeString(pathFileBase64)
 	if err != nil {
-		respn.Status = "Error : decoding base64"
-		respn.Response = err.Error()
-		at.WriteJSON(w, http.StatusNotImplemented, respn)
+		at.WriteError(w, at.NewError(at.CodeBadRequest, "decoding base64", err.Error()))
 		return
 	}
 	namaprj := string(decoded)
 	//cek apakah user memiliki akses ke project
 	prj, err := atdb.GetOneDoc[model.Project](config.Mongoconn, "project", primitive.M{"name": namaprj})
 	if err != nil {
-		respn.Status = "Error : Data lapak tidak di temukan"
-		respn.Response = err.Error()
-		at.WriteJSON(w, http.StatusNotImplemented, respn)
+		at.WriteError(w, at.NewError(at.CodeNotFound, "Data lapak tidak di temukan", err.Error()))
 		return
 	}
 	//check apakah dia owner
-	if prj.Owner.PhoneNumber != docuser.PhoneNumber {
-		respn.Status = "Error : User bukan owner project tidak berhak"
-		respn.Response = "User bukan owner dari project ini"
-		at.WriteJSON(w, http.StatusNotImplemented, respn)
+	if err = authorizeResource(r, rbac.ProjectRead, prj.Owner.PhoneNumber); err != nil {
+		writeForbidden(w, err)
 		return
 	}
 	filecontent, err := dokped.GenerateSPK(prj, config.AESKey)
 	if err != nil {
-		respn.Status = "Error : Dokumen gagal di generate"
-		respn.Info = prj.Name
-		respn.Location = prj.ID.Hex()
-		respn.Response = err.Error()
-		at.WriteJSON(w, http.StatusBadRequest, respn)
+		at.WriteError(w, at.NewError(at.CodeInternal, "Dokumen gagal di generate", err.Error()).WithInfo(prj.Name))
 		return
 	}
 	at.WriteFile(w, http.StatusOK, filecontent)
 }
 
 func GetFileDraftSPKT(w http.ResponseWriter, r *http.Request) {
-	var respn model.Response
-	payload, err := watoken.Decode(config.PublicKeyWhatsAuth, at.GetLoginFromHeader(r))
+	payload, err := config.TokenKeyring.Decode(at.GetLoginFromHeader(r))
 	if err != nil {
-		respn.Status = "Error : Token Tidak Valid"
-		respn.Info = at.GetSecretFromHeader(r)
-		respn.Location = "Decode Token Error"
-		respn.Response = err.Error()
-		at.WriteJSON(w, http.StatusForbidden, respn)
+		at.WriteError(w, at.NewError(at.CodeInvalidToken, "Token Tidak Valid", err.Error()))
 		return
 	}
-	docuser, err := atdb.GetOneDoc[model.Userdomyikado](config.Mongoconn, "user", primitive.M{"phonenumber": payload.Id})
+	_, err = atdb.GetOneDoc[model.Userdomyikado](config.Mongoconn, "user", primitive.M{"phonenumber": payload.Id})
 	if err != nil {
-		respn.Status = "Error : Data user tidak di temukan"
-		respn.Response = err.Error()
-		at.WriteJSON(w, http.StatusNotImplemented, respn)
+		at.WriteError(w, at.NewError(at.CodeNotFound, "Data user tidak di temukan", err.Error()))
 		return
 	}
-	pathFileBase64 := at.GetParam(r)
+	pathFileBase64 := router.Param(r, "namaproject")
 	// Decode string dari Base64
 	decoded, err := base64.StdEncoding.DecodeString(pathFileBase64)
 	if err != nil {
-		respn.Status = "Error : decoding base64"
-		respn.Response = err.Error()
-		at.WriteJSON(w, http.StatusNotImplemented, respn)
+		at.WriteError(w, at.NewError(at.CodeBadRequest, "decoding base64", err.Error()))
 		return
 	}
 	namaprj := string(decoded)
 	//cek apakah user memiliki akses ke project
 	prj, err := atdb.GetOneDoc[model.Project](config.Mongoconn, "project", primitive.M{"name": namaprj})
 	if err != nil {
-		respn.Status = "Error : Data lapak tidak di temukan"
-		respn.Response = err.Error()
-		at.WriteJSON(w, http.StatusNotImplemented, respn)
+		at.WriteError(w, at.NewError(at.CodeNotFound, "Data lapak tidak di temukan", err.Error()))
 		return
 	}
 	//check apakah dia owner
-	if prj.Owner.PhoneNumber != docuser.PhoneNumber {
-		respn.Status = "Error : User bukan owner project tidak berhak"
-		respn.Response = "User bukan owner dari project ini"
-		at.WriteJSON(w, http.StatusNotImplemented, respn)
+	if err = authorizeResource(r, rbac.ProjectRead, prj.Owner.PhoneNumber); err != nil {
+		writeForbidden(w, err)
 		return
 	}
 	filecontent, err := dokped.GenerateSPKT(prj, config.AESKey)
 	if err != nil {
-		respn.Status = "Error : Dokumen gagal di generate"
-		respn.Info = prj.Name
-		respn.Location = prj.ID.Hex()
-		respn.Response = err.Error()
-		at.WriteJSON(w, http.StatusBadRequest, respn)
+		at.WriteError(w, at.NewError(at.CodeInternal, "Dokumen gagal di generate", err.Error()).WithInfo(prj.Name))
 		return
 	}
 	at.WriteFile(w, http.StatusOK, filecontent)
 }
 
 func GetFileDraftSPI(w http.ResponseWriter, r *http.Request) {
-	var respn model.Response
-	payload, err := watoken.Decode(config.PublicKeyWhatsAuth, at.GetLoginFromHeader(r))
+	payload, err := config.TokenKeyring.Decode(at.GetLoginFromHeader(r))
 	if err != nil {
-		respn.Status = "Error : Token Tidak Valid"
-		respn.Info = at.GetSecretFromHeader(r)
-		respn.Location = "Decode Token Error"
-		respn.Response = err.Error()
-		at.WriteJSON(w, http.StatusForbidden, respn)
+		at.WriteError(w, at.NewError(at.CodeInvalidToken, "Token Tidak Valid", err.Error()))
 		return
 	}
-	docuser, err := atdb.GetOneDoc[model.Userdomyikado](config.Mongoconn, "user", primitive.M{"phonenumber": payload.Id})
+	_, err = atdb.GetOneDoc[model.Userdomyikado](config.Mongoconn, "user", primitive.M{"phonenumber": payload.Id})
 	if err != nil {
-		respn.Status = "Error : Data user tidak di temukan"
-		respn.Response = err.Error()
-		at.WriteJSON(w, http.StatusNotImplemented, respn)
+		at.WriteError(w, at.NewError(at.CodeNotFound, "Data user tidak di temukan", err.Error()))
 		return
 	}
-	pathFileBase64 := at.GetParam(r)
+	pathFileBase64 := router.Param(r, "path")
 	// Decode string dari Base64
 	decoded, err := base64.StdEncoding.DecodeString(pathFileBase64)
 	if err != nil {
-		respn.Status = "Error : decoding base64"
-		respn.Response = err.Error()
-		at.WriteJSON(w, http.StatusNotImplemented, respn)
+		at.WriteError(w, at.NewError(at.CodeBadRequest, "decoding base64", err.Error()))
 		return
 	}
 	pathFile := string(decoded)
@@ -222,47 +161,31 @@ func GetFileDraftSPI(w http.ResponseWriter, r *http.Request) {
 	//cek apakah user memiliki akses ke project
 	prj, err := atdb.GetOneDoc[model.Project](config.Mongoconn, "project", primitive.M{"name": namaprj})
 	if err != nil {
-		respn.Status = "Error : Data lapak tidak di temukan"
-		respn.Response = err.Error()
-		at.WriteJSON(w, http.StatusNotImplemented, respn)
+		at.WriteError(w, at.NewError(at.CodeNotFound, "Data lapak tidak di temukan", err.Error()))
 		return
 	}
 	//check apakah dia owner
-	if prj.Owner.PhoneNumber != docuser.PhoneNumber {
-		respn.Status = "Error : User bukan owner project tidak berhak"
-		respn.Response = "User bukan owner dari project ini"
-		at.WriteJSON(w, http.StatusNotImplemented, respn)
+	if err = authorizeResource(r, rbac.ProjectRead, prj.Owner.PhoneNumber); err != nil {
+		writeForbidden(w, err)
 		return
 	}
 	//ambil surat pengantar
 	filecontentpengantar, err := dokped.GenerateSPI(prj, config.AESKey)
 	if err != nil {
-		respn.Status = "Error : Dokumen gagal di generate"
-		respn.Info = prj.Name
-		respn.Location = prj.ID.Hex()
-		respn.Response = err.Error()
-		at.WriteJSON(w, http.StatusBadRequest, respn)
+		at.WriteError(w, at.NewError(at.CodeInternal, "Dokumen gagal di generate", err.Error()).WithInfo(prj.Name))
 		return
 	}
 	//gabungkan dengan pdf sampul
 	/* githubOrg := "penerbitbukupedia"
 	githubRepo := "draft"
-	filecontentsampul, err := ghupload.GithubGetFile(config.GHAccessToken, githubOrg, githubRepo, pathFile)
+	filecontentsampul, err := ghupload.GithubGetFile(config.GHAccessToken(), githubOrg, githubRepo, pathFile)
 	if err != nil {
-		respn.Status = "Error : Data tidak bisa diambil dari github"
-		respn.Info = githubOrg + "/" + githubRepo
-		respn.Location = pathFile
-		respn.Response = err.Error()
-		at.WriteJSON(w, http.StatusBadRequest, respn)
+		at.WriteError(w, at.NewError(at.CodeUpstream, "Data tidak bisa diambil dari github", err.Error()).WithInfo(githubOrg + "/" + githubRepo))
 		return
 	}
 	filecontent, err := fpdf.MergePDFBytes(filecontentpengantar, filecontentsampul)
 	if err != nil {
-		respn.Status = "Error : Dokumen gagal di merge"
-		respn.Info = prj.Name
-		respn.Location = prj.ID.Hex()
-		respn.Response = err.Error()
-		at.WriteJSON(w, http.StatusBadRequest, respn)
+		at.WriteError(w, at.NewError(at.CodeInternal, "Dokumen gagal di merge", err.Error()).WithInfo(prj.Name))
 		return
 	} */
 	at.WriteFile(w, http.StatusOK, filecontentpengantar)
@@ -270,45 +193,35 @@ func GetFileDraftSPI(w http.ResponseWriter, r *http.Request) {
 
 func UploadProfilePictureHandler(w http.ResponseWriter, r *http.Request) {
 	var respn model.Response
-	payload, err := watoken.Decode(config.PublicKeyWhatsAuth, at.GetLoginFromHeader(r))
+	payload, err := config.TokenKeyring.Decode(at.GetLoginFromHeader(r))
 	if err != nil {
-		respn.Status = "Error : Token Tidak Valid"
-		respn.Info = at.GetSecretFromHeader(r)
-		respn.Location = "Decode Token Error"
-		respn.Response = err.Error()
-		at.WriteJSON(w, http.StatusForbidden, respn)
+		at.WriteError(w, at.NewError(at.CodeInvalidToken, "Token Tidak Valid", err.Error()))
 		return
 	}
 	userdoc, err := atdb.GetOneDoc[model.Userdomyikado](config.Mongoconn, "user", primitive.M{"phonenumber": payload.Id})
 	if err != nil {
-		respn.Status = "Error : Data user tidak di temukan"
-		respn.Response = err.Error()
-		at.WriteJSON(w, http.StatusNotImplemented, respn)
+		at.WriteError(w, at.NewError(at.CodeNotFound, "Data user tidak di temukan", err.Error()))
 		return
 	}
 	file, header, err := r.FormFile("profpic")
 	if err != nil {
-		respn.Status = "Error : File tidak ada"
-		respn.Response = err.Error()
-		at.WriteJSON(w, http.StatusBadRequest, respn)
+		at.WriteError(w, at.NewError(at.CodeBadRequest, "File tidak ada", err.Error()))
 		return
 	}
 	defer file.Close()
 	// Read the file content
 	fileContent, err := io.ReadAll(file)
 	if err != nil {
-		respn.Status = "Error : File tidak bisa dibaca"
-		respn.Response = err.Error()
-		at.WriteJSON(w, http.StatusInternalServerError, respn)
+		at.WriteError(w, at.NewError(at.CodeInternal, "File tidak bisa dibaca", err.Error()))
 		return
 	}
 	// Calculate hash of the file content
 	//hashedFileName := ghupload.CalculateHash(fileContent)
 
 	// Get GitHub credentials and other details from the request or environment variables
-	GitHubAccessToken := config.GHAccessToken
-	GitHubAuthorName := "Rolly Maulana Awangga"
-	GitHubAuthorEmail := "awangga@gmail.com"
+	GitHubAccessToken := config.GHAccessToken()
+	GitHubAuthorName := config.GHAuthorName()
+	GitHubAuthorEmail := config.GHAuthorEmail()
 	githubOrg := "penerbitbukupedia"
 	githubRepo := "profile"
 	pathFile := "picture/" + userdoc.ID.Hex() + "/" + userdoc.ID.Hex() + header.Filename[strings.LastIndex(header.Filename, "."):] // Append the original file extension
@@ -317,9 +230,7 @@ func UploadProfilePictureHandler(w http.ResponseWriter, r *http.Request) {
 	// Use GithubUpload function to upload the file to GitHub
 	content, _, err := ghupload.GithubUpload(GitHubAccessToken, GitHubAuthorName, GitHubAuthorEmail, fileContent, githubOrg, githubRepo, pathFile, replace)
 	if err != nil {
-		respn.Status = "Error : File tidak bisa diupload ke github"
-		respn.Response = err.Error()
-		at.WriteJSON(w, http.StatusInternalServerError, respn)
+		at.WriteError(w, at.NewError(at.CodeUpstream, "File tidak bisa diupload ke github", err.Error()))
 		return
 	}
 	//update data profpic
@@ -336,55 +247,43 @@ func UploadProfilePictureHandler(w http.ResponseWriter, r *http.Request) {
 
 func FileUploadWithParamFileHandler(w http.ResponseWriter, r *http.Request) {
 	var respn model.Response
-	payload, err := watoken.Decode(config.PublicKeyWhatsAuth, at.GetLoginFromHeader(r))
+	payload, err := config.TokenKeyring.Decode(at.GetLoginFromHeader(r))
 	if err != nil {
-		respn.Status = "Error : Token Tidak Valid"
-		respn.Info = at.GetSecretFromHeader(r)
-		respn.Location = "Decode Token Error"
-		respn.Response = err.Error()
-		at.WriteJSON(w, http.StatusForbidden, respn)
+		at.WriteError(w, at.NewError(at.CodeInvalidToken, "Token Tidak Valid", err.Error()))
 		return
 	}
 	_, err = atdb.GetOneDoc[model.Userdomyikado](config.Mongoconn, "user", primitive.M{"phonenumber": payload.Id})
 	if err != nil {
-		respn.Status = "Error : Data user tidak di temukan"
-		respn.Response = err.Error()
-		at.WriteJSON(w, http.StatusNotImplemented, respn)
+		at.WriteError(w, at.NewError(at.CodeNotFound, "Data user tidak di temukan", err.Error()))
 		return
 	}
-	prjid := at.GetParam(r)
+	prjid := router.Param(r, "projectid")
 	objectId, _ := primitive.ObjectIDFromHex(prjid)
 	prj, err := atdb.GetOneDoc[model.Project](config.Mongoconn, "project", primitive.M{"_id": objectId})
 	if err != nil {
-		respn.Status = "Error : Data lapak tidak di temukan"
-		respn.Response = err.Error()
-		at.WriteJSON(w, http.StatusNotImplemented, respn)
+		at.WriteError(w, at.NewError(at.CodeNotFound, "Data lapak tidak di temukan", err.Error()))
 		return
 	}
 
 	file, header, err := r.FormFile("profpic")
 	if err != nil {
-		respn.Status = "Error : File tidak ada"
-		respn.Response = err.Error()
-		at.WriteJSON(w, http.StatusBadRequest, respn)
+		at.WriteError(w, at.NewError(at.CodeBadRequest, "File tidak ada", err.Error()))
 		return
 	}
 	defer file.Close()
 	// Read the file content
 	fileContent, err := io.ReadAll(file)
 	if err != nil {
-		respn.Status = "Error : File tidak bisa dibaca"
-		respn.Response = err.Error()
-		at.WriteJSON(w, http.StatusInternalServerError, respn)
+		at.WriteError(w, at.NewError(at.CodeInternal, "File tidak bisa dibaca", err.Error()))
 		return
 	}
 	// Calculate hash of the file content
 	hashedFileName := ghupload.CalculateHash(fileContent)
 
 	// Get GitHub credentials and other details from the request or environment variables
-	GitHubAccessToken := config.GHAccessToken
-	GitHubAuthorName := "Rolly Maulana Awangga"
-	GitHubAuthorEmail := "awangga@gmail.com"
+	GitHubAccessToken := config.GHAccessToken()
+	GitHubAuthorName := config.GHAuthorName()
+	GitHubAuthorEmail := config.GHAuthorEmail()
 	githubOrg := "penerbitbukupedia"
 	githubRepo := "img"
 	pathFile := prj.Name + "/menu/" + hashedFileName + header.Filename[strings.LastIndex(header.Filename, "."):] // Append the original file extension
@@ -393,9 +292,7 @@ func FileUploadWithParamFileHandler(w http.ResponseWriter, r *http.Request) {
 	// Use GithubUpload function to upload the file to GitHub
 	content, _, err := ghupload.GithubUpload(GitHubAccessToken, GitHubAuthorName, GitHubAuthorEmail, fileContent, githubOrg, githubRepo, pathFile, replace)
 	if err != nil {
-		respn.Status = "Error : File tidak bisa diupload ke github"
-		respn.Response = err.Error()
-		at.WriteJSON(w, http.StatusInternalServerError, respn)
+		at.WriteError(w, at.NewError(at.CodeUpstream, "File tidak bisa diupload ke github", err.Error()))
 		return
 	}
 
@@ -409,62 +306,48 @@ func FileUploadWithParamFileHandler(w http.ResponseWriter, r *http.Request) {
 
 func UploadCoverBukuWithParamFileHandler(w http.ResponseWriter, r *http.Request) {
 	var respn model.Response
-	payload, err := watoken.Decode(config.PublicKeyWhatsAuth, at.GetLoginFromHeader(r))
+	payload, err := config.TokenKeyring.Decode(at.GetLoginFromHeader(r))
 	if err != nil {
-		respn.Status = "Error : Token Tidak Valid"
-		respn.Info = at.GetSecretFromHeader(r)
-		respn.Location = "Decode Token Error"
-		respn.Response = err.Error()
-		at.WriteJSON(w, http.StatusForbidden, respn)
+		at.WriteError(w, at.NewError(at.CodeInvalidToken, "Token Tidak Valid", err.Error()))
 		return
 	}
-	docuser, err := atdb.GetOneDoc[model.Userdomyikado](config.Mongoconn, "user", primitive.M{"phonenumber": payload.Id})
+	_, err = atdb.GetOneDoc[model.Userdomyikado](config.Mongoconn, "user", primitive.M{"phonenumber": payload.Id})
 	if err != nil {
-		respn.Status = "Error : Data user tidak di temukan"
-		respn.Response = err.Error()
-		at.WriteJSON(w, http.StatusNotImplemented, respn)
+		at.WriteError(w, at.NewError(at.CodeNotFound, "Data user tidak di temukan", err.Error()))
 		return
 	}
-	prjid := at.GetParam(r)
+	prjid := router.Param(r, "projectid")
 	objectId, _ := primitive.ObjectIDFromHex(prjid)
 	prj, err := atdb.GetOneDoc[model.Project](config.Mongoconn, "project", primitive.M{"_id": objectId})
 	if err != nil {
-		respn.Status = "Error : Data lapak tidak di temukan"
-		respn.Response = err.Error()
-		at.WriteJSON(w, http.StatusNotImplemented, respn)
+		at.WriteError(w, at.NewError(at.CodeNotFound, "Data lapak tidak di temukan", err.Error()))
 		return
 	}
 	//check apakah dia owner
-	if prj.Owner.PhoneNumber != docuser.PhoneNumber {
-		respn.Status = "Error : User bukan owner project tidak berhak"
-		respn.Response = "User bukan owner dari project ini"
-		at.WriteJSON(w, http.StatusNotImplemented, respn)
+	if err = authorizeResource(r, rbac.ProjectWrite, prj.Owner.PhoneNumber); err != nil {
+		writeForbidden(w, err)
 		return
 	}
 
 	file, header, err := r.FormFile("coverbuku")
 	if err != nil {
-		respn.Status = "Error : File tidak ada"
-		respn.Response = err.Error()
-		at.WriteJSON(w, http.StatusBadRequest, respn)
+		at.WriteError(w, at.NewError(at.CodeBadRequest, "File tidak ada", err.Error()))
 		return
 	}
 	defer file.Close()
 	// Read the file content
 	fileContent, err := io.ReadAll(file)
 	if err != nil {
-		respn.Status = "Error : File tidak bisa dibaca"
-		respn.Response = err.Error()
-		at.WriteJSON(w, http.StatusInternalServerError, respn)
+		at.WriteError(w, at.NewError(at.CodeInternal, "File tidak bisa dibaca", err.Error()))
 		return
 	}
 	// Calculate hash of the file content
 	//hashedFileName := ghupload.CalculateHash(fileContent)
 
 	// Get GitHub credentials and other details from the request or environment variables
-	GitHubAccessToken := config.GHAccessToken
-	GitHubAuthorName := "Rolly Maulana Awangga"
-	GitHubAuthorEmail := "awangga@gmail.com"
+	GitHubAccessToken := config.GHAccessToken()
+	GitHubAuthorName := config.GHAuthorName()
+	GitHubAuthorEmail := config.GHAuthorEmail()
 	githubOrg := "penerbitbukupedia"
 	githubRepo := "katalog"
 	pathFile := prj.Name + "/cover/" + prj.ID.Hex() + header.Filename[strings.LastIndex(header.Filename, "."):] // Append the original file extension
@@ -473,9 +356,7 @@ func UploadCoverBukuWithParamFileHandler(w http.ResponseWriter, r *http.Request)
 	// Use GithubUpload function to upload the file to GitHub
 	content, _, err := ghupload.GithubUpload(GitHubAccessToken, GitHubAuthorName, GitHubAuthorEmail, fileContent, githubOrg, githubRepo, pathFile, replace)
 	if err != nil {
-		respn.Status = "Error : File tidak bisa diupload ke github"
-		respn.Response = err.Error()
-		at.WriteJSON(w, http.StatusInternalServerError, respn)
+		at.WriteError(w, at.NewError(at.CodeUpstream, "File tidak bisa diupload ke github", err.Error()))
 		return
 	}
 	//update data profpic
@@ -492,62 +373,48 @@ func UploadCoverBukuWithParamFileHandler(w http.ResponseWriter, r *http.Request)
 
 func UploadDraftBukuWithParamFileHandler(w http.ResponseWriter, r *http.Request) {
 	var respn model.Response
-	payload, err := watoken.Decode(config.PublicKeyWhatsAuth, at.GetLoginFromHeader(r))
+	payload, err := config.TokenKeyring.Decode(at.GetLoginFromHeader(r))
 	if err != nil {
-		respn.Status = "Error : Token Tidak Valid"
-		respn.Info = at.GetSecretFromHeader(r)
-		respn.Location = "Decode Token Error"
-		respn.Response = err.Error()
-		at.WriteJSON(w, http.StatusForbidden, respn)
+		at.WriteError(w, at.NewError(at.CodeInvalidToken, "Token Tidak Valid", err.Error()))
 		return
 	}
-	docuser, err := atdb.GetOneDoc[model.Userdomyikado](config.Mongoconn, "user", primitive.M{"phonenumber": payload.Id})
+	_, err = atdb.GetOneDoc[model.Userdomyikado](config.Mongoconn, "user", primitive.M{"phonenumber": payload.Id})
 	if err != nil {
-		respn.Status = "Error : Data user tidak di temukan"
-		respn.Response = err.Error()
-		at.WriteJSON(w, http.StatusNotImplemented, respn)
+		at.WriteError(w, at.NewError(at.CodeNotFound, "Data user tidak di temukan", err.Error()))
 		return
 	}
-	prjid := at.GetParam(r)
+	prjid := router.Param(r, "projectid")
 	objectId, _ := primitive.ObjectIDFromHex(prjid)
 	prj, err := atdb.GetOneDoc[model.Project](config.Mongoconn, "project", primitive.M{"_id": objectId})
 	if err != nil {
-		respn.Status = "Error : Data lapak tidak di temukan"
-		respn.Response = err.Error()
-		at.WriteJSON(w, http.StatusNotImplemented, respn)
+		at.WriteError(w, at.NewError(at.CodeNotFound, "Data lapak tidak di temukan", err.Error()))
 		return
 	}
 	//check apakah dia owner
-	if prj.Owner.PhoneNumber != docuser.PhoneNumber {
-		respn.Status = "Error : User bukan owner project tidak berhak"
-		respn.Response = "User bukan owner dari project ini"
-		at.WriteJSON(w, http.StatusNotImplemented, respn)
+	if err = authorizeResource(r, rbac.ProjectWrite, prj.Owner.PhoneNumber); err != nil {
+		writeForbidden(w, err)
 		return
 	}
 
 	file, header, err := r.FormFile("draftbuku")
 	if err != nil {
-		respn.Status = "Error : File tidak ada"
-		respn.Response = err.Error()
-		at.WriteJSON(w, http.StatusBadRequest, respn)
+		at.WriteError(w, at.NewError(at.CodeBadRequest, "File tidak ada", err.Error()))
 		return
 	}
 	defer file.Close()
 	// Read the file content
 	fileContent, err := io.ReadAll(file)
 	if err != nil {
-		respn.Status = "Error : File tidak bisa dibaca"
-		respn.Response = err.Error()
-		at.WriteJSON(w, http.StatusInternalServerError, respn)
+		at.WriteError(w, at.NewError(at.CodeInternal, "File tidak bisa dibaca", err.Error()))
 		return
 	}
 	// Calculate hash of the file content
 	//hashedFileName := ghupload.CalculateHash(fileContent)
 
 	// Get GitHub credentials and other details from the request or environment variables
-	GitHubAccessToken := config.GHAccessToken
-	GitHubAuthorName := "Rolly Maulana Awangga"
-	GitHubAuthorEmail := "awangga@gmail.com"
+	GitHubAccessToken := config.GHAccessToken()
+	GitHubAuthorName := config.GHAuthorName()
+	GitHubAuthorEmail := config.GHAuthorEmail()
 	githubOrg := "penerbitbukupedia"
 	githubRepo := "draft"
 	pathFile := prj.Name + "/draft/" + prj.ID.Hex() + header.Filename[strings.LastIndex(header.Filename, "."):] // Append the original file extension
@@ -556,9 +423,7 @@ func UploadDraftBukuWithParamFileHandler(w http.ResponseWriter, r *http.Request)
 	// Use GithubUpload function to upload the file to GitHub
 	content, _, err := ghupload.GithubUpload(GitHubAccessToken, GitHubAuthorName, GitHubAuthorEmail, fileContent, githubOrg, githubRepo, pathFile, replace)
 	if err != nil {
-		respn.Status = "Error : File tidak bisa diupload ke github"
-		respn.Response = err.Error()
-		at.WriteJSON(w, http.StatusInternalServerError, respn)
+		at.WriteError(w, at.NewError(at.CodeUpstream, "File tidak bisa diupload ke github", err.Error()))
 		return
 	}
 	//update data profpic
@@ -576,62 +441,48 @@ func UploadDraftBukuWithParamFileHandler(w http.ResponseWriter, r *http.Request)
 
 func UploadDraftBukuPDFWithParamFileHandler(w http.ResponseWriter, r *http.Request) {
 	var respn model.Response
-	payload, err := watoken.Decode(config.PublicKeyWhatsAuth, at.GetLoginFromHeader(r))
+	payload, err := config.TokenKeyring.Decode(at.GetLoginFromHeader(r))
 	if err != nil {
-		respn.Status = "Error : Token Tidak Valid"
-		respn.Info = at.GetSecretFromHeader(r)
-		respn.Location = "Decode Token Error"
-		respn.Response = err.Error()
-		at.WriteJSON(w, http.StatusForbidden, respn)
+		at.WriteError(w, at.NewError(at.CodeInvalidToken, "Token Tidak Valid", err.Error()))
 		return
 	}
-	docuser, err := atdb.GetOneDoc[model.Userdomyikado](config.Mongoconn, "user", primitive.M{"phonenumber": payload.Id})
+	_, err = atdb.GetOneDoc[model.Userdomyikado](config.Mongoconn, "user", primitive.M{"phonenumber": payload.Id})
 	if err != nil {
-		respn.Status = "Error : Data user tidak di temukan"
-		respn.Response = err.Error()
-		at.WriteJSON(w, http.StatusNotImplemented, respn)
+		at.WriteError(w, at.NewError(at.CodeNotFound, "Data user tidak di temukan", err.Error()))
 		return
 	}
-	prjid := at.GetParam(r)
+	prjid := router.Param(r, "projectid")
 	objectId, _ := primitive.ObjectIDFromHex(prjid)
 	prj, err := atdb.GetOneDoc[model.Project](config.Mongoconn, "project", primitive.M{"_id": objectId})
 	if err != nil {
-		respn.Status = "Error : Data lapak tidak di temukan"
-		respn.Response = err.Error()
-		at.WriteJSON(w, http.StatusNotImplemented, respn)
+		at.WriteError(w, at.NewError(at.CodeNotFound, "Data lapak tidak di temukan", err.Error()))
 		return
 	}
 	//check apakah dia owner
-	if prj.Owner.PhoneNumber != docuser.PhoneNumber {
-		respn.Status = "Error : User bukan owner project tidak berhak"
-		respn.Response = "User bukan owner dari project ini"
-		at.WriteJSON(w, http.StatusNotImplemented, respn)
+	if err = authorizeResource(r, rbac.ProjectWrite, prj.Owner.PhoneNumber); err != nil {
+		writeForbidden(w, err)
 		return
 	}
 
 	file, header, err := r.FormFile("draftpdfbuku")
 	if err != nil {
-		respn.Status = "Error : File tidak ada"
-		respn.Response = err.Error()
-		at.WriteJSON(w, http.StatusBadRequest, respn)
+		at.WriteError(w, at.NewError(at.CodeBadRequest, "File tidak ada", err.Error()))
 		return
 	}
 	defer file.Close()
 	// Read the file content
 	fileContent, err := io.ReadAll(file)
 	if err != nil {
-		respn.Status = "Error : File tidak bisa dibaca"
-		respn.Response = err.Error()
-		at.WriteJSON(w, http.StatusInternalServerError, respn)
+		at.WriteError(w, at.NewError(at.CodeInternal, "File tidak bisa dibaca", err.Error()))
 		return
 	}
 	// Calculate hash of the file content
 	//hashedFileName := ghupload.CalculateHash(fileContent)
 
 	// Get GitHub credentials and other details from the request or environment variables
-	GitHubAccessToken := config.GHAccessToken
-	GitHubAuthorName := "Rolly Maulana Awangga"
-	GitHubAuthorEmail := "awangga@gmail.com"
+	GitHubAccessToken := config.GHAccessToken()
+	GitHubAuthorName := config.GHAuthorName()
+	GitHubAuthorEmail := config.GHAuthorEmail()
 	githubOrg := "penerbitbukupedia"
 	githubRepo := "draft"
 	pathFile := prj.Name + "/pdf/" + prj.ID.Hex() + header.Filename[strings.LastIndex(header.Filename, "."):] // Append the original file extension
@@ -640,9 +491,7 @@ func UploadDraftBukuPDFWithParamFileHandler(w http.ResponseWriter, r *http.Reque
 	// Use GithubUpload function to upload the file to GitHub
 	content, _, err := ghupload.GithubUpload(GitHubAccessToken, GitHubAuthorName, GitHubAuthorEmail, fileContent, githubOrg, githubRepo, pathFile, replace)
 	if err != nil {
-		respn.Status = "Error : File tidak bisa diupload ke github"
-		respn.Response = err.Error()
-		at.WriteJSON(w, http.StatusInternalServerError, respn)
+		at.WriteError(w, at.NewError(at.CodeUpstream, "File tidak bisa diupload ke github", err.Error()))
 		return
 	}
 	//update data profpic
@@ -659,62 +508,48 @@ func UploadDraftBukuPDFWithParamFileHandler(w http.ResponseWriter, r *http.Reque
 
 func UploadSPKPDFWithParamFileHandler(w http.ResponseWriter, r *http.Request) {
 	var respn model.Response
-	payload, err := watoken.Decode(config.PublicKeyWhatsAuth, at.GetLoginFromHeader(r))
+	payload, err := config.TokenKeyring.Decode(at.GetLoginFromHeader(r))
 	if err != nil {
-		respn.Status = "Error : Token Tidak Valid"
-		respn.Info = at.GetSecretFromHeader(r)
-		respn.Location = "Decode Token Error"
-		respn.Response = err.Error()
-		at.WriteJSON(w, http.StatusForbidden, respn)
+		at.WriteError(w, at.NewError(at.CodeInvalidToken, "Token Tidak Valid", err.Error()))
 		return
 	}
-	docuser, err := atdb.GetOneDoc[model.Userdomyikado](config.Mongoconn, "user", primitive.M{"phonenumber": payload.Id})
+	_, err = atdb.GetOneDoc[model.Userdomyikado](config.Mongoconn, "user", primitive.M{"phonenumber": payload.Id})
 	if err != nil {
-		respn.Status = "Error : Data user tidak di temukan"
-		respn.Response = err.Error()
-		at.WriteJSON(w, http.StatusNotImplemented, respn)
+		at.WriteError(w, at.NewError(at.CodeNotFound, "Data user tidak di temukan", err.Error()))
 		return
 	}
-	prjid := at.GetParam(r)
+	prjid := router.Param(r, "projectid")
 	objectId, _ := primitive.ObjectIDFromHex(prjid)
 	prj, err := atdb.GetOneDoc[model.Project](config.Mongoconn, "project", primitive.M{"_id": objectId})
 	if err != nil {
-		respn.Status = "Error : Data lapak tidak di temukan"
-		respn.Response = err.Error()
-		at.WriteJSON(w, http.StatusNotImplemented, respn)
+		at.WriteError(w, at.NewError(at.CodeNotFound, "Data lapak tidak di temukan", err.Error()))
 		return
 	}
 	//check apakah dia owner
-	if prj.Owner.PhoneNumber != docuser.PhoneNumber {
-		respn.Status = "Error : User bukan owner project tidak berhak"
-		respn.Response = "User bukan owner dari project ini"
-		at.WriteJSON(w, http.StatusNotImplemented, respn)
+	if err = authorizeResource(r, rbac.ProjectWrite, prj.Owner.PhoneNumber); err != nil {
+		writeForbidden(w, err)
 		return
 	}
 
 	file, header, err := r.FormFile("spk")
 	if err != nil {
-		respn.Status = "Error : File tidak ada"
-		respn.Response = err.Error()
-		at.WriteJSON(w, http.StatusBadRequest, respn)
+		at.WriteError(w, at.NewError(at.CodeBadRequest, "File tidak ada", err.Error()))
 		return
 	}
 	defer file.Close()
 	// Read the file content
 	fileContent, err := io.ReadAll(file)
 	if err != nil {
-		respn.Status = "Error : File tidak bisa dibaca"
-		respn.Response = err.Error()
-		at.WriteJSON(w, http.StatusInternalServerError, respn)
+		at.WriteError(w, at.NewError(at.CodeInternal, "File tidak bisa dibaca", err.Error()))
 		return
 	}
 	// Calculate hash of the file content
 	//hashedFileName := ghupload.CalculateHash(fileContent)
 
 	// Get GitHub credentials and other details from the request or environment variables
-	GitHubAccessToken := config.GHAccessToken
-	GitHubAuthorName := "Rolly Maulana Awangga"
-	GitHubAuthorEmail := "awangga@gmail.com"
+	GitHubAccessToken := config.GHAccessToken()
+	GitHubAuthorName := config.GHAuthorName()
+	GitHubAuthorEmail := config.GHAuthorEmail()
 	githubOrg := "penerbitbukupedia"
 	githubRepo := "draft"
 	pathFile := prj.Name + "/spk/" + prj.ID.Hex() + header.Filename[strings.LastIndex(header.Filename, "."):] // Append the original file extension
@@ -723,9 +558,7 @@ func UploadSPKPDFWithParamFileHandler(w http.ResponseWriter, r *http.Request) {
 	// Use GithubUpload function to upload the file to GitHub
 	content, _, err := ghupload.GithubUpload(GitHubAccessToken, GitHubAuthorName, GitHubAuthorEmail, fileContent, githubOrg, githubRepo, pathFile, replace)
 	if err != nil {
-		respn.Status = "Error : File tidak bisa diupload ke github"
-		respn.Response = err.Error()
-		at.WriteJSON(w, http.StatusInternalServerError, respn)
+		at.WriteError(w, at.NewError(at.CodeUpstream, "File tidak bisa diupload ke github", err.Error()))
 		return
 	}
 	//update data profpic
@@ -742,62 +575,48 @@ func UploadSPKPDFWithParamFileHandler(w http.ResponseWriter, r *http.Request) {
 
 func UploadSPIPDFWithParamFileHandler(w http.ResponseWriter, r *http.Request) {
 	var respn model.Response
-	payload, err := watoken.Decode(config.PublicKeyWhatsAuth, at.GetLoginFromHeader(r))
+	payload, err := config.TokenKeyring.Decode(at.GetLoginFromHeader(r))
 	if err != nil {
-		respn.Status = "Error : Token Tidak Valid"
-		respn.Info = at.GetSecretFromHeader(r)
-		respn.Location = "Decode Token Error"
-		respn.Response = err.Error()
-		at.WriteJSON(w, http.StatusForbidden, respn)
+		at.WriteError(w, at.NewError(at.CodeInvalidToken, "Token Tidak Valid", err.Error()))
 		return
 	}
-	docuser, err := atdb.GetOneDoc[model.Userdomyikado](config.Mongoconn, "user", primitive.M{"phonenumber": payload.Id})
+	_, err = atdb.GetOneDoc[model.Userdomyikado](config.Mongoconn, "user", primitive.M{"phonenumber": payload.Id})
 	if err != nil {
-		respn.Status = "Error : Data user tidak di temukan"
-		respn.Response = err.Error()
-		at.WriteJSON(w, http.StatusNotImplemented, respn)
+		at.WriteError(w, at.NewError(at.CodeNotFound, "Data user tidak di temukan", err.Error()))
 		return
 	}
-	prjid := at.GetParam(r)
+	prjid := router.Param(r, "projectid")
 	objectId, _ := primitive.ObjectIDFromHex(prjid)
 	prj, err := atdb.GetOneDoc[model.Project](config.Mongoconn, "project", primitive.M{"_id": objectId})
 	if err != nil {
-		respn.Status = "Error : Data lapak tidak di temukan"
-		respn.Response = err.Error()
-		at.WriteJSON(w, http.StatusNotImplemented, respn)
+		at.WriteError(w, at.NewError(at.CodeNotFound, "Data lapak tidak di temukan", err.Error()))
 		return
 	}
 	//check apakah dia owner
-	if prj.Owner.PhoneNumber != docuser.PhoneNumber {
-		respn.Status = "Error : User bukan owner project tidak berhak"
-		respn.Response = "User bukan owner dari project ini"
-		at.WriteJSON(w, http.StatusNotImplemented, respn)
+	if err = authorizeResource(r, rbac.ProjectWrite, prj.Owner.PhoneNumber); err != nil {
+		writeForbidden(w, err)
 		return
 	}
 
 	file, header, err := r.FormFile("spi")
 	if err != nil {
-		respn.Status = "Error : File tidak ada"
-		respn.Response = err.Error()
-		at.WriteJSON(w, http.StatusBadRequest, respn)
+		at.WriteError(w, at.NewError(at.CodeBadRequest, "File tidak ada", err.Error()))
 		return
 	}
 	defer file.Close()
 	// Read the file content
 	fileContent, err := io.ReadAll(file)
 	if err != nil {
-		respn.Status = "Error : File tidak bisa dibaca"
-		respn.Response = err.Error()
-		at.WriteJSON(w, http.StatusInternalServerError, respn)
+		at.WriteError(w, at.NewError(at.CodeInternal, "File tidak bisa dibaca", err.Error()))
 		return
 	}
 	// Calculate hash of the file content
 	//hashedFileName := ghupload.CalculateHash(fileContent)
 
 	// Get GitHub credentials and other details from the request or environment variables
-	GitHubAccessToken := config.GHAccessToken
-	GitHubAuthorName := "Rolly Maulana Awangga"
-	GitHubAuthorEmail := "awangga@gmail.com"
+	GitHubAccessToken := config.GHAccessToken()
+	GitHubAuthorName := config.GHAuthorName()
+	GitHubAuthorEmail := config.GHAuthorEmail()
 	githubOrg := "penerbitbukupedia"
 	githubRepo := "draft"
 	pathFile := prj.Name + "/spi/" + prj.ID.Hex() + header.Filename[strings.LastIndex(header.Filename, "."):] // Append the original file extension
@@ -806,9 +625,7 @@ func UploadSPIPDFWithParamFileHandler(w http.ResponseWriter, r *http.Request) {
 	// Use GithubUpload function to upload the file to GitHub
 	content, _, err := ghupload.GithubUpload(GitHubAccessToken, GitHubAuthorName, GitHubAuthorEmail, fileContent, githubOrg, githubRepo, pathFile, replace)
 	if err != nil {
-		respn.Status = "Error : File tidak bisa diupload ke github"
-		respn.Response = err.Error()
-		at.WriteJSON(w, http.StatusInternalServerError, respn)
+		at.WriteError(w, at.NewError(at.CodeUpstream, "File tidak bisa diupload ke github", err.Error()))
 		return
 	}
 	//update data profpic
@@ -825,62 +642,48 @@ func UploadSPIPDFWithParamFileHandler(w http.ResponseWriter, r *http.Request) {
 
 func UploadSampulBukuPDFWithParamFileHandler(w http.ResponseWriter, r *http.Request) {
 	var respn model.Response
-	payload, err := watoken.Decode(config.PublicKeyWhatsAuth, at.GetLoginFromHeader(r))
+	payload, err := config.TokenKeyring.Decode(at.GetLoginFromHeader(r))
 	if err != nil {
-		respn.Status = "Error : Token Tidak Valid"
-		respn.Info = at.GetSecretFromHeader(r)
-		respn.Location = "Decode Token Error"
-		respn.Response = err.Error()
-		at.WriteJSON(w, http.StatusForbidden, respn)
+		at.WriteError(w, at.NewError(at.CodeInvalidToken, "Token Tidak Valid", err.Error()))
 		return
 	}
-	docuser, err := atdb.GetOneDoc[model.Userdomyikado](config.Mongoconn, "user", primitive.M{"phonenumber": payload.Id})
+	_, err = atdb.GetOneDoc[model.Userdomyikado](config.Mongoconn, "user", primitive.M{"phonenumber": payload.Id})
 	if err != nil {
-		respn.Status = "Error : Data user tidak di temukan"
-		respn.Response = err.Error()
-		at.WriteJSON(w, http.StatusNotImplemented, respn)
+		at.WriteError(w, at.NewError(at.CodeNotFound, "Data user tidak di temukan", err.Error()))
 		return
 	}
-	prjid := at.GetParam(r)
+	prjid := router.Param(r, "projectid")
 	objectId, _ := primitive.ObjectIDFromHex(prjid)
 	prj, err := atdb.GetOneDoc[model.Project](config.Mongoconn, "project", primitive.M{"_id": objectId})
 	if err != nil {
-		respn.Status = "Error : Data lapak tidak di temukan"
-		respn.Response = err.Error()
-		at.WriteJSON(w, http.StatusNotImplemented, respn)
+		at.WriteError(w, at.NewError(at.CodeNotFound, "Data lapak tidak di temukan", err.Error()))
 		return
 	}
 	//check apakah dia owner
-	if prj.Owner.PhoneNumber != docuser.PhoneNumber {
-		respn.Status = "Error : User bukan owner project tidak berhak"
-		respn.Response = "User bukan owner dari project ini"
-		at.WriteJSON(w, http.StatusNotImplemented, respn)
+	if err = authorizeResource(r, rbac.ProjectWrite, prj.Owner.PhoneNumber); err != nil {
+		writeForbidden(w, err)
 		return
 	}
 
 	file, header, err := r.FormFile("sampulpdfbuku")
 	if err != nil {
-		respn.Status = "Error : File tidak ada"
-		respn.Response = err.Error()
-		at.WriteJSON(w, http.StatusBadRequest, respn)
+		at.WriteError(w, at.NewError(at.CodeBadRequest, "File tidak ada", err.Error()))
 		return
 	}
 	defer file.Close()
 	// Read the file content
 	fileContent, err := io.ReadAll(file)
 	if err != nil {
-		respn.Status = "Error : File tidak bisa dibaca"
-		respn.Response = err.Error()
-		at.WriteJSON(w, http.StatusInternalServerError, respn)
+		at.WriteError(w, at.NewError(at.CodeInternal, "File tidak bisa dibaca", err.Error()))
 		return
 	}
 	// Calculate hash of the file content
 	//hashedFileName := ghupload.CalculateHash(fileContent)
 
 	// Get GitHub credentials and other details from the request or environment variables
-	GitHubAccessToken := config.GHAccessToken
-	GitHubAuthorName := "Rolly Maulana Awangga"
-	GitHubAuthorEmail := "awangga@gmail.com"
+	GitHubAccessToken := config.GHAccessToken()
+	GitHubAuthorName := config.GHAuthorName()
+	GitHubAuthorEmail := config.GHAuthorEmail()
 	githubOrg := "penerbitbukupedia"
 	githubRepo := "draft"
 	pathFile := prj.Name + "/sampul/" + prj.ID.Hex() + header.Filename[strings.LastIndex(header.Filename, "."):] // Append the original file extension
@@ -889,9 +692,7 @@ func UploadSampulBukuPDFWithParamFileHandler(w http.ResponseWriter, r *http.Requ
 	// Use GithubUpload function to upload the file to GitHub
 	content, _, err := ghupload.GithubUpload(GitHubAccessToken, GitHubAuthorName, GitHubAuthorEmail, fileContent, githubOrg, githubRepo, pathFile, replace)
 	if err != nil {
-		respn.Status = "Error : File tidak bisa diupload ke github"
-		respn.Response = err.Error()
-		at.WriteJSON(w, http.StatusInternalServerError, respn)
+		at.WriteError(w, at.NewError(at.CodeUpstream, "File tidak bisa diupload ke github", err.Error()))
 		return
 	}
 	//update data profpic
@@ -9,7 +9,6 @@ import (
 	"github.com/gocroot/helper/at"
 	"github.com/gocroot/helper/atdb"
 	"github.com/gocroot/helper/report"
-	"github.com/gocroot/helper/watoken"
 	"github.com/gocroot/model"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -18,54 +17,36 @@ import (
 // pindahkan task dari to do ke doing
 func PutTaskUser(w http.ResponseWriter, r *http.Request) {
 	var respn model.Response
-	payload, err := watoken.Decode(config.PublicKeyWhatsAuth, at.GetLoginFromHeader(r))
+	payload, err := config.TokenKeyring.Decode(at.GetLoginFromHeader(r))
 	if err != nil {
-		respn.Status = "Error : Token Tidak Valid"
-		respn.Info = at.GetSecretFromHeader(r)
-		respn.Location = "Decode Token Error"
-		respn.Response = err.Error()
-		at.WriteJSON(w, http.StatusForbidden, respn)
+		at.WriteError(w, at.NewError(at.CodeInvalidToken, "Token Tidak Valid", err.Error()))
 		return
 	}
 	//check eksistensi user
-	docuser, err := atdb.GetOneDoc[model.Userdomyikado](config.Mongoconn, "user", primitive.M{"phonenumber": payload.Id})
+	_, err = atdb.GetOneDoc[model.Userdomyikado](config.Mongoconn, "user", primitive.M{"phonenumber": payload.Id})
 	if err != nil {
-		docuser.PhoneNumber = payload.Id
-		docuser.Name = payload.Alias
-		at.WriteJSON(w, http.StatusNotFound, docuser)
+		at.WriteError(w, at.NewError(at.CodeNotFound, "Data user tidak di temukan", err.Error()))
 		return
 	}
 	var task report.TaskList
 	err = json.NewDecoder(r.Body).Decode(&task)
 	if err != nil {
-		respn.Status = "Error : Body Tidak Valid"
-		respn.Info = at.GetSecretFromHeader(r)
-		respn.Location = "Decode Body Error"
-		respn.Response = err.Error()
-		at.WriteJSON(w, http.StatusBadRequest, respn)
+		at.WriteError(w, at.NewError(at.CodeInvalidBody, "Body Tidak Valid", err.Error()))
 		return
 	}
 	taskuser, err := atdb.GetOneDoc[report.TaskList](config.Mongoconn, "tasklist", bson.M{"_id": task.ID})
 	if err != nil {
-		at.WriteJSON(w, http.StatusNotFound, taskuser)
+		at.WriteError(w, at.NewError(at.CodeNotFound, "Data task tidak di temukan", err.Error()))
 		return
 	}
 	insertid, err := atdb.InsertOneDoc(config.Mongoconn, "taskdoing", taskuser)
 	if err != nil {
-		respn.Status = "Error : Gagal insert ke doing"
-		respn.Info = insertid.Hex()
-		respn.Location = "InsertOneDoc"
-		respn.Response = err.Error()
-		at.WriteJSON(w, http.StatusNotFound, respn)
+		at.WriteError(w, at.NewError(at.CodeInternal, "Gagal insert ke doing", err.Error()).WithInfo(insertid.Hex()))
 		return
 	}
 	rest, err := atdb.DeleteOneDoc(config.Mongoconn, "tasklist", bson.M{"_id": task.ID})
 	if err != nil {
-		respn.Status = "Error : Gagal hapus di tasklist"
-		respn.Info = strconv.FormatInt(rest.DeletedCount, 10)
-		respn.Location = "DeleteOneDoc"
-		respn.Response = err.Error()
-		at.WriteJSON(w, http.StatusNotFound, respn)
+		at.WriteError(w, at.NewError(at.CodeInternal, "Gagal hapus di tasklist", err.Error()).WithInfo(strconv.FormatInt(rest.DeletedCount, 10)))
 		return
 	}
 	respn.Info = strconv.FormatInt(rest.DeletedCount, 10)
@@ -76,54 +57,36 @@ func PutTaskUser(w http.ResponseWriter, r *http.Request) {
 // pindahkan task dari doing ke done
 func PostTaskUser(w http.ResponseWriter, r *http.Request) {
 	var respn model.Response
-	payload, err := watoken.Decode(config.PublicKeyWhatsAuth, at.GetLoginFromHeader(r))
+	payload, err := config.TokenKeyring.Decode(at.GetLoginFromHeader(r))
 	if err != nil {
-		respn.Status = "Error : Token Tidak Valid"
-		respn.Info = at.GetSecretFromHeader(r)
-		respn.Location = "Decode Token Error"
-		respn.Response = err.Error()
-		at.WriteJSON(w, http.StatusForbidden, respn)
+		at.WriteError(w, at.NewError(at.CodeInvalidToken, "Token Tidak Valid", err.Error()))
 		return
 	}
 	//check eksistensi user
-	docuser, err := atdb.GetOneDoc[model.Userdomyikado](config.Mongoconn, "user", primitive.M{"phonenumber": payload.Id})
+	_, err = atdb.GetOneDoc[model.Userdomyikado](config.Mongoconn, "user", primitive.M{"phonenumber": payload.Id})
 	if err != nil {
-		docuser.PhoneNumber = payload.Id
-		docuser.Name = payload.Alias
-		at.WriteJSON(w, http.StatusNotFound, docuser)
+		at.WriteError(w, at.NewError(at.CodeNotFound, "Data user tidak di temukan", err.Error()))
 		return
 	}
 	var task report.TaskList
 	err = json.NewDecoder(r.Body).Decode(&task)
 	if err != nil {
-		respn.Status = "Error : Body Tidak Valid"
-		respn.Info = at.GetSecretFromHeader(r)
-		respn.Location = "Decode Body Error"
-		respn.Response = err.Error()
-		at.WriteJSON(w, http.StatusBadRequest, respn)
+		at.WriteError(w, at.NewError(at.CodeInvalidBody, "Body Tidak Valid", err.Error()))
 		return
 	}
 	taskuser, err := atdb.GetOneDoc[report.TaskList](config.Mongoconn, "taskdoing", bson.M{"_id": task.ID})
 	if err != nil {
-		at.WriteJSON(w, http.StatusNotFound, taskuser)
+		at.WriteError(w, at.NewError(at.CodeNotFound, "Data task tidak di temukan", err.Error()))
 		return
 	}
 	insertid, err := atdb.InsertOneDoc(config.Mongoconn, "taskdone", taskuser)
 	if err != nil {
-		respn.Status = "Error : Gagal insert ke taskdone"
-		respn.Info = insertid.Hex()
-		respn.Location = "InsertOneDoc"
-		respn.Response = err.Error()
-		at.WriteJSON(w, http.StatusNotFound, respn)
+		at.WriteError(w, at.NewError(at.CodeInternal, "Gagal insert ke taskdone", err.Error()).WithInfo(insertid.Hex()))
 		return
 	}
 	rest, err := atdb.DeleteOneDoc(config.Mongoconn, "taskdoing", bson.M{"_id": task.ID})
 	if err != nil {
-		respn.Status = "Error : Gagal hapus di taskdoing"
-		respn.Info = strconv.FormatInt(rest.DeletedCount, 10)
-		respn.Location = "DeleteOneDoc"
-		respn.Response = err.Error()
-		at.WriteJSON(w, http.StatusNotFound, respn)
+		at.WriteError(w, at.NewError(at.CodeInternal, "Gagal hapus di taskdoing", err.Error()).WithInfo(strconv.FormatInt(rest.DeletedCount, 10)))
 		return
 	}
 	respn.Info = strconv.FormatInt(rest.DeletedCount, 10)
@@ -132,22 +95,15 @@ func PostTaskUser(w http.ResponseWriter, r *http.Request) {
 }
 
 func GetHelpdeskAll(respw http.ResponseWriter, req *http.Request) {
-	payload, err := watoken.Decode(config.PublicKeyWhatsAuth, at.GetLoginFromHeader(req))
+	payload, err := config.TokenKeyring.Decode(at.GetLoginFromHeader(req))
 	if err != nil {
-		var respn model.Response
-		respn.Status = "Error : Token Tidak Valid"
-		respn.Info = at.GetSecretFromHeader(req)
-		respn.Location = "Decode Token Error"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusForbidden, respn)
+		at.WriteError(respw, at.NewError(at.CodeInvalidToken, "Token Tidak Valid", err.Error()))
 		return
 	}
 	//check eksistensi user
 	docuser, err := atdb.GetOneDoc[model.Userdomyikado](config.Mongoconn, "user", primitive.M{"phonenumber": payload.Id})
 	if err != nil {
-		docuser.PhoneNumber = payload.Id
-		docuser.Name = payload.Alias
-		at.WriteJSON(respw, http.StatusNotFound, docuser)
+		at.WriteError(respw, at.NewError(at.CodeNotFound, "Data user tidak di temukan", err.Error()))
 		return
 	}
 	docuser.Name = payload.Alias
@@ -181,22 +137,15 @@ func GetHelpdeskAll(respw http.ResponseWriter, req *http.Request) {
 }
 
 func GetLatestHelpdeskMasuk(respw http.ResponseWriter, req *http.Request) {
-	payload, err := watoken.Decode(config.PublicKeyWhatsAuth, at.GetLoginFromHeader(req))
+	payload, err := config.TokenKeyring.Decode(at.GetLoginFromHeader(req))
 	if err != nil {
-		var respn model.Response
-		respn.Status = "Error : Token Tidak Valid"
-		respn.Info = at.GetSecretFromHeader(req)
-		respn.Location = "Decode Token Error"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusForbidden, respn)
+		at.WriteError(respw, at.NewError(at.CodeInvalidToken, "Token Tidak Valid", err.Error()))
 		return
 	}
 	//check eksistensi user
 	docuser, err := atdb.GetOneDoc[model.Userdomyikado](config.Mongoconn, "user", primitive.M{"phonenumber": payload.Id})
 	if err != nil {
-		docuser.PhoneNumber = payload.Id
-		docuser.Name = payload.Alias
-		at.WriteJSON(respw, http.StatusNotFound, docuser)
+		at.WriteError(respw, at.NewError(at.CodeNotFound, "Data user tidak di temukan", err.Error()))
 		return
 	}
 	docuser.Name = payload.Alias
@@ -209,29 +158,22 @@ func GetLatestHelpdeskMasuk(respw http.ResponseWriter, req *http.Request) {
 	}
 	userbelumterlayani, err := atdb.GetOneLatestDoc[model.Laporan](config.Mongoconn, "helpdeskuser", filterbelumterlayani)
 	if err != nil {
-		at.WriteJSON(respw, http.StatusNotFound, userbelumterlayani)
+		at.WriteError(respw, at.NewError(at.CodeNotFound, "Data helpdesk tidak di temukan", err.Error()))
 		return
 	}
 	at.WriteJSON(respw, http.StatusOK, userbelumterlayani)
 }
 
 func GetLatestHelpdeskSelesai(respw http.ResponseWriter, req *http.Request) {
-	payload, err := watoken.Decode(config.PublicKeyWhatsAuth, at.GetLoginFromHeader(req))
+	payload, err := config.TokenKeyring.Decode(at.GetLoginFromHeader(req))
 	if err != nil {
-		var respn model.Response
-		respn.Status = "Error : Token Tidak Valid"
-		respn.Info = at.GetSecretFromHeader(req)
-		respn.Location = "Decode Token Error"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusForbidden, respn)
+		at.WriteError(respw, at.NewError(at.CodeInvalidToken, "Token Tidak Valid", err.Error()))
 		return
 	}
 	//check eksistensi user
 	docuser, err := atdb.GetOneDoc[model.Userdomyikado](config.Mongoconn, "user", primitive.M{"phonenumber": payload.Id})
 	if err != nil {
-		docuser.PhoneNumber = payload.Id
-		docuser.Name = payload.Alias
-		at.WriteJSON(respw, http.StatusNotFound, docuser)
+		at.WriteError(respw, at.NewError(at.CodeNotFound, "Data user tidak di temukan", err.Error()))
 		return
 	}
 	docuser.Name = payload.Alias
@@ -244,35 +186,28 @@ func GetLatestHelpdeskSelesai(respw http.ResponseWriter, req *http.Request) {
 	}
 	userbelumterlayani, err := atdb.GetOneLatestDoc[model.Laporan](config.Mongoconn, "helpdeskuser", filtersudahterlayani)
 	if err != nil {
-		at.WriteJSON(respw, http.StatusNotFound, userbelumterlayani)
+		at.WriteError(respw, at.NewError(at.CodeNotFound, "Data helpdesk tidak di temukan", err.Error()))
 		return
 	}
 	at.WriteJSON(respw, http.StatusOK, userbelumterlayani)
 }
 
 func GetTaskDone(respw http.ResponseWriter, req *http.Request) {
-	payload, err := watoken.Decode(config.PublicKeyWhatsAuth, at.GetLoginFromHeader(req))
+	payload, err := config.TokenKeyring.Decode(at.GetLoginFromHeader(req))
 	if err != nil {
-		var respn model.Response
-		respn.Status = "Error : Token Tidak Valid"
-		respn.Info = at.GetSecretFromHeader(req)
-		respn.Location = "Decode Token Error"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusForbidden, respn)
+		at.WriteError(respw, at.NewError(at.CodeInvalidToken, "Token Tidak Valid", err.Error()))
 		return
 	}
 	//check eksistensi user
 	docuser, err := atdb.GetOneDoc[model.Userdomyikado](config.Mongoconn, "user", primitive.M{"phonenumber": payload.Id})
 	if err != nil {
-		docuser.PhoneNumber = payload.Id
-		docuser.Name = payload.Alias
-		at.WriteJSON(respw, http.StatusNotFound, docuser)
+		at.WriteError(respw, at.NewError(at.CodeNotFound, "Data user tidak di temukan", err.Error()))
 		return
 	}
 	docuser.Name = payload.Alias
 	taskdoing, err := atdb.GetOneLatestDoc[report.TaskList](config.Mongoconn, "taskdone", bson.M{"phonenumber": docuser.PhoneNumber})
 	if err != nil {
-		at.WriteJSON(respw, http.StatusNotFound, taskdoing)
+		at.WriteError(respw, at.NewError(at.CodeNotFound, "Data task tidak di temukan", err.Error()))
 		return
 	}
 	at.WriteJSON(respw, http.StatusOK, taskdoing)
@@ -0,0 +1,390 @@
+package controller
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gocroot/config"
+	"github.com/gocroot/helper/at"
+	"github.com/gocroot/helper/atapi"
+	"github.com/gocroot/helper/atdb"
+	"github.com/gocroot/helper/auth"
+	"github.com/gocroot/helper/identity"
+	"github.com/gocroot/helper/session"
+	"github.com/gocroot/model"
+	"github.com/whatsauth/itmodel"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"golang.org/x/crypto/bcrypt"
+	"google.golang.org/api/idtoken"
+)
+
+const (
+	identityChallengeTTL    = 10 * time.Minute
+	identityChallengeTrials = 5
+)
+
+// identityFilter filter user pemilik identitas, termasuk user lama yang identitasnya masih di field phonenumber, email atau githubusername
+func identityFilter(typ, subject string) bson.M {
+	or := bson.A{bson.M{"identities": bson.M{"$elemMatch": bson.M{"type": typ, "subject": subject}}}}
+	switch typ {
+	case identity.Phone:
+		or = append(or, bson.M{"phonenumber": subject})
+	case identity.Email:
+		// email lama tersimpan apa adanya, bisa mengandung huruf besar
+		or = append(or, bson.M{"email": primitive.Regex{Pattern: "^" + regexp.QuoteMeta(subject) + "$", Options: "i"}})
+	case identity.GitHub:
+		or = append(or, bson.M{"githubusername": subject})
+	}
+	return bson.M{"$or": or}
+}
+
+// userByIdentity user pemilik identitas, subject harus sudah dinormalisasi
+func userByIdentity(typ, subject string) (model.Userdomyikado, error) {
+	return atdb.GetOneDoc[model.Userdomyikado](config.Mongoconn, "user", identityFilter(typ, subject))
+}
+
+// verifyGoogleIDToken memvalidasi Google ID token dengan client id dari collection credentials
+func verifyGoogleIDToken(token string) (*idtoken.Payload, error) {
+	creds, err := atdb.GetOneDoc[auth.GoogleCredential](config.Mongoconn, "credentials", bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	return auth.VerifyIDToken(token, creds.ClientID)
+}
+
+// GetIdentities daftar identitas milik user dari token
+func GetIdentities(respw http.ResponseWriter, req *http.Request) {
+	docuser, ok := identityUser(respw, req)
+	if !ok {
+		return
+	}
+	at.WriteJSON(respw, http.StatusOK, docuser.LinkedIdentities())
+}
+
+// PostLinkIdentity menautkan identitas baru ke user dari token.
+// Google langsung diverifikasi dengan id_token, email dengan Google id_token yang emailnya terverifikasi atau password akun pemilik email,
+// sedangkan nomor WhatsApp dan GitHub harus diselesaikan lewat PostVerifyIdentity.
+func PostLinkIdentity(respw http.ResponseWriter, req *http.Request) {
+	docuser, ok := identityUser(respw, req)
+	if !ok {
+		return
+	}
+	body, ok := decodeIdentityRequest(respw, req)
+	if !ok {
+		return
+	}
+	switch body.Type {
+	case identity.Google:
+		payload, err := verifyGoogleIDToken(body.IDToken)
+		if err != nil {
+			at.WriteError(respw, at.NewError(at.CodeInvalidToken, "Token Google tidak valid", err.Error()))
+			return
+		}
+		completeLink(respw, req, docuser, identity.Identity{Type: identity.Google, Subject: payload.Subject}, body.Merge)
+	case identity.Email:
+		if !verifyEmailOwnership(respw, req, body) {
+			return
+		}
+		completeLink(respw, req, docuser, identity.Identity{Type: identity.Email, Subject: body.Subject}, body.Merge)
+	case identity.Phone, identity.GitHub:
+		startIdentityChallenge(respw, req, docuser, body)
+	}
+}
+
+// verifyEmailOwnership email terbukti milik user jika Google sudah memverifikasinya, atau user tahu password akun pemilik email
+func verifyEmailOwnership(respw http.ResponseWriter, req *http.Request, body model.IdentityRequest) bool {
+	if body.IDToken != "" {
+		payload, err := verifyGoogleIDToken(body.IDToken)
+		if err == nil {
+			email, _ := payload.Claims["email"].(string)
+			verified, _ := payload.Claims["email_verified"].(bool)
+			if verified && strings.EqualFold(email, body.Subject) {
+				return true
+			}
+			err = errors.New("email di token Google tidak sama atau belum terverifikasi")
+		}
+		at.WriteError(respw, at.NewError(at.CodeUnauthorized, "Email tidak bisa diverifikasi", err.Error()))
+		return false
+	}
+	if !limitAuth(respw, req, loginRule, body.Subject) {
+		return false
+	}
+	owner, err := userByIdentity(identity.Email, body.Subject)
+	if err == nil && owner.Password != "" && bcrypt.CompareHashAndPassword([]byte(owner.Password), []byte(body.Password)) == nil {
+		authSucceeded(req, body.Subject)
+		return true
+	}
+	if err == nil && owner.Password != "" {
+		authFailed(req, body.Subject)
+	}
+	at.WriteError(respw, at.NewError(at.CodeUnauthorized, "Email tidak bisa diverifikasi", "Sertakan id_token Google dengan email tersebut, atau password akun yang memakai email tersebut"))
+	return false
+}
+
+// startIdentityChallenge mengirim kode ke WhatsApp untuk nomor baru, atau memberi kode yang harus ditulis di bio GitHub
+func startIdentityChallenge(respw http.ResponseWriter, req *http.Request, docuser model.Userdomyikado, body model.IdentityRequest) {
+	if body.Type == identity.Phone && !limitAuth(respw, req, otpRule, body.Subject) {
+		return
+	}
+	code, err := identityCode()
+	if err != nil {
+		at.WriteError(respw, at.NewError(at.CodeInternal, "Gagal membuat kode verifikasi", err.Error()))
+		return
+	}
+	challenge := model.IdentityChallenge{
+		ID:          docuser.PhoneNumber + "|" + body.Type + "|" + body.Subject,
+		PhoneNumber: docuser.PhoneNumber,
+		Type:        body.Type,
+		Subject:     body.Subject,
+		Code:        session.HashToken(code),
+		ExpiresAt:   time.Now().Add(identityChallengeTTL),
+	}
+	// kode GitHub bukan rahasia karena ditulis di bio publik
+	if body.Type == identity.GitHub {
+		code = "verifikasi-" + code
+		challenge.Code = code
+	}
+	_, err = config.Mongoconn.Collection(model.IdentityChallengeCollection).ReplaceOne(context.TODO(),
+		bson.M{"_id": challenge.ID}, challenge, options.Replace().SetUpsert(true))
+	if err != nil {
+		at.WriteError(respw, at.NewError(at.CodeInternal, "Gagal menyimpan kode verifikasi", err.Error()))
+		return
+	}
+	response := map[string]interface{}{
+		"verification_required": true,
+		"type":                  body.Type,
+		"subject":               body.Subject,
+		"expires_in":            int64(identityChallengeTTL.Seconds()),
+	}
+	if body.Type == identity.GitHub {
+		response["message"] = "Tulis kode di bio GitHub lalu panggil verifikasi, bio boleh dikembalikan setelahnya"
+		response["code"] = code
+		at.WriteJSON(respw, http.StatusAccepted, response)
+		return
+	}
+	dt := &itmodel.TextMessage{
+		To:       body.Subject,
+		IsGroup:  false,
+		Messages: "Kode verifikasi untuk menautkan nomor ini ke akun " + docuser.Name + ": *" + code + "*\n\nAbaikan pesan ini jika Anda tidak memintanya.",
+	}
+	if _, _, err = atapi.PostStructWithToken[itmodel.Response]("Token", config.WAAPIToken, dt, config.WAAPIMessage); err != nil {
+		at.WriteError(respw, at.NewError(at.CodeUpstream, "Kode verifikasi gagal dikirim", err.Error()))
+		return
+	}
+	response["message"] = "Kode verifikasi sudah dikirim ke WhatsApp"
+	at.WriteJSON(respw, http.StatusAccepted, response)
+}
+
+// PostVerifyIdentity menyelesaikan penautan nomor WhatsApp dengan kode, atau GitHub dengan memeriksa bio
+func PostVerifyIdentity(respw http.ResponseWriter, req *http.Request) {
+	docuser, ok := identityUser(respw, req)
+	if !ok {
+		return
+	}
+	body, ok := decodeIdentityRequest(respw, req)
+	if !ok {
+		return
+	}
+	col := config.Mongoconn.Collection(model.IdentityChallengeCollection)
+	id := docuser.PhoneNumber + "|" + body.Type + "|" + body.Subject
+	var challenge model.IdentityChallenge
+	err := col.FindOneAndUpdate(context.TODO(),
+		bson.M{"_id": id, "expires_at": bson.M{"$gt": time.Now()}, "attempts": bson.M{"$lt": identityChallengeTrials}},
+		bson.M{"$inc": bson.M{"attempts": 1}}).Decode(&challenge)
+	if err != nil {
+		at.WriteError(respw, at.NewError(at.CodeNotFound, "Verifikasi tidak ditemukan", "Kode sudah kedaluwarsa atau terlalu banyak percobaan, silakan tautkan ulang"))
+		return
+	}
+	switch challenge.Type {
+	case identity.Phone:
+		ok = challenge.Code == session.HashToken(strings.TrimSpace(body.Code))
+	case identity.GitHub:
+		ok, err = githubBioContains(challenge.Subject, challenge.Code)
+	}
+	if err != nil {
+		at.WriteError(respw, at.NewError(at.CodeUpstream, "Profil GitHub tidak bisa dibaca", err.Error()))
+		return
+	}
+	if !ok {
+		at.WriteError(respw, at.NewError(at.CodeUnauthorized, "Verifikasi gagal", "Kode tidak cocok"))
+		return
+	}
+	if res, err := col.DeleteOne(context.TODO(), bson.M{"_id": id}); err != nil || res.DeletedCount == 0 {
+		at.WriteError(respw, at.NewError(at.CodeConflict, "Verifikasi sudah dipakai", "Silakan tautkan ulang"))
+		return
+	}
+	completeLink(respw, req, docuser, identity.Identity{Type: challenge.Type, Subject: challenge.Subject}, body.Merge)
+}
+
+// githubBioContains memeriksa kode verifikasi di bio profil GitHub publik
+func githubBioContains(username, code string) (bool, error) {
+	status, profile, err := atapi.GetWithBearer[struct {
+		Login string `json:"login"`
+		Bio   string `json:"bio"`
+	}](config.GHAccessToken(), "https://api.github.com/users/"+username)
+	if err != nil {
+		return false, err
+	}
+	if status != http.StatusOK {
+		return false, fmt.Errorf("github mengembalikan status %d", status)
+	}
+	return strings.EqualFold(profile.Login, username) && strings.Contains(profile.Bio, code), nil
+}
+
+// completeLink menyimpan identitas yang sudah terverifikasi. Jika identitas login dipakai akun lain,
+// akun tersebut digabungkan ke akun ini hanya bila user meminta merge karena kepemilikannya sudah terbukti.
+func completeLink(respw http.ResponseWriter, req *http.Request, docuser model.Userdomyikado, id identity.Identity, merge bool) {
+	var respn model.Response
+	owner, err := userByIdentity(id.Type, id.Subject)
+	switch {
+	case err == nil && owner.ID != docuser.ID:
+		if !identity.CanLogin(id.Type) || !merge {
+			apierr := at.NewError(at.CodeConflict, "Identitas dipakai akun lain", "Identitas ini milik akun lain")
+			if identity.CanLogin(id.Type) {
+				apierr.Info = "Kirim ulang dengan merge true untuk menggabungkan akun tersebut ke akun ini"
+			}
+			at.WriteError(respw, apierr)
+			return
+		}
+		if _, err = mergeUsers(docuser, owner, docuser.PhoneNumber); err != nil {
+			at.WriteError(respw, at.NewError(at.CodeInternal, "Gagal menggabungkan akun", err.Error()))
+			return
+		}
+		docuser, err = atdb.GetOneDoc[model.Userdomyikado](config.Mongoconn, "user", bson.M{"_id": docuser.ID})
+	case errors.Is(err, mongo.ErrNoDocuments):
+		err = nil
+	}
+	if err != nil {
+		at.WriteError(respw, at.NewError(at.CodeInternal, "Data user tidak bisa diambil", err.Error()))
+		return
+	}
+	id.LinkedAt = time.Now()
+	set := bson.M{}
+	if id.Type == identity.Email && docuser.Email == "" {
+		set["email"] = id.Subject
+		set["emailverification"] = model.EmailVerified
+	}
+	if id.Type == identity.GitHub && docuser.GithubUsername == "" {
+		set["githubusername"] = id.Subject
+	}
+	update := bson.M{}
+	if !identity.Contains(docuser.Identities, id.Type, id.Subject) {
+		update["$push"] = bson.M{"identities": id}
+	}
+	if len(set) > 0 {
+		update["$set"] = set
+	}
+	if len(update) > 0 {
+		_, err = config.Mongoconn.Collection("user").UpdateOne(context.TODO(), bson.M{"_id": docuser.ID}, update)
+	}
+	if mongo.IsDuplicateKeyError(err) {
+		// identitas baru saja ditautkan ke akun lain oleh request bersamaan
+		at.WriteError(respw, at.NewError(at.CodeConflict, "Identitas dipakai akun lain", err.Error()))
+		return
+	}
+	if err != nil {
+		at.WriteError(respw, at.NewError(at.CodeInternal, "Gagal menautkan identitas", err.Error()))
+		return
+	}
+	respn.Status = "Success"
+	respn.Response = id.Type + " " + id.Subject + " sudah ditautkan"
+	at.WriteJSON(respw, http.StatusOK, respn)
+}
+
+// DeleteIdentity melepas identitas, nomor utama tidak bisa dilepas dan minimal satu identitas login harus tersisa
+func DeleteIdentity(respw http.ResponseWriter, req *http.Request) {
+	var respn model.Response
+	docuser, ok := identityUser(respw, req)
+	if !ok {
+		return
+	}
+	body, ok := decodeIdentityRequest(respw, req)
+	if !ok {
+		return
+	}
+	linked := docuser.LinkedIdentities()
+	rest, found := identity.Remove(linked, body.Type, body.Subject)
+	switch {
+	case !found:
+		at.WriteError(respw, at.NewError(at.CodeNotFound, "Identitas tidak ditemukan", body.Type+" "+body.Subject))
+		return
+	case body.Type == identity.Phone && body.Subject == docuser.PhoneNumber:
+		at.WriteError(respw, at.NewError(at.CodeConflict, "Nomor utama tidak bisa dilepas", "Nomor utama dipakai sebagai id akun"))
+		return
+	case identity.CountLogin(rest) == 0:
+		at.WriteError(respw, at.NewError(at.CodeConflict, "Identitas login terakhir", "Minimal satu identitas login harus tersisa"))
+		return
+	}
+	update := bson.M{"$pull": bson.M{"identities": bson.M{"type": body.Type, "subject": body.Subject}}}
+	unset := bson.M{}
+	if body.Type == identity.Email && strings.EqualFold(docuser.Email, body.Subject) {
+		unset["email"], unset["password"] = "", ""
+	}
+	if body.Type == identity.GitHub && strings.EqualFold(docuser.GithubUsername, body.Subject) {
+		unset["githubusername"] = ""
+	}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
+	if _, err := config.Mongoconn.Collection("user").UpdateOne(context.TODO(), bson.M{"_id": docuser.ID}, update); err != nil {
+		at.WriteError(respw, at.NewError(at.CodeInternal, "Gagal melepas identitas", err.Error()))
+		return
+	}
+	respn.Status = "Success"
+	respn.Response = body.Type + " " + body.Subject + " sudah dilepas"
+	at.WriteJSON(respw, http.StatusOK, respn)
+}
+
+// identityUser user dari token login
+func identityUser(respw http.ResponseWriter, req *http.Request) (docuser model.Userdomyikado, ok bool) {
+	payload, err := decodeLoginToken(req)
+	if err != nil {
+		writeTokenError(respw, err)
+		return
+	}
+	docuser, err = atdb.GetOneDoc[model.Userdomyikado](config.Mongoconn, "user", bson.M{"phonenumber": payload.Id})
+	if err != nil {
+		at.WriteError(respw, at.NewError(at.CodeNotFound, "Data user tidak di temukan", err.Error()))
+		return
+	}
+	return docuser, true
+}
+
+// decodeIdentityRequest membaca body dan menormalisasi subject, subject google diambil dari id_token
+func decodeIdentityRequest(respw http.ResponseWriter, req *http.Request) (body model.IdentityRequest, ok bool) {
+	err := json.NewDecoder(req.Body).Decode(&body)
+	switch {
+	case err != nil:
+	case body.Type == identity.Google && body.Subject == "":
+		if body.IDToken == "" {
+			err = identity.ErrInvalidSubject
+		}
+	default:
+		body.Subject, err = identity.Normalize(body.Type, body.Subject)
+	}
+	if err != nil {
+		at.WriteError(respw, at.NewError(at.CodeInvalidBody, "Body tidak valid", err.Error()))
+		return
+	}
+	return body, true
+}
+
+// identityCode kode verifikasi 6 digit
+func identityCode() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%06d", n.Int64()), nil
+}
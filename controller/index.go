@@ -0,0 +1,41 @@
+package controller
+
+import (
+	"log"
+	"sync"
+
+	"github.com/gocroot/config"
+	"github.com/gocroot/helper/atdb"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// uniqueIndex index unik yang menjaga data tetap konsisten saat ada request bersamaan dari beberapa instance
+type uniqueIndex struct {
+	collection string
+	keys       bson.D
+	partial    bson.M
+}
+
+var uniqueIndexes = []uniqueIndex{
+	// satu identitas login hanya boleh dimiliki satu user, user tanpa identitas tidak ikut diindeks
+	{"user", bson.D{{Key: "identities.type", Value: 1}, {Key: "identities.subject", Value: 1}}, bson.M{"identities.subject": bson.M{"$exists": true}}},
+	// satu cart per user, upsert getOrCreateCart yang bersamaan tidak membuat cart kedua
+	{"cart", bson.D{{Key: "user_id", Value: 1}}, nil},
+	// kode voucher unik di semua lapak karena voucher dicari hanya dari kode
+	{"voucher", bson.D{{Key: "code", Value: 1}}, nil},
+	// satu ulasan per user per produk, upsert PostReview yang bersamaan tidak membuat ulasan kedua
+	{"review", bson.D{{Key: "product_id", Value: 1}, {Key: "user_id", Value: 1}}, nil},
+}
+
+var indexOnce sync.Once
+
+// EnsureIndexes membuat index unik sekali per instance, kegagalan hanya dicatat supaya request tetap jalan
+func EnsureIndexes() {
+	indexOnce.Do(func() {
+		for _, idx := range uniqueIndexes {
+			if _, err := atdb.CreateUniqueIndex(config.Mongoconn, idx.collection, idx.keys, idx.partial); err != nil {
+				log.Println("gagal membuat index " + idx.collection + ": " + err.Error())
+			}
+		}
+	})
+}
@@ -10,11 +10,9 @@ import (
 )
 
 func GetCountDocUser(w http.ResponseWriter, r *http.Request) {
-	var resp model.Response
 	rkp, err := lms.GetRekapPendaftaranUsers(config.Mongoconn)
 	if err != nil {
-		resp.Response = err.Error()
-		at.WriteJSON(w, http.StatusConflict, resp)
+		at.WriteError(w, at.NewError(at.CodeConflict, "", err.Error()))
 		return
 	}
 	at.WriteJSON(w, http.StatusOK, rkp)
@@ -24,8 +22,7 @@ func RefreshLMSCookie(respw http.ResponseWriter, req *http.Request) {
 	var resp model.Response
 	err := lms.RefreshCookie(config.Mongoconn)
 	if err != nil {
-		resp.Response = err.Error()
-		at.WriteJSON(respw, http.StatusBadRequest, resp)
+		at.WriteError(respw, at.NewError(at.CodeBadRequest, "", err.Error()))
 		return
 	}
 	resp.Info = "ok"
@@ -33,7 +33,7 @@ func GetRegion(respw http.ResponseWriter, req *http.Request) {
 	// Cari region berdasarkan filter
 	region, err := atdb.GetOneDoc[model.Region](config.MongoconnGeo, "region", filter)
 	if err != nil {
-		at.WriteJSON(respw, http.StatusNotFound, bson.M{"error": "Region not found"})
+		at.WriteError(respw, at.NewError(at.CodeNotFound, "Region tidak ditemukan", err.Error()))
 		return
 	}
 
@@ -65,7 +65,7 @@ func GetRegion(respw http.ResponseWriter, req *http.Request) {
 func GetRoads(respw http.ResponseWriter, req *http.Request) {
 	var longlat model.LongLat
 	if err := json.NewDecoder(req.Body).Decode(&longlat); err != nil {
-		at.WriteJSON(respw, http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
+		at.WriteError(respw, at.NewError(at.CodeInvalidBody, "", err.Error()))
 		return
 	}
 
@@ -85,7 +85,7 @@ func GetRoads(respw http.ResponseWriter, req *http.Request) {
 	// Melakukan operasi pencarian pada MongoDB untuk mengambil data dokumen dari koleksi "roads"
 	roads, err := atdb.GetAllDoc[[]model.Roads](config.MongoconnGeo, "roads", filter)
 	if err != nil {
-		at.WriteJSON(respw, http.StatusNotFound, map[string]string{"error": "No roads found"})
+		at.WriteError(respw, at.NewError(at.CodeNotFound, "Data jalan tidak ditemukan", err.Error()))
 		return
 	}
 
@@ -0,0 +1,302 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gocroot/config"
+	"github.com/gocroot/helper/at"
+	"github.com/gocroot/helper/atdb"
+	"github.com/gocroot/helper/session"
+	"github.com/gocroot/helper/voucher"
+	"github.com/gocroot/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// phoneOwned collection yang datanya dimiliki user lewat nomor telepon dan ikut dipindah saat akun digabung
+var phoneOwned = []struct{ collection, field string }{
+	{"address", "user_id"},
+	{"checkout", "phonenumber"},
+	{"confirmation", "phonenumber"},
+}
+
+// PostMergeUsers admin menggabungkan akun duplikat ke akun utama
+func PostMergeUsers(respw http.ResponseWriter, req *http.Request) {
+	var body model.MergeRequest
+	if err := json.NewDecoder(req.Body).Decode(&body); err != nil || body.Primary == "" || body.Duplicate == "" || body.Primary == body.Duplicate {
+		at.WriteError(respw, at.NewError(at.CodeInvalidBody, "Body tidak valid", "primary dan duplicate harus berisi dua nomor yang berbeda"))
+		return
+	}
+	primary, err := atdb.GetOneDoc[model.Userdomyikado](config.Mongoconn, "user", bson.M{"phonenumber": body.Primary})
+	var dup model.Userdomyikado
+	if err == nil {
+		dup, err = atdb.GetOneDoc[model.Userdomyikado](config.Mongoconn, "user", bson.M{"phonenumber": body.Duplicate})
+	}
+	if err != nil {
+		at.WriteError(respw, at.NewError(at.CodeNotFound, "Akun tidak ditemukan", err.Error()))
+		return
+	}
+	sub, _ := requestSubject(req)
+	audit, err := mergeUsers(primary, dup, sub.ID)
+	if err != nil {
+		at.WriteError(respw, at.NewError(at.CodeInternal, "Gagal menggabungkan akun", err.Error()))
+		return
+	}
+	at.WriteJSON(respw, http.StatusOK, audit)
+}
+
+// GetUserMerges riwayat penggabungan akun terbaru
+func GetUserMerges(respw http.ResponseWriter, req *http.Request) {
+	opts := options.Find().SetSort(bson.M{"created_at": -1}).SetLimit(100)
+	cur, err := config.Mongoconn.Collection(model.UserMergeCollection).Find(context.TODO(), bson.M{}, opts)
+	var merges []model.UserMerge
+	if err == nil {
+		err = cur.All(context.TODO(), &merges)
+	}
+	if err != nil {
+		at.WriteError(respw, at.NewError(at.CodeInternal, "Riwayat penggabungan tidak bisa diambil", err.Error()))
+		return
+	}
+	at.WriteJSON(respw, http.StatusOK, merges)
+}
+
+// mergeUsers melipat akun dup ke primary: profil, poin, identitas dan role lewat MergeFrom,
+// keanggotaan dan peran di project, data yang dimiliki lewat nomor telepon, lalu akun dup dihapus.
+// Semua penulisan berjalan dalam satu transaksi, sesi akun dup dicabut setelah transaksi commit.
+func mergeUsers(primary, dup model.Userdomyikado, actor string) (audit model.UserMerge, err error) {
+	if primary.ID == dup.ID {
+		return audit, errors.New("akun yang digabung sama")
+	}
+	ctx := context.TODO()
+	merged := primary
+	merged.MergeFrom(dup)
+	sess, err := config.Mongoconn.Client().StartSession()
+	if err != nil {
+		return
+	}
+	defer sess.EndSession(ctx)
+	// callback bisa diulang oleh driver saat transaksi bentrok, audit disusun ulang setiap percobaan
+	_, err = sess.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+		var txerr error
+		audit, txerr = mergeUserDocs(sc, merged, dup, actor)
+		return nil, txerr
+	})
+	if err != nil {
+		return
+	}
+	if dup.PhoneNumber != "" && dup.PhoneNumber != primary.PhoneNumber {
+		_, err = session.RevokeAll(config.Mongoconn, dup.PhoneNumber, "akun digabung", time.Now())
+	}
+	return
+}
+
+// mergeUserDocs isi transaksi mergeUsers, primary sudah berisi hasil MergeFrom
+func mergeUserDocs(sc mongo.SessionContext, primary, dup model.Userdomyikado, actor string) (audit model.UserMerge, err error) {
+	db := config.Mongoconn
+	// dup dihapus lebih dulu karena identitasnya dipindah ke primary dan dijaga index unik
+	if _, err = db.Collection("user").DeleteOne(sc, bson.M{"_id": dup.ID}); err != nil {
+		return
+	}
+	if _, err = db.Collection("user").ReplaceOne(sc, bson.M{"_id": primary.ID}, primary); err != nil {
+		return
+	}
+	audit = model.UserMerge{
+		Actor:     actor,
+		Primary:   primary.ID,
+		Duplicate: dup,
+		CreatedAt: time.Now(),
+	}
+	// salinan user di project tidak membawa password dan identitas
+	member := primary
+	member.Password, member.Identities = "", nil
+	projects := db.Collection("project")
+	for _, field := range []string{"owner", "editor", "manager"} {
+		var res *mongo.UpdateResult
+		res, err = projects.UpdateMany(sc, bson.M{field + "._id": dup.ID}, bson.M{"$set": bson.M{field: member}})
+		if err != nil {
+			return
+		}
+		audit.Projects += res.ModifiedCount
+	}
+	// anggota project: ganti dup dengan primary, kecuali primary sudah jadi anggota maka dup cukup dihapus
+	res, err := projects.UpdateMany(sc,
+		bson.M{"$and": bson.A{bson.M{"members._id": dup.ID}, bson.M{"members._id": bson.M{"$ne": primary.ID}}}},
+		bson.M{"$set": bson.M{"members.$[m]": member}},
+		options.Update().SetArrayFilters(options.ArrayFilters{Filters: bson.A{bson.M{"m._id": dup.ID}}}))
+	if err != nil {
+		return
+	}
+	audit.Projects += res.ModifiedCount
+	res, err = projects.UpdateMany(sc, bson.M{"members._id": dup.ID}, bson.M{"$pull": bson.M{"members": bson.M{"_id": dup.ID}}})
+	if err != nil {
+		return
+	}
+	audit.Projects += res.ModifiedCount
+	// task project dari notulen meeting
+	for _, collection := range []string{"tasklist", "taskdoing", "taskdone"} {
+		_, err = db.Collection(collection).UpdateMany(sc, bson.M{"userid": dup.ID},
+			bson.M{"$set": bson.M{"userid": primary.ID, "phonenumber": primary.PhoneNumber, "name": primary.Name}})
+		if err != nil {
+			return
+		}
+	}
+
+	if dup.PhoneNumber != "" && dup.PhoneNumber != primary.PhoneNumber {
+		if audit.Moved, err = movePhoneOwnedData(sc, dup.PhoneNumber, primary.PhoneNumber); err != nil {
+			return
+		}
+		if _, err = db.Collection(model.TOTPCollection).DeleteOne(sc, bson.M{"_id": dup.PhoneNumber}); err != nil {
+			return
+		}
+	}
+	res2, err := db.Collection(model.UserMergeCollection).InsertOne(sc, audit)
+	if err != nil {
+		return
+	}
+	audit.ID = res2.InsertedID.(primitive.ObjectID)
+	return
+}
+
+// movePhoneOwnedData memindahkan data milik nomor lama ke nomor utama. Ulasan, cart dan wishlist digabung
+// supaya tidak dobel, pemakaian voucher per user ikut dipindah supaya batas per user tetap berlaku.
+func movePhoneOwnedData(sc mongo.SessionContext, from, to string) (moved int64, err error) {
+	db := config.Mongoconn
+	for _, owned := range phoneOwned {
+		update := bson.M{owned.field: to}
+		if owned.collection == "address" {
+			// alamat utama tetap alamat default akun utama
+			update["default"] = false
+		}
+		var res *mongo.UpdateResult
+		res, err = db.Collection(owned.collection).UpdateMany(sc, bson.M{owned.field: from}, bson.M{"$set": update})
+		if err != nil {
+			return
+		}
+		moved += res.ModifiedCount
+	}
+
+	n, err := moveReviews(sc, from, to)
+	if err != nil {
+		return
+	}
+	moved += n
+	n, err = moveVoucherUsage(sc, from, to)
+	if err != nil {
+		return
+	}
+	moved += n
+
+	var wishlist []model.Wishlist
+	cur, err := db.Collection("wishlist").Find(sc, bson.M{"user_id": from})
+	if err != nil {
+		return
+	}
+	if err = cur.All(sc, &wishlist); err != nil {
+		return
+	}
+	for _, w := range wishlist {
+		_, err = db.Collection("wishlist").UpdateOne(sc,
+			bson.M{"user_id": to, "product_id": w.ProductID},
+			bson.M{"$setOnInsert": bson.M{"created_at": w.CreatedAt}}, options.Update().SetUpsert(true))
+		if err != nil {
+			return
+		}
+		moved++
+	}
+	if _, err = db.Collection("wishlist").DeleteMany(sc, bson.M{"user_id": from}); err != nil {
+		return
+	}
+
+	var oldCart, cart model.Cart
+	err = db.Collection("cart").FindOne(sc, bson.M{"user_id": from}).Decode(&oldCart)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return moved, nil
+	}
+	if err != nil {
+		return
+	}
+	err = db.Collection("cart").FindOne(sc, bson.M{"user_id": to}).Decode(&cart)
+	switch {
+	case errors.Is(err, mongo.ErrNoDocuments):
+		_, err = db.Collection("cart").UpdateOne(sc, bson.M{"_id": oldCart.ID}, bson.M{"$set": bson.M{"user_id": to}})
+	case err == nil:
+		for _, item := range oldCart.Items {
+			cart.AddItem(item)
+		}
+		_, err = db.Collection("cart").UpdateOne(sc, bson.M{"_id": cart.ID}, bson.M{"$set": bson.M{"items": cart.Items, "updated_at": time.Now()}})
+		if err == nil {
+			_, err = db.Collection("cart").DeleteOne(sc, bson.M{"_id": oldCart.ID})
+		}
+	}
+	if err == nil {
+		moved++
+	}
+	return
+}
+
+// moveReviews memindahkan ulasan ke nomor utama. Jika kedua akun mengulas produk yang sama hanya ulasan
+// terbaru yang disimpan, lalu rating produk yang terdampak dihitung ulang.
+func moveReviews(sc mongo.SessionContext, from, to string) (moved int64, err error) {
+	reviews := config.Mongoconn.Collection("review")
+	var old []model.Review
+	cur, err := reviews.Find(sc, bson.M{"user_id": from})
+	if err != nil {
+		return
+	}
+	if err = cur.All(sc, &old); err != nil {
+		return
+	}
+	for _, r := range old {
+		var existing model.Review
+		err = reviews.FindOne(sc, bson.M{"product_id": r.ProductID, "user_id": to}).Decode(&existing)
+		switch {
+		case errors.Is(err, mongo.ErrNoDocuments):
+			_, err = reviews.UpdateOne(sc, bson.M{"_id": r.ID}, bson.M{"$set": bson.M{"user_id": to}})
+		case err == nil && r.UpdatedAt.After(existing.UpdatedAt):
+			if _, err = reviews.DeleteOne(sc, bson.M{"_id": existing.ID}); err == nil {
+				_, err = reviews.UpdateOne(sc, bson.M{"_id": r.ID}, bson.M{"$set": bson.M{"user_id": to}})
+			}
+		case err == nil:
+			_, err = reviews.DeleteOne(sc, bson.M{"_id": r.ID})
+		}
+		if err != nil {
+			return
+		}
+		if err = updateProductRating(sc, r.ProductID); err != nil {
+			return
+		}
+		moved++
+	}
+	return
+}
+
+// moveVoucherUsage menjumlahkan pemakaian voucher nomor lama ke nomor utama lalu menghapus penghitung lama
+func moveVoucherUsage(sc mongo.SessionContext, from, to string) (moved int64, err error) {
+	vouchers := config.Mongoconn.Collection("voucher")
+	var used []voucher.Voucher
+	cur, err := vouchers.Find(sc, bson.M{"usage." + from: bson.M{"$exists": true}})
+	if err != nil {
+		return
+	}
+	if err = cur.All(sc, &used); err != nil {
+		return
+	}
+	for _, v := range used {
+		update := bson.M{
+			"$inc":   bson.M{"usage." + to: v.Usage[from]},
+			"$unset": bson.M{"usage." + from: ""},
+		}
+		if _, err = vouchers.UpdateOne(sc, bson.M{"_id": v.ID}, update); err != nil {
+			return
+		}
+		moved++
+	}
+	_, err = config.Mongoconn.Collection("voucherredemption").UpdateMany(sc, bson.M{"user_id": from}, bson.M{"$set": bson.M{"user_id": to}})
+	return
+}
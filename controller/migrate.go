@@ -0,0 +1,161 @@
+package controller
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"sync"
+
+	"github.com/gocroot/config"
+	"github.com/gocroot/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// legacyProductCollection collection produk lama sebelum semua handler memakai model.ProductCollection
+const legacyProductCollection = "products"
+
+// migrations migrasi data yang dijalankan berurutan, semuanya harus aman diulang
+var migrations = []struct {
+	name string
+	run  func(ctx context.Context) (int, error)
+}{
+	{"produk dari " + legacyProductCollection, migrateLegacyProducts},
+	{"cart ganda per user", mergeDuplicateCarts},
+	{"ulasan ganda per user per produk", dropDuplicateReviews},
+}
+
+var migrateOnce sync.Once
+
+// Migrate menjalankan migrasi data sekali per instance sebelum index unik dibuat,
+// kegagalan hanya dicatat supaya request tetap jalan
+func Migrate() {
+	migrateOnce.Do(func() {
+		for _, m := range migrations {
+			n, err := m.run(context.TODO())
+			if err != nil {
+				log.Println("gagal migrasi " + m.name + ": " + err.Error())
+			}
+			if n > 0 {
+				log.Println("migrasi " + m.name + ": " + strconv.Itoa(n) + " dokumen")
+			}
+		}
+	})
+}
+
+// migrateLegacyProducts menyalin dokumen collection products ke product dengan _id yang sama lalu menghapusnya dari products.
+// Dokumen yang sudah ada di product tidak ditimpa, sehingga migrasi aman diulang jika berhenti di tengah jalan.
+func migrateLegacyProducts(ctx context.Context) (moved int, err error) {
+	legacy := config.Mongoconn.Collection(legacyProductCollection)
+	products := config.Mongoconn.Collection(model.ProductCollection)
+	cur, err := legacy.Find(ctx, bson.M{})
+	if err != nil {
+		return
+	}
+	defer cur.Close(ctx)
+	for cur.Next(ctx) {
+		var doc bson.M
+		if err = cur.Decode(&doc); err != nil {
+			return
+		}
+		id := doc["_id"]
+		delete(doc, "_id")
+		_, err = products.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$setOnInsert": doc}, options.Update().SetUpsert(true))
+		if err != nil {
+			return
+		}
+		if _, err = legacy.DeleteOne(ctx, bson.M{"_id": id}); err != nil {
+			return
+		}
+		moved++
+	}
+	return moved, cur.Err()
+}
+
+// mergeDuplicateCarts menggabungkan cart ganda milik satu user ke cart yang paling lama supaya index unik cart.user_id bisa dibuat
+func mergeDuplicateCarts(ctx context.Context) (merged int, err error) {
+	carts := config.Mongoconn.Collection("cart")
+	pipeline := []bson.M{
+		{"$group": bson.M{"_id": "$user_id", "ids": bson.M{"$push": "$_id"}, "count": bson.M{"$sum": 1}}},
+		{"$match": bson.M{"count": bson.M{"$gt": 1}}},
+	}
+	cur, err := carts.Aggregate(ctx, pipeline)
+	if err != nil {
+		return
+	}
+	var groups []struct {
+		IDs []primitive.ObjectID `bson:"ids"`
+	}
+	if err = cur.All(ctx, &groups); err != nil {
+		return
+	}
+	for _, group := range groups {
+		var list []model.Cart
+		cur, err = carts.Find(ctx, bson.M{"_id": bson.M{"$in": group.IDs}}, options.Find().SetSort(bson.M{"created_at": 1}))
+		if err != nil {
+			return
+		}
+		if err = cur.All(ctx, &list); err != nil {
+			return
+		}
+		if len(list) < 2 {
+			continue
+		}
+		keep := list[0]
+		for _, dup := range list[1:] {
+			for _, item := range dup.Items {
+				keep.AddItem(item)
+			}
+		}
+		if err = saveCartItems(&keep); err != nil {
+			return
+		}
+		for _, dup := range list[1:] {
+			if _, err = carts.DeleteOne(ctx, bson.M{"_id": dup.ID}); err != nil {
+				return
+			}
+			merged++
+		}
+	}
+	return
+}
+
+// dropDuplicateReviews menyisakan ulasan terbaru untuk tiap user dan produk supaya index unik review bisa dibuat,
+// rating produk yang ulasannya dihapus dihitung ulang
+func dropDuplicateReviews(ctx context.Context) (dropped int, err error) {
+	reviews := config.Mongoconn.Collection("review")
+	pipeline := []bson.M{
+		{"$sort": bson.M{"updated_at": -1}},
+		{"$group": bson.M{
+			"_id":   bson.M{"product_id": "$product_id", "user_id": "$user_id"},
+			"ids":   bson.M{"$push": "$_id"},
+			"count": bson.M{"$sum": 1},
+		}},
+		{"$match": bson.M{"count": bson.M{"$gt": 1}}},
+	}
+	cur, err := reviews.Aggregate(ctx, pipeline)
+	if err != nil {
+		return
+	}
+	var groups []struct {
+		Key struct {
+			ProductID primitive.ObjectID `bson:"product_id"`
+		} `bson:"_id"`
+		IDs []primitive.ObjectID `bson:"ids"`
+	}
+	if err = cur.All(ctx, &groups); err != nil {
+		return
+	}
+	for _, group := range groups {
+		res, err := reviews.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": group.IDs[1:]}})
+		if err != nil {
+			return dropped, err
+		}
+		dropped += int(res.DeletedCount)
+		if err = updateProductRating(ctx, group.Key.ProductID); err != nil {
+			return dropped, err
+		}
+	}
+	return
+}
@@ -3,56 +3,145 @@ package controller
 import (
 	"encoding/json"
 	"fmt"
+	"log"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gocroot/config"
 	"github.com/gocroot/helper/at"
 	"github.com/gocroot/helper/atapi"
 	"github.com/gocroot/helper/atdb"
 	"github.com/gocroot/helper/jualin"
+	"github.com/gocroot/helper/rbac"
+	"github.com/gocroot/helper/router"
 	"github.com/gocroot/model"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 // Fungsi untuk menangani request order
 func HandleOrder(w http.ResponseWriter, r *http.Request) {
-	namalapak := at.GetParam(r)
-	var orderRequest jualin.PaymentRequest
+	namalapak := router.Param(r, "namalapak")
+	var body jualin.PaymentRequest
 
 	// Decode JSON request ke struct
 	decoder := json.NewDecoder(r.Body)
-	if err := decoder.Decode(&orderRequest); err != nil {
-		http.Error(w, "Bad request", http.StatusBadRequest)
+	if err := decoder.Decode(&body); err != nil {
+		at.WriteError(w, at.NewError(at.CodeInvalidBody, "", err.Error()))
 		return
 	}
-	_, err := atdb.InsertOneDoc(config.Mongoconn, "order", orderRequest)
+	// hanya field pesanan yang diambil dari client, id, status dan riwayat diisi server
+	orderRequest := jualin.PaymentRequest{
+		Orders:        body.Orders,
+		Total:         body.Total,
+		User:          body.User,
+		Payment:       body.Payment,
+		PaymentMethod: body.PaymentMethod,
+	}
+	// ambil data lapak untuk harga menu dan nomor owner
+	prj, err := atdb.GetOneDoc[model.Project](config.Mongoconn, "project", primitive.M{"name": namalapak})
 	if err != nil {
-		http.Error(w, "Insert Database Gagal", http.StatusBadRequest)
+		at.WriteError(w, at.NewError(at.CodeNotFound, "Lapak tidak ditemukan", err.Error()))
+		return
+	}
+	// harga dan total dihitung ulang dari menu lapak, bukan dari client
+	if err = jualin.CekTotal(&orderRequest, menuLapak(prj)); err != nil {
+		at.WriteError(w, at.NewError(at.CodeConflict, "Total pesanan tidak sesuai", err.Error()))
+		return
+	}
+	orderRequest.NamaLapak = prj.Name
+	orderRequest.CreatedAt = time.Now()
+	jualin.SetStatus(&orderRequest, jualin.StatusPending, orderRequest.User.Whatsapp)
+	orderRequest.ID, err = atdb.InsertOneDoc(config.Mongoconn, "order", orderRequest)
+	if err != nil {
+		at.WriteError(w, at.NewError(at.CodeInternal, "Gagal menyimpan pesanan", err.Error()))
 		return
 	}
 
 	//kirim pesan ke tenant
-	message := "*Pesanan Masuk " + namalapak + "*\n" + orderRequest.User.Name + "\n" + orderRequest.User.Whatsapp + "\n" + orderRequest.User.Address + "\n" + createOrderMessage(orderRequest.Orders) + "\nTotal: " + strconv.Itoa(orderRequest.Total) + "\nPembayaran: " + orderRequest.PaymentMethod
+	message := "*Pesanan Masuk " + namalapak + "*\n" + orderRequest.User.Name + "\n" + orderRequest.User.Whatsapp + "\n" + orderRequest.User.Address + "\n" + createOrderMessage(orderRequest.Orders) + "\nTotal: " + strconv.Itoa(orderRequest.Total) + "\nPembayaran: " + orderRequest.PaymentMethod + "\nID Pesanan: " + orderRequest.ID.Hex()
 	newmsg := model.SendText{
-		To:       "628111269691",
+		To:       prj.Owner.PhoneNumber,
 		IsGroup:  false,
 		Messages: message,
 	}
+	// pesanan sudah tersimpan, kegagalan notifikasi hanya dicatat supaya client tidak mengulang dan membuat pesanan ganda
 	_, _, err = atapi.PostStructWithToken[model.Response]("token", config.WAAPIToken, newmsg, config.WAAPIMessage)
 	if err != nil {
-		http.Error(w, "Gagal Mengirim pesan", http.StatusBadRequest)
-		return
+		log.Println("gagal mengirim notifikasi pesanan " + orderRequest.ID.Hex() + ": " + err.Error())
 	}
-	// Cetak data order ke terminal (bisa diganti dengan logic lain, misal menyimpan ke database)
-	fmt.Printf("Received Order: %+v\n", orderRequest)
 
 	// Kirim response kembali ke client
 	w.Header().Set("Content-Type", "application/json")
-	response := map[string]string{"status": "success", "message": "Order received"}
+	response := map[string]interface{}{
+		"status":  "success",
+		"message": "Order received",
+		"id":      orderRequest.ID.Hex(),
+		"total":   orderRequest.Total,
+	}
 	json.NewEncoder(w).Encode(response)
 }
 
+// PutStatusOrder dipakai owner lapak untuk memindahkan status pesanan
+func PutStatusOrder(respw http.ResponseWriter, req *http.Request) {
+	payload, err := config.TokenKeyring.Decode(at.GetLoginFromHeader(req))
+	if err != nil {
+		at.WriteError(respw, at.NewError(at.CodeInvalidToken, "Token Tidak Valid", err.Error()))
+		return
+	}
+	var stsreq jualin.StatusRequest
+	err = json.NewDecoder(req.Body).Decode(&stsreq)
+	if err != nil {
+		at.WriteError(respw, at.NewError(at.CodeInvalidBody, "Body tidak valid", err.Error()))
+		return
+	}
+	objectId, err := primitive.ObjectIDFromHex(stsreq.ID)
+	if err != nil {
+		at.WriteError(respw, at.NewError(at.CodeBadRequest, "ObjectID Tidak Valid", err.Error()))
+		return
+	}
+	order, err := atdb.GetOneDoc[jualin.PaymentRequest](config.Mongoconn, "order", primitive.M{"_id": objectId})
+	if err != nil {
+		at.WriteError(respw, at.NewError(at.CodeNotFound, "Pesanan tidak ditemukan", err.Error()))
+		return
+	}
+	prj, err := atdb.GetOneDoc[model.Project](config.Mongoconn, "project", primitive.M{"name": order.NamaLapak})
+	if err != nil {
+		at.WriteError(respw, at.NewError(at.CodeNotFound, "Data lapak tidak di temukan", err.Error()))
+		return
+	}
+	//check apakah dia owner lapak
+	if err = authorizeResource(req, rbac.LapakManage, prj.Owner.PhoneNumber); err != nil {
+		writeForbidden(respw, err)
+		return
+	}
+	if !jualin.BisaPindahStatus(order.Status, stsreq.Status) {
+		at.WriteError(respw, at.NewError(at.CodeConflict, "Status tidak bisa diubah", "Status "+order.Status+" tidak bisa diubah menjadi "+stsreq.Status))
+		return
+	}
+	jualin.SetStatus(&order, stsreq.Status, payload.Id)
+	_, err = atdb.ReplaceOneDoc(config.Mongoconn, "order", primitive.M{"_id": order.ID}, order)
+	if err != nil {
+		at.WriteError(respw, at.NewError(at.CodeInternal, "Gagal memperbarui database", err.Error()))
+		return
+	}
+	at.WriteJSON(respw, http.StatusOK, order)
+}
+
+// menu lapak diubah ke bentuk menu jualin untuk perhitungan harga
+func menuLapak(prj model.Project) (menu []jualin.MenuItem) {
+	for _, m := range prj.Menu {
+		menu = append(menu, jualin.MenuItem{
+			ID:    m.ID,
+			Name:  m.Name,
+			Price: m.Price,
+			Image: m.Image,
+		})
+	}
+	return
+}
+
 // Fungsi untuk membuat pesan dari orders
 func createOrderMessage(orders []jualin.Order) string {
 	var orderStrings []string
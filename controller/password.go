@@ -0,0 +1,220 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gocroot/config"
+	"github.com/gocroot/helper/at"
+	"github.com/gocroot/helper/atapi"
+	"github.com/gocroot/helper/atdb"
+	"github.com/gocroot/helper/auth"
+	"github.com/gocroot/helper/authtoken"
+	"github.com/gocroot/helper/gcallapi"
+	"github.com/gocroot/helper/identity"
+	"github.com/gocroot/helper/session"
+	"github.com/gocroot/model"
+	"github.com/whatsauth/itmodel"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+const (
+	verifyEmailTTL    = 24 * time.Hour
+	resetPasswordTTL  = 30 * time.Minute
+	minPasswordLength = 8
+)
+
+// validatePassword aturan minimal password akun form
+func validatePassword(password string) error {
+	if utf8.RuneCountInString(password) < minPasswordLength {
+		return errors.New("password minimal 8 karakter")
+	}
+	return nil
+}
+
+// issueAuthToken membuat token sekali pakai lalu mengirimnya lewat email atau WhatsApp.
+// Verifikasi email selalu lewat email, reset password lewat email jika diminta dengan email atau user tidak punya nomor.
+func issueAuthToken(docuser model.Userdomyikado, purpose string, byEmail bool) (channel string, err error) {
+	channel = "whatsapp"
+	if docuser.Email != "" && (purpose == authtoken.VerifyEmail || byEmail || docuser.PhoneNumber == "") {
+		channel = "email"
+	}
+	token, err := randomToken()
+	if err != nil {
+		return
+	}
+	ttl := resetPasswordTTL
+	if purpose == authtoken.VerifyEmail {
+		ttl = verifyEmailTTL
+	}
+	// token lama dengan tujuan yang sama tidak berlaku lagi
+	_, err = config.Mongoconn.Collection(authtoken.Collection).DeleteMany(context.TODO(),
+		bson.M{"user_id": docuser.ID, "purpose": purpose, "used_at": bson.M{"$exists": false}})
+	if err != nil {
+		return
+	}
+	now := time.Now()
+	doc := authtoken.Token{
+		ID:        session.HashToken(token),
+		Purpose:   purpose,
+		UserID:    docuser.ID,
+		Email:     strings.ToLower(docuser.Email),
+		Channel:   channel,
+		CreatedAt: now,
+		ExpiresAt: now.Add(ttl),
+	}
+	if _, err = atdb.InsertOneDoc(config.Mongoconn, authtoken.Collection, doc); err != nil {
+		return
+	}
+	subject, body := authTokenMessage(docuser, purpose, token, ttl)
+	if channel == "email" {
+		err = gcallapi.SendEmail(config.Mongoconn, docuser.Email, subject, body)
+	} else {
+		dt := &itmodel.TextMessage{To: docuser.PhoneNumber, IsGroup: false, Messages: "*" + subject + "*\n\n" + body}
+		_, _, err = atapi.PostStructWithToken[itmodel.Response]("Token", config.WAAPIToken, dt, config.WAAPIMessage)
+	}
+	if err != nil {
+		// token yang tidak terkirim langsung dihapus
+		atdb.DeleteOneDoc(config.Mongoconn, authtoken.Collection, bson.M{"_id": doc.ID})
+	}
+	return
+}
+
+func authTokenMessage(docuser model.Userdomyikado, purpose, token string, ttl time.Duration) (subject, body string) {
+	if purpose == authtoken.VerifyEmail {
+		return "Verifikasi email akun", "Halo " + docuser.Name + ",\n\nMasukkan token berikut untuk memverifikasi email Anda:\n" + token +
+			"\n\nToken berlaku " + ttl.String() + " dan hanya bisa dipakai sekali."
+	}
+	return "Reset password akun", "Halo " + docuser.Name + ",\n\nMasukkan token berikut untuk membuat password baru:\n" + token +
+		"\n\nToken berlaku " + ttl.String() + " dan hanya bisa dipakai sekali. Abaikan pesan ini jika Anda tidak meminta reset password."
+}
+
+// PostVerifyEmail menandai email akun sudah diverifikasi
+func PostVerifyEmail(respw http.ResponseWriter, req *http.Request) {
+	var respn model.Response
+	var body model.TokenRequest
+	if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+		at.WriteError(respw, at.NewError(at.CodeInvalidBody, "Body tidak valid", err.Error()))
+		return
+	}
+	token, err := authtoken.Consume(config.Mongoconn, body.Token, authtoken.VerifyEmail, time.Now())
+	if err != nil {
+		at.WriteError(respw, at.NewError(at.CodeInvalidToken, "Token tidak valid", "Token salah, sudah dipakai atau kedaluwarsa"))
+		return
+	}
+	docuser, err := atdb.GetOneDoc[model.Userdomyikado](config.Mongoconn, "user", bson.M{"_id": token.UserID})
+	if err != nil || !strings.EqualFold(docuser.Email, token.Email) {
+		at.WriteError(respw, at.NewError(at.CodeExpired, "Token tidak berlaku", "Email akun sudah berubah sejak token dikirim"))
+		return
+	}
+	if _, err = atdb.UpdateOneDoc(config.Mongoconn, "user", bson.M{"_id": docuser.ID}, bson.M{"emailverification": model.EmailVerified}); err != nil {
+		at.WriteError(respw, at.NewError(at.CodeInternal, "Gagal menyimpan verifikasi", err.Error()))
+		return
+	}
+	respn.Status = "Success"
+	respn.Response = "Email " + docuser.Email + " sudah diverifikasi"
+	at.WriteJSON(respw, http.StatusOK, respn)
+}
+
+// PostResendEmailVerification mengirim ulang token verifikasi untuk akun yang emailnya belum diverifikasi
+func PostResendEmailVerification(respw http.ResponseWriter, req *http.Request) {
+	docuser, _, ok := authTokenUser(respw, req)
+	if !ok {
+		return
+	}
+	eligible := !docuser.ID.IsZero() && docuser.EmailVerification == model.EmailPending
+	authtoken.Respond(respw, eligible, func() error {
+		_, err := issueAuthToken(docuser, authtoken.VerifyEmail, true)
+		return err
+	})
+}
+
+// PostForgotPassword mengirim token reset password ke email atau WhatsApp pemilik akun form
+func PostForgotPassword(respw http.ResponseWriter, req *http.Request) {
+	docuser, byEmail, ok := authTokenUser(respw, req)
+	if !ok {
+		return
+	}
+	// reset hanya untuk akun yang bisa login dengan email dan password
+	eligible := !docuser.ID.IsZero() && docuser.Email != ""
+	authtoken.Respond(respw, eligible, func() error {
+		_, err := issueAuthToken(docuser, authtoken.ResetPassword, byEmail)
+		return err
+	})
+}
+
+// PostResetPassword mengganti password dengan token reset, semua sesi lama dicabut
+func PostResetPassword(respw http.ResponseWriter, req *http.Request) {
+	var respn model.Response
+	var body model.TokenRequest
+	err := json.NewDecoder(req.Body).Decode(&body)
+	if err == nil {
+		err = validatePassword(body.Password)
+	}
+	if err != nil {
+		at.WriteError(respw, at.NewError(at.CodeInvalidBody, "Body tidak valid", err.Error()))
+		return
+	}
+	token, err := authtoken.Consume(config.Mongoconn, body.Token, authtoken.ResetPassword, time.Now())
+	if err != nil {
+		at.WriteError(respw, at.NewError(at.CodeInvalidToken, "Token tidak valid", "Token salah, sudah dipakai atau kedaluwarsa"))
+		return
+	}
+	hashed, err := auth.HashPassword(body.Password)
+	if err != nil {
+		at.WriteError(respw, at.NewError(at.CodeInternal, "Gagal hash password", err.Error()))
+		return
+	}
+	update := bson.M{"password": hashed}
+	// token yang diterima lewat email sekaligus membuktikan email tersebut milik user
+	if token.Channel == "email" {
+		update["emailverification"] = model.EmailVerified
+	}
+	res, err := config.Mongoconn.Collection("user").UpdateOne(context.TODO(), bson.M{"_id": token.UserID}, bson.M{"$set": update})
+	if err == nil && res.MatchedCount == 0 {
+		err = errors.New("akun sudah tidak ada")
+	}
+	if err != nil {
+		at.WriteError(respw, at.NewError(at.CodeInternal, "Gagal menyimpan password", err.Error()))
+		return
+	}
+	docuser, err := atdb.GetOneDoc[model.Userdomyikado](config.Mongoconn, "user", bson.M{"_id": token.UserID})
+	if err == nil && docuser.PhoneNumber != "" {
+		_, err = session.RevokeAll(config.Mongoconn, docuser.PhoneNumber, "reset password", time.Now())
+	}
+	if err != nil {
+		log.Println("gagal mencabut sesi setelah reset password: " + err.Error())
+	}
+	respn.Status = "Success"
+	respn.Response = "Password sudah diganti, silakan login ulang"
+	at.WriteJSON(respw, http.StatusOK, respn)
+}
+
+// authTokenUser membaca email atau nomor dari body lalu mencari user-nya.
+// User yang tidak ditemukan dikembalikan kosong dengan ok true supaya response tetap sama.
+func authTokenUser(respw http.ResponseWriter, req *http.Request) (docuser model.Userdomyikado, byEmail, ok bool) {
+	var body model.EmailRequest
+	err := json.NewDecoder(req.Body).Decode(&body)
+	typ, subject := identity.Email, body.Email
+	if body.Email == "" {
+		typ, subject = identity.Phone, body.PhoneNumber
+	}
+	if err == nil {
+		subject, err = identity.Normalize(typ, subject)
+	}
+	if err != nil {
+		at.WriteError(respw, at.NewError(at.CodeInvalidBody, "Body tidak valid", "isi email atau phonenumber yang valid"))
+		return
+	}
+	if !limitAuth(respw, req, recoveryRule, subject) {
+		return
+	}
+	docuser, _ = userByIdentity(typ, subject)
+	return docuser, typ == identity.Email, true
+}
@@ -0,0 +1,224 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gocroot/config"
+	"github.com/gocroot/helper/at"
+	"github.com/gocroot/helper/atdb"
+	"github.com/gocroot/helper/dokped"
+	"github.com/gocroot/helper/payment"
+	"github.com/gocroot/helper/router"
+	"github.com/gocroot/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// PaymentRequest body untuk membuat tagihan payment gateway
+type PaymentRequest struct {
+	Provider string `json:"provider"`
+	Method   string `json:"method"`
+	Bank     string `json:"bank,omitempty"`
+}
+
+// PaymentEvent catatan callback yang sudah diproses, _id provider:event_id supaya callback berulang terdeteksi
+type PaymentEvent struct {
+	ID        string           `bson:"_id"`
+	Provider  string           `bson:"provider"`
+	Callback  payment.Callback `bson:"callback"`
+	Result    string           `bson:"result"`
+	CreatedAt time.Time        `bson:"created_at"`
+}
+
+// getPaymentProvider provider yang aktif sesuai secret saat ini, dibuat ulang supaya perubahan secret langsung terpakai
+func getPaymentProvider(name string) (provider payment.Provider, ok bool) {
+	var providers []payment.Provider
+	if key := config.MidtransServerKey(); key != "" {
+		providers = append(providers, &payment.Midtrans{BaseURL: config.MidtransAPI, ServerKey: key})
+	}
+	if secret := config.PaymentFakeSecret(); secret != "" {
+		providers = append(providers, &payment.Fake{Secret: secret})
+	}
+	for _, p := range providers {
+		if p.Name() == name {
+			return p, true
+		}
+	}
+	return nil, false
+}
+
+// PostCheckoutPayment pembeli membuat tagihan VA/QRIS/invoice untuk checkout pending miliknya
+func PostCheckoutPayment(respw http.ResponseWriter, req *http.Request) {
+	payload, err := decodeLoginToken(req)
+	if err != nil {
+		writeTokenError(respw, err)
+		return
+	}
+	objectId, err := primitive.ObjectIDFromHex(router.Param(req, "checkoutid"))
+	if err != nil {
+		at.WriteError(respw, at.NewError(at.CodeBadRequest, "ObjectID Tidak Valid", err.Error()))
+		return
+	}
+	var payreq PaymentRequest
+	if err = json.NewDecoder(req.Body).Decode(&payreq); err != nil {
+		at.WriteError(respw, at.NewError(at.CodeInvalidBody, "Body tidak valid", err.Error()))
+		return
+	}
+	provider, ok := getPaymentProvider(payreq.Provider)
+	if !ok {
+		at.WriteError(respw, at.NewError(at.CodeBadRequest, "Provider pembayaran tidak tersedia", "provider "+payreq.Provider+" tidak aktif"))
+		return
+	}
+	checkout, err := atdb.GetOneDoc[model.Checkout](config.Mongoconn, "checkout", bson.M{"_id": objectId, "phonenumber": payload.Id})
+	if err != nil {
+		at.WriteError(respw, at.NewError(at.CodeNotFound, "Data checkout tidak di temukan", err.Error()))
+		return
+	}
+	// checkout lama tanpa items harganya dari client, hanya checkout dari CheckoutCart yang boleh ditagih
+	if len(checkout.Items) == 0 {
+		at.WriteError(respw, at.NewError(at.CodeConflict, "Checkout tidak bisa dibayar", "Checkout tidak dibuat dari cart"))
+		return
+	}
+	if checkout.Status != model.CheckoutPending || (!checkout.ReservedUntil.IsZero() && time.Now().After(checkout.ReservedUntil)) {
+		at.WriteError(respw, at.NewError(at.CodeConflict, "Checkout tidak bisa dibayar", "Status checkout "+checkout.Status))
+		return
+	}
+	// tagihan yang masih pending dipakai lagi supaya tidak ada dua tagihan untuk satu checkout
+	if checkout.Payment != nil && checkout.Payment.Status == payment.StatusPending && checkout.Payment.Provider == provider.Name() {
+		at.WriteJSON(respw, http.StatusOK, checkout.Payment)
+		return
+	}
+	invoice, err := provider.CreateInvoice(req.Context(), payment.InvoiceRequest{
+		OrderID:       checkout.ID.Hex(),
+		Amount:        int64(checkout.TotalPrice),
+		Method:        payreq.Method,
+		Bank:          payreq.Bank,
+		CustomerPhone: checkout.PhoneNumber,
+		Description:   "Pembayaran " + checkout.NamaLapak,
+		ExpiresAt:     checkout.ReservedUntil,
+	})
+	if err != nil {
+		at.WriteError(respw, at.NewError(at.CodeUpstream, "Tagihan gagal dibuat", err.Error()))
+		return
+	}
+	update := bson.M{"$set": bson.M{"payment": invoice, "paymentmethod": provider.Name() + ":" + invoice.Method}}
+	_, err = config.Mongoconn.Collection("checkout").UpdateOne(context.TODO(), bson.M{"_id": checkout.ID, "status": model.CheckoutPending}, update)
+	if err != nil {
+		at.WriteError(respw, at.NewError(at.CodeInternal, "Gagal memperbarui database", err.Error()))
+		return
+	}
+	at.WriteJSON(respw, http.StatusOK, invoice)
+}
+
+// PostPaymentCallback menerima callback dari payment gateway. Signature diverifikasi provider dan
+// checkout hanya ditandai lunas jika masih pending sehingga callback berulang tidak diproses dua kali
+func PostPaymentCallback(respw http.ResponseWriter, req *http.Request) {
+	var respn model.Response
+	provider, ok := getPaymentProvider(router.Param(req, "provider"))
+	if !ok {
+		at.WriteError(respw, at.NewError(at.CodeNotFound, "Provider pembayaran tidak tersedia", ""))
+		return
+	}
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		at.WriteError(respw, at.NewError(at.CodeInvalidBody, "Body tidak bisa dibaca", err.Error()))
+		return
+	}
+	cb, err := provider.ParseCallback(req.Header, body)
+	if err == payment.ErrInvalidSignature {
+		at.WriteError(respw, at.NewError(at.CodeUnauthorized, "Signature tidak valid", err.Error()))
+		return
+	}
+	if err != nil {
+		at.WriteError(respw, at.NewError(at.CodeBadRequest, "Callback tidak valid", err.Error()))
+		return
+	}
+	eventID := provider.Name() + ":" + cb.EventID
+	if _, err = atdb.GetOneDoc[PaymentEvent](config.Mongoconn, "paymentevent", bson.M{"_id": eventID}); err == nil {
+		respn.Status = "Success"
+		respn.Response = "Callback sudah pernah diproses"
+		at.WriteJSON(respw, http.StatusOK, respn)
+		return
+	}
+	result, err := handlePaymentCallback(provider.Name(), cb)
+	if err != nil {
+		// gagal sementara, provider akan mengirim ulang callback
+		at.WriteError(respw, at.NewError(at.CodeInternal, "Callback gagal diproses", err.Error()))
+		return
+	}
+	event := PaymentEvent{ID: eventID, Provider: provider.Name(), Callback: cb, Result: result, CreatedAt: time.Now()}
+	if _, err = atdb.InsertOneDoc(config.Mongoconn, "paymentevent", event); err != nil && !mongo.IsDuplicateKeyError(err) {
+		log.Println("gagal mencatat callback " + eventID + ": " + err.Error())
+	}
+	respn.Status = "Success"
+	respn.Response = result
+	at.WriteJSON(respw, http.StatusOK, respn)
+}
+
+// handlePaymentCallback memperbarui checkout sesuai status callback dan mengembalikan ringkasan hasilnya
+func handlePaymentCallback(providerName string, cb payment.Callback) (result string, err error) {
+	objectId, err := primitive.ObjectIDFromHex(cb.OrderID)
+	if err != nil {
+		return "order_id bukan checkout", nil
+	}
+	checkout, err := atdb.GetOneDoc[model.Checkout](config.Mongoconn, "checkout", bson.M{"_id": objectId})
+	if err == mongo.ErrNoDocuments {
+		return "checkout tidak ditemukan", nil
+	}
+	if err != nil {
+		return
+	}
+	col := config.Mongoconn.Collection("checkout")
+	if cb.Status != payment.StatusPaid {
+		_, err = col.UpdateOne(context.TODO(), bson.M{"_id": checkout.ID, "status": model.CheckoutPending}, bson.M{"$set": bson.M{"payment.status": cb.Status}})
+		return "status pembayaran " + cb.Status, err
+	}
+	if cb.Amount != int64(checkout.TotalPrice) {
+		log.Println("nominal callback " + cb.OrderID + " tidak sama dengan total checkout")
+		return "nominal pembayaran tidak sesuai", nil
+	}
+
+	prevStatus := checkout.Status
+	checkout.Status = model.CheckoutPaid
+	checkout.ReceiptNumber = dokped.GenerateReceiptNumber()
+	update := bson.M{"$set": bson.M{
+		"status":         checkout.Status,
+		"receiptnumber":  checkout.ReceiptNumber,
+		"payment.status": payment.StatusPaid,
+	}}
+	res, err := col.UpdateOne(context.TODO(), bson.M{"_id": checkout.ID, "status": model.CheckoutPending}, update)
+	if err != nil {
+		return
+	}
+	if res.ModifiedCount == 0 {
+		// sudah lunas lewat callback lain, atau sudah kedaluwarsa dan stoknya dilepas
+		if prevStatus != model.CheckoutPaid {
+			log.Println("pembayaran masuk untuk checkout " + cb.OrderID + " yang tidak pending, perlu dicek manual")
+		}
+		return "checkout tidak pending", nil
+	}
+
+	// konfirmasi approved dibuat otomatis supaya kwitansi bisa diambil seperti pembayaran manual
+	conf := model.Confirmation{
+		CheckoutID:  checkout.ID,
+		PhoneNumber: checkout.PhoneNumber,
+		Status:      model.ConfirmationApproved,
+		ApprovedBy:  providerName,
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+	}
+	if conf.ID, err = atdb.InsertOneDoc(config.Mongoconn, "confirmation", conf); err != nil {
+		log.Println("gagal menyimpan konfirmasi " + cb.OrderID + ": " + err.Error())
+		return "checkout lunas", nil
+	}
+	if err = SendReceiptWA(checkout, conf); err != nil {
+		log.Println("kwitansi " + checkout.ReceiptNumber + " gagal dikirim: " + err.Error())
+	}
+	return "checkout lunas", nil
+}
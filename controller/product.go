@@ -1,61 +1,115 @@
 package controller
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"time"
 
 	"github.com/gocroot/config"
 	"github.com/gocroot/helper/at"
 	"github.com/gocroot/helper/atdb"
-	"github.com/gocroot/helper/watoken"
+	"github.com/gocroot/helper/katalog"
+	"github.com/gocroot/helper/productevent"
+	"github.com/gocroot/helper/rbac"
+	"github.com/gocroot/helper/router"
 	"github.com/gocroot/model"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-// Handler to get all products
+// Handler to get all products, mendukung pencarian q, filter category, lapak, min_price, max_price, discount,
+// sort dan cursor pagination. Tanpa query sama sekali response tetap array produk dengan field id seperti sebelumnya,
+// supaya client lama tidak rusak.
 func GetAllProducts(respw http.ResponseWriter, r *http.Request) {
-	data, err := atdb.GetAllDoc[[]model.Product](config.Mongoconn, "products", primitive.M{})
+	if len(r.URL.Query()) == 0 {
+		getAllProductsLegacy(respw)
+		return
+	}
+	query, err := katalog.ParseQuery(r.URL.Query())
 	if err != nil {
-		var respn model.Response
-		respn.Status = "Error: Data kategori tidak ditemukan"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusNotFound, respn)
+		at.WriteError(respw, at.NewError(at.CodeBadRequest, "Parameter pencarian tidak valid", err.Error()))
+		return
+	}
+	ctx := context.TODO()
+	cur, err := config.Mongoconn.Collection(model.ProductCollection).Aggregate(ctx, katalog.Pipeline(query))
+	if err != nil {
+		at.WriteError(respw, at.NewError(at.CodeInternal, "Data product tidak bisa diambil", err.Error()))
+		return
+	}
+	defer cur.Close(ctx)
+	data := []model.Product{}
+	if err = cur.All(ctx, &data); err != nil {
+		at.WriteError(respw, at.NewError(at.CodeInternal, "Data product tidak bisa dibaca", err.Error()))
 		return
 	}
 
+	// pipeline mengambil satu produk lebih, jika ada berarti masih ada halaman berikutnya
+	page := katalog.Page[model.Product]{Data: data}
+	if int64(len(data)) > query.Limit {
+		page.Data = data[:query.Limit]
+		last := page.Data[len(page.Data)-1]
+		page.NextCursor = katalog.NextCursor(query, katalog.Cursor{
+			ID:    last.ID,
+			Price: model.UnitPrice(last),
+			Name:  last.Name,
+		})
+	}
+	at.WriteJSON(respw, http.StatusOK, page)
+}
+
+// getAllProductsLegacy response lama GetAllProducts: semua produk tanpa pagination dalam array
+func getAllProductsLegacy(respw http.ResponseWriter) {
+	data, err := atdb.GetAllDoc[[]model.Product](config.Mongoconn, model.ProductCollection, bson.M{})
+	if err != nil {
+		at.WriteError(respw, at.NewError(at.CodeInternal, "Data product tidak bisa diambil", err.Error()))
+		return
+	}
 	if len(data) == 0 {
-		var respn model.Response
-		respn.Status = "Error: Data product kosong"
-		at.WriteJSON(respw, http.StatusNotFound, respn)
-		return
-	}
-
-	var categories []map[string]interface{}
-	for _, category := range data {
-		categories = append(categories, map[string]interface{}{
-			"id":             category.ID,
-			"name":           category.Name,
-			"description":    category.Description,
-			"original_price": category.OriginalPrice,
-			"discount_price": category.DiscountPrice,
-			"image":          category.Image,
-			"created_at":     category.CreatedAt,
-			"updated_at":     category.UpdatedAt,
+		at.WriteError(respw, at.NewError(at.CodeNotFound, "Data product kosong", ""))
+		return
+	}
+	var products []map[string]interface{}
+	for _, product := range data {
+		products = append(products, map[string]interface{}{
+			"id":             product.ID,
+			"name":           product.Name,
+			"description":    product.Description,
+			"original_price": product.OriginalPrice,
+			"discount_price": product.DiscountPrice,
+			"image":          product.Image,
+			"stock":          product.Stock,
+			"created_at":     product.CreatedAt,
+			"updated_at":     product.UpdatedAt,
 		})
 	}
+	at.WriteJSON(respw, http.StatusOK, products)
+}
 
+// GetProductCategories mengembalikan daftar kategori produk, bisa difilter per lapak
+func GetProductCategories(respw http.ResponseWriter, r *http.Request) {
+	filter := bson.M{"category": bson.M{"$nin": bson.A{"", nil}}}
+	if lapak := r.URL.Query().Get("lapak"); lapak != "" {
+		filter["namalapak"] = lapak
+	}
+	categories, err := atdb.GetAllDistinct[string](config.Mongoconn, filter, "category", model.ProductCollection)
+	if err != nil {
+		at.WriteError(respw, at.NewError(at.CodeNotFound, "Data kategori tidak ditemukan", err.Error()))
+		return
+	}
 	at.WriteJSON(respw, http.StatusOK, categories)
 }
 
 // Handler to get a product by ID
 func GetProductByID(w http.ResponseWriter, r *http.Request) {
-	// Get the product ID from URL params
-	id := r.URL.Query().Get("id")
+	// Get the product ID from path param :id
+	id := router.Param(r, "id")
 	objectID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
-		http.Error(w, "Invalid product ID", http.StatusBadRequest)
+		at.WriteError(w, at.NewError(at.CodeBadRequest, "ID produk tidak valid", err.Error()))
 		return
 	}
 
@@ -64,9 +118,9 @@ func GetProductByID(w http.ResponseWriter, r *http.Request) {
 	filter := bson.M{"_id": objectID}
 
 	// Perbaiki pemanggilan GetOneDoc tanpa mengirimkan pointer
-	product, err = atdb.GetOneDoc[model.Product](config.Mongoconn, "product", filter)
+	product, err = atdb.GetOneDoc[model.Product](config.Mongoconn, model.ProductCollection, filter)
 	if err != nil {
-		http.Error(w, "Product not found", http.StatusNotFound)
+		at.WriteError(w, at.NewError(at.CodeNotFound, "Produk tidak ditemukan", err.Error()))
 		return
 	}
 
@@ -77,35 +131,35 @@ func GetProductByID(w http.ResponseWriter, r *http.Request) {
 
 // Handler to create a new product
 func CreateProduct(w http.ResponseWriter, r *http.Request) {
-	_, err := watoken.Decode(config.PublicKeyWhatsAuth, at.GetLoginFromHeader(r))
-	if err != nil {
-		_, err = watoken.Decode(config.PUBLICKEY, at.GetLoginFromHeader(r))
-		if err != nil {
-			var respn model.Response
-			respn.Status = "Error: Token Tidak Valid"
-			respn.Response = err.Error()
-			at.WriteJSON(w, http.StatusForbidden, respn)
-			return
-		}
-	}
 	var product model.Product
 
 	// Decode the request body into the Product struct
 	decoder := json.NewDecoder(r.Body)
-	err = decoder.Decode(&product)
+	err := decoder.Decode(&product)
 	if err != nil {
-		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		at.WriteError(w, at.NewError(at.CodeInvalidBody, "", err.Error()))
+		return
+	}
+
+	// Produk harus milik lapak yang owner-nya adalah user dari token
+	if err = checkLapakAccess(r, rbac.ProductWrite, product.NamaLapak); err != nil {
+		writeForbidden(w, err)
 		return
 	}
+	// Galeri hanya diisi lewat upload media produk
+	product.Images = nil
+	// Rating dihitung dari ulasan pembeli
+	product.RatingAvg = 0
+	product.RatingCount = 0
 
 	// Set the created and updated timestamps
 	product.CreatedAt = primitive.NewDateTimeFromTime(time.Now())
 	product.UpdatedAt = primitive.NewDateTimeFromTime(time.Now())
 
 	// Insert the product into the database
-	_, err = atdb.InsertOneDoc(config.Mongoconn, "product", product)
+	_, err = atdb.InsertOneDoc(config.Mongoconn, model.ProductCollection, product)
 	if err != nil {
-		http.Error(w, "Error inserting product", http.StatusInternalServerError)
+		at.WriteError(w, at.NewError(at.CodeInternal, "Gagal menyimpan produk", err.Error()))
 		return
 	}
 
@@ -115,45 +169,94 @@ func CreateProduct(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(response)
 }
 
+// productUpdate body UpdateProduct. Stok tidak ditimpa karena bisa berubah oleh checkout di antara baca dan tulis,
+// perubahan stok dikirim sebagai stock_delta dan diterapkan dengan $inc.
+type productUpdate struct {
+	model.Product
+	StockDelta int `json:"stock_delta"`
+}
+
 // Handler to update an existing product
 func UpdateProduct(w http.ResponseWriter, r *http.Request) {
-	// Get the product ID from URL params
-	id := r.URL.Query().Get("id")
+	// Get the product ID from path param :id
+	id := router.Param(r, "id")
 	objectID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
-		http.Error(w, "Invalid product ID", http.StatusBadRequest)
+		at.WriteError(w, at.NewError(at.CodeBadRequest, "ID produk tidak valid", err.Error()))
 		return
 	}
 
 	// Decode the request body into the Product struct
-	var product model.Product
+	var body productUpdate
 	decoder := json.NewDecoder(r.Body)
-	err = decoder.Decode(&product)
+	err = decoder.Decode(&body)
 	if err != nil {
-		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		at.WriteError(w, at.NewError(at.CodeInvalidBody, "", err.Error()))
 		return
 	}
+	product := body.Product
 
 	// Set the updated timestamp
 	product.UpdatedAt = primitive.NewDateTimeFromTime(time.Now())
 
 	// Update the product in the database
 	filter := bson.M{"_id": objectID}
+	existing, err := atdb.GetOneDoc[model.Product](config.Mongoconn, model.ProductCollection, filter)
+	if err != nil {
+		at.WriteError(w, at.NewError(at.CodeNotFound, "Produk tidak ditemukan", err.Error()))
+		return
+	}
+	if err = checkLapakAccess(r, rbac.ProductWrite, existing.NamaLapak); err != nil {
+		writeForbidden(w, err)
+		return
+	}
+	// Jika produk punya galeri, gambar utama diatur lewat media produk
+	if len(existing.Images) > 0 {
+		product.Image = existing.Image
+	}
 	updateFields := bson.M{
 		"name":           product.Name,
 		"description":    product.Description,
 		"discount_price": product.DiscountPrice,
 		"original_price": product.OriginalPrice,
 		"image":          product.Image,
+		"category":       product.Category,
 		"updated_at":     product.UpdatedAt,
 	}
+	update := bson.M{"$set": updateFields}
+	stockFilter := bson.M{"_id": objectID}
+	if body.StockDelta != 0 {
+		update["$inc"] = bson.M{"stock": body.StockDelta}
+		if body.StockDelta < 0 {
+			// stok tidak boleh minus
+			stockFilter["stock"] = bson.M{"$gte": -body.StockDelta}
+		}
+	}
 
-	_, err = atdb.UpdateOneDoc(config.Mongoconn, "product", filter, updateFields)
+	var updated model.Product
+	err = config.Mongoconn.Collection(model.ProductCollection).FindOneAndUpdate(context.TODO(), stockFilter, update,
+		options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&updated)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		at.WriteError(w, at.NewError(at.CodeConflict, "Stok tidak mencukupi", "stok sekarang lebih kecil dari pengurangan stock_delta"))
+		return
+	}
 	if err != nil {
-		http.Error(w, "Error updating product", http.StatusInternalServerError)
+		at.WriteError(w, at.NewError(at.CodeInternal, "Gagal memperbarui produk", err.Error()))
 		return
 	}
 
+	// Event diskon baru atau stok kembali untuk notifikasi wishlist
+	change, changed := productevent.Diff(existing.ID, productSnapshot(existing), productevent.Snapshot{
+		Name:          product.Name,
+		NamaLapak:     existing.NamaLapak,
+		OriginalPrice: product.OriginalPrice,
+		DiscountPrice: product.DiscountPrice,
+		Stock:         updated.Stock,
+	})
+	if changed {
+		emitProductChange(change)
+	}
+
 	// Respond with a success message
 	w.Header().Set("Content-Type", "application/json")
 	response := map[string]string{"status": "success", "message": "Product updated"}
@@ -162,24 +265,45 @@ func UpdateProduct(w http.ResponseWriter, r *http.Request) {
 
 // Handler to delete a product
 func DeleteProduct(w http.ResponseWriter, r *http.Request) {
-	// Get the product ID from URL params
-	id := r.URL.Query().Get("id")
+	// Get the product ID from path param :id
+	id := router.Param(r, "id")
 	objectID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
-		http.Error(w, "Invalid product ID", http.StatusBadRequest)
+		at.WriteError(w, at.NewError(at.CodeBadRequest, "ID produk tidak valid", err.Error()))
 		return
 	}
 
 	// Delete the product from the database
 	filter := bson.M{"_id": objectID}
-	_, err = atdb.DeleteOneDoc(config.Mongoconn, "product", filter)
+	product, err := atdb.GetOneDoc[model.Product](config.Mongoconn, model.ProductCollection, filter)
+	if err != nil {
+		at.WriteError(w, at.NewError(at.CodeNotFound, "Produk tidak ditemukan", err.Error()))
+		return
+	}
+	if err = checkLapakAccess(r, rbac.ProductWrite, product.NamaLapak); err != nil {
+		writeForbidden(w, err)
+		return
+	}
+	_, err = atdb.DeleteOneDoc(config.Mongoconn, model.ProductCollection, filter)
 	if err != nil {
-		http.Error(w, "Error deleting product", http.StatusInternalServerError)
+		at.WriteError(w, at.NewError(at.CodeInternal, "Gagal menghapus produk", err.Error()))
 		return
 	}
+	// Hapus juga gambar galeri dari repo
+	deleteProductImages(product)
 
 	// Respond with a success message
 	w.Header().Set("Content-Type", "application/json")
 	response := map[string]string{"status": "success", "message": "Product deleted"}
 	json.NewEncoder(w).Encode(response)
 }
+
+func productSnapshot(product model.Product) productevent.Snapshot {
+	return productevent.Snapshot{
+		Name:          product.Name,
+		NamaLapak:     product.NamaLapak,
+		OriginalPrice: product.OriginalPrice,
+		DiscountPrice: product.DiscountPrice,
+		Stock:         product.Stock,
+	}
+}
@@ -0,0 +1,297 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/gocroot/config"
+	"github.com/gocroot/helper/at"
+	"github.com/gocroot/helper/atdb"
+	"github.com/gocroot/helper/ghupload"
+	"github.com/gocroot/helper/rbac"
+	"github.com/gocroot/helper/router"
+	"github.com/gocroot/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// batas ukuran upload gambar produk sekaligus
+const maxProductMediaSize = 10 << 20
+
+// productImageTypes jenis gambar produk yang boleh diupload beserta ekstensi file di repo
+var productImageTypes = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/webp": ".webp",
+}
+
+// pathSegment nama yang aman dipakai sebagai satu segmen path di repo, selain huruf kecil, angka, - dan _ diganti -
+func pathSegment(name string) string {
+	segment := strings.Trim(strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '-', r == '_':
+			return r
+		}
+		return '-'
+	}, strings.ToLower(name)), "-")
+	if segment == "" {
+		return "lapak"
+	}
+	return segment
+}
+
+// MediaRequest body untuk mengatur urutan galeri dan gambar utama
+type MediaRequest struct {
+	Order   []string `json:"order,omitempty"`
+	Primary string   `json:"primary,omitempty"`
+}
+
+// PostProductMedia owner lapak mengupload satu atau beberapa gambar produk (field multipart "images")
+func PostProductMedia(w http.ResponseWriter, r *http.Request) {
+	product, ok := getOwnedProduct(w, r)
+	if !ok {
+		return
+	}
+	if err := r.ParseMultipartForm(maxProductMediaSize); err != nil {
+		at.WriteError(w, at.NewError(at.CodeInvalidBody, "Form tidak valid", err.Error()))
+		return
+	}
+	files := r.MultipartForm.File["images"]
+	if len(files) == 0 {
+		at.WriteError(w, at.NewError(at.CodeBadRequest, "File tidak ada", "field images kosong"))
+		return
+	}
+
+	GitHubAccessToken := config.GHAccessToken()
+	GitHubAuthorName := config.GHAuthorName()
+	GitHubAuthorEmail := config.GHAuthorEmail()
+	githubOrg := "penerbitbukupedia"
+	githubRepo := "katalog"
+	replace := true
+	// semua file dibaca dan dicek dulu supaya file yang ditolak tidak menyisakan upload setengah jalan
+	type upload struct {
+		name    string
+		content []byte
+		ext     string
+	}
+	var uploads []upload
+	for _, header := range files {
+		file, err := header.Open()
+		if err != nil {
+			at.WriteError(w, at.NewError(at.CodeBadRequest, "File tidak bisa dibuka", err.Error()))
+			return
+		}
+		fileContent, err := io.ReadAll(file)
+		file.Close()
+		if err != nil {
+			at.WriteError(w, at.NewError(at.CodeInternal, "File tidak bisa dibaca", err.Error()))
+			return
+		}
+		// jenis file dicek dari isinya, ekstensi dari nama file client tidak dipakai
+		ext, ok := productImageTypes[http.DetectContentType(fileContent)]
+		if !ok {
+			at.WriteError(w, at.NewError(at.CodeValidation, "File bukan gambar", "hanya gambar jpeg, png atau webp yang boleh diupload").WithInfo(header.Filename))
+			return
+		}
+		uploads = append(uploads, upload{header.Filename, fileContent, ext})
+	}
+	for _, up := range uploads {
+		hashedFileName := ghupload.CalculateHash(up.content)
+		pathFile := pathSegment(product.NamaLapak) + "/product/" + product.ID.Hex() + "/" + hashedFileName + up.ext
+		content, _, err := ghupload.GithubUpload(GitHubAccessToken, GitHubAuthorName, GitHubAuthorEmail, up.content, githubOrg, githubRepo, pathFile, replace)
+		if err != nil {
+			at.WriteError(w, at.NewError(at.CodeUpstream, "File tidak bisa diupload ke github", err.Error()).WithInfo(up.name))
+			return
+		}
+		img := model.ProductImage{
+			ID:   hashedFileName,
+			URL:  "https://raw.githubusercontent.com/" + githubOrg + "/" + githubRepo + "/main/" + *content.Content.Path,
+			Path: *content.Content.Path,
+		}
+		if err = pushProductImage(product.ID, img); err != nil {
+			at.WriteError(w, at.NewError(at.CodeInternal, "Gagal memperbarui database", err.Error()).WithInfo(up.name))
+			return
+		}
+	}
+	product, err := syncProductPrimary(product.ID)
+	if err != nil {
+		at.WriteError(w, at.NewError(at.CodeInternal, "Gagal memperbarui database", err.Error()))
+		return
+	}
+	at.WriteJSON(w, http.StatusOK, product)
+}
+
+// PutProductMedia owner lapak mengubah urutan galeri dan/atau gambar utama
+func PutProductMedia(w http.ResponseWriter, r *http.Request) {
+	product, ok := getOwnedProduct(w, r)
+	if !ok {
+		return
+	}
+	before := append([]model.ProductImage(nil), product.Images...)
+	var mediareq MediaRequest
+	if err := json.NewDecoder(r.Body).Decode(&mediareq); err != nil {
+		at.WriteError(w, at.NewError(at.CodeInvalidBody, "Body tidak valid", err.Error()))
+		return
+	}
+	if len(mediareq.Order) > 0 && !product.ReorderImages(mediareq.Order) {
+		at.WriteError(w, at.NewError(at.CodeBadRequest, "Urutan gambar tidak valid", "order harus berisi semua id gambar produk tepat satu kali"))
+		return
+	}
+	if mediareq.Primary != "" && !product.SetPrimaryImage(mediareq.Primary) {
+		at.WriteError(w, at.NewError(at.CodeNotFound, "Gambar tidak ditemukan", "id gambar "+mediareq.Primary+" tidak ada di galeri produk"))
+		return
+	}
+	saved, err := saveProductImages(product, before)
+	if err == nil && !saved {
+		at.WriteError(w, at.NewError(at.CodeConflict, "Galeri produk berubah", "galeri diubah request lain, muat ulang produk lalu ulangi"))
+		return
+	}
+	if err != nil {
+		at.WriteError(w, at.NewError(at.CodeInternal, "Gagal memperbarui database", err.Error()))
+		return
+	}
+	at.WriteJSON(w, http.StatusOK, product)
+}
+
+// DeleteProductMedia owner lapak menghapus gambar dari galeri dan dari repo github, id gambar dari query image
+func DeleteProductMedia(w http.ResponseWriter, r *http.Request) {
+	product, ok := getOwnedProduct(w, r)
+	if !ok {
+		return
+	}
+	var img model.ProductImage
+	imageID := r.URL.Query().Get("image")
+	for _, existing := range product.Images {
+		if existing.ID == imageID {
+			img = existing
+		}
+	}
+	if img.ID == "" {
+		at.WriteError(w, at.NewError(at.CodeNotFound, "Gambar tidak ditemukan", "id gambar tidak ada di galeri produk"))
+		return
+	}
+	removed, err := pullProductImage(product.ID, imageID)
+	if err == nil && !removed {
+		at.WriteError(w, at.NewError(at.CodeNotFound, "Gambar tidak ditemukan", "id gambar tidak ada di galeri produk"))
+		return
+	}
+	if err == nil {
+		product, err = syncProductPrimary(product.ID)
+	}
+	if err != nil {
+		at.WriteError(w, at.NewError(at.CodeInternal, "Gagal memperbarui database", err.Error()))
+		return
+	}
+	// gambar sudah keluar dari galeri, file yang gagal dihapus di github hanya dicatat
+	err = ghupload.GithubDeleteFile(config.GHAccessToken(), config.GHAuthorName(), config.GHAuthorEmail(), "penerbitbukupedia", "katalog", img.Path)
+	if err != nil {
+		log.Println("gagal menghapus gambar produk " + img.Path + ": " + err.Error())
+	}
+	at.WriteJSON(w, http.StatusOK, product)
+}
+
+// getOwnedProduct mengambil produk dari param url dan memastikan user dari token boleh mengelola produk lapaknya
+func getOwnedProduct(w http.ResponseWriter, r *http.Request) (product model.Product, ok bool) {
+	objectId, err := primitive.ObjectIDFromHex(router.Param(r, "id"))
+	if err != nil {
+		at.WriteError(w, at.NewError(at.CodeBadRequest, "ObjectID Tidak Valid", err.Error()))
+		return
+	}
+	product, err = atdb.GetOneDoc[model.Product](config.Mongoconn, model.ProductCollection, bson.M{"_id": objectId})
+	if err != nil {
+		at.WriteError(w, at.NewError(at.CodeNotFound, "Data produk tidak di temukan", err.Error()))
+		return
+	}
+	if err = checkLapakAccess(r, rbac.ProductWrite, product.NamaLapak); err != nil {
+		writeForbidden(w, err)
+		return
+	}
+	return product, true
+}
+
+// pushProductImage menambahkan gambar di akhir galeri dengan $push supaya upload bersamaan tidak saling menimpa,
+// gambar dengan id yang sama hanya diperbarui url dan path-nya
+func pushProductImage(productID primitive.ObjectID, img model.ProductImage) error {
+	products := config.Mongoconn.Collection(model.ProductCollection)
+	now := primitive.NewDateTimeFromTime(time.Now())
+	img.Primary = false
+	res, err := products.UpdateOne(context.TODO(),
+		bson.M{"_id": productID, "images.id": bson.M{"$ne": img.ID}},
+		bson.M{"$push": bson.M{"images": img}, "$set": bson.M{"updated_at": now}})
+	if err != nil || res.MatchedCount > 0 {
+		return err
+	}
+	_, err = products.UpdateOne(context.TODO(),
+		bson.M{"_id": productID, "images.id": img.ID},
+		bson.M{"$set": bson.M{"images.$.url": img.URL, "images.$.path": img.Path, "updated_at": now}})
+	return err
+}
+
+// pullProductImage menghapus gambar dari galeri dengan $pull
+func pullProductImage(productID primitive.ObjectID, imageID string) (removed bool, err error) {
+	res, err := config.Mongoconn.Collection(model.ProductCollection).UpdateOne(context.TODO(),
+		bson.M{"_id": productID, "images.id": imageID},
+		bson.M{"$pull": bson.M{"images": bson.M{"id": imageID}}, "$set": bson.M{"updated_at": primitive.NewDateTimeFromTime(time.Now())}})
+	if err != nil {
+		return
+	}
+	return res.ModifiedCount > 0, nil
+}
+
+// saveProductImages menyimpan urutan galeri dan gambar utama hanya jika galeri masih sama dengan before,
+// saved false berarti galeri sudah diubah request lain sejak dibaca
+func saveProductImages(product model.Product, before []model.ProductImage) (saved bool, err error) {
+	filter := bson.M{"_id": product.ID, "images": before}
+	if len(before) == 0 {
+		filter["images"] = bson.M{"$in": bson.A{nil, bson.A{}}}
+	}
+	updateFields := bson.M{
+		"image":      product.Image,
+		"images":     product.Images,
+		"updated_at": primitive.NewDateTimeFromTime(time.Now()),
+	}
+	res, err := config.Mongoconn.Collection(model.ProductCollection).UpdateOne(context.TODO(), filter, bson.M{"$set": updateFields})
+	if err != nil {
+		return
+	}
+	return res.MatchedCount > 0, nil
+}
+
+// syncProductPrimary membaca ulang galeri setelah $push atau $pull lalu memastikan gambar utama tetap satu,
+// dibaca ulang jika galeri berubah lagi di antara baca dan tulis
+func syncProductPrimary(productID primitive.ObjectID) (product model.Product, err error) {
+	for i := 0; i < 3; i++ {
+		product, err = atdb.GetOneDoc[model.Product](config.Mongoconn, model.ProductCollection, bson.M{"_id": productID})
+		if err != nil {
+			return
+		}
+		before := append([]model.ProductImage(nil), product.Images...)
+		image := product.Image
+		product.SyncPrimary()
+		if image == product.Image && reflect.DeepEqual(before, product.Images) {
+			return
+		}
+		var saved bool
+		if saved, err = saveProductImages(product, before); err != nil || saved {
+			return
+		}
+	}
+	return product, errors.New("galeri produk terus berubah, gambar utama belum bisa diatur")
+}
+
+// deleteProductImages menghapus semua file galeri produk dari repo github, dipakai saat produk dihapus
+func deleteProductImages(product model.Product) {
+	for _, img := range product.Images {
+		err := ghupload.GithubDeleteFile(config.GHAccessToken(), config.GHAuthorName(), config.GHAuthorEmail(), "penerbitbukupedia", "katalog", img.Path)
+		if err != nil {
+			log.Println("gagal menghapus gambar produk " + img.Path + ": " + err.Error())
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package controller
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestPathSegment(t *testing.T) {
+	cases := map[string]string{
+		"Warung Bu Tini": "warung-bu-tini",
+		"../../etc":      "etc",
+		"lapak_01":       "lapak_01",
+		"":               "lapak",
+		"///":            "lapak",
+	}
+	for in, want := range cases {
+		if got := pathSegment(in); got != want {
+			t.Errorf("pathSegment(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestProductImageTypes(t *testing.T) {
+	cases := []struct {
+		name    string
+		content []byte
+		ext     string
+		ok      bool
+	}{
+		{"png", []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR"), ".png", true},
+		{"jpeg", []byte("\xff\xd8\xff\xe0\x00\x10JFIF\x00"), ".jpg", true},
+		{"webp", []byte("RIFF\x00\x00\x00\x00WEBPVP8 "), ".webp", true},
+		{"html", []byte("<html><script>alert(1)</script></html>"), "", false},
+		{"svg", []byte("<svg xmlns=\"http://www.w3.org/2000/svg\"></svg>"), "", false},
+	}
+	for _, c := range cases {
+		ext, ok := productImageTypes[http.DetectContentType(c.content)]
+		if ok != c.ok || ext != c.ext {
+			t.Errorf("%s: ext = %q, %v", c.name, ext, ok)
+		}
+	}
+}
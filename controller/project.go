@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	"github.com/gocroot/config"
+	"github.com/gocroot/helper/rbac"
 	"github.com/gocroot/model"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 
@@ -17,54 +18,38 @@ import (
 )
 
 func PostKatalogBuku(respw http.ResponseWriter, req *http.Request) {
-	var respn model.Response
-	payload, err := watoken.Decode(config.PublicKeyWhatsAuth, at.GetLoginFromHeader(req))
+	payload, err := config.TokenKeyring.Decode(at.GetLoginFromHeader(req))
 	if err != nil {
-		respn.Status = "Error : Token Tidak Valid"
-		respn.Info = at.GetSecretFromHeader(req)
-		respn.Location = "Decode Token Error"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusForbidden, respn)
+		at.WriteError(respw, at.NewError(at.CodeInvalidToken, "Token Tidak Valid", err.Error()))
 		return
 	}
 	var prj model.Project
 	err = json.NewDecoder(req.Body).Decode(&prj)
 	if err != nil {
-		respn.Status = "Error : Body tidak valid"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, at.NewError(at.CodeInvalidBody, "Body tidak valid", err.Error()))
 		return
 	}
 	//mendapatkan user dari token
-	docuser, err := atdb.GetOneDoc[model.Userdomyikado](config.Mongoconn, "user", primitive.M{"phonenumber": payload.Id})
+	_, err = atdb.GetOneDoc[model.Userdomyikado](config.Mongoconn, "user", primitive.M{"phonenumber": payload.Id})
 	if err != nil {
-		respn.Status = "Error : User tidak berhak"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusForbidden, respn)
+		at.WriteError(respw, at.NewError(at.CodeForbidden, "User tidak berhak", err.Error()))
 		return
 	}
 	//cek apakah user memiliki akses ke project
 	project, err := atdb.GetOneDoc[model.Project](config.Mongoconn, "project", primitive.M{"_id": prj.ID})
 	if err != nil {
-		respn.Status = "Error : Data lapak tidak di temukan"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusNotImplemented, respn)
+		at.WriteError(respw, at.NewError(at.CodeNotFound, "Data lapak tidak di temukan", err.Error()))
 		return
 	}
 	//check apakah dia owner
-	if project.Owner.PhoneNumber != docuser.PhoneNumber {
-		respn.Status = "Error : User bukan owner project tidak berhak"
-		respn.Response = "User bukan owner dari project ini"
-		at.WriteJSON(respw, http.StatusNotImplemented, respn)
+	if err = authorizeResource(req, rbac.ProjectWrite, project.Owner.PhoneNumber); err != nil {
+		writeForbidden(respw, err)
 		return
 	}
 
 	//check cover buku apakah kosong  atau engga
 	if project.CoverBuku == "" {
-		var respn model.Response
-		respn.Status = "Belum ada Cover Buku"
-		respn.Response = "Mohon upload dahulu cover buku anda pada form yang disediakan"
-		at.WriteJSON(respw, http.StatusConflict, respn)
+		at.WriteError(respw, at.NewError(at.CodeConflict, "Belum ada Cover Buku", "Mohon upload dahulu cover buku anda pada form yang disediakan"))
 		return
 	}
 
@@ -91,10 +76,7 @@ func PostKatalogBuku(respw http.ResponseWriter, req *http.Request) {
 
 	bpost, err := gcallapi.PostToBlogger(config.Mongoconn, project.URLKatalog, "3471446342567707906", project.Title, postingan)
 	if err != nil {
-		var respn model.Response
-		respn.Status = "Gagal post ke blogger"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusConflict, respn)
+		at.WriteError(respw, at.NewError(at.CodeUpstream, "Gagal post ke blogger", err.Error()))
 		return
 	}
 	//update data content
@@ -103,41 +85,27 @@ func PostKatalogBuku(respw http.ResponseWriter, req *http.Request) {
 	//update project data
 	_, err = atdb.ReplaceOneDoc(config.Mongoconn, "project", primitive.M{"_id": project.ID}, project)
 	if err != nil {
-		var respn model.Response
-		respn.Status = "Gagal replaceonedoc"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusConflict, respn)
+		at.WriteError(respw, at.NewError(at.CodeInternal, "Gagal replaceonedoc", err.Error()))
 		return
 	}
 	at.WriteJSON(respw, http.StatusOK, project)
 }
 
 func PostDataProject(respw http.ResponseWriter, req *http.Request) {
-	payload, err := watoken.Decode(config.PublicKeyWhatsAuth, at.GetLoginFromHeader(req))
+	payload, err := config.TokenKeyring.Decode(at.GetLoginFromHeader(req))
 	if err != nil {
-		var respn model.Response
-		respn.Status = "Error : Token Tidak Valid"
-		respn.Info = at.GetSecretFromHeader(req)
-		respn.Location = "Decode Token Error"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusForbidden, respn)
+		at.WriteError(respw, at.NewError(at.CodeInvalidToken, "Token Tidak Valid", err.Error()))
 		return
 	}
 	var prj model.Project
 	err = json.NewDecoder(req.Body).Decode(&prj)
 	if err != nil {
-		var respn model.Response
-		respn.Status = "Error : Body tidak valid"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, at.NewError(at.CodeInvalidBody, "Body tidak valid", err.Error()))
 		return
 	}
 	docuser, err := atdb.GetOneDoc[model.Userdomyikado](config.Mongoconn, "user", primitive.M{"phonenumber": payload.Id})
 	if err != nil {
-		var respn model.Response
-		respn.Status = "Error : Data user tidak di temukan"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusNotImplemented, respn)
+		at.WriteError(respw, at.NewError(at.CodeNotFound, "Data user tidak di temukan", err.Error()))
 		return
 	}
 	prj.Owner = docuser
@@ -147,95 +115,50 @@ func PostDataProject(respw http.ResponseWriter, req *http.Request) {
 	if err != nil {
 		idprj, err := atdb.InsertOneDoc(config.Mongoconn, "project", prj)
 		if err != nil {
-			var respn model.Response
-			respn.Status = "Gagal Insert Database"
-			respn.Response = err.Error()
-			at.WriteJSON(respw, http.StatusNotModified, respn)
+			at.WriteError(respw, at.NewError(at.CodeInternal, "Gagal Insert Database", err.Error()))
 			return
 		}
 		prj.ID = idprj
 		at.WriteJSON(respw, http.StatusOK, prj)
 	} else {
-		var respn model.Response
-		respn.Status = "Error : Nama Project sudah ada"
-		respn.Response = existingprj.Name
-		at.WriteJSON(respw, http.StatusConflict, respn)
+		at.WriteError(respw, at.NewError(at.CodeConflict, "Nama Project sudah ada", existingprj.Name))
 		return
 	}
 
 }
 
 func GetDataProject(respw http.ResponseWriter, req *http.Request) {
-	payload, err := watoken.Decode(config.PublicKeyWhatsAuth, at.GetLoginFromHeader(req))
+	payload, err := config.TokenKeyring.Decode(at.GetLoginFromHeader(req))
 	if err != nil {
-		var respn model.Response
-		respn.Status = "Error : Token Tidak Valid"
-		respn.Info = at.GetSecretFromHeader(req)
-		respn.Location = "Decode Token Error"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusForbidden, respn)
+		at.WriteError(respw, at.NewError(at.CodeInvalidToken, "Token Tidak Valid", err.Error()))
 		return
 	}
 	docuser, err := atdb.GetOneDoc[model.Userdomyikado](config.Mongoconn, "user", primitive.M{"phonenumber": payload.Id})
 	if err != nil {
-		var respn model.Response
-		respn.Status = "Error : Data user tidak di temukan"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusNotImplemented, respn)
+		at.WriteError(respw, at.NewError(at.CodeNotFound, "Data user tidak di temukan", err.Error()))
 		return
 	}
 	existingprjs, err := atdb.GetAllDoc[[]model.Project](config.Mongoconn, "project", primitive.M{"owner._id": docuser.ID})
 	if err != nil {
-		var respn model.Response
-		respn.Status = "Error : Data project tidak di temukan"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusNotFound, respn)
+		at.WriteError(respw, at.NewError(at.CodeNotFound, "Data project tidak di temukan", err.Error()))
 		return
 	}
 	if len(existingprjs) == 0 {
-		var respn model.Response
-		respn.Status = "Error : Data project tidak di temukan"
-		respn.Response = "Kakak belum input proyek, silahkan input dulu ya"
-		at.WriteJSON(respw, http.StatusNotFound, respn)
+		at.WriteError(respw, at.NewError(at.CodeNotFound, "Data project tidak di temukan", "Kakak belum input proyek, silahkan input dulu ya"))
 		return
 	}
 	at.WriteJSON(respw, http.StatusOK, existingprjs)
 }
 
-// untuk manager
+// untuk manager, akses dicek di route
 func GetEditorApprovedProject(respw http.ResponseWriter, req *http.Request) {
-	payload, err := watoken.Decode(config.PublicKeyWhatsAuth, at.GetLoginFromHeader(req))
-	if err != nil {
-		var respn model.Response
-		respn.Status = "Error : Token Tidak Valid"
-		respn.Info = at.GetSecretFromHeader(req)
-		respn.Location = "Decode Token Error"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusForbidden, respn)
-		return
-	}
-	//akses khusus manager
-	_, err = atdb.GetOneDoc[model.Userdomyikado](config.Mongoconn, "user", primitive.M{"phonenumber": payload.Id, "ismanager": true})
-	if err != nil {
-		var respn model.Response
-		respn.Status = "Akses dibatasi"
-		respn.Response = "Anda bukan manager bukupedia"
-		at.WriteJSON(respw, http.StatusNotImplemented, respn)
-		return
-	}
 	existingprjs, err := atdb.GetAllDoc[[]model.Project](config.Mongoconn, "project", primitive.M{"isapproved": true})
 	if err != nil {
-		var respn model.Response
-		respn.Status = "Error : Data project yang di approve tidak di temukan"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusNotFound, respn)
+		at.WriteError(respw, at.NewError(at.CodeNotFound, "Data project yang di approve tidak di temukan", err.Error()))
 		return
 	}
 	if len(existingprjs) == 0 {
-		var respn model.Response
-		respn.Status = "Error : Data project yang di approve tidak di temukan"
-		respn.Response = "Kakak belum input proyek, silahkan input dulu ya"
-		at.WriteJSON(respw, http.StatusNotFound, respn)
+		at.WriteError(respw, at.NewError(at.CodeNotFound, "Data project yang di approve tidak di temukan", "Kakak belum input proyek, silahkan input dulu ya"))
 		return
 	}
 	at.WriteJSON(respw, http.StatusOK, existingprjs)
@@ -243,14 +166,9 @@ func GetEditorApprovedProject(respw http.ResponseWriter, req *http.Request) {
 
 func PutMetaDataProject(respw http.ResponseWriter, req *http.Request) {
 	// Decode token from header
-	payload, err := watoken.Decode(config.PublicKeyWhatsAuth, at.GetLoginFromHeader(req))
+	payload, err := config.TokenKeyring.Decode(at.GetLoginFromHeader(req))
 	if err != nil {
-		var respn model.Response
-		respn.Status = "Error: Token Tidak Valid"
-		respn.Info = at.GetSecretFromHeader(req)
-		respn.Location = "Decode Token Error"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusForbidden, respn)
+		at.WriteError(respw, at.NewError(at.CodeInvalidToken, "Token Tidak Valid", err.Error()))
 		return
 	}
 
@@ -258,30 +176,21 @@ func PutMetaDataProject(respw http.ResponseWriter, req *http.Request) {
 	var prj model.Project
 	err = json.NewDecoder(req.Body).Decode(&prj)
 	if err != nil {
-		var respn model.Response
-		respn.Status = "Error: Body tidak valid"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, at.NewError(at.CodeInvalidBody, "Body tidak valid", err.Error()))
 		return
 	}
 
 	// Get user data from the database
 	docuser, err := atdb.GetOneDoc[model.Userdomyikado](config.Mongoconn, "user", primitive.M{"phonenumber": payload.Id})
 	if err != nil {
-		var respn model.Response
-		respn.Status = "Error: Data user tidak ditemukan"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusNotImplemented, respn)
+		at.WriteError(respw, at.NewError(at.CodeNotFound, "Data user tidak ditemukan", err.Error()))
 		return
 	}
 
 	// Check if the project exists and belongs to the user
 	existingprj, err := atdb.GetOneDoc[model.Project](config.Mongoconn, "project", primitive.M{"_id": prj.ID, "owner._id": docuser.ID})
 	if err != nil {
-		var respn model.Response
-		respn.Status = "Error: Project tidak ditemukan"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusNotFound, respn)
+		at.WriteError(respw, at.NewError(at.CodeNotFound, "Project tidak ditemukan", err.Error()))
 		return
 	}
 
@@ -293,10 +202,7 @@ func PutMetaDataProject(respw http.ResponseWriter, req *http.Request) {
 	// Save the updated project back to the database using ReplaceOneDoc
 	_, err = atdb.ReplaceOneDoc(config.Mongoconn, "project", primitive.M{"_id": existingprj.ID}, existingprj)
 	if err != nil {
-		var respn model.Response
-		respn.Status = "Error: Gagal memperbarui database"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusInternalServerError, respn)
+		at.WriteError(respw, at.NewError(at.CodeInternal, "Gagal memperbarui database", err.Error()))
 		return
 	}
 
@@ -306,14 +212,9 @@ func PutMetaDataProject(respw http.ResponseWriter, req *http.Request) {
 
 func PutPublishProject(respw http.ResponseWriter, req *http.Request) {
 	// Decode token from header
-	payload, err := watoken.Decode(config.PublicKeyWhatsAuth, at.GetLoginFromHeader(req))
+	payload, err := config.TokenKeyring.Decode(at.GetLoginFromHeader(req))
 	if err != nil {
-		var respn model.Response
-		respn.Status = "Error: Token Tidak Valid"
-		respn.Info = at.GetSecretFromHeader(req)
-		respn.Location = "Decode Token Error"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusForbidden, respn)
+		at.WriteError(respw, at.NewError(at.CodeInvalidToken, "Token Tidak Valid", err.Error()))
 		return
 	}
 
@@ -321,30 +222,21 @@ func PutPublishProject(respw http.ResponseWriter, req *http.Request) {
 	var prj model.Project
 	err = json.NewDecoder(req.Body).Decode(&prj)
 	if err != nil {
-		var respn model.Response
-		respn.Status = "Error: Body tidak valid"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, at.NewError(at.CodeInvalidBody, "Body tidak valid", err.Error()))
 		return
 	}
 
-	// Get user data from the database and make sure its manager
-	docuser, err := atdb.GetOneDoc[model.Userdomyikado](config.Mongoconn, "user", primitive.M{"phonenumber": payload.Id, "ismanager": true})
+	// Get user data from the database, akses manager dicek di route
+	docuser, err := atdb.GetOneDoc[model.Userdomyikado](config.Mongoconn, "user", primitive.M{"phonenumber": payload.Id})
 	if err != nil {
-		var respn model.Response
-		respn.Status = "Tidak ada akses"
-		respn.Response = "Kakak bukan manager"
-		at.WriteJSON(respw, http.StatusNotImplemented, respn)
+		at.WriteError(respw, at.NewError(at.CodeNotFound, "Data user tidak di temukan", err.Error()))
 		return
 	}
 
 	// ambil data project
 	existingprj, err := atdb.GetOneDoc[model.Project](config.Mongoconn, "project", primitive.M{"_id": prj.ID})
 	if err != nil {
-		var respn model.Response
-		respn.Status = "Error: Project tidak ditemukan"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusNotFound, respn)
+		at.WriteError(respw, at.NewError(at.CodeNotFound, "Project tidak ditemukan", err.Error()))
 		return
 	}
 
@@ -362,10 +254,7 @@ func PutPublishProject(respw http.ResponseWriter, req *http.Request) {
 	// Save the updated project back to the database using ReplaceOneDoc
 	_, err = atdb.ReplaceOneDoc(config.Mongoconn, "project", primitive.M{"_id": existingprj.ID}, existingprj)
 	if err != nil {
-		var respn model.Response
-		respn.Status = "Error: Gagal memperbarui database"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusInternalServerError, respn)
+		at.WriteError(respw, at.NewError(at.CodeInternal, "Gagal memperbarui database", err.Error()))
 		return
 	}
 
@@ -375,14 +264,9 @@ func PutPublishProject(respw http.ResponseWriter, req *http.Request) {
 
 func PutDataProject(respw http.ResponseWriter, req *http.Request) {
 	// Decode token from header
-	payload, err := watoken.Decode(config.PublicKeyWhatsAuth, at.GetLoginFromHeader(req))
+	payload, err := config.TokenKeyring.Decode(at.GetLoginFromHeader(req))
 	if err != nil {
-		var respn model.Response
-		respn.Status = "Error: Token Tidak Valid"
-		respn.Info = at.GetSecretFromHeader(req)
-		respn.Location = "Decode Token Error"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusForbidden, respn)
+		at.WriteError(respw, at.NewError(at.CodeInvalidToken, "Token Tidak Valid", err.Error()))
 		return
 	}
 
@@ -390,30 +274,21 @@ func PutDataProject(respw http.ResponseWriter, req *http.Request) {
 	var prj model.Project
 	err = json.NewDecoder(req.Body).Decode(&prj)
 	if err != nil {
-		var respn model.Response
-		respn.Status = "Error: Body tidak valid"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, at.NewError(at.CodeInvalidBody, "Body tidak valid", err.Error()))
 		return
 	}
 
 	// Get user data from the database
 	docuser, err := atdb.GetOneDoc[model.Userdomyikado](config.Mongoconn, "user", primitive.M{"phonenumber": payload.Id})
 	if err != nil {
-		var respn model.Response
-		respn.Status = "Error: Data user tidak ditemukan"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusNotImplemented, respn)
+		at.WriteError(respw, at.NewError(at.CodeNotFound, "Data user tidak ditemukan", err.Error()))
 		return
 	}
 
 	// Check if the project exists and belongs to the user
 	existingprj, err := atdb.GetOneDoc[model.Project](config.Mongoconn, "project", primitive.M{"_id": prj.ID, "owner._id": docuser.ID})
 	if err != nil {
-		var respn model.Response
-		respn.Status = "Error: Project tidak ditemukan"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusNotFound, respn)
+		at.WriteError(respw, at.NewError(at.CodeNotFound, "Project tidak ditemukan", err.Error()))
 		return
 	}
 
@@ -431,10 +306,7 @@ func PutDataProject(respw http.ResponseWriter, req *http.Request) {
 	// Save the updated project back to the database using ReplaceOneDoc
 	_, err = atdb.ReplaceOneDoc(config.Mongoconn, "project", primitive.M{"_id": existingprj.ID}, prj)
 	if err != nil {
-		var respn model.Response
-		respn.Status = "Error: Gagal memperbarui database"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusInternalServerError, respn)
+		at.WriteError(respw, at.NewError(at.CodeInternal, "Gagal memperbarui database", err.Error()))
 		return
 	}
 
@@ -444,14 +316,9 @@ func PutDataProject(respw http.ResponseWriter, req *http.Request) {
 
 func DeleteDataProject(respw http.ResponseWriter, req *http.Request) {
 	// Dekode token dari header permintaan
-	payload, err := watoken.Decode(config.PublicKeyWhatsAuth, at.GetLoginFromHeader(req))
+	payload, err := config.TokenKeyring.Decode(at.GetLoginFromHeader(req))
 	if err != nil {
-		var respn model.Response
-		respn.Status = "Error : Token Tidak Valid"
-		respn.Info = at.GetSecretFromHeader(req)
-		respn.Location = "Decode Token Error"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusForbidden, respn)
+		at.WriteError(respw, at.NewError(at.CodeInvalidToken, "Token Tidak Valid", err.Error()))
 		return
 	}
 
@@ -461,40 +328,28 @@ func DeleteDataProject(respw http.ResponseWriter, req *http.Request) {
 	}
 	err = json.NewDecoder(req.Body).Decode(&reqBody)
 	if err != nil {
-		var respn model.Response
-		respn.Status = "Error : Body tidak valid"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, at.NewError(at.CodeInvalidBody, "Body tidak valid", err.Error()))
 		return
 	}
 
 	// Dapatkan data pengguna berdasarkan ID dari payload token
 	docuser, err := atdb.GetOneDoc[model.Userdomyikado](config.Mongoconn, "user", primitive.M{"phonenumber": payload.Id})
 	if err != nil {
-		var respn model.Response
-		respn.Status = "Error : Data user tidak di temukan"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusNotImplemented, respn)
+		at.WriteError(respw, at.NewError(at.CodeNotFound, "Data user tidak di temukan", err.Error()))
 		return
 	}
 
 	// Cek apakah proyek dengan nama yang diberikan ada dan dimiliki oleh pengguna
 	existingprj, err := atdb.GetOneDoc[model.Project](config.Mongoconn, "project", primitive.M{"name": reqBody.ProjectName, "owner._id": docuser.ID})
 	if err != nil {
-		var respn model.Response
-		respn.Status = "Error : Data project tidak di temukan"
-		respn.Response = "Proyek dengan nama tersebut tidak ditemukan atau bukan milik Anda"
-		at.WriteJSON(respw, http.StatusNotFound, respn)
+		at.WriteError(respw, at.NewError(at.CodeNotFound, "Data project tidak di temukan", "Proyek dengan nama tersebut tidak ditemukan atau bukan milik Anda"))
 		return
 	}
 
 	// Hapus proyek dari koleksi "project" di MongoDB
 	_, err = atdb.DeleteOneDoc(config.Mongoconn, "project", primitive.M{"_id": existingprj.ID})
 	if err != nil {
-		var respn model.Response
-		respn.Status = "Error : Gagal menghapus project"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusExpectationFailed, respn)
+		at.WriteError(respw, at.NewError(at.CodeInternal, "Gagal menghapus project", err.Error()))
 		return
 	}
 
@@ -503,174 +358,116 @@ func DeleteDataProject(respw http.ResponseWriter, req *http.Request) {
 }
 
 func GetDataMemberProject(respw http.ResponseWriter, req *http.Request) {
-	payload, err := watoken.Decode(config.PublicKeyWhatsAuth, at.GetLoginFromHeader(req))
+	payload, err := config.TokenKeyring.Decode(at.GetLoginFromHeader(req))
 	if err != nil {
-		var respn model.Response
-		respn.Status = "Error : Token Tidak Valid"
-		respn.Info = at.GetSecretFromHeader(req)
-		respn.Location = "Decode Token Error"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusForbidden, respn)
+		at.WriteError(respw, at.NewError(at.CodeInvalidToken, "Token Tidak Valid", err.Error()))
 		return
 	}
 	docuser, err := atdb.GetOneDoc[model.Userdomyikado](config.Mongoconn, "user", primitive.M{"phonenumber": payload.Id})
 	if err != nil {
-		var respn model.Response
-		respn.Status = "Error : Data user tidak di temukan"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusNotImplemented, respn)
+		at.WriteError(respw, at.NewError(at.CodeNotFound, "Data user tidak di temukan", err.Error()))
 		return
 	}
 	existingprjs, err := atdb.GetAllDoc[[]model.Project](config.Mongoconn, "project", primitive.M{"members._id": docuser.ID})
 	if err != nil {
-		var respn model.Response
-		respn.Status = "Error : Data project tidak di temukan"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusNotFound, respn)
+		at.WriteError(respw, at.NewError(at.CodeNotFound, "Data project tidak di temukan", err.Error()))
 		return
 	}
 	if len(existingprjs) == 0 {
-		var respn model.Response
-		respn.Status = "Error : Data project tidak di temukan"
-		respn.Response = "Kakak belum menjadi anggota proyek manapun"
-		at.WriteJSON(respw, http.StatusNotFound, respn)
+		at.WriteError(respw, at.NewError(at.CodeNotFound, "Data project tidak di temukan", "Kakak belum menjadi anggota proyek manapun"))
 		return
 	}
 	at.WriteJSON(respw, http.StatusOK, existingprjs)
 }
 
 func GetDataEditorProject(respw http.ResponseWriter, req *http.Request) {
-	payload, err := watoken.Decode(config.PublicKeyWhatsAuth, at.GetLoginFromHeader(req))
+	payload, err := config.TokenKeyring.Decode(at.GetLoginFromHeader(req))
 	if err != nil {
-		var respn model.Response
-		respn.Status = "Error : Token Tidak Valid"
-		respn.Info = at.GetSecretFromHeader(req)
-		respn.Location = "Decode Token Error"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusForbidden, respn)
+		at.WriteError(respw, at.NewError(at.CodeInvalidToken, "Token Tidak Valid", err.Error()))
 		return
 	}
 	docuser, err := atdb.GetOneDoc[model.Userdomyikado](config.Mongoconn, "user", primitive.M{"phonenumber": payload.Id})
 	if err != nil {
-		var respn model.Response
-		respn.Status = "Error : Data user tidak di temukan"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusNotImplemented, respn)
+		at.WriteError(respw, at.NewError(at.CodeNotFound, "Data user tidak di temukan", err.Error()))
 		return
 	}
 	existingprjs, err := atdb.GetAllDoc[[]model.Project](config.Mongoconn, "project", primitive.M{"editor._id": docuser.ID})
 	if err != nil {
-		var respn model.Response
-		respn.Status = "Error : Data project tidak di temukan"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusNotFound, respn)
+		at.WriteError(respw, at.NewError(at.CodeNotFound, "Data project tidak di temukan", err.Error()))
 		return
 	}
 	if len(existingprjs) == 0 {
-		var respn model.Response
-		respn.Status = "Error : Data project tidak di temukan"
-		respn.Response = "Kakak belum menjadi anggota proyek manapun"
-		at.WriteJSON(respw, http.StatusNotFound, respn)
+		at.WriteError(respw, at.NewError(at.CodeNotFound, "Data project tidak di temukan", "Kakak belum menjadi anggota proyek manapun"))
 		return
 	}
 	at.WriteJSON(respw, http.StatusOK, existingprjs)
 }
 
 func PostDataMemberProject(respw http.ResponseWriter, req *http.Request) {
-	var respn model.Response
-	payload, err := watoken.Decode(config.PublicKeyWhatsAuth, at.GetLoginFromHeader(req))
+	payload, err := config.TokenKeyring.Decode(at.GetLoginFromHeader(req))
 	if err != nil {
-		respn.Status = "Error : Token Tidak Valid"
-		respn.Info = at.GetSecretFromHeader(req)
-		respn.Location = "Decode Token Error"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusForbidden, respn)
+		at.WriteError(respw, at.NewError(at.CodeInvalidToken, "Token Tidak Valid", err.Error()))
 		return
 	}
 	var idprjuser model.Userdomyikado
 	err = json.NewDecoder(req.Body).Decode(&idprjuser)
 	if err != nil {
-		respn.Status = "Error : Body tidak valid"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, at.NewError(at.CodeInvalidBody, "Body tidak valid", err.Error()))
 		return
 	}
 	docuserowner, err := atdb.GetOneDoc[model.Userdomyikado](config.Mongoconn, "user", primitive.M{"phonenumber": payload.Id})
 	if err != nil {
-		respn.Status = "Error : Data owner tidak di temukan"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusNotImplemented, respn)
+		at.WriteError(respw, at.NewError(at.CodeNotFound, "Data owner tidak di temukan", err.Error()))
 		return
 	}
 	existingprj, err := atdb.GetOneDoc[model.Project](config.Mongoconn, "project", primitive.M{"_id": idprjuser.ID, "owner._id": docuserowner.ID})
 	if err != nil {
-		respn.Status = "Error : Data project tidak di temukan"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusNotFound, respn)
+		at.WriteError(respw, at.NewError(at.CodeNotFound, "Data project tidak di temukan", err.Error()))
 		return
 	}
 	docusermember, err := atdb.GetOneDoc[model.Userdomyikado](config.Mongoconn, "user", primitive.M{"phonenumber": idprjuser.PhoneNumber})
 	if err != nil {
-		respn.Status = "Error : Data member tidak di temukan"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusConflict, respn)
+		at.WriteError(respw, at.NewError(at.CodeNotFound, "Data member tidak di temukan", err.Error()))
 		return
 	}
 	docusermember.Poin = 0 //set user poin per project, jika baru dimasukkan maka set0 karena belum ada kontribusi di project ini
 	rest, err := atdb.AddDocToArray[model.Userdomyikado](config.Mongoconn, "project", idprjuser.ID, "members", docusermember)
 	if err != nil {
-		respn.Status = "Error : Gagal menambahkan member ke project"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusExpectationFailed, respn)
+		at.WriteError(respw, at.NewError(at.CodeInternal, "Gagal menambahkan member ke project", err.Error()))
 		return
 	}
 	if rest.ModifiedCount == 0 {
-		respn.Status = "Error : Gagal menambahkan member ke project"
-		respn.Response = "Tidak ada perubahan pada dokumen proyek"
-		at.WriteJSON(respw, http.StatusExpectationFailed, respn)
+		at.WriteError(respw, at.NewError(at.CodeInternal, "Gagal menambahkan member ke project", "Tidak ada perubahan pada dokumen proyek"))
 		return
 	}
 	at.WriteJSON(respw, http.StatusOK, existingprj)
 }
 
 func PostDataEditorProject(respw http.ResponseWriter, req *http.Request) {
-	var respn model.Response
-	payload, err := watoken.Decode(config.PublicKeyWhatsAuth, at.GetLoginFromHeader(req))
+	payload, err := config.TokenKeyring.Decode(at.GetLoginFromHeader(req))
 	if err != nil {
-		respn.Status = "Error : Token Tidak Valid"
-		respn.Info = at.GetSecretFromHeader(req)
-		respn.Location = "Decode Token Error"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusForbidden, respn)
+		at.WriteError(respw, at.NewError(at.CodeInvalidToken, "Token Tidak Valid", err.Error()))
 		return
 	}
 	var idprjuser model.Project
 	err = json.NewDecoder(req.Body).Decode(&idprjuser)
 	if err != nil {
-		respn.Status = "Error : Body tidak valid"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, at.NewError(at.CodeInvalidBody, "Body tidak valid", err.Error()))
 		return
 	}
 	docuserowner, err := atdb.GetOneDoc[model.Userdomyikado](config.Mongoconn, "user", primitive.M{"phonenumber": payload.Id})
 	if err != nil {
-		respn.Status = "Error : Data owner tidak di temukan"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusNotImplemented, respn)
+		at.WriteError(respw, at.NewError(at.CodeNotFound, "Data owner tidak di temukan", err.Error()))
 		return
 	}
 	existingprj, err := atdb.GetOneDoc[model.Project](config.Mongoconn, "project", primitive.M{"_id": idprjuser.ID, "owner._id": docuserowner.ID})
 	if err != nil {
-		respn.Status = "Error : Data project tidak di temukan"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusNotFound, respn)
+		at.WriteError(respw, at.NewError(at.CodeNotFound, "Data project tidak di temukan", err.Error()))
 		return
 	}
 	docusermember, err := atdb.GetOneDoc[model.Userdomyikado](config.Mongoconn, "user", primitive.M{"_id": idprjuser.Editor.ID})
 	if err != nil {
-		respn.Status = "Error : Data editor tidak di temukan"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusConflict, respn)
+		at.WriteError(respw, at.NewError(at.CodeNotFound, "Data editor tidak di temukan", err.Error()))
 		return
 	}
 	docusermember.Poin = 0 //set user poin per project, jika baru dimasukkan maka set0 karena belum ada kontribusi di project ini
@@ -680,46 +477,37 @@ func PostDataEditorProject(respw http.ResponseWriter, req *http.Request) {
 	// Save the updated project back to the database using ReplaceOneDoc
 	_, err = atdb.ReplaceOneDoc(config.Mongoconn, "project", primitive.M{"_id": existingprj.ID}, existingprj)
 	if err != nil {
-		var respn model.Response
-		respn.Status = "Error: Gagal memperbarui database"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusInternalServerError, respn)
+		at.WriteError(respw, at.NewError(at.CodeInternal, "Gagal memperbarui database", err.Error()))
 		return
 	}
 	at.WriteJSON(respw, http.StatusOK, existingprj)
 }
 
 func PUtApprovedEditorProject(respw http.ResponseWriter, req *http.Request) {
-	var respn model.Response
-	payload, err := watoken.Decode(config.PublicKeyWhatsAuth, at.GetLoginFromHeader(req))
+	payload, err := config.TokenKeyring.Decode(at.GetLoginFromHeader(req))
 	if err != nil {
-		respn.Status = "Error : Token Tidak Valid"
-		respn.Info = at.GetSecretFromHeader(req)
-		respn.Location = "Decode Token Error"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusForbidden, respn)
+		at.WriteError(respw, at.NewError(at.CodeInvalidToken, "Token Tidak Valid", err.Error()))
 		return
 	}
 	var idprjuser model.Project
 	err = json.NewDecoder(req.Body).Decode(&idprjuser)
 	if err != nil {
-		respn.Status = "Error : Body tidak valid"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, at.NewError(at.CodeInvalidBody, "Body tidak valid", err.Error()))
 		return
 	}
-	docusereditor, err := atdb.GetOneDoc[model.Userdomyikado](config.Mongoconn, "user", primitive.M{"phonenumber": payload.Id})
+	_, err = atdb.GetOneDoc[model.Userdomyikado](config.Mongoconn, "user", primitive.M{"phonenumber": payload.Id})
 	if err != nil {
-		respn.Status = "Error : Data owner tidak di temukan"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusNotImplemented, respn)
+		at.WriteError(respw, at.NewError(at.CodeNotFound, "Data owner tidak di temukan", err.Error()))
 		return
 	}
-	existingprj, err := atdb.GetOneDoc[model.Project](config.Mongoconn, "project", primitive.M{"_id": idprjuser.ID, "editor.phonenumber": docusereditor.PhoneNumber})
+	existingprj, err := atdb.GetOneDoc[model.Project](config.Mongoconn, "project", primitive.M{"_id": idprjuser.ID})
 	if err != nil {
-		respn.Status = "Error : Data project tidak di temukan"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusNotFound, respn)
+		at.WriteError(respw, at.NewError(at.CodeNotFound, "Data project tidak di temukan", err.Error()))
+		return
+	}
+	//hanya editor project yang boleh approve
+	if err = authorizeResource(req, rbac.ProjectApprove, existingprj.Editor.PhoneNumber); err != nil {
+		writeForbidden(respw, err)
 		return
 	}
 	existingprj.IsApproved = true
@@ -727,75 +515,52 @@ func PUtApprovedEditorProject(respw http.ResponseWriter, req *http.Request) {
 	// Save the updated project back to the database using ReplaceOneDoc
 	_, err = atdb.ReplaceOneDoc(config.Mongoconn, "project", primitive.M{"_id": existingprj.ID}, existingprj)
 	if err != nil {
-		var respn model.Response
-		respn.Status = "Error: Gagal memperbarui database"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusInternalServerError, respn)
+		at.WriteError(respw, at.NewError(at.CodeInternal, "Gagal memperbarui database", err.Error()))
 		return
 	}
 	at.WriteJSON(respw, http.StatusOK, existingprj)
 }
 
 func PostDataMenuProject(respw http.ResponseWriter, req *http.Request) {
-	var respn model.Response
-	payload, err := watoken.Decode(config.PublicKeyWhatsAuth, at.GetLoginFromHeader(req))
+	payload, err := config.TokenKeyring.Decode(at.GetLoginFromHeader(req))
 	if err != nil {
-		respn.Status = "Error : Token Tidak Valid"
-		respn.Info = at.GetSecretFromHeader(req)
-		respn.Location = "Decode Token Error"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusForbidden, respn)
+		at.WriteError(respw, at.NewError(at.CodeInvalidToken, "Token Tidak Valid", err.Error()))
 		return
 	}
 	var idprjuser model.MenuItem
 	err = json.NewDecoder(req.Body).Decode(&idprjuser)
 	if err != nil {
-		respn.Status = "Error : Body tidak valid"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, at.NewError(at.CodeInvalidBody, "Body tidak valid", err.Error()))
 		return
 	}
 	docuserowner, err := atdb.GetOneDoc[model.Userdomyikado](config.Mongoconn, "user", primitive.M{"phonenumber": payload.Id})
 	if err != nil {
-		respn.Status = "Error : Data owner tidak di temukan"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusNotImplemented, respn)
+		at.WriteError(respw, at.NewError(at.CodeNotFound, "Data owner tidak di temukan", err.Error()))
 		return
 	}
 	existingprj, err := atdb.GetOneDoc[model.Project](config.Mongoconn, "project", primitive.M{"_id": idprjuser.IDDatabase, "owner._id": docuserowner.ID})
 	if err != nil {
-		respn.Status = "Error : Data project tidak di temukan"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusNotFound, respn)
+		at.WriteError(respw, at.NewError(at.CodeNotFound, "Data project tidak di temukan", err.Error()))
 		return
 	}
 	//bikin insert menu
 	//idprjuser.IDDatabase = primitive.NilObjectID
 	rest, err := atdb.AddDocToArray[model.MenuItem](config.Mongoconn, "project", idprjuser.IDDatabase, "menu", idprjuser)
 	if err != nil {
-		respn.Status = "Error : Gagal menambahkan menu ke lapak"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusExpectationFailed, respn)
+		at.WriteError(respw, at.NewError(at.CodeInternal, "Gagal menambahkan menu ke lapak", err.Error()))
 		return
 	}
 	if rest.ModifiedCount == 0 {
-		respn.Status = "Error : Gagal menambahkan member ke project"
-		respn.Response = "Tidak ada perubahan pada dokumen proyek"
-		at.WriteJSON(respw, http.StatusExpectationFailed, respn)
+		at.WriteError(respw, at.NewError(at.CodeInternal, "Gagal menambahkan member ke project", "Tidak ada perubahan pada dokumen proyek"))
 		return
 	}
 	at.WriteJSON(respw, http.StatusOK, existingprj)
 }
 
 func DeleteDataMenuProject(respw http.ResponseWriter, req *http.Request) {
-	var respn model.Response
-	payload, err := watoken.Decode(config.PublicKeyWhatsAuth, at.GetLoginFromHeader(req))
+	payload, err := config.TokenKeyring.Decode(at.GetLoginFromHeader(req))
 	if err != nil {
-		respn.Status = "Error : Token Tidak Valid"
-		respn.Info = at.GetSecretFromHeader(req)
-		respn.Location = "Decode Token Error"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusForbidden, respn)
+		at.WriteError(respw, at.NewError(at.CodeInvalidToken, "Token Tidak Valid", err.Error()))
 		return
 	}
 
@@ -806,25 +571,19 @@ func DeleteDataMenuProject(respw http.ResponseWriter, req *http.Request) {
 
 	err = json.NewDecoder(req.Body).Decode(&requestPayload)
 	if err != nil {
-		respn.Status = "Error : Body tidak valid"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, at.NewError(at.CodeInvalidBody, "Body tidak valid", err.Error()))
 		return
 	}
 
 	docuserowner, err := atdb.GetOneDoc[model.Userdomyikado](config.Mongoconn, "user", primitive.M{"phonenumber": payload.Id})
 	if err != nil {
-		respn.Status = "Error : Data owner tidak ditemukan"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusNotImplemented, respn)
+		at.WriteError(respw, at.NewError(at.CodeNotFound, "Data owner tidak ditemukan", err.Error()))
 		return
 	}
 
 	existingprj, err := atdb.GetOneDoc[model.Project](config.Mongoconn, "project", primitive.M{"name": requestPayload.ProjectName, "owner._id": docuserowner.ID})
 	if err != nil {
-		respn.Status = "Error : Data project tidak ditemukan"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusNotFound, respn)
+		at.WriteError(respw, at.NewError(at.CodeNotFound, "Data project tidak ditemukan", err.Error()))
 		return
 	}
 
@@ -832,15 +591,11 @@ func DeleteDataMenuProject(respw http.ResponseWriter, req *http.Request) {
 	menuToDelete := model.MenuItem{ID: requestPayload.MenuID}
 	rest, err := atdb.DeleteDocFromArray[model.MenuItem](config.Mongoconn, "project", existingprj.ID, "menu", menuToDelete)
 	if err != nil {
-		respn.Status = "Error : Gagal menghapus menu dari lapak"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusExpectationFailed, respn)
+		at.WriteError(respw, at.NewError(at.CodeInternal, "Gagal menghapus menu dari lapak", err.Error()))
 		return
 	}
 	if rest.ModifiedCount == 0 {
-		respn.Status = "Error : Gagal menghapus menu dari lapak"
-		respn.Response = "Tidak ada perubahan pada dokumen proyek:" + menuToDelete.ID
-		at.WriteJSON(respw, http.StatusExpectationFailed, respn)
+		at.WriteError(respw, at.NewError(at.CodeInternal, "Gagal menghapus menu dari lapak", "Tidak ada perubahan pada dokumen proyek:"+menuToDelete.ID))
 		return
 	}
 
@@ -848,14 +603,9 @@ func DeleteDataMenuProject(respw http.ResponseWriter, req *http.Request) {
 }
 
 func DeleteDataMemberProject(respw http.ResponseWriter, req *http.Request) {
-	var respn model.Response
-	payload, err := watoken.Decode(config.PublicKeyWhatsAuth, at.GetLoginFromHeader(req))
+	payload, err := config.TokenKeyring.Decode(at.GetLoginFromHeader(req))
 	if err != nil {
-		respn.Status = "Error : Token Tidak Valid"
-		respn.Info = at.GetSecretFromHeader(req)
-		respn.Location = "Decode Token Error"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusForbidden, respn)
+		at.WriteError(respw, at.NewError(at.CodeInvalidToken, "Token Tidak Valid", err.Error()))
 		return
 	}
 
@@ -866,25 +616,19 @@ func DeleteDataMemberProject(respw http.ResponseWriter, req *http.Request) {
 
 	err = json.NewDecoder(req.Body).Decode(&requestPayload)
 	if err != nil {
-		respn.Status = "Error : Body tidak valid"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, at.NewError(at.CodeInvalidBody, "Body tidak valid", err.Error()))
 		return
 	}
 
 	docuserowner, err := atdb.GetOneDoc[model.Userdomyikado](config.Mongoconn, "user", primitive.M{"phonenumber": payload.Id})
 	if err != nil {
-		respn.Status = "Error : Data owner tidak ditemukan"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusNotImplemented, respn)
+		at.WriteError(respw, at.NewError(at.CodeNotFound, "Data owner tidak ditemukan", err.Error()))
 		return
 	}
 
 	existingprj, err := atdb.GetOneDoc[model.Project](config.Mongoconn, "project", primitive.M{"name": requestPayload.ProjectName, "owner._id": docuserowner.ID})
 	if err != nil {
-		respn.Status = "Error : Data project tidak ditemukan"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusNotFound, respn)
+		at.WriteError(respw, at.NewError(at.CodeNotFound, "Data project tidak ditemukan", err.Error()))
 		return
 	}
 
@@ -892,15 +636,11 @@ func DeleteDataMemberProject(respw http.ResponseWriter, req *http.Request) {
 	memberToDelete := model.Userdomyikado{PhoneNumber: requestPayload.PhoneNumber}
 	rest, err := atdb.DeleteDocFromArray[model.Userdomyikado](config.Mongoconn, "project", existingprj.ID, "members", memberToDelete)
 	if err != nil {
-		respn.Status = "Error : Gagal menghapus member dari project"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusExpectationFailed, respn)
+		at.WriteError(respw, at.NewError(at.CodeInternal, "Gagal menghapus member dari project", err.Error()))
 		return
 	}
 	if rest.ModifiedCount == 0 {
-		respn.Status = "Error : Gagal menghapus member dari project"
-		respn.Response = "Tidak ada perubahan pada dokumen proyek"
-		at.WriteJSON(respw, http.StatusExpectationFailed, respn)
+		at.WriteError(respw, at.NewError(at.CodeInternal, "Gagal menghapus member dari project", "Tidak ada perubahan pada dokumen proyek"))
 		return
 	}
 
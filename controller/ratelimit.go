@@ -0,0 +1,106 @@
+package controller
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gocroot/config"
+	"github.com/gocroot/helper/at"
+	"github.com/gocroot/helper/ratelimit"
+)
+
+// batas request endpoint auth per nomor/email, batas per IP dibuat lebih longgar karena satu IP bisa dipakai banyak user
+var (
+	otpRule      = ratelimit.Rule{Name: "otp", Limit: 3, Window: 10 * time.Minute}
+	loginRule    = ratelimit.Rule{Name: "login", Limit: 10, Window: 15 * time.Minute}
+	registerRule = ratelimit.Rule{Name: "register", Limit: 3, Window: time.Hour}
+	recoveryRule = ratelimit.Rule{Name: "recovery", Limit: 3, Window: time.Hour} // kirim ulang verifikasi email dan lupa password
+	ipMultiplier = 5
+
+	// login dikunci setelah 5 kali salah password dalam 15 menit
+	loginLockout = ratelimit.Lockout{MaxFailures: 5, Window: 15 * time.Minute, Duration: 15 * time.Minute}
+)
+
+var (
+	authLimiter     *ratelimit.Limiter
+	authLimiterOnce sync.Once
+)
+
+func getAuthLimiter() *ratelimit.Limiter {
+	authLimiterOnce.Do(func() {
+		authLimiter = ratelimit.New(config.Mongoconn)
+		if err := authLimiter.EnsureIndexes(context.TODO()); err != nil {
+			log.Println("gagal membuat index ratelimit: " + err.Error())
+		}
+	})
+	return authLimiter
+}
+
+// limitAuth mengecek kunci login dan rate limit untuk identitas (nomor atau email) dan IP client,
+// jika ditolak response 429 sudah ditulis dan mengembalikan false
+func limitAuth(respw http.ResponseWriter, r *http.Request, rule ratelimit.Rule, identity string) bool {
+	ctx := r.Context()
+	now := time.Now()
+	limiter := getAuthLimiter()
+	if rule.Name == loginRule.Name {
+		locked, err := limiter.Locked(ctx, rule.Name, identity, now)
+		if err != nil {
+			log.Println("gagal cek kunci login: " + err.Error())
+		}
+		if locked > 0 {
+			writeTooManyRequests(respw, "Akun dikunci sementara karena terlalu banyak percobaan gagal", locked)
+			return false
+		}
+	}
+	ip, _ := at.GetClientIP(r)
+	ipRule := rule
+	ipRule.Name += ":ip"
+	ipRule.Limit *= ipMultiplier
+	checks := []struct {
+		rule ratelimit.Rule
+		key  string
+	}{{rule, identity}, {ipRule, ip}}
+	for _, c := range checks {
+		if c.key == "" {
+			continue
+		}
+		d, err := limiter.Allow(ctx, c.rule, c.key, now)
+		if err != nil {
+			// rate limit tidak boleh membuat login mati total saat Mongo bermasalah
+			log.Println("gagal cek rate limit " + c.rule.Name + ": " + err.Error())
+			continue
+		}
+		if !d.Allowed {
+			writeTooManyRequests(respw, "Terlalu banyak permintaan, silakan coba lagi nanti", d.RetryAfter)
+			return false
+		}
+	}
+	return true
+}
+
+// authFailed mencatat login gagal, setelah batas kegagalan identitas tersebut dikunci
+func authFailed(r *http.Request, identity string) {
+	if _, err := getAuthLimiter().Fail(r.Context(), loginLockout, loginRule.Name, identity, time.Now()); err != nil {
+		log.Println("gagal mencatat login gagal: " + err.Error())
+	}
+}
+
+// authSucceeded menghapus hitungan kegagalan setelah login berhasil
+func authSucceeded(r *http.Request, identity string) {
+	if err := getAuthLimiter().Reset(r.Context(), loginRule.Name, identity); err != nil {
+		log.Println("gagal reset login gagal: " + err.Error())
+	}
+}
+
+func writeTooManyRequests(respw http.ResponseWriter, info string, retry time.Duration) {
+	seconds := int(retry.Round(time.Second).Seconds())
+	if seconds < 1 {
+		seconds = 1
+	}
+	respw.Header().Set("Retry-After", strconv.Itoa(seconds))
+	at.WriteError(respw, at.NewError(at.CodeRateLimited, "", "Silakan coba lagi dalam "+strconv.Itoa(seconds)+" detik").WithInfo(info))
+}
@@ -0,0 +1,208 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gocroot/config"
+	"github.com/gocroot/helper/at"
+	"github.com/gocroot/helper/atdb"
+	"github.com/gocroot/helper/rbac"
+	"github.com/gocroot/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Require membungkus handler dengan pengecekan permission di level route.
+// Subject disimpan di context supaya handler bisa lanjut cek kepemilikan resource lewat authorizeResource.
+func Require(perm rbac.Permission, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		payload, err := decodeLoginToken(r)
+		if err != nil {
+			writeTokenError(w, err)
+			return
+		}
+		sub, err := loadSubject(payload.Id)
+		if err != nil {
+			at.WriteError(w, at.NewError(at.CodeInternal, "Data user tidak bisa diambil", err.Error()))
+			return
+		}
+		if err = rbac.DefaultPolicy.Authorize(sub, perm); err != nil {
+			writeForbidden(w, err)
+			return
+		}
+		next(w, r.WithContext(rbac.WithSubject(r.Context(), sub)))
+	}
+}
+
+// loadSubject menyusun role efektif user dari collection user, user yang belum terdaftar tetap dapat role default
+func loadSubject(phonenumber string) (sub rbac.Subject, err error) {
+	docuser, err := atdb.GetOneDoc[model.Userdomyikado](config.Mongoconn, "user", bson.M{"phonenumber": phonenumber})
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		docuser.PhoneNumber = phonenumber
+		err = nil
+	}
+	if err != nil {
+		return
+	}
+	return rbac.Subject{ID: phonenumber, Roles: userRoles(docuser)}, nil
+}
+
+// userRoles role default ditambah role dari flag lama dan role yang diberikan admin.
+// Team tidak memberi role apa pun karena akun form otomatis masuk team, operator helpdesk diberi role lewat PutUserRole.
+func userRoles(docuser model.Userdomyikado) []rbac.Role {
+	roles := append([]rbac.Role{}, rbac.DefaultRoles...)
+	add := func(role rbac.Role) {
+		for _, r := range roles {
+			if r == role {
+				return
+			}
+		}
+		roles = append(roles, role)
+	}
+	if docuser.IsManager {
+		add(rbac.RoleManager)
+	}
+	if docuser.IsEditor {
+		add(rbac.RoleEditor)
+	}
+	for _, s := range docuser.Roles {
+		if role, ok := rbac.ParseRole(s); ok {
+			add(role)
+		}
+	}
+	return roles
+}
+
+// requestSubject mengambil subject dari context Require, atau dari token jika route tidak dibungkus Require
+func requestSubject(r *http.Request) (rbac.Subject, error) {
+	if sub, ok := rbac.SubjectFrom(r.Context()); ok {
+		return sub, nil
+	}
+	payload, err := decodeLoginToken(r)
+	if err != nil {
+		return rbac.Subject{}, err
+	}
+	return loadSubject(payload.Id)
+}
+
+// authorizeResource memastikan user dari request boleh melakukan perm pada resource milik owners
+func authorizeResource(r *http.Request, perm rbac.Permission, owners ...string) error {
+	sub, err := requestSubject(r)
+	if err != nil {
+		return err
+	}
+	return rbac.DefaultPolicy.AuthorizeOwner(sub, perm, owners...)
+}
+
+// checkLapakAccess memastikan user dari request boleh melakukan perm pada lapak tersebut
+func checkLapakAccess(r *http.Request, perm rbac.Permission, namalapak string) error {
+	prj, err := atdb.GetOneDoc[model.Project](config.Mongoconn, "project", bson.M{"name": namalapak})
+	if err != nil {
+		return errors.New("lapak " + namalapak + " tidak ditemukan")
+	}
+	return authorizeResource(r, perm, prj.Owner.PhoneNumber)
+}
+
+// writeForbidden response 403 yang seragam untuk semua penolakan akses
+func writeForbidden(w http.ResponseWriter, err error) {
+	apierr := at.NewError(at.CodeForbidden, "", err.Error())
+	var denied *rbac.DeniedError
+	if errors.As(err, &denied) {
+		apierr.Info = string(denied.Permission)
+	}
+	at.WriteError(w, apierr)
+}
+
+// GetUserRoles role efektif user dari query phonenumber
+func GetUserRoles(respw http.ResponseWriter, req *http.Request) {
+	phonenumber := req.URL.Query().Get("phonenumber")
+	sub, err := loadSubject(phonenumber)
+	if err != nil || phonenumber == "" {
+		detail := "phonenumber wajib diisi"
+		if err != nil {
+			detail = err.Error()
+		}
+		at.WriteError(respw, at.NewError(at.CodeNotFound, "Data user tidak di temukan", detail))
+		return
+	}
+	roles := model.UserRoles{PhoneNumber: sub.ID}
+	for _, role := range sub.Roles {
+		roles.Roles = append(roles.Roles, string(role))
+	}
+	at.WriteJSON(respw, http.StatusOK, roles)
+}
+
+// PutUserRole memberi atau mencabut role user dan mencatatnya di audit role
+func PutUserRole(respw http.ResponseWriter, req *http.Request) {
+	actor, err := requestSubject(req)
+	if err != nil {
+		writeTokenError(respw, err)
+		return
+	}
+	var rolereq model.RoleRequest
+	if err = json.NewDecoder(req.Body).Decode(&rolereq); err != nil {
+		at.WriteError(respw, at.NewError(at.CodeInvalidBody, "Body tidak valid", err.Error()))
+		return
+	}
+	role, ok := rbac.ParseRole(rolereq.Role)
+	if !ok || rolereq.PhoneNumber == "" {
+		at.WriteError(respw, at.NewError(at.CodeBadRequest, "Role tidak dikenal", "Role "+rolereq.Role+" tidak ada atau phonenumber kosong"))
+		return
+	}
+	var update bson.M
+	switch rolereq.Action {
+	case model.RoleActionGrant:
+		update = bson.M{"$addToSet": bson.M{"roles": string(role)}}
+	case model.RoleActionRevoke:
+		update = bson.M{"$pull": bson.M{"roles": string(role)}}
+	default:
+		at.WriteError(respw, at.NewError(at.CodeBadRequest, "Action tidak dikenal", "Action harus "+model.RoleActionGrant+" atau "+model.RoleActionRevoke))
+		return
+	}
+	opts := options.Update().SetUpsert(rolereq.Action == model.RoleActionGrant)
+	res, err := config.Mongoconn.Collection("user").UpdateOne(context.TODO(), bson.M{"phonenumber": rolereq.PhoneNumber}, update, opts)
+	if err != nil {
+		at.WriteError(respw, at.NewError(at.CodeInternal, "Gagal memperbarui role", err.Error()))
+		return
+	}
+	if res.MatchedCount == 0 && res.UpsertedCount == 0 {
+		at.WriteError(respw, at.NewError(at.CodeNotFound, "Data user tidak di temukan", rolereq.PhoneNumber))
+		return
+	}
+	audit := model.RoleAudit{
+		Actor:     actor.ID,
+		Target:    rolereq.PhoneNumber,
+		Role:      string(role),
+		Action:    rolereq.Action,
+		CreatedAt: time.Now(),
+	}
+	if audit.ID, err = atdb.InsertOneDoc(config.Mongoconn, "roleaudit", audit); err != nil {
+		at.WriteError(respw, at.NewError(at.CodeInternal, "Gagal mencatat audit role", err.Error()))
+		return
+	}
+	at.WriteJSON(respw, http.StatusOK, audit)
+}
+
+// GetRoleAudit riwayat perubahan role, bisa difilter dengan query phonenumber
+func GetRoleAudit(respw http.ResponseWriter, req *http.Request) {
+	filter := bson.M{}
+	if phonenumber := req.URL.Query().Get("phonenumber"); phonenumber != "" {
+		filter["target"] = phonenumber
+	}
+	opts := options.Find().SetSort(bson.M{"created_at": -1}).SetLimit(200)
+	cur, err := config.Mongoconn.Collection("roleaudit").Find(context.TODO(), filter, opts)
+	audits := []model.RoleAudit{}
+	if err == nil {
+		err = cur.All(context.TODO(), &audits)
+	}
+	if err != nil {
+		at.WriteError(respw, at.NewError(at.CodeInternal, "Data audit role tidak bisa diambil", err.Error()))
+		return
+	}
+	at.WriteJSON(respw, http.StatusOK, audits)
+}
@@ -1 +1,94 @@
-package controller
\ No newline at end of file
+package controller
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"net/http"
+
+	"github.com/gocroot/config"
+	"github.com/gocroot/helper/at"
+	"github.com/gocroot/helper/atapi"
+	"github.com/gocroot/helper/atdb"
+	"github.com/gocroot/helper/dokped"
+	"github.com/gocroot/helper/rbac"
+	"github.com/gocroot/helper/router"
+	"github.com/gocroot/model"
+	"github.com/whatsauth/itmodel"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// GetReceipt download kwitansi pdf, hanya untuk pembeli atau owner lapak
+func GetReceipt(w http.ResponseWriter, r *http.Request) {
+	objectId, err := primitive.ObjectIDFromHex(router.Param(r, "checkoutid"))
+	if err != nil {
+		at.WriteError(w, at.NewError(at.CodeBadRequest, "ObjectID Tidak Valid", err.Error()))
+		return
+	}
+	checkout, err := atdb.GetOneDoc[model.Checkout](config.Mongoconn, "checkout", primitive.M{"_id": objectId})
+	if err != nil {
+		at.WriteError(w, at.NewError(at.CodeNotFound, "Data checkout tidak di temukan", err.Error()))
+		return
+	}
+	// kwitansi boleh diambil pembeli atau owner lapak
+	owners := []string{checkout.PhoneNumber}
+	if prj, err := atdb.GetOneDoc[model.Project](config.Mongoconn, "project", primitive.M{"name": checkout.NamaLapak}); err == nil {
+		owners = append(owners, prj.Owner.PhoneNumber)
+	}
+	if err = authorizeResource(r, rbac.CheckoutRead, owners...); err != nil {
+		writeForbidden(w, err)
+		return
+	}
+	conf, err := atdb.GetOneDoc[model.Confirmation](config.Mongoconn, "confirmation", primitive.M{"checkoutid": checkout.ID, "status": model.ConfirmationApproved})
+	if err != nil {
+		at.WriteError(w, at.NewError(at.CodeNotFound, "Pembayaran belum di approve", err.Error()))
+		return
+	}
+	filecontent, err := dokped.GenerateReceipt(checkout, conf)
+	if err != nil {
+		at.WriteError(w, at.NewError(at.CodeInternal, "Dokumen gagal di generate", err.Error()).WithInfo(checkout.ReceiptNumber))
+		return
+	}
+	at.WriteFile(w, http.StatusOK, filecontent)
+}
+
+// SendReceiptWA membuat kwitansi pdf dan mengirimkannya ke whatsapp pembeli
+func SendReceiptWA(checkout model.Checkout, conf model.Confirmation) (err error) {
+	if checkout.PhoneNumber == "" {
+		return errors.New("nomor pembeli tidak ada di checkout")
+	}
+	filecontent, err := dokped.GenerateReceipt(checkout, conf)
+	if err != nil {
+		return
+	}
+	dt := &itmodel.DocumentMessage{
+		To:        checkout.PhoneNumber,
+		IsGroup:   false,
+		Base64Doc: base64.StdEncoding.EncodeToString(filecontent),
+		Filename:  checkout.ReceiptNumber + ".pdf",
+		Caption:   "Terima kasih kak, pembayaran untuk pesanan di " + checkout.NamaLapak + " sudah kami terima. Berikut kwitansinya ya.",
+	}
+	_, _, err = atapi.PostStructWithToken[model.Response]("Token", config.WAAPIToken, dt, config.WAAPIDocMessage)
+	return
+}
+
+// setCheckoutPaid menandai checkout lunas dan memberi nomor kwitansi. Update bersyarat status pending supaya
+// approve bersamaan hanya menerbitkan satu kwitansi, checkout yang sudah lunas atau kedaluwarsa ditolak.
+func setCheckoutPaid(checkout model.Checkout) (model.Checkout, error) {
+	checkout.Status = model.CheckoutPaid
+	checkout.ReceiptNumber = dokped.GenerateReceiptNumber()
+	filter := bson.M{"_id": checkout.ID, "status": model.CheckoutPending}
+	update := bson.M{"$set": bson.M{
+		"status":        checkout.Status,
+		"receiptnumber": checkout.ReceiptNumber,
+	}}
+	res, err := config.Mongoconn.Collection("checkout").UpdateOne(context.TODO(), filter, update)
+	if err != nil {
+		return checkout, err
+	}
+	if res.MatchedCount == 0 {
+		return checkout, errors.New("checkout sudah lunas atau kedaluwarsa")
+	}
+	return checkout, nil
+}
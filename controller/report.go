@@ -18,18 +18,14 @@ func GetYesterdayDistincWAGroup(respw http.ResponseWriter, req *http.Request) {
 	filter := bson.M{"_id": report.YesterdayFilter()}
 	wagroupidlist, err := atdb.GetAllDistinctDoc(config.Mongoconn, filter, "project.wagroupid", "pushrepo")
 	if err != nil {
-		resp.Info = "Gagal Query Distincs project.wagroupid"
-		resp.Response = err.Error()
-		at.WriteJSON(respw, http.StatusUnauthorized, resp)
+		at.WriteError(respw, at.NewError(at.CodeInternal, "Gagal mengambil daftar wagroupid", err.Error()))
 		return
 	}
 	for _, wagroupid := range wagroupidlist {
 		// Type assertion to convert any to string
 		groupID, ok := wagroupid.(string)
 		if !ok {
-			resp.Info = "wagroupid is not a string"
-			resp.Response = "wagroupid is not a string"
-			at.WriteJSON(respw, http.StatusUnauthorized, resp)
+			at.WriteError(respw, at.NewError(at.CodeInternal, "Data wagroupid tidak valid", "wagroupid bukan string"))
 			return
 		}
 		//kirim report ke group
@@ -38,11 +34,9 @@ func GetYesterdayDistincWAGroup(respw http.ResponseWriter, req *http.Request) {
 			IsGroup:  true,
 			Messages: report.GetDataRepoMasukHariIni(config.Mongoconn, groupID) + "\n" + report.GetDataLaporanMasukHariini(config.Mongoconn, groupID),
 		}
-		_, resp, err := atapi.PostStructWithToken[model.Response]("Token", config.WAAPIToken, dt, config.WAAPIMessage)
+		_, _, err := atapi.PostStructWithToken[model.Response]("Token", config.WAAPIToken, dt, config.WAAPIMessage)
 		if err != nil {
-			resp.Info = "Tidak berhak"
-			resp.Response = err.Error()
-			at.WriteJSON(respw, http.StatusUnauthorized, resp)
+			at.WriteError(respw, at.NewError(at.CodeUpstream, "Pesan WhatsApp gagal dikirim", err.Error()))
 			return
 		}
 	}
@@ -59,9 +53,7 @@ func GetReportHariIni(respw http.ResponseWriter, req *http.Request) {
 	}
 	_, resp, err := atapi.PostStructWithToken[model.Response]("Token", config.WAAPIToken, dt, config.WAAPIMessage)
 	if err != nil {
-		resp.Info = "Tidak berhak"
-		resp.Response = err.Error()
-		at.WriteJSON(respw, http.StatusUnauthorized, resp)
+		at.WriteError(respw, at.NewError(at.CodeUpstream, "Pesan WhatsApp gagal dikirim", err.Error()))
 		return
 	}
 	at.WriteJSON(respw, http.StatusOK, resp)
@@ -0,0 +1,187 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/gocroot/config"
+	"github.com/gocroot/helper/at"
+	"github.com/gocroot/helper/atdb"
+	"github.com/gocroot/helper/rbac"
+	"github.com/gocroot/helper/router"
+	"github.com/gocroot/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ReviewRequest body ulasan pembeli
+type ReviewRequest struct {
+	ProductID primitive.ObjectID `json:"product_id"`
+	Rating    int                `json:"rating"`
+	Komentar  string             `json:"komentar"`
+}
+
+// ReplyRequest body balasan penjual
+type ReplyRequest struct {
+	Komentar string `json:"komentar"`
+}
+
+// PostReview pembeli memberi rating 1-5 dan ulasan untuk produk yang ada di checkout miliknya yang sudah selesai (diterima).
+// Satu user satu ulasan per produk, kirim ulang akan memperbarui ulasan.
+func PostReview(respw http.ResponseWriter, req *http.Request) {
+	payload, err := decodeLoginToken(req)
+	if err != nil {
+		writeTokenError(respw, err)
+		return
+	}
+	var reviewreq ReviewRequest
+	if err = json.NewDecoder(req.Body).Decode(&reviewreq); err != nil {
+		at.WriteError(respw, at.NewError(at.CodeInvalidBody, "Body tidak valid", err.Error()))
+		return
+	}
+	if reviewreq.Rating < 1 || reviewreq.Rating > 5 {
+		at.WriteError(respw, at.NewError(at.CodeBadRequest, "Rating tidak valid", "rating harus 1 sampai 5"))
+		return
+	}
+	product, err := atdb.GetOneDoc[model.Product](config.Mongoconn, model.ProductCollection, bson.M{"_id": reviewreq.ProductID})
+	if err != nil {
+		at.WriteError(respw, at.NewError(at.CodeNotFound, "Data produk tidak di temukan", err.Error()))
+		return
+	}
+	// hanya pembeli yang checkout-nya berisi produk ini dan barangnya sudah diterima
+	filter := bson.M{
+		"phonenumber":      payload.Id,
+		"status":           model.CheckoutDone,
+		"items.product_id": product.ID,
+	}
+	checkout, err := atdb.GetOneLatestDoc[model.Checkout](config.Mongoconn, "checkout", filter)
+	if err != nil {
+		at.WriteError(respw, at.NewError(at.CodeForbidden, "User belum membeli produk ini", "Ulasan hanya untuk pembeli dengan pesanan yang sudah selesai"))
+		return
+	}
+
+	now := time.Now()
+	update := bson.M{
+		"$set": bson.M{
+			"namalapak":   product.NamaLapak,
+			"checkout_id": checkout.ID,
+			"rating":      reviewreq.Rating,
+			"komentar":    reviewreq.Komentar,
+			"updated_at":  now,
+		},
+		"$setOnInsert": bson.M{
+			"product_id": product.ID,
+			"user_id":    payload.Id,
+			"created_at": now,
+		},
+	}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+	var review model.Review
+	filter = bson.M{"product_id": product.ID, "user_id": payload.Id}
+	err = config.Mongoconn.Collection("review").FindOneAndUpdate(context.TODO(), filter, update, opts).Decode(&review)
+	if mongo.IsDuplicateKeyError(err) {
+		// request lain baru saja membuat ulasan yang sama, ulangi sebagai update
+		err = config.Mongoconn.Collection("review").FindOneAndUpdate(context.TODO(), filter, update, opts).Decode(&review)
+	}
+	if err != nil {
+		at.WriteError(respw, at.NewError(at.CodeInternal, "Gagal menyimpan ulasan", err.Error()))
+		return
+	}
+	if err = updateProductRating(context.TODO(), product.ID); err != nil {
+		at.WriteError(respw, at.NewError(at.CodeInternal, "Gagal memperbarui rating produk", err.Error()))
+		return
+	}
+	at.WriteJSON(respw, http.StatusOK, review)
+}
+
+// GetProductReviews daftar ulasan produk terbaru
+func GetProductReviews(respw http.ResponseWriter, req *http.Request) {
+	objectId, err := primitive.ObjectIDFromHex(router.Param(req, "id"))
+	if err != nil {
+		at.WriteError(respw, at.NewError(at.CodeBadRequest, "ObjectID Tidak Valid", err.Error()))
+		return
+	}
+	opts := options.Find().SetSort(bson.M{"created_at": -1}).SetLimit(100)
+	cur, err := config.Mongoconn.Collection("review").Find(context.TODO(), bson.M{"product_id": objectId}, opts)
+	if err != nil {
+		at.WriteError(respw, at.NewError(at.CodeInternal, "Data ulasan tidak bisa diambil", err.Error()))
+		return
+	}
+	reviews := []model.Review{}
+	if err = cur.All(context.TODO(), &reviews); err != nil {
+		at.WriteError(respw, at.NewError(at.CodeInternal, "Data ulasan tidak bisa dibaca", err.Error()))
+		return
+	}
+	at.WriteJSON(respw, http.StatusOK, reviews)
+}
+
+// PutReviewReply owner lapak membalas ulasan produk lapaknya
+func PutReviewReply(respw http.ResponseWriter, req *http.Request) {
+	payload, err := decodeLoginToken(req)
+	if err != nil {
+		writeTokenError(respw, err)
+		return
+	}
+	objectId, err := primitive.ObjectIDFromHex(router.Param(req, "id"))
+	if err != nil {
+		at.WriteError(respw, at.NewError(at.CodeBadRequest, "ObjectID Tidak Valid", err.Error()))
+		return
+	}
+	var replyreq ReplyRequest
+	if err = json.NewDecoder(req.Body).Decode(&replyreq); err != nil || replyreq.Komentar == "" {
+		at.WriteError(respw, at.NewError(at.CodeInvalidBody, "Body tidak valid", "komentar balasan wajib diisi"))
+		return
+	}
+	review, err := atdb.GetOneDoc[model.Review](config.Mongoconn, "review", bson.M{"_id": objectId})
+	if err != nil {
+		at.WriteError(respw, at.NewError(at.CodeNotFound, "Data ulasan tidak di temukan", err.Error()))
+		return
+	}
+	if err = checkLapakAccess(req, rbac.LapakManage, review.NamaLapak); err != nil {
+		writeForbidden(respw, err)
+		return
+	}
+	review.Reply = &model.ReviewReply{
+		Komentar:  replyreq.Komentar,
+		By:        payload.Id,
+		CreatedAt: time.Now(),
+	}
+	if _, err = atdb.UpdateOneDoc(config.Mongoconn, "review", bson.M{"_id": review.ID}, bson.M{"reply": review.Reply}); err != nil {
+		at.WriteError(respw, at.NewError(at.CodeInternal, "Gagal memperbarui database", err.Error()))
+		return
+	}
+	at.WriteJSON(respw, http.StatusOK, review)
+}
+
+// updateProductRating menghitung ulang rata-rata rating dan jumlah ulasan lalu menyimpannya di produk
+func updateProductRating(ctx context.Context, productID primitive.ObjectID) (err error) {
+	pipeline := []bson.M{
+		{"$match": bson.M{"product_id": productID}},
+		{"$group": bson.M{"_id": nil, "avg": bson.M{"$avg": "$rating"}, "count": bson.M{"$sum": 1}}},
+	}
+	cur, err := config.Mongoconn.Collection("review").Aggregate(ctx, pipeline)
+	if err != nil {
+		return
+	}
+	var result []struct {
+		Avg   float64 `bson:"avg"`
+		Count int     `bson:"count"`
+	}
+	if err = cur.All(ctx, &result); err != nil {
+		return
+	}
+	var avg float64
+	var count int
+	if len(result) > 0 {
+		avg = math.Round(result[0].Avg*10) / 10
+		count = result[0].Count
+	}
+	_, err = config.Mongoconn.Collection(model.ProductCollection).UpdateOne(ctx, bson.M{"_id": productID},
+		bson.M{"$set": bson.M{"rating_avg": avg, "rating_count": count}})
+	return
+}
@@ -0,0 +1,65 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gocroot/config"
+	"github.com/gocroot/helper/at"
+	"github.com/gocroot/model"
+)
+
+// SecretRequest body untuk menyimpan secret ke collection secrets
+type SecretRequest struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// GetSecrets status semua secret beserta sumbernya, nilai secret tidak pernah dikirim
+func GetSecrets(respw http.ResponseWriter, req *http.Request) {
+	at.WriteJSON(respw, http.StatusOK, map[string]interface{}{
+		"loaded_at": config.Secrets.LoadedAt(),
+		"secrets":   config.Secrets.Status(),
+	})
+}
+
+// PutSecret menyimpan secret terenkripsi ke Mongo lalu memuat ulang semua secret.
+// Secret yang juga diset di env atau file tetap memakai nilai dari sana karena prioritasnya lebih tinggi.
+func PutSecret(respw http.ResponseWriter, req *http.Request) {
+	var body SecretRequest
+	if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+		at.WriteError(respw, at.NewError(at.CodeInvalidBody, "Body tidak valid", err.Error()))
+		return
+	}
+	if !config.Secrets.Known(body.Name) {
+		at.WriteError(respw, at.NewError(at.CodeBadRequest, "Secret tidak dikenal", body.Name))
+		return
+	}
+	var err error
+	if body.Value == "" {
+		err = config.SecretMongo.Delete(req.Context(), body.Name)
+	} else {
+		err = config.SecretMongo.Put(req.Context(), body.Name, body.Value)
+	}
+	if err != nil {
+		at.WriteError(respw, at.NewError(at.CodeInternal, "Gagal menyimpan secret", err.Error()))
+		return
+	}
+	reloadSecrets(respw)
+}
+
+// PostReloadSecrets memuat ulang secret dari semua provider tanpa menunggu interval reload
+func PostReloadSecrets(respw http.ResponseWriter, req *http.Request) {
+	reloadSecrets(respw)
+}
+
+// reloadSecrets secret wajib yang masih kosong dilaporkan di info, bukan dianggap gagal
+func reloadSecrets(respw http.ResponseWriter) {
+	var respn model.Response
+	respn.Status = "Success"
+	if err := config.LoadSecrets(true); err != nil {
+		respn.Info = err.Error()
+	}
+	respn.Response = config.Secrets.LoadedAt().String()
+	at.WriteJSON(respw, http.StatusOK, respn)
+}
@@ -0,0 +1,133 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gocroot/config"
+	"github.com/gocroot/helper/at"
+	"github.com/gocroot/helper/session"
+	"github.com/gocroot/model"
+)
+
+// RefreshRequest body untuk refresh token dan logout
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// RevokeRequest body untuk mencabut semua sesi user lain
+type RevokeRequest struct {
+	PhoneNumber string `json:"phonenumber"`
+}
+
+// issueSession membuat sesi baru untuk user yang berhasil login beserta token akses dan refresh token
+func issueSession(req *http.Request, phonenumber, alias string) (tokens session.Tokens, err error) {
+	sess, refresh, err := session.Create(config.Mongoconn, phonenumber, alias, req.UserAgent(), time.Now())
+	if err != nil {
+		return
+	}
+	return sessionTokens(sess, refresh)
+}
+
+func sessionTokens(sess session.Session, refresh string) (tokens session.Tokens, err error) {
+	tokens.AccessToken, err = config.TokenKeyring.EncodeSession(sess.UserID, sess.Alias, sess.ID, session.AccessTTL)
+	tokens.RefreshToken = refresh
+	tokens.ExpiresIn = int64(session.AccessTTL.Seconds())
+	tokens.SessionID = sess.ID
+	return
+}
+
+// PostRefreshToken menukar refresh token dengan token akses baru dan refresh token baru
+func PostRefreshToken(respw http.ResponseWriter, req *http.Request) {
+	var body RefreshRequest
+	if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+		at.WriteError(respw, at.NewError(at.CodeInvalidBody, "Body tidak valid", err.Error()))
+		return
+	}
+	sess, refresh, err := session.Rotate(config.Mongoconn, body.RefreshToken, time.Now())
+	if errors.Is(err, session.ErrInvalidRefresh) || errors.Is(err, session.ErrReused) {
+		at.WriteError(respw, at.NewError(at.CodeInvalidToken, "Refresh token ditolak", err.Error()))
+		return
+	}
+	var tokens session.Tokens
+	if err == nil {
+		tokens, err = sessionTokens(sess, refresh)
+	}
+	if err != nil {
+		at.WriteError(respw, at.NewError(at.CodeInternal, "Gagal refresh token", err.Error()))
+		return
+	}
+	at.WriteJSON(respw, http.StatusOK, tokens)
+}
+
+// PostLogout mencabut sesi dari token akses yang dipakai
+func PostLogout(respw http.ResponseWriter, req *http.Request) {
+	var respn model.Response
+	payload, err := decodeLoginToken(req)
+	if err != nil {
+		writeTokenError(respw, err)
+		return
+	}
+	if payload.Jti == "" {
+		at.WriteError(respw, at.NewError(at.CodeInvalidToken, "Token tidak punya sesi", "Token lama tidak terikat sesi, gunakan logout semua perangkat"))
+		return
+	}
+	if _, err = session.Revoke(config.Mongoconn, payload.Id, payload.Jti, "logout", time.Now()); err != nil {
+		at.WriteError(respw, at.NewError(at.CodeInternal, "Gagal logout", err.Error()))
+		return
+	}
+	respn.Status = "Success"
+	respn.Response = "Sesi " + payload.Jti + " sudah logout"
+	at.WriteJSON(respw, http.StatusOK, respn)
+}
+
+// PostLogoutAll mencabut semua sesi dan token user dari token akses yang dipakai
+func PostLogoutAll(respw http.ResponseWriter, req *http.Request) {
+	payload, err := decodeLoginToken(req)
+	if err != nil {
+		writeTokenError(respw, err)
+		return
+	}
+	revokeAllSessions(respw, payload.Id, "logout semua perangkat")
+}
+
+// GetSessions daftar sesi aktif milik user dari token
+func GetSessions(respw http.ResponseWriter, req *http.Request) {
+	payload, err := decodeLoginToken(req)
+	if err != nil {
+		writeTokenError(respw, err)
+		return
+	}
+	sessions, err := session.Active(config.Mongoconn, payload.Id, time.Now())
+	if err != nil {
+		at.WriteError(respw, at.NewError(at.CodeInternal, "Data sesi tidak bisa diambil", err.Error()))
+		return
+	}
+	at.WriteJSON(respw, http.StatusOK, sessions)
+}
+
+// PostRevokeUserSessions mencabut semua sesi user lain, misalnya token dicuri atau anggota tim dikeluarkan
+func PostRevokeUserSessions(respw http.ResponseWriter, req *http.Request) {
+	var body RevokeRequest
+	if err := json.NewDecoder(req.Body).Decode(&body); err != nil || body.PhoneNumber == "" {
+		at.WriteError(respw, at.NewError(at.CodeInvalidBody, "Body tidak valid", "phonenumber wajib diisi"))
+		return
+	}
+	sub, _ := requestSubject(req)
+	revokeAllSessions(respw, body.PhoneNumber, "dicabut oleh "+sub.ID)
+}
+
+func revokeAllSessions(respw http.ResponseWriter, phonenumber, reason string) {
+	var respn model.Response
+	count, err := session.RevokeAll(config.Mongoconn, phonenumber, reason, time.Now())
+	if err != nil {
+		at.WriteError(respw, at.NewError(at.CodeInternal, "Gagal mencabut sesi", err.Error()))
+		return
+	}
+	respn.Status = "Success"
+	respn.Response = strconv.FormatInt(count, 10) + " sesi " + phonenumber + " dicabut"
+	at.WriteJSON(respw, http.StatusOK, respn)
+}
@@ -0,0 +1,196 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gocroot/config"
+	"github.com/gocroot/helper/at"
+	"github.com/gocroot/helper/atdb"
+	"github.com/gocroot/helper/ongkir"
+	"github.com/gocroot/helper/rbac"
+	"github.com/gocroot/helper/router"
+	"github.com/gocroot/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// QuoteRequest body untuk menghitung ongkir dari lapak ke alamat user
+type QuoteRequest struct {
+	NamaLapak string             `json:"namalapak"`
+	AddressID primitive.ObjectID `json:"addressid,omitempty"`
+}
+
+// PutShippingRate owner lapak menyimpan tabel tarif ongkir dan titik asal pengiriman lapaknya
+func PutShippingRate(respw http.ResponseWriter, req *http.Request) {
+	var rt ongkir.RateTable
+	err := json.NewDecoder(req.Body).Decode(&rt)
+	if err != nil {
+		at.WriteError(respw, at.NewError(at.CodeInvalidBody, "Body tidak valid", err.Error()))
+		return
+	}
+	if err = ongkir.Validate(rt); err != nil {
+		at.WriteError(respw, at.NewError(at.CodeBadRequest, "Tarif ongkir tidak valid", err.Error()))
+		return
+	}
+	if err = checkLapakAccess(req, rbac.LapakManage, rt.NamaLapak); err != nil {
+		writeForbidden(respw, err)
+		return
+	}
+	// wilayah asal diambil dari region yang memuat titik origin
+	rt.Origin, err = pointRegion(rt.Origin.Longitude, rt.Origin.Latitude)
+	if err != nil {
+		at.WriteError(respw, at.NewError(at.CodeBadRequest, "Titik asal tidak valid", err.Error()))
+		return
+	}
+	rt.ID = primitive.NilObjectID
+	rt.UpdatedAt = time.Now()
+	opts := options.Replace().SetUpsert(true)
+	_, err = config.Mongoconn.Collection("shippingrate").ReplaceOne(context.TODO(), bson.M{"namalapak": rt.NamaLapak}, rt, opts)
+	if err != nil {
+		at.WriteError(respw, at.NewError(at.CodeInternal, "Gagal memperbarui database", err.Error()))
+		return
+	}
+	at.WriteJSON(respw, http.StatusOK, rt)
+}
+
+// GetShippingRate tabel tarif ongkir lapak
+func GetShippingRate(respw http.ResponseWriter, req *http.Request) {
+	rt, err := atdb.GetOneDoc[ongkir.RateTable](config.Mongoconn, "shippingrate", bson.M{"namalapak": router.Param(req, "namalapak")})
+	if err != nil {
+		at.WriteError(respw, at.NewError(at.CodeNotFound, "Tarif ongkir lapak belum diatur", err.Error()))
+		return
+	}
+	at.WriteJSON(respw, http.StatusOK, rt)
+}
+
+// PostShippingQuote menghitung ongkir dari lapak ke alamat di buku alamat user (alamat default jika addressid kosong)
+func PostShippingQuote(respw http.ResponseWriter, req *http.Request) {
+	payload, err := decodeLoginToken(req)
+	if err != nil {
+		writeTokenError(respw, err)
+		return
+	}
+	var quotereq QuoteRequest
+	if err = json.NewDecoder(req.Body).Decode(&quotereq); err != nil {
+		at.WriteError(respw, at.NewError(at.CodeInvalidBody, "Body tidak valid", err.Error()))
+		return
+	}
+	addr, err := getCheckoutAddress(quotereq.AddressID, payload.Id)
+	if err != nil {
+		at.WriteError(respw, at.NewError(at.CodeBadRequest, "Alamat tidak valid", err.Error()))
+		return
+	}
+	quote, err := shippingQuote(quotereq.NamaLapak, addr)
+	if err != nil {
+		at.WriteError(respw, at.NewError(at.CodeValidation, "Ongkir tidak bisa dihitung", err.Error()))
+		return
+	}
+	at.WriteJSON(respw, http.StatusOK, quote)
+}
+
+// shippingQuote menghitung ongkir dari titik asal lapak ke alamat tujuan dengan tabel tarif lapak
+func shippingQuote(namalapak string, addr model.Address) (quote ongkir.Quote, err error) {
+	rt, err := atdb.GetOneDoc[ongkir.RateTable](config.Mongoconn, "shippingrate", bson.M{"namalapak": namalapak})
+	if err != nil {
+		err = errors.New("tarif ongkir lapak " + namalapak + " belum diatur")
+		return
+	}
+	dest, err := addressPoint(addr)
+	if err != nil {
+		return
+	}
+	return ongkir.Calculate(rt, rt.Origin, dest)
+}
+
+// addressPoint titik tujuan dari alamat. Koordinat alamat hanya dipakai jika berada di dalam region alamat,
+// selain itu dipakai titik tengah region supaya ongkir per km tidak bisa dimurahkan dengan koordinat palsu
+func addressPoint(addr model.Address) (point ongkir.Point, err error) {
+	point = ongkir.Point{
+		Longitude:   addr.Longitude,
+		Latitude:    addr.Latitude,
+		Province:    addr.Province,
+		District:    addr.City,
+		SubDistrict: addr.SubDistrict,
+		Village:     addr.Village,
+	}
+	filter := addressRegionFilter(addr)
+	if addr.Longitude != 0 || addr.Latitude != 0 {
+		inside, cerr := regionContains(filter, addr.Longitude, addr.Latitude)
+		if cerr == nil && inside {
+			return
+		}
+	}
+	region, err := atdb.GetOneDoc[model.Region](config.MongoconnGeo, "region", filter)
+	if err != nil {
+		err = errors.New("region alamat " + addr.City + " tidak ditemukan")
+		return
+	}
+	var ok bool
+	point.Longitude, point.Latitude, ok = ongkir.Centroid(region.Border.Coordinates)
+	if !ok {
+		err = errors.New("region alamat " + addr.City + " tidak punya batas wilayah")
+	}
+	return
+}
+
+// addressRegionFilter filter region sesuai nama wilayah alamat yang sudah disamakan oleh validateAddress
+func addressRegionFilter(addr model.Address) bson.M {
+	filter := bson.M{"province": addr.Province, "district": addr.City}
+	if addr.SubDistrict != "" {
+		filter["sub_district"] = addr.SubDistrict
+	}
+	if addr.Village != "" {
+		filter["village"] = addr.Village
+	}
+	return filter
+}
+
+// regionContains apakah ada region sesuai filter yang memuat titik tersebut
+func regionContains(filter bson.M, long, lat float64) (bool, error) {
+	geo := bson.M{"border": bson.M{
+		"$geoIntersects": bson.M{
+			"$geometry": bson.M{
+				"type":        "Point",
+				"coordinates": []float64{long, lat},
+			},
+		},
+	}}
+	for k, v := range filter {
+		geo[k] = v
+	}
+	n, err := config.MongoconnGeo.Collection("region").CountDocuments(context.TODO(), geo, options.Count().SetLimit(1))
+	return n > 0, err
+}
+
+// pointRegion mencari region yang memuat titik, sama seperti GetRegion
+func pointRegion(long, lat float64) (point ongkir.Point, err error) {
+	filter := bson.M{
+		"border": bson.M{
+			"$geoIntersects": bson.M{
+				"$geometry": bson.M{
+					"type":        "Point",
+					"coordinates": []float64{long, lat},
+				},
+			},
+		},
+	}
+	region, err := atdb.GetOneDoc[model.Region](config.MongoconnGeo, "region", filter)
+	if err != nil {
+		err = errors.New("titik tidak berada di region manapun")
+		return
+	}
+	point = ongkir.Point{
+		Longitude:   long,
+		Latitude:    lat,
+		Province:    region.Province,
+		District:    region.District,
+		SubDistrict: region.SubDistrict,
+		Village:     region.Village,
+	}
+	return
+}
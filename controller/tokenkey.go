@@ -0,0 +1,88 @@
+package controller
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gocroot/config"
+	"github.com/gocroot/helper/at"
+	"github.com/gocroot/helper/atdb"
+	"github.com/gocroot/helper/watoken"
+	"github.com/gocroot/model"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// GetTokenKeys daftar kunci token di Mongo beserta kid yang sedang aktif di keyring, private key tidak ikut dikirim
+func GetTokenKeys(respw http.ResponseWriter, req *http.Request) {
+	keys, err := atdb.GetAllDoc[[]config.TokenKey](config.Mongoconn, config.TokenKeyCollection, bson.M{})
+	if err != nil {
+		at.WriteError(respw, at.NewError(at.CodeInternal, "Data kunci token tidak bisa diambil", err.Error()))
+		return
+	}
+	at.WriteJSON(respw, http.StatusOK, map[string]interface{}{
+		"signing": config.TokenKeyring.SigningKid(),
+		"keyring": config.TokenKeyring.KeyIDs(),
+		"stored":  keys,
+	})
+}
+
+// PostRotateTokenKey membuat signing key baru, kunci lama tetap aktif untuk verifikasi sehingga token yang sudah ada tetap berlaku.
+// Private key disimpan terenkripsi AESKEY, rotasi ditolak jika AESKEY belum diset.
+func PostRotateTokenKey(respw http.ResponseWriter, req *http.Request) {
+	var respn model.Response
+	key, err := config.SealTokenKey(config.TokenKey{
+		Key:       watoken.GenerateKeyWithID(),
+		Active:    true,
+		Signing:   true,
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		at.WriteError(respw, at.NewError(at.CodeInternal, "Gagal mengenkripsi kunci token", err.Error()))
+		return
+	}
+	if _, err = atdb.InsertOneDoc(config.Mongoconn, config.TokenKeyCollection, key); err != nil {
+		at.WriteError(respw, at.NewError(at.CodeInternal, "Gagal menyimpan kunci token", err.Error()))
+		return
+	}
+	_, err = config.Mongoconn.Collection(config.TokenKeyCollection).UpdateMany(context.TODO(),
+		bson.M{"kid": bson.M{"$ne": key.ID}, "signing": true},
+		bson.M{"$set": bson.M{"signing": false}})
+	if err == nil {
+		err = config.LoadTokenKeys(true)
+	}
+	if err != nil {
+		at.WriteError(respw, at.NewError(at.CodeInternal, "Gagal memuat ulang kunci token", err.Error()))
+		return
+	}
+	respn.Status = "Success"
+	respn.Info = key.ID
+	respn.Response = "Signing key sekarang " + config.TokenKeyring.SigningKid()
+	at.WriteJSON(respw, http.StatusOK, respn)
+}
+
+// DeleteTokenKey menonaktifkan kunci dari query kid, token yang ditandatangani kunci tersebut tidak berlaku lagi
+func DeleteTokenKey(respw http.ResponseWriter, req *http.Request) {
+	var respn model.Response
+	kid := req.URL.Query().Get("kid")
+	if kid == "" || kid == config.TokenKeyring.SigningKid() {
+		at.WriteError(respw, at.NewError(at.CodeBadRequest, "Kunci tidak bisa dinonaktifkan", "kid kosong atau masih dipakai sebagai signing key, rotasi dulu"))
+		return
+	}
+	res, err := config.Mongoconn.Collection(config.TokenKeyCollection).UpdateOne(context.TODO(),
+		bson.M{"kid": kid}, bson.M{"$set": bson.M{"active": false, "signing": false}})
+	if err == nil && res.MatchedCount == 0 {
+		at.WriteError(respw, at.NewError(at.CodeNotFound, "Kunci tidak di temukan", "kid "+kid+" tidak ada di "+config.TokenKeyCollection))
+		return
+	}
+	if err == nil {
+		err = config.LoadTokenKeys(true)
+	}
+	if err != nil {
+		at.WriteError(respw, at.NewError(at.CodeInternal, "Gagal menonaktifkan kunci token", err.Error()))
+		return
+	}
+	respn.Status = "Success"
+	respn.Response = "Kunci " + kid + " dinonaktifkan"
+	at.WriteJSON(respw, http.StatusOK, respn)
+}
@@ -0,0 +1,328 @@
+package controller
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gocroot/config"
+	"github.com/gocroot/helper/at"
+	"github.com/gocroot/helper/atdb"
+	"github.com/gocroot/helper/rbac"
+	"github.com/gocroot/helper/secrets"
+	"github.com/gocroot/helper/session"
+	"github.com/gocroot/helper/totp"
+	"github.com/gocroot/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	totpIssuer         = "Bukupedia"
+	totpRecoveryCount  = 10
+	mfaChallengeTTL    = 5 * time.Minute
+	mfaChallengeTrials = 5
+)
+
+// hanya akun yang bisa menerbitkan buku ISBN yang boleh memakai TOTP
+func totpEligible(sub rbac.Subject) bool {
+	return sub.HasRole(rbac.RoleManager) || sub.HasRole(rbac.RoleEditor) || sub.HasRole(rbac.RoleAdmin)
+}
+
+// PostTOTPEnroll membuat secret baru yang belum aktif dan mengembalikan uri otpauth untuk QR code
+func PostTOTPEnroll(respw http.ResponseWriter, req *http.Request) {
+	payload, err := decodeLoginToken(req)
+	if err != nil {
+		writeTokenError(respw, err)
+		return
+	}
+	sub, err := loadSubject(payload.Id)
+	if err != nil {
+		at.WriteError(respw, at.NewError(at.CodeInternal, "Data user tidak bisa diambil", err.Error()))
+		return
+	}
+	if !totpEligible(sub) {
+		at.WriteError(respw, at.NewError(at.CodeForbidden, "Akses ditolak", "TOTP hanya untuk akun manager atau editor"))
+		return
+	}
+	existing, err := atdb.GetOneDoc[model.TOTPEnrollment](config.Mongoconn, model.TOTPCollection, bson.M{"_id": payload.Id})
+	if err == nil && existing.Enabled {
+		at.WriteError(respw, at.NewError(at.CodeConflict, "TOTP sudah aktif", "Nonaktifkan TOTP terlebih dahulu untuk mendaftarkan authenticator baru"))
+		return
+	}
+	secret, err := totp.GenerateSecret()
+	var sealed string
+	if err == nil {
+		sealed, err = secrets.Encrypt(secret, config.AESKey)
+	}
+	if err != nil {
+		at.WriteError(respw, at.NewError(at.CodeInternal, "Gagal membuat secret TOTP", err.Error()))
+		return
+	}
+	doc := model.TOTPEnrollment{
+		ID:        payload.Id,
+		Secret:    sealed,
+		CreatedAt: time.Now(),
+	}
+	// pendaftaran yang belum dikonfirmasi boleh ditimpa
+	_, err = config.Mongoconn.Collection(model.TOTPCollection).ReplaceOne(context.TODO(),
+		bson.M{"_id": payload.Id, "enabled": bson.M{"$ne": true}}, doc, options.Replace().SetUpsert(true))
+	if err != nil {
+		at.WriteError(respw, at.NewError(at.CodeInternal, "Gagal menyimpan secret TOTP", err.Error()))
+		return
+	}
+	at.WriteJSON(respw, http.StatusOK, map[string]interface{}{
+		"secret":      secret,
+		"otpauth_uri": totp.ProvisioningURI(totpIssuer, payload.Id, secret),
+	})
+}
+
+// PostTOTPConfirm mengaktifkan TOTP setelah kode pertama dari authenticator cocok, kode pemulihan hanya ditampilkan sekali
+func PostTOTPConfirm(respw http.ResponseWriter, req *http.Request) {
+	payload, err := decodeLoginToken(req)
+	if err != nil {
+		writeTokenError(respw, err)
+		return
+	}
+	var body model.TOTPRequest
+	if err = json.NewDecoder(req.Body).Decode(&body); err != nil {
+		at.WriteError(respw, at.NewError(at.CodeInvalidBody, "Body tidak valid", err.Error()))
+		return
+	}
+	doc, err := atdb.GetOneDoc[model.TOTPEnrollment](config.Mongoconn, model.TOTPCollection, bson.M{"_id": payload.Id, "enabled": false})
+	if err != nil {
+		at.WriteError(respw, at.NewError(at.CodeNotFound, "Pendaftaran TOTP tidak ditemukan", "Lakukan enroll terlebih dahulu"))
+		return
+	}
+	secret, err := secrets.Decrypt(doc.Secret, config.AESKey)
+	if err != nil {
+		at.WriteError(respw, at.NewError(at.CodeInternal, "Secret TOTP tidak bisa dibaca", err.Error()))
+		return
+	}
+	step, ok := totp.Verify(secret, body.Code, time.Now(), doc.LastStep)
+	if !ok {
+		at.WriteError(respw, at.NewError(at.CodeUnauthorized, "Kode TOTP salah", "Kode dari authenticator tidak cocok"))
+		return
+	}
+	codes, hashes, err := totp.GenerateRecoveryCodes(totpRecoveryCount)
+	if err != nil {
+		at.WriteError(respw, at.NewError(at.CodeInternal, "Gagal membuat kode pemulihan", err.Error()))
+		return
+	}
+	res, err := config.Mongoconn.Collection(model.TOTPCollection).UpdateOne(context.TODO(),
+		bson.M{"_id": payload.Id, "enabled": false, "secret": doc.Secret},
+		bson.M{"$set": bson.M{"enabled": true, "recovery_hashes": hashes, "last_step": step, "enabled_at": time.Now()}})
+	if err == nil && res.ModifiedCount == 0 {
+		err = errors.New("pendaftaran TOTP berubah, silakan enroll ulang")
+	}
+	if err != nil {
+		at.WriteError(respw, at.NewError(at.CodeConflict, "Gagal mengaktifkan TOTP", err.Error()))
+		return
+	}
+	at.WriteJSON(respw, http.StatusOK, map[string]interface{}{
+		"status":         "Success",
+		"recovery_codes": codes,
+	})
+}
+
+// DeleteTOTP menonaktifkan TOTP, wajib menyertakan kode TOTP atau kode pemulihan
+func DeleteTOTP(respw http.ResponseWriter, req *http.Request) {
+	var respn model.Response
+	doc, ok := totpVerifiedRequest(respw, req)
+	if !ok {
+		return
+	}
+	_, err := config.Mongoconn.Collection(model.TOTPCollection).DeleteOne(context.TODO(), bson.M{"_id": doc.ID})
+	if err != nil {
+		at.WriteError(respw, at.NewError(at.CodeInternal, "Gagal menonaktifkan TOTP", err.Error()))
+		return
+	}
+	respn.Status = "Success"
+	respn.Response = "TOTP " + doc.ID + " sudah dinonaktifkan"
+	at.WriteJSON(respw, http.StatusOK, respn)
+}
+
+// PostTOTPRecoveryCodes membuat ulang kode pemulihan, kode lama tidak berlaku lagi
+func PostTOTPRecoveryCodes(respw http.ResponseWriter, req *http.Request) {
+	doc, ok := totpVerifiedRequest(respw, req)
+	if !ok {
+		return
+	}
+	codes, hashes, err := totp.GenerateRecoveryCodes(totpRecoveryCount)
+	if err == nil {
+		_, err = config.Mongoconn.Collection(model.TOTPCollection).UpdateOne(context.TODO(),
+			bson.M{"_id": doc.ID, "enabled": true}, bson.M{"$set": bson.M{"recovery_hashes": hashes}})
+	}
+	if err != nil {
+		at.WriteError(respw, at.NewError(at.CodeInternal, "Gagal membuat kode pemulihan", err.Error()))
+		return
+	}
+	at.WriteJSON(respw, http.StatusOK, map[string]interface{}{
+		"status":         "Success",
+		"recovery_codes": codes,
+	})
+}
+
+// totpVerifiedRequest membaca token dan body lalu memastikan kode TOTP atau kode pemulihan benar
+func totpVerifiedRequest(respw http.ResponseWriter, req *http.Request) (doc model.TOTPEnrollment, ok bool) {
+	p, err := decodeLoginToken(req)
+	if err != nil {
+		writeTokenError(respw, err)
+		return
+	}
+	var body model.TOTPRequest
+	if err = json.NewDecoder(req.Body).Decode(&body); err != nil {
+		at.WriteError(respw, at.NewError(at.CodeInvalidBody, "Body tidak valid", err.Error()))
+		return
+	}
+	doc, err = atdb.GetOneDoc[model.TOTPEnrollment](config.Mongoconn, model.TOTPCollection, bson.M{"_id": p.Id, "enabled": true})
+	if err != nil {
+		at.WriteError(respw, at.NewError(at.CodeNotFound, "TOTP belum aktif", "Akun ini belum mengaktifkan TOTP"))
+		return
+	}
+	if !limitAuth(respw, req, loginRule, p.Id) {
+		return
+	}
+	if ok, err = verifySecondFactor(doc, body); err != nil {
+		at.WriteError(respw, at.NewError(at.CodeInternal, "Gagal memeriksa kode TOTP", err.Error()))
+		return
+	}
+	if !ok {
+		authFailed(req, p.Id)
+		at.WriteError(respw, at.NewError(at.CodeUnauthorized, "Kode TOTP salah", "Kode TOTP atau kode pemulihan tidak cocok"))
+	}
+	return
+}
+
+// verifySecondFactor mencocokkan kode TOTP atau kode pemulihan. Keduanya dipakai secara atomik
+// sehingga kode yang sama tidak bisa diterima dua kali walaupun dikirim bersamaan.
+func verifySecondFactor(doc model.TOTPEnrollment, body model.TOTPRequest) (bool, error) {
+	col := config.Mongoconn.Collection(model.TOTPCollection)
+	if body.RecoveryCode != "" {
+		hash := totp.HashRecoveryCode(body.RecoveryCode)
+		res, err := col.UpdateOne(context.TODO(),
+			bson.M{"_id": doc.ID, "enabled": true, "recovery_hashes": hash},
+			bson.M{"$pull": bson.M{"recovery_hashes": hash}})
+		if err != nil {
+			return false, err
+		}
+		return res.ModifiedCount == 1, nil
+	}
+	secret, err := secrets.Decrypt(doc.Secret, config.AESKey)
+	if err != nil {
+		return false, err
+	}
+	step, ok := totp.Verify(secret, body.Code, time.Now(), doc.LastStep)
+	if !ok {
+		return false, nil
+	}
+	res, err := col.UpdateOne(context.TODO(),
+		bson.M{"_id": doc.ID, "enabled": true, "last_step": bson.M{"$lt": step}},
+		bson.M{"$set": bson.M{"last_step": step}})
+	if err != nil {
+		return false, err
+	}
+	return res.ModifiedCount == 1, nil
+}
+
+// requireTOTP dipanggil setelah faktor pertama login berhasil. Jika user mengaktifkan TOTP,
+// token tidak diterbitkan dan yang dikirim adalah mfa_token untuk ditukar di /auth/totp/verify.
+// Mengembalikan true jika response sudah ditulis.
+func requireTOTP(respw http.ResponseWriter, phonenumber, alias string) bool {
+	_, err := atdb.GetOneDoc[model.TOTPEnrollment](config.Mongoconn, model.TOTPCollection, bson.M{"_id": phonenumber, "enabled": true})
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return false
+	}
+	var token string
+	if err == nil {
+		token, err = randomToken()
+	}
+	if err == nil {
+		_, err = atdb.InsertOneDoc(config.Mongoconn, model.MFAChallengeCollection, model.MFAChallenge{
+			ID:          session.HashToken(token),
+			PhoneNumber: phonenumber,
+			Alias:       alias,
+			ExpiresAt:   time.Now().Add(mfaChallengeTTL),
+		})
+	}
+	if err != nil {
+		// gagal tertutup, login tidak boleh lolos tanpa faktor kedua
+		at.WriteError(respw, at.NewError(at.CodeInternal, "Gagal memeriksa TOTP", err.Error()))
+		return true
+	}
+	at.WriteJSON(respw, http.StatusOK, map[string]interface{}{
+		"message":       "Masukkan kode dari aplikasi authenticator",
+		"totp_required": true,
+		"mfa_token":     token,
+		"expires_in":    int64(mfaChallengeTTL.Seconds()),
+	})
+	return true
+}
+
+// PostTOTPVerify menukar mfa_token dan kode TOTP atau kode pemulihan dengan token login
+func PostTOTPVerify(respw http.ResponseWriter, req *http.Request) {
+	var body model.TOTPRequest
+	if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+		at.WriteError(respw, at.NewError(at.CodeInvalidBody, "Body tidak valid", err.Error()))
+		return
+	}
+	id := session.HashToken(body.MFAToken)
+	col := config.Mongoconn.Collection(model.MFAChallengeCollection)
+	var challenge model.MFAChallenge
+	err := col.FindOneAndUpdate(context.TODO(),
+		bson.M{"_id": id, "expires_at": bson.M{"$gt": time.Now()}, "attempts": bson.M{"$lt": mfaChallengeTrials}},
+		bson.M{"$inc": bson.M{"attempts": 1}}).Decode(&challenge)
+	if err != nil {
+		at.WriteError(respw, at.NewError(at.CodeInvalidToken, "mfa_token tidak valid", "Tantangan login sudah kedaluwarsa atau terlalu banyak percobaan, silakan login ulang"))
+		return
+	}
+	if !limitAuth(respw, req, loginRule, challenge.PhoneNumber) {
+		return
+	}
+	doc, err := atdb.GetOneDoc[model.TOTPEnrollment](config.Mongoconn, model.TOTPCollection, bson.M{"_id": challenge.PhoneNumber, "enabled": true})
+	var ok bool
+	if err == nil {
+		ok, err = verifySecondFactor(doc, body)
+	}
+	if err != nil {
+		at.WriteError(respw, at.NewError(at.CodeInternal, "Gagal memeriksa kode TOTP", err.Error()))
+		return
+	}
+	if !ok {
+		authFailed(req, challenge.PhoneNumber)
+		at.WriteError(respw, at.NewError(at.CodeUnauthorized, "Kode TOTP salah", "Kode TOTP atau kode pemulihan tidak cocok"))
+		return
+	}
+	authSucceeded(req, challenge.PhoneNumber)
+	// mfa_token hanya boleh dipakai sekali
+	res, err := col.DeleteOne(context.TODO(), bson.M{"_id": id})
+	if err != nil || res.DeletedCount == 0 {
+		at.WriteError(respw, at.NewError(at.CodeInvalidToken, "mfa_token sudah dipakai", "Silakan login ulang"))
+		return
+	}
+	tokens, err := issueSession(req, challenge.PhoneNumber, challenge.Alias)
+	if err != nil {
+		at.WriteError(respw, at.NewError(at.CodeInternal, "Gagal membuat token", err.Error()))
+		return
+	}
+	at.WriteJSON(respw, http.StatusOK, map[string]interface{}{
+		"message":       "Authenticated successfully",
+		"name":          challenge.Alias,
+		"token":         tokens.AccessToken,
+		"refresh_token": tokens.RefreshToken,
+		"expires_in":    tokens.ExpiresIn,
+	})
+}
+
+func randomToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
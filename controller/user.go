@@ -6,10 +6,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/gocroot/config"
+	"github.com/gocroot/helper/router"
 	"github.com/gocroot/model"
 	"github.com/whatsauth/itmodel"
 	"go.mongodb.org/mongo-driver/bson"
@@ -21,45 +23,32 @@ import (
 	"github.com/gocroot/helper/gcallapi"
 	"github.com/gocroot/helper/lms"
 	"github.com/gocroot/helper/report"
-	"github.com/gocroot/helper/watoken"
 	"github.com/gocroot/helper/whatsauth"
 )
 
 func GetDataUserFromApi(respw http.ResponseWriter, req *http.Request) {
-	payload, err := watoken.Decode(config.PublicKeyWhatsAuth, at.GetLoginFromHeader(req))
+	payload, err := config.TokenKeyring.Decode(at.GetLoginFromHeader(req))
 	if err != nil {
-		var respn model.Response
-		respn.Status = "Error : Token Tidak Valid "
-		respn.Info = at.GetSecretFromHeader(req)
-		respn.Location = "Decode Token Error: " + at.GetLoginFromHeader(req)
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusForbidden, respn)
+		at.WriteError(respw, at.NewError(at.CodeInvalidToken, "Token Tidak Valid", err.Error()))
 		return
 	}
 	userdt := lms.GetDataFromAPI(payload.Id)
 	if userdt.Data.Fullname == "" {
-		at.WriteJSON(respw, http.StatusNotFound, userdt)
+		at.WriteError(respw, at.NewError(at.CodeNotFound, "Data user LMS tidak di temukan", payload.Id))
 		return
 	}
 	at.WriteJSON(respw, http.StatusOK, userdt)
 }
 
 func GetDataUser(respw http.ResponseWriter, req *http.Request) {
-	payload, err := watoken.Decode(config.PublicKeyWhatsAuth, at.GetLoginFromHeader(req))
+	payload, err := config.TokenKeyring.Decode(at.GetLoginFromHeader(req))
 	if err != nil {
-		var respn model.Response
-		respn.Status = "Error : Token Tidak Valid "
-		respn.Info = config.PublicKeyWhatsAuth
-		respn.Location = "Decode Token Error: " + at.GetLoginFromHeader(req)
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusForbidden, respn)
+		at.WriteError(respw, at.NewError(at.CodeInvalidToken, "Token Tidak Valid", err.Error()))
 		return
 	}
 	docuser, err := atdb.GetOneDoc[model.Userdomyikado](config.Mongoconn, "user", primitive.M{"phonenumber": payload.Id})
 	if err != nil {
-		docuser.PhoneNumber = payload.Id
-		docuser.Name = payload.Alias
-		at.WriteJSON(respw, http.StatusNotFound, docuser)
+		at.WriteError(respw, at.NewError(at.CodeNotFound, "Data user tidak di temukan", err.Error()))
 		return
 	}
 	docuser.Name = payload.Alias
@@ -68,33 +57,26 @@ func GetDataUser(respw http.ResponseWriter, req *http.Request) {
 
 // melakukan pengecekan apakah suda link device klo ada generate token 5tahun
 func PutTokenDataUser(respw http.ResponseWriter, req *http.Request) {
-	payload, err := watoken.Decode(config.PublicKeyWhatsAuth, at.GetLoginFromHeader(req))
+	payload, err := config.TokenKeyring.Decode(at.GetLoginFromHeader(req))
 	if err != nil {
-		var respn model.Response
-		respn.Status = "Error : Token Tidak Valid "
-		respn.Info = at.GetSecretFromHeader(req)
-		respn.Location = "Decode Token Error: " + at.GetLoginFromHeader(req)
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusForbidden, respn)
+		at.WriteError(respw, at.NewError(at.CodeInvalidToken, "Token Tidak Valid", err.Error()))
 		return
 	}
 	docuser, err := atdb.GetOneDoc[model.Userdomyikado](config.Mongoconn, "user", primitive.M{"phonenumber": payload.Id})
 	if err != nil {
-		docuser.PhoneNumber = payload.Id
-		docuser.Name = payload.Alias
-		at.WriteJSON(respw, http.StatusNotFound, docuser)
+		at.WriteError(respw, at.NewError(at.CodeNotFound, "Data user tidak di temukan", err.Error()))
 		return
 	}
 	docuser.Name = payload.Alias
 	hcode, qrstat, err := atapi.Get[model.QRStatus](config.WAAPIGetDevice + at.GetLoginFromHeader(req))
 	if err != nil {
-		at.WriteJSON(respw, http.StatusMisdirectedRequest, docuser)
+		at.WriteError(respw, at.NewError(at.CodeUpstream, "Status device WhatsApp tidak bisa diambil", err.Error()))
 		return
 	}
 	if hcode == http.StatusOK && !qrstat.Status {
-		docuser.LinkedDevice, err = watoken.EncodeforHours(docuser.PhoneNumber, docuser.Name, config.PrivateKey, 43830)
+		docuser.LinkedDevice, err = config.TokenKeyring.EncodeforHours(docuser.PhoneNumber, docuser.Name, 43830)
 		if err != nil {
-			at.WriteJSON(respw, http.StatusFailedDependency, docuser)
+			at.WriteError(respw, at.NewError(at.CodeInternal, "Gagal membuat token", err.Error()))
 			return
 		}
 	} else {
@@ -102,62 +84,57 @@ func PutTokenDataUser(respw http.ResponseWriter, req *http.Request) {
 	}
 	_, err = atdb.ReplaceOneDoc(config.Mongoconn, "user", primitive.M{"phonenumber": payload.Id}, docuser)
 	if err != nil {
-		at.WriteJSON(respw, http.StatusExpectationFailed, docuser)
+		at.WriteError(respw, at.NewError(at.CodeInternal, "Gagal memperbarui data user", err.Error()))
 		return
 	}
 	at.WriteJSON(respw, http.StatusOK, docuser)
 }
 
+// profileUser hanya menyalin isian profil dari client. Role, team, identitas, email dan password
+// hanya boleh diubah lewat alur khusus seperti PutUserRole, penautan identitas dan auth.
+func profileUser(usr model.Userdomyikado) model.Userdomyikado {
+	return model.Userdomyikado{
+		NIK:          usr.NIK,
+		Pekerjaan:    usr.Pekerjaan,
+		AlamatRumah:  usr.AlamatRumah,
+		AlamatKantor: usr.AlamatKantor,
+		Bio:          usr.Bio,
+	}
+}
+
 func PostDataUser(respw http.ResponseWriter, req *http.Request) {
-	payload, err := watoken.Decode(config.PublicKeyWhatsAuth, at.GetLoginFromHeader(req))
+	payload, err := config.TokenKeyring.Decode(at.GetLoginFromHeader(req))
 	if err != nil {
-		var respn model.Response
-		respn.Status = "Error : Token Tidak Valid"
-		respn.Info = at.GetSecretFromHeader(req)
-		respn.Location = "Decode Token Error"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusForbidden, respn)
+		at.WriteError(respw, at.NewError(at.CodeInvalidToken, "Token Tidak Valid", err.Error()))
 		return
 	}
 	var usr model.Userdomyikado
 	err = json.NewDecoder(req.Body).Decode(&usr)
 	if err != nil {
-		var respn model.Response
-		respn.Status = "Error : Body tidak valid"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, at.NewError(at.CodeInvalidBody, "Body tidak valid", err.Error()))
 		return
 	}
 	//pengecekan isian usr
 	if usr.NIK == "" || usr.Pekerjaan == "" || usr.AlamatRumah == "" || usr.AlamatKantor == "" {
-		var respn model.Response
-		respn.Status = "Isian tidak lengkap"
-		respn.Response = "Mohon isi lengkap NIK, Pekerjaan, dan kedua alamat"
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, at.NewError(at.CodeBadRequest, "Isian tidak lengkap", "Mohon isi lengkap NIK, Pekerjaan, dan kedua alamat"))
 		return
 	}
 	docuser, err := atdb.GetOneDoc[model.Userdomyikado](config.Mongoconn, "user", primitive.M{"phonenumber": payload.Id})
 	if err != nil {
-		usr.PhoneNumber = payload.Id
-		usr.Name = payload.Alias
-		idusr, err := atdb.InsertOneDoc(config.Mongoconn, "user", usr)
+		newusr := profileUser(usr)
+		newusr.PhoneNumber = payload.Id
+		newusr.Name = payload.Alias
+		newusr.ID, err = atdb.InsertOneDoc(config.Mongoconn, "user", newusr)
 		if err != nil {
-			var respn model.Response
-			respn.Status = "Gagal Insert Database"
-			respn.Response = err.Error()
-			at.WriteJSON(respw, http.StatusNotModified, respn)
+			at.WriteError(respw, at.NewError(at.CodeInternal, "Gagal Insert Database", err.Error()))
 			return
 		}
-		usr.ID = idusr
-		at.WriteJSON(respw, http.StatusOK, usr)
+		at.WriteJSON(respw, http.StatusOK, newusr)
 		return
 	}
 	//jika email belum gsign maka gsign dulu
 	if docuser.Email == "" {
-		var respn model.Response
-		respn.Status = "Email belum terdaftar"
-		respn.Response = "Mohon lakukan google sign in dahulu agar email bisa terdaftar"
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, at.NewError(at.CodeBadRequest, "Email belum terdaftar", "Mohon lakukan google sign in dahulu agar email bisa terdaftar"))
 		return
 	}
 	docuser.NIK = usr.NIK
@@ -166,10 +143,7 @@ func PostDataUser(respw http.ResponseWriter, req *http.Request) {
 	docuser.AlamatKantor = usr.AlamatKantor
 	_, err = atdb.ReplaceOneDoc(config.Mongoconn, "user", primitive.M{"phonenumber": payload.Id}, docuser)
 	if err != nil {
-		var respn model.Response
-		respn.Status = "Gagal replaceonedoc"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusConflict, respn)
+		at.WriteError(respw, at.NewError(at.CodeInternal, "Gagal replaceonedoc", err.Error()))
 		return
 	}
 	//melakukan update di seluruh member project
@@ -188,18 +162,12 @@ func PostDataUser(respw http.ResponseWriter, req *http.Request) {
 		memberToDelete := model.Userdomyikado{PhoneNumber: docuser.PhoneNumber}
 		_, err := atdb.DeleteDocFromArray[model.Userdomyikado](config.Mongoconn, "project", prj.ID, "members", memberToDelete)
 		if err != nil {
-			var respn model.Response
-			respn.Status = "Error : Data project tidak di temukan"
-			respn.Response = err.Error()
-			at.WriteJSON(respw, http.StatusNotFound, respn)
+			at.WriteError(respw, at.NewError(at.CodeNotFound, "Data project tidak di temukan", err.Error()))
 			return
 		}
 		_, err = atdb.AddDocToArray[model.Userdomyikado](config.Mongoconn, "project", prj.ID, "members", docuser)
 		if err != nil {
-			var respn model.Response
-			respn.Status = "Error : Gagal menambahkan member ke project"
-			respn.Response = err.Error()
-			at.WriteJSON(respw, http.StatusExpectationFailed, respn)
+			at.WriteError(respw, at.NewError(at.CodeInternal, "Gagal menambahkan member ke project", err.Error()))
 			return
 		}
 
@@ -209,47 +177,33 @@ func PostDataUser(respw http.ResponseWriter, req *http.Request) {
 }
 
 func PostDataBioUser(respw http.ResponseWriter, req *http.Request) {
-	payload, err := watoken.Decode(config.PublicKeyWhatsAuth, at.GetLoginFromHeader(req))
+	payload, err := config.TokenKeyring.Decode(at.GetLoginFromHeader(req))
 	if err != nil {
-		var respn model.Response
-		respn.Status = "Error : Token Tidak Valid"
-		respn.Info = at.GetSecretFromHeader(req)
-		respn.Location = "Decode Token Error"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusForbidden, respn)
+		at.WriteError(respw, at.NewError(at.CodeInvalidToken, "Token Tidak Valid", err.Error()))
 		return
 	}
 	var usr model.Userdomyikado
 	err = json.NewDecoder(req.Body).Decode(&usr)
 	if err != nil {
-		var respn model.Response
-		respn.Status = "Error : Body tidak valid"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, at.NewError(at.CodeInvalidBody, "Body tidak valid", err.Error()))
 		return
 	}
 	docuser, err := atdb.GetOneDoc[model.Userdomyikado](config.Mongoconn, "user", primitive.M{"phonenumber": payload.Id})
 	if err != nil {
-		usr.PhoneNumber = payload.Id
-		usr.Name = payload.Alias
-		idusr, err := atdb.InsertOneDoc(config.Mongoconn, "user", usr)
+		newusr := profileUser(usr)
+		newusr.PhoneNumber = payload.Id
+		newusr.Name = payload.Alias
+		newusr.ID, err = atdb.InsertOneDoc(config.Mongoconn, "user", newusr)
 		if err != nil {
-			var respn model.Response
-			respn.Status = "Gagal Insert Database"
-			respn.Response = err.Error()
-			at.WriteJSON(respw, http.StatusNotModified, respn)
+			at.WriteError(respw, at.NewError(at.CodeInternal, "Gagal Insert Database", err.Error()))
 			return
 		}
-		usr.ID = idusr
-		at.WriteJSON(respw, http.StatusOK, usr)
+		at.WriteJSON(respw, http.StatusOK, newusr)
 		return
 	}
 	//check profpic apakah kosong  atau engga
 	if docuser.ProfilePicture == "" {
-		var respn model.Response
-		respn.Status = "Belum ada Profile Picture"
-		respn.Response = "Mohon upload dahulu profile picture anda pada form yang disediakan"
-		at.WriteJSON(respw, http.StatusConflict, respn)
+		at.WriteError(respw, at.NewError(at.CodeConflict, "Belum ada Profile Picture", "Mohon upload dahulu profile picture anda pada form yang disediakan"))
 		return
 	}
 
@@ -258,10 +212,7 @@ func PostDataBioUser(respw http.ResponseWriter, req *http.Request) {
 	postingan = strings.ReplaceAll(postingan, "##BIO##", usr.Bio)
 	bpost, err := gcallapi.PostToBlogger(config.Mongoconn, docuser.URLBio, "2587271685863777988", docuser.Name, postingan)
 	if err != nil {
-		var respn model.Response
-		respn.Status = "Gagal post ke blogger"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusConflict, respn)
+		at.WriteError(respw, at.NewError(at.CodeUpstream, "Gagal post ke blogger", err.Error()))
 		return
 	}
 	//update data content
@@ -271,10 +222,7 @@ func PostDataBioUser(respw http.ResponseWriter, req *http.Request) {
 	//update user data
 	_, err = atdb.ReplaceOneDoc(config.Mongoconn, "user", primitive.M{"phonenumber": payload.Id}, docuser)
 	if err != nil {
-		var respn model.Response
-		respn.Status = "Gagal replaceonedoc"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusConflict, respn)
+		at.WriteError(respw, at.NewError(at.CodeInternal, "Gagal replaceonedoc", err.Error()))
 		return
 	}
 	//melakukan update di seluruh member project
@@ -293,18 +241,12 @@ func PostDataBioUser(respw http.ResponseWriter, req *http.Request) {
 		memberToDelete := model.Userdomyikado{PhoneNumber: docuser.PhoneNumber}
 		_, err := atdb.DeleteDocFromArray[model.Userdomyikado](config.Mongoconn, "project", prj.ID, "members", memberToDelete)
 		if err != nil {
-			var respn model.Response
-			respn.Status = "Error : Data project tidak di temukan"
-			respn.Response = err.Error()
-			at.WriteJSON(respw, http.StatusNotFound, respn)
+			at.WriteError(respw, at.NewError(at.CodeNotFound, "Data project tidak di temukan", err.Error()))
 			return
 		}
 		_, err = atdb.AddDocToArray[model.Userdomyikado](config.Mongoconn, "project", prj.ID, "members", docuser)
 		if err != nil {
-			var respn model.Response
-			respn.Status = "Error : Gagal menambahkan member ke project"
-			respn.Response = err.Error()
-			at.WriteJSON(respw, http.StatusExpectationFailed, respn)
+			at.WriteError(respw, at.NewError(at.CodeInternal, "Gagal menambahkan member ke project", err.Error()))
 			return
 		}
 
@@ -315,32 +257,30 @@ func PostDataBioUser(respw http.ResponseWriter, req *http.Request) {
 
 func PostDataUserFromWA(respw http.ResponseWriter, req *http.Request) {
 	var resp itmodel.Response
-	prof, err := whatsauth.GetAppProfile(at.GetParam(req), config.Mongoconn)
+	prof, err := whatsauth.GetAppProfile(router.Param(req, "nomorwa"), config.Mongoconn)
 	if err != nil {
-		resp.Response = err.Error()
-		at.WriteJSON(respw, http.StatusBadRequest, resp)
+		at.WriteError(respw, at.NewError(at.CodeNotFound, "Profile WhatsApp tidak di temukan", err.Error()))
 		return
 	}
 	if at.GetSecretFromHeader(req) != prof.Secret {
-		resp.Response = "Salah secret: " + at.GetSecretFromHeader(req)
-		at.WriteJSON(respw, http.StatusUnauthorized, resp)
+		at.WriteError(respw, at.NewError(at.CodeUnauthorized, "Salah secret", ""))
 		return
 	}
 	var usr model.Userdomyikado
 	err = json.NewDecoder(req.Body).Decode(&usr)
 	if err != nil {
-		resp.Response = "Error : Body tidak valid"
-		resp.Info = err.Error()
-		at.WriteJSON(respw, http.StatusBadRequest, resp)
+		at.WriteError(respw, at.NewError(at.CodeInvalidBody, "", err.Error()))
 		return
 	}
 	docuser, err := atdb.GetOneDoc[model.Userdomyikado](config.Mongoconn, "user", primitive.M{"phonenumber": usr.PhoneNumber})
 	if err != nil {
-		idusr, err := atdb.InsertOneDoc(config.Mongoconn, "user", usr)
+		newusr := profileUser(usr)
+		newusr.PhoneNumber = usr.PhoneNumber
+		newusr.Name = usr.Name
+		newusr.Email = usr.Email
+		idusr, err := atdb.InsertOneDoc(config.Mongoconn, "user", newusr)
 		if err != nil {
-			resp.Response = "Gagal Insert Database"
-			resp.Info = err.Error()
-			at.WriteJSON(respw, http.StatusNotModified, resp)
+			at.WriteError(respw, at.NewError(at.CodeInternal, "Gagal Insert Database", err.Error()))
 			return
 		}
 		resp.Info = idusr.Hex()
@@ -351,9 +291,7 @@ func PostDataUserFromWA(respw http.ResponseWriter, req *http.Request) {
 	docuser.Email = usr.Email
 	_, err = atdb.ReplaceOneDoc(config.Mongoconn, "user", primitive.M{"phonenumber": usr.PhoneNumber}, docuser)
 	if err != nil {
-		resp.Response = "Gagal replaceonedoc"
-		resp.Info = err.Error()
-		at.WriteJSON(respw, http.StatusConflict, resp)
+		at.WriteError(respw, at.NewError(at.CodeInternal, "Gagal replaceonedoc", err.Error()))
 		return
 	}
 	//melakukan update di seluruh member project
@@ -374,16 +312,12 @@ func PostDataUserFromWA(respw http.ResponseWriter, req *http.Request) {
 		memberToDelete := model.Userdomyikado{PhoneNumber: docuser.PhoneNumber}
 		_, err := atdb.DeleteDocFromArray[model.Userdomyikado](config.Mongoconn, "project", prj.ID, "members", memberToDelete)
 		if err != nil {
-			resp.Response = "Error : Data project tidak di temukan"
-			resp.Info = err.Error()
-			at.WriteJSON(respw, http.StatusNotFound, resp)
+			at.WriteError(respw, at.NewError(at.CodeNotFound, "Data project tidak di temukan", err.Error()))
 			return
 		}
 		_, err = atdb.AddDocToArray[model.Userdomyikado](config.Mongoconn, "project", prj.ID, "members", docuser)
 		if err != nil {
-			resp.Response = "Error : Gagal menambahkan member ke project"
-			resp.Info = err.Error()
-			at.WriteJSON(respw, http.StatusExpectationFailed, resp)
+			at.WriteError(respw, at.NewError(at.CodeInternal, "Gagal menambahkan member ke project", err.Error()))
 			return
 		}
 
@@ -396,7 +330,7 @@ func PostDataUserFromWA(respw http.ResponseWriter, req *http.Request) {
 func ApproveBimbinganbyPoin(w http.ResponseWriter, r *http.Request) {
 	noHp := r.Header.Get("nohp")
 	if noHp == "" {
-		http.Error(w, "No valid phone number found", http.StatusForbidden)
+		at.WriteError(w, at.NewError(at.CodeForbidden, "Nomor telepon tidak ditemukan di header", ""))
 		return
 	}
 
@@ -405,8 +339,12 @@ func ApproveBimbinganbyPoin(w http.ResponseWriter, r *http.Request) {
 		Topik string `json:"topik"`
 	}
 	err := json.NewDecoder(r.Body).Decode(&requestData)
-	if err != nil || requestData.NIM == "" || requestData.Topik == "" {
-		http.Error(w, "Invalid request body or NIM/Topik not provided", http.StatusBadRequest)
+	if err != nil {
+		at.WriteError(w, at.NewError(at.CodeInvalidBody, "", err.Error()))
+		return
+	}
+	if requestData.NIM == "" || requestData.Topik == "" {
+		at.WriteError(w, at.NewError(at.CodeValidation, "NIM dan Topik wajib diisi", ""))
 		return
 	}
 
@@ -414,7 +352,7 @@ func ApproveBimbinganbyPoin(w http.ResponseWriter, r *http.Request) {
 	var conf model.Config
 	err = config.Mongoconn.Collection("config").FindOne(context.TODO(), bson.M{"phonenumber": "62895601060000"}).Decode(&conf)
 	if err != nil {
-		http.Error(w, "Mohon maaf ada kesalahan dalam pengambilan config di database: "+err.Error(), http.StatusInternalServerError)
+		at.WriteError(w, at.NewError(at.CodeInternal, "Mohon maaf ada kesalahan dalam pengambilan config di database", err.Error()))
 		return
 	}
 
@@ -424,7 +362,7 @@ func ApproveBimbinganbyPoin(w http.ResponseWriter, r *http.Request) {
 		"topik": requestData.Topik,
 	})
 	if err != nil {
-		http.Error(w, "Gagal membuat request body: "+err.Error(), http.StatusInternalServerError)
+		at.WriteError(w, at.NewError(at.CodeInternal, "Gagal membuat request body", err.Error()))
 		return
 	}
 
@@ -432,7 +370,7 @@ func ApproveBimbinganbyPoin(w http.ResponseWriter, r *http.Request) {
 	client := &http.Client{Timeout: 10 * time.Second}
 	req, err := http.NewRequest("POST", conf.ApproveBimbinganURL, bytes.NewBuffer(requestBody))
 	if err != nil {
-		http.Error(w, "Gagal membuat request: "+err.Error(), http.StatusInternalServerError)
+		at.WriteError(w, at.NewError(at.CodeInternal, "Gagal membuat request", err.Error()))
 		return
 	}
 	req.Header.Set("Content-Type", "application/json")
@@ -440,7 +378,7 @@ func ApproveBimbinganbyPoin(w http.ResponseWriter, r *http.Request) {
 
 	resp, err := client.Do(req)
 	if err != nil {
-		http.Error(w, "Gagal mengirim request: "+err.Error(), http.StatusInternalServerError)
+		at.WriteError(w, at.NewError(at.CodeUpstream, "Gagal mengirim request", err.Error()))
 		return
 	}
 	defer resp.Body.Close()
@@ -448,11 +386,11 @@ func ApproveBimbinganbyPoin(w http.ResponseWriter, r *http.Request) {
 	if resp.StatusCode != http.StatusOK {
 		switch resp.StatusCode {
 		case http.StatusNotFound:
-			http.Error(w, "Token tidak ditemukan! Silahkan Login Kembali", http.StatusNotFound)
+			at.WriteError(w, at.NewError(at.CodeNotFound, "Token tidak ditemukan! Silahkan Login Kembali", ""))
 		case http.StatusForbidden:
-			http.Error(w, "Gagal, Bimbingan telah disetujui!", http.StatusForbidden)
+			at.WriteError(w, at.NewError(at.CodeForbidden, "Gagal, Bimbingan telah disetujui!", ""))
 		default:
-			http.Error(w, fmt.Sprintf("Gagal approve bimbingan, status code: %d", resp.StatusCode), http.StatusInternalServerError)
+			at.WriteError(w, at.NewError(at.CodeUpstream, "Gagal approve bimbingan", "status "+strconv.Itoa(resp.StatusCode)))
 		}
 		return
 	}
@@ -460,7 +398,7 @@ func ApproveBimbinganbyPoin(w http.ResponseWriter, r *http.Request) {
 	var responseMap map[string]string
 	err = json.NewDecoder(resp.Body).Decode(&responseMap)
 	if err != nil {
-		http.Error(w, "Gagal memproses response: "+err.Error(), http.StatusInternalServerError)
+		at.WriteError(w, at.NewError(at.CodeInternal, "Gagal memproses response", err.Error()))
 		return
 	}
 
@@ -468,14 +406,14 @@ func ApproveBimbinganbyPoin(w http.ResponseWriter, r *http.Request) {
 	phonenumber := responseMap["no_hp"]
 	_, err = report.KurangPoinUserbyPhoneNumber(config.Mongoconn, phonenumber, 13.0)
 	if err != nil {
-		http.Error(w, "Gagal mengurangi poin: "+err.Error(), http.StatusInternalServerError)
+		at.WriteError(w, at.NewError(at.CodeInternal, "Gagal mengurangi poin", err.Error()))
 		return
 	}
 
 	// Get updated user data to return the current points
 	usr, err := atdb.GetOneDoc[model.Userdomyikado](config.Mongoconn, "user", bson.M{"phonenumber": phonenumber})
 	if err != nil {
-		http.Error(w, "Gagal mengambil data pengguna: "+err.Error(), http.StatusInternalServerError)
+		at.WriteError(w, at.NewError(at.CodeInternal, "Gagal mengambil data pengguna", err.Error()))
 		return
 	}
 
@@ -16,8 +16,8 @@ import (
 	"github.com/gocroot/helper/lms"
 	"github.com/gocroot/helper/phone"
 	"github.com/gocroot/helper/report"
+	"github.com/gocroot/helper/router"
 	"github.com/gocroot/helper/tiket"
-	"github.com/gocroot/helper/watoken"
 	"github.com/gocroot/helper/whatsauth"
 	"github.com/gocroot/mod/helpdesk"
 	"github.com/gocroot/model"
@@ -27,34 +27,29 @@ import (
 
 func PostTaskList(w http.ResponseWriter, r *http.Request) {
 	var resp itmodel.Response
-	prof, err := whatsauth.GetAppProfile(at.GetParam(r), config.Mongoconn)
+	prof, err := whatsauth.GetAppProfile(router.Param(r, "id"), config.Mongoconn)
 	if err != nil {
-		resp.Response = err.Error()
-		at.WriteJSON(w, http.StatusBadRequest, resp)
+		at.WriteError(w, at.NewError(at.CodeNotFound, "Profile WhatsApp tidak di temukan", err.Error()))
 		return
 	}
 	if at.GetSecretFromHeader(r) != prof.Secret {
-		resp.Response = "Salah secret: " + at.GetSecretFromHeader(r)
-		at.WriteJSON(w, http.StatusUnauthorized, resp)
+		at.WriteError(w, at.NewError(at.CodeUnauthorized, "Salah secret", ""))
 		return
 	}
 	var tasklists []report.TaskList
 	err = json.NewDecoder(r.Body).Decode(&tasklists)
 	if err != nil {
-		resp.Response = err.Error()
-		at.WriteJSON(w, http.StatusBadRequest, resp)
+		at.WriteError(w, at.NewError(at.CodeInvalidBody, "", err.Error()))
 		return
 	}
 	docusr, err := atdb.GetOneLatestDoc[model.Userdomyikado](config.Mongoconn, "user", primitive.M{"phonenumber": tasklists[0].PhoneNumber})
 	if err != nil {
-		resp.Response = "Error : user tidak di temukan " + err.Error()
-		at.WriteJSON(w, http.StatusForbidden, resp)
+		at.WriteError(w, at.NewError(at.CodeNotFound, "Data user tidak di temukan", err.Error()))
 		return
 	}
 	lapuser, err := atdb.GetOneLatestDoc[model.Laporan](config.Mongoconn, "uxlaporan", primitive.M{"_id": tasklists[0].LaporanID})
 	if err != nil {
-		resp.Response = "Error : user tidak di temukan " + err.Error()
-		at.WriteJSON(w, http.StatusForbidden, resp)
+		at.WriteError(w, at.NewError(at.CodeNotFound, "Data laporan tidak di temukan", err.Error()))
 		return
 	}
 	for _, task := range tasklists {
@@ -68,17 +63,13 @@ func PostTaskList(w http.ResponseWriter, r *http.Request) {
 		task.ProjectWAGroupID = lapuser.Project.WAGroupID
 		_, err = atdb.InsertOneDoc(config.Mongoconn, "tasklist", task)
 		if err != nil {
-			resp.Info = "Kakak sudah melaporkan tasklist sebelumnya"
-			resp.Response = "Error : tidak bisa insert ke database " + err.Error()
-			at.WriteJSON(w, http.StatusForbidden, resp)
+			at.WriteError(w, at.NewError(at.CodeConflict, "Kakak sudah melaporkan tasklist sebelumnya", err.Error()))
 			return
 		}
 	}
 	res, err := report.TambahPoinTasklistbyPhoneNumber(config.Mongoconn, docusr.PhoneNumber, lapuser.Project, float64(len(tasklists)), "tasklist")
 	if err != nil {
-		resp.Info = "Tambah Poin Tasklist gagal"
-		resp.Response = err.Error()
-		at.WriteJSON(w, http.StatusExpectationFailed, resp)
+		at.WriteError(w, at.NewError(at.CodeInternal, "Tambah Poin Tasklist gagal", err.Error()))
 		return
 	}
 	resp.Response = strconv.Itoa(int(res.ModifiedCount))
@@ -88,42 +79,34 @@ func PostTaskList(w http.ResponseWriter, r *http.Request) {
 
 func PostPresensi(respw http.ResponseWriter, req *http.Request) {
 	var resp itmodel.Response
-	prof, err := whatsauth.GetAppProfile(at.GetParam(req), config.Mongoconn)
+	prof, err := whatsauth.GetAppProfile(router.Param(req, "id"), config.Mongoconn)
 	if err != nil {
-		resp.Response = err.Error()
-		at.WriteJSON(respw, http.StatusBadRequest, resp)
+		at.WriteError(respw, at.NewError(at.CodeNotFound, "Profile WhatsApp tidak di temukan", err.Error()))
 		return
 	}
 	if at.GetSecretFromHeader(req) != prof.Secret {
-		resp.Response = "Salah secret: " + at.GetSecretFromHeader(req)
-		at.WriteJSON(respw, http.StatusUnauthorized, resp)
+		at.WriteError(respw, at.NewError(at.CodeUnauthorized, "Salah secret", ""))
 		return
 	}
 	var presensi report.PresensiDomyikado
 	err = json.NewDecoder(req.Body).Decode(&presensi)
 	if err != nil {
-		resp.Response = err.Error()
-		at.WriteJSON(respw, http.StatusBadRequest, resp)
+		at.WriteError(respw, at.NewError(at.CodeInvalidBody, "", err.Error()))
 		return
 	}
 	docusr, err := atdb.GetOneLatestDoc[model.Userdomyikado](config.Mongoconn, "user", primitive.M{"phonenumber": presensi.PhoneNumber})
 	if err != nil {
-		resp.Response = "Error : user tidak di temukan " + err.Error()
-		at.WriteJSON(respw, http.StatusForbidden, resp)
+		at.WriteError(respw, at.NewError(at.CodeNotFound, "Data user tidak di temukan", err.Error()))
 		return
 	}
 	_, err = atdb.InsertOneDoc(config.Mongoconn, "presensi", presensi)
 	if err != nil {
-		resp.Info = "Kakak sudah melaporkan presensi sebelumnya"
-		resp.Response = "Error : tidak bisa insert ke database " + err.Error()
-		at.WriteJSON(respw, http.StatusForbidden, resp)
+		at.WriteError(respw, at.NewError(at.CodeConflict, "Kakak sudah melaporkan presensi sebelumnya", err.Error()))
 		return
 	}
 	res, err := report.TambahPoinPresensibyPhoneNumber(config.Mongoconn, presensi.PhoneNumber, presensi.Lokasi, presensi.Skor, config.WAAPIToken, config.WAAPIMessage, "presensi")
 	if err != nil {
-		resp.Info = "Tambah Poin Presensi gagal"
-		resp.Response = err.Error()
-		at.WriteJSON(respw, http.StatusExpectationFailed, resp)
+		at.WriteError(respw, at.NewError(at.CodeInternal, "Tambah Poin Presensi gagal", err.Error()))
 		return
 	}
 	resp.Response = strconv.Itoa(int(res.ModifiedCount))
@@ -133,19 +116,14 @@ func PostPresensi(respw http.ResponseWriter, req *http.Request) {
 
 // testimoni dari useng lms pamong
 func PostTestimoni(respw http.ResponseWriter, req *http.Request) {
-	payload, err := watoken.Decode(config.PublicKeyWhatsAuth, at.GetLoginFromHeader(req))
+	payload, err := config.TokenKeyring.Decode(at.GetLoginFromHeader(req))
 	if err != nil {
-		var respn model.Response
-		respn.Status = "Error : Token Tidak Valid "
-		respn.Info = at.GetSecretFromHeader(req)
-		respn.Location = "Decode Token Error: " + at.GetLoginFromHeader(req)
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusForbidden, respn)
+		at.WriteError(respw, at.NewError(at.CodeInvalidToken, "Token Tidak Valid", err.Error()))
 		return
 	}
 	userdt := lms.GetDataFromAPI(payload.Id)
 	if userdt.Data.Fullname == "" {
-		at.WriteJSON(respw, http.StatusNotFound, userdt)
+		at.WriteError(respw, at.NewError(at.CodeNotFound, "Data user LMS tidak di temukan", payload.Id))
 		return
 	}
 	//pindah ke struck user
@@ -161,18 +139,14 @@ func PostTestimoni(respw http.ResponseWriter, req *http.Request) {
 	var respn model.Response
 	err = json.NewDecoder(req.Body).Decode(&rating)
 	if err != nil {
-		respn.Status = "Error : Body tidak valid"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, at.NewError(at.CodeInvalidBody, "Body tidak valid", err.Error()))
 		return
 	}
 	usersub.Rating = rating.Rating
 	usersub.Komentar = rating.Komentar
 	res, err := atdb.InsertOneDoc(config.Mongoconn, "unsubs", usersub)
 	if err != nil {
-		respn.Status = "Error : Data laporan tidak berhasil di update data rating"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusNotImplemented, respn)
+		at.WriteError(respw, at.NewError(at.CodeInternal, "Data laporan tidak berhasil di update data rating", err.Error()))
 		return
 	}
 	respn.Response = res.Hex()
@@ -182,12 +156,9 @@ func PostTestimoni(respw http.ResponseWriter, req *http.Request) {
 
 // mendapatkan random testi 4 buah untuk halaman depan
 func GetRandomTesti4(respw http.ResponseWriter, req *http.Request) {
-	var respn model.Response
 	lstpeserta, err := atdb.GetRandomDoc[model.Peserta](config.Mongoconn, "unsubs", 4)
 	if err != nil {
-		respn.Status = "Error : Data laporan tidak berhasil di update data rating"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusNotImplemented, respn)
+		at.WriteError(respw, at.NewError(at.CodeInternal, "Data laporan tidak berhasil di update data rating", err.Error()))
 		return
 	}
 	var listtesti []model.Testi
@@ -211,34 +182,24 @@ func PostUnsubscribe(respw http.ResponseWriter, req *http.Request) {
 	var respn model.Response
 	err := json.NewDecoder(req.Body).Decode(&rating)
 	if err != nil {
-		respn.Status = "Error : Body tidak valid"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, at.NewError(at.CodeInvalidBody, "Body tidak valid", err.Error()))
 		return
 	}
 	objectId, err := primitive.ObjectIDFromHex(rating.ID)
 	if err != nil {
-		respn.Status = "Error : ObjectID Tidak Valid"
-		respn.Info = at.GetSecretFromHeader(req)
-		respn.Location = "Encode Object ID Error"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, at.NewError(at.CodeBadRequest, "ObjectID Tidak Valid", err.Error()))
 		return
 	}
 	hasil, err := atdb.GetOneLatestDoc[model.Peserta](config.Mongoconn, "sent", primitive.M{"_id": objectId})
 	if err != nil {
-		respn.Status = "Error : Data laporan tidak di temukan"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusNotImplemented, respn)
+		at.WriteError(respw, at.NewError(at.CodeNotFound, "Data laporan tidak di temukan", err.Error()))
 		return
 	}
 	hasil.Rating = rating.Rating
 	hasil.Komentar = rating.Komentar
 	res, err := atdb.InsertOneDoc(config.Mongoconn, "unsubs", hasil)
 	if err != nil {
-		respn.Status = "Error : Data laporan tidak berhasil di update data rating"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusNotImplemented, respn)
+		at.WriteError(respw, at.NewError(at.CodeInternal, "Data laporan tidak berhasil di update data rating", err.Error()))
 		return
 	}
 	respn.Response = res.Hex()
@@ -248,23 +209,15 @@ func PostUnsubscribe(respw http.ResponseWriter, req *http.Request) {
 
 // mendapatkan data FAQ
 func GetFAQ(respw http.ResponseWriter, req *http.Request) {
-	id := at.GetParam(req)
+	id := router.Param(req, "id")
 	objectId, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
-		var respn model.Response
-		respn.Status = "Error : ObjectID Tidak Valid"
-		respn.Info = at.GetSecretFromHeader(req)
-		respn.Location = "Encode Object ID Error"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, at.NewError(at.CodeBadRequest, "ObjectID Tidak Valid", err.Error()))
 		return
 	}
 	hasil, err := atdb.GetOneLatestDoc[kimseok.Datasets](config.Mongoconn, "faq", primitive.M{"_id": objectId})
 	if err != nil {
-		var respn model.Response
-		respn.Status = "Error : Data profile user sent tidak di temukan"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusNotImplemented, respn)
+		at.WriteError(respw, at.NewError(at.CodeNotFound, "Data profile user sent tidak di temukan", err.Error()))
 		return
 	}
 	at.WriteJSON(respw, http.StatusOK, hasil)
@@ -272,23 +225,15 @@ func GetFAQ(respw http.ResponseWriter, req *http.Request) {
 
 // mendapatkan user yang sent dan mau unnsubscribe
 func GetSentItem(respw http.ResponseWriter, req *http.Request) {
-	id := at.GetParam(req)
+	id := router.Param(req, "id")
 	objectId, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
-		var respn model.Response
-		respn.Status = "Error : ObjectID Tidak Valid"
-		respn.Info = at.GetSecretFromHeader(req)
-		respn.Location = "Encode Object ID Error"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, at.NewError(at.CodeBadRequest, "ObjectID Tidak Valid", err.Error()))
 		return
 	}
 	hasil, err := atdb.GetOneLatestDoc[model.Peserta](config.Mongoconn, "sent", primitive.M{"_id": objectId})
 	if err != nil {
-		var respn model.Response
-		respn.Status = "Error : Data profile user sent tidak di temukan"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusNotImplemented, respn)
+		at.WriteError(respw, at.NewError(at.CodeNotFound, "Data profile user sent tidak di temukan", err.Error()))
 		return
 	}
 	hasil.PhoneNumber = ""
@@ -297,23 +242,15 @@ func GetSentItem(respw http.ResponseWriter, req *http.Request) {
 
 // mendapatkan tiket yang sudah closed Profile, err := atdb.GetOneDoc[itmodel.Profile](Mongoconn, "profile", primitive.M{"phonenumber": PhoneNumber})
 func GetClosedTicket(respw http.ResponseWriter, req *http.Request) {
-	id := at.GetParam(req)
+	id := router.Param(req, "id")
 	objectId, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
-		var respn model.Response
-		respn.Status = "Error : ObjectID Tidak Valid"
-		respn.Info = at.GetSecretFromHeader(req)
-		respn.Location = "Encode Object ID Error"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, at.NewError(at.CodeBadRequest, "ObjectID Tidak Valid", err.Error()))
 		return
 	}
 	hasil, err := atdb.GetOneLatestDoc[tiket.Bantuan](config.Mongoconn, "tiket", primitive.M{"_id": objectId})
 	if err != nil {
-		var respn model.Response
-		respn.Status = "Error : Data tiket tidak di temukan"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusNotImplemented, respn)
+		at.WriteError(respw, at.NewError(at.CodeNotFound, "Data tiket tidak di temukan", err.Error()))
 		return
 	}
 
@@ -324,10 +261,7 @@ func GetClosedTicket(respw http.ResponseWriter, req *http.Request) {
 func GetBotList(respw http.ResponseWriter, req *http.Request) {
 	Profiles, err := atdb.GetAllDoc[[]itmodel.Profile](config.Mongoconn, "profile", primitive.M{})
 	if err != nil {
-		var respn model.Response
-		respn.Status = "Error : Data tiket tidak di temukan"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusNotImplemented, respn)
+		at.WriteError(respw, at.NewError(at.CodeNotFound, "Data tiket tidak di temukan", err.Error()))
 		return
 	}
 	var phonelist []string
@@ -346,18 +280,12 @@ func PostMasukanTiket(respw http.ResponseWriter, req *http.Request) {
 	var respn model.Response
 	err := json.NewDecoder(req.Body).Decode(&rating)
 	if err != nil {
-		respn.Status = "Error : Body tidak valid"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, at.NewError(at.CodeInvalidBody, "Body tidak valid", err.Error()))
 		return
 	}
 	objectId, err := primitive.ObjectIDFromHex(rating.ID)
 	if err != nil {
-		respn.Status = "Error : ObjectID Tidak Valid"
-		respn.Info = at.GetSecretFromHeader(req)
-		respn.Location = "Encode Object ID Error"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, at.NewError(at.CodeBadRequest, "ObjectID Tidak Valid", err.Error()))
 		return
 	}
 	updatefields := primitive.M{
@@ -366,17 +294,13 @@ func PostMasukanTiket(respw http.ResponseWriter, req *http.Request) {
 	}
 	res, err := atdb.UpdateOneDoc(config.Mongoconn, "tiket", primitive.M{"_id": objectId}, updatefields)
 	if err != nil {
-		respn.Status = "Error : Data laporan tidak berhasil di update data rating"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusNotImplemented, respn)
+		at.WriteError(respw, at.NewError(at.CodeInternal, "Data laporan tidak berhasil di update data rating", err.Error()))
 		return
 	}
 	//mendapatkan document untuk informasi ke admin
 	hasil, err := atdb.GetOneLatestDoc[tiket.Bantuan](config.Mongoconn, "tiket", primitive.M{"_id": objectId})
 	if err != nil {
-		respn.Status = "Error : Data laporan tidak di temukan"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusNotImplemented, respn)
+		at.WriteError(respw, at.NewError(at.CodeNotFound, "Data laporan tidak di temukan", err.Error()))
 		return
 	}
 
@@ -401,38 +325,27 @@ func PostMasukanTiket(respw http.ResponseWriter, req *http.Request) {
 }
 
 func PostMeeting(w http.ResponseWriter, r *http.Request) {
-	var respn model.Response
 	//otorisasi dan validasi inputan
-	payload, err := watoken.Decode(config.PublicKeyWhatsAuth, at.GetLoginFromHeader(r))
+	payload, err := config.TokenKeyring.Decode(at.GetLoginFromHeader(r))
 	if err != nil {
-		respn.Status = "Error : Token Tidak Valid"
-		respn.Info = at.GetSecretFromHeader(r)
-		respn.Location = "Decode Token Error"
-		respn.Response = err.Error()
-		at.WriteJSON(w, http.StatusForbidden, respn)
+		at.WriteError(w, at.NewError(at.CodeInvalidToken, "Token Tidak Valid", err.Error()))
 		return
 	}
 	var event gcallapi.SimpleEvent
 	err = json.NewDecoder(r.Body).Decode(&event)
 	if err != nil {
-		respn.Status = "Error : Body tidak valid"
-		respn.Response = err.Error()
-		at.WriteJSON(w, http.StatusBadRequest, respn)
+		at.WriteError(w, at.NewError(at.CodeInvalidBody, "Body tidak valid", err.Error()))
 		return
 	}
 	//check validasi user
 	docuser, err := atdb.GetOneDoc[model.Userdomyikado](config.Mongoconn, "user", primitive.M{"phonenumber": payload.Id})
 	if err != nil {
-		respn.Status = "Error : Data user tidak di temukan: " + payload.Id
-		respn.Response = err.Error()
-		at.WriteJSON(w, http.StatusNotImplemented, respn)
+		at.WriteError(w, at.NewError(at.CodeNotFound, "Data user tidak di temukan"+payload.Id, err.Error()))
 		return
 	}
 	prjuser, err := atdb.GetOneDoc[model.Project](config.Mongoconn, "project", primitive.M{"_id": event.ProjectID})
 	if err != nil {
-		respn.Status = "Error : Data project tidak di temukan: " + event.ProjectID.Hex()
-		respn.Response = err.Error()
-		at.WriteJSON(w, http.StatusNotImplemented, respn)
+		at.WriteError(w, at.NewError(at.CodeNotFound, "Data project tidak di temukan"+event.ProjectID.Hex(), err.Error()))
 		return
 	}
 	//lojik inputan post
@@ -453,23 +366,17 @@ func PostMeeting(w http.ResponseWriter, r *http.Request) {
 
 	gevt, err := gcallapi.HandlerCalendar(config.Mongoconn, event)
 	if err != nil {
-		respn.Status = "Gagal Membuat Google Calendar"
-		respn.Response = err.Error()
-		at.WriteJSON(w, http.StatusNotModified, respn)
+		at.WriteError(w, at.NewError(at.CodeUpstream, "Gagal Membuat Google Calendar", err.Error()))
 		return
 	}
 	_, err = atdb.InsertOneDoc(config.Mongoconn, "meetinglog", gevt)
 	if err != nil {
-		respn.Status = "Gagal Insert Database meetinglog"
-		respn.Response = err.Error()
-		at.WriteJSON(w, http.StatusNotModified, respn)
+		at.WriteError(w, at.NewError(at.CodeInternal, "Gagal Insert Database meetinglog", err.Error()))
 		return
 	}
 	event.ID, err = atdb.InsertOneDoc(config.Mongoconn, "meeting", event)
 	if err != nil {
-		respn.Status = "Gagal Insert Database meeting"
-		respn.Response = err.Error()
-		at.WriteJSON(w, http.StatusNotModified, respn)
+		at.WriteError(w, at.NewError(at.CodeInternal, "Gagal Insert Database meeting", err.Error()))
 		return
 	}
 	lap.MeetID = event.ID
@@ -477,17 +384,12 @@ func PostMeeting(w http.ResponseWriter, r *http.Request) {
 	lap.Kode = gevt.HtmlLink
 	lap.ID, err = atdb.InsertOneDoc(config.Mongoconn, "uxlaporan", lap)
 	if err != nil {
-		respn.Status = "Gagal Insert Database"
-		respn.Response = err.Error()
-		at.WriteJSON(w, http.StatusNotModified, respn)
+		at.WriteError(w, at.NewError(at.CodeInternal, "Gagal Insert Database", err.Error()))
 		return
 	}
 	_, err = report.TambahPoinLaporanbyPhoneNumber(config.Mongoconn, prjuser, docuser.PhoneNumber, 1, "meeting")
 	if err != nil {
-		var resp model.Response
-		resp.Info = "TambahPoinLaporanbyPhoneNumber gagal"
-		resp.Response = err.Error()
-		at.WriteJSON(w, http.StatusExpectationFailed, resp)
+		at.WriteError(w, at.NewError(at.CodeInternal, "Gagal menambah poin laporan", err.Error()))
 		return
 	}
 
@@ -497,11 +399,9 @@ func PostMeeting(w http.ResponseWriter, r *http.Request) {
 		IsGroup:  true,
 		Messages: message,
 	}
-	_, resp, err := atapi.PostStructWithToken[model.Response]("Token", config.WAAPIToken, dt, config.WAAPIMessage)
+	_, _, err = atapi.PostStructWithToken[model.Response]("Token", config.WAAPIToken, dt, config.WAAPIMessage)
 	if err != nil {
-		resp.Info = "Tidak berhak"
-		resp.Response = err.Error()
-		at.WriteJSON(w, http.StatusUnauthorized, resp)
+		at.WriteError(w, at.NewError(at.CodeUpstream, "Pesan WhatsApp gagal dikirim", err.Error()))
 		return
 	}
 	at.WriteJSON(w, http.StatusOK, lap)
@@ -509,58 +409,36 @@ func PostMeeting(w http.ResponseWriter, r *http.Request) {
 
 func PostLaporan(respw http.ResponseWriter, req *http.Request) {
 	//otorisasi dan validasi inputan
-	payload, err := watoken.Decode(config.PublicKeyWhatsAuth, at.GetLoginFromHeader(req))
+	payload, err := config.TokenKeyring.Decode(at.GetLoginFromHeader(req))
 	if err != nil {
-		var respn model.Response
-		respn.Status = "Error : Token Tidak Valid"
-		respn.Info = at.GetSecretFromHeader(req)
-		respn.Location = "Decode Token Error"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusForbidden, respn)
+		at.WriteError(respw, at.NewError(at.CodeInvalidToken, "Token Tidak Valid", err.Error()))
 		return
 	}
 	var lap model.Laporan
 	err = json.NewDecoder(req.Body).Decode(&lap)
 	if err != nil {
-		var respn model.Response
-		respn.Status = "Error : Body tidak valid"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, at.NewError(at.CodeInvalidBody, "Body tidak valid", err.Error()))
 		return
 	}
 	if lap.Solusi == "" {
-		var respn model.Response
-		respn.Status = "Error : Telepon atau nama atau solusi tidak diisi"
-		respn.Response = "Isi lebih lengkap dahulu"
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, at.NewError(at.CodeBadRequest, "Telepon atau nama atau solusi tidak diisi", "Isi lebih lengkap dahulu"))
 		return
 	}
 	//check validasi user
 	docuser, err := atdb.GetOneDoc[model.Userdomyikado](config.Mongoconn, "user", primitive.M{"phonenumber": payload.Id})
 	if err != nil {
-		var respn model.Response
-		respn.Status = "Error : Data user tidak di temukan: " + payload.Id
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusNotImplemented, respn)
+		at.WriteError(respw, at.NewError(at.CodeNotFound, "Data user tidak di temukan"+payload.Id, err.Error()))
 		return
 	}
 	//ambil data project
 	prjobjectId, err := primitive.ObjectIDFromHex(lap.Kode)
 	if err != nil {
-		var respn model.Response
-		respn.Status = "Error : ObjectID Tidak Valid"
-		respn.Info = lap.Kode
-		respn.Location = "Encode Object ID Error"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, at.NewError(at.CodeBadRequest, "ObjectID Tidak Valid", err.Error()).WithInfo(lap.Kode))
 		return
 	}
 	prjuser, err := atdb.GetOneDoc[model.Project](config.Mongoconn, "project", primitive.M{"_id": prjobjectId})
 	if err != nil {
-		var respn model.Response
-		respn.Status = "Error : Data project tidak di temukan: " + lap.Kode
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusNotImplemented, respn)
+		at.WriteError(respw, at.NewError(at.CodeNotFound, "Data project tidak di temukan"+lap.Kode, err.Error()))
 		return
 	}
 	//lojik inputan post
@@ -573,18 +451,12 @@ func PostLaporan(respw http.ResponseWriter, req *http.Request) {
 
 	idlap, err := atdb.InsertOneDoc(config.Mongoconn, "uxlaporan", lap)
 	if err != nil {
-		var respn model.Response
-		respn.Status = "Gagal Insert Database"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusNotModified, respn)
+		at.WriteError(respw, at.NewError(at.CodeInternal, "Gagal Insert Database", err.Error()))
 		return
 	}
 	_, err = report.TambahPoinLaporanbyPhoneNumber(config.Mongoconn, prjuser, docuser.PhoneNumber, 1, "laporan")
 	if err != nil {
-		var resp model.Response
-		resp.Info = "TambahPoinPushRepobyGithubUsername gagal"
-		resp.Response = err.Error()
-		at.WriteJSON(respw, http.StatusExpectationFailed, resp)
+		at.WriteError(respw, at.NewError(at.CodeInternal, "Gagal menambah poin push repo", err.Error()))
 		return
 	}
 	message := "*Permintaan Feedback Pekerjaan*\n" + "Petugas : " + docuser.Name + "\nDeskripsi:" + lap.Solusi + "\n Beri Nilai: " + "https://www.do.my.id/rate/#" + idlap.Hex()
@@ -593,11 +465,9 @@ func PostLaporan(respw http.ResponseWriter, req *http.Request) {
 		IsGroup:  false,
 		Messages: message,
 	}
-	_, resp, err := atapi.PostStructWithToken[model.Response]("Token", config.WAAPIToken, dt, config.WAAPIMessage)
+	_, _, err = atapi.PostStructWithToken[model.Response]("Token", config.WAAPIToken, dt, config.WAAPIMessage)
 	if err != nil {
-		resp.Info = "Tidak berhak"
-		resp.Response = err.Error()
-		at.WriteJSON(respw, http.StatusUnauthorized, resp)
+		at.WriteError(respw, at.NewError(at.CodeUpstream, "Pesan WhatsApp gagal dikirim", err.Error()))
 		return
 	}
 	at.WriteJSON(respw, http.StatusOK, lap)
@@ -606,58 +476,36 @@ func PostLaporan(respw http.ResponseWriter, req *http.Request) {
 
 func PostFeedback(respw http.ResponseWriter, req *http.Request) {
 	//otorisasi dan validasi inputan
-	payload, err := watoken.Decode(config.PublicKeyWhatsAuth, at.GetLoginFromHeader(req))
+	payload, err := config.TokenKeyring.Decode(at.GetLoginFromHeader(req))
 	if err != nil {
-		var respn model.Response
-		respn.Status = "Error : Token Tidak Valid"
-		respn.Info = at.GetSecretFromHeader(req)
-		respn.Location = "Decode Token Error"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusForbidden, respn)
+		at.WriteError(respw, at.NewError(at.CodeInvalidToken, "Token Tidak Valid", err.Error()))
 		return
 	}
 	var lap model.Laporan
 	err = json.NewDecoder(req.Body).Decode(&lap)
 	if err != nil {
-		var respn model.Response
-		respn.Status = "Error : Body tidak valid"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, at.NewError(at.CodeInvalidBody, "Body tidak valid", err.Error()))
 		return
 	}
 	if lap.Phone == "" || lap.Nama == "" || lap.Solusi == "" {
-		var respn model.Response
-		respn.Status = "Error : Telepon atau nama atau solusi tidak diisi"
-		respn.Response = "Isi lebih lengkap dahulu"
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, at.NewError(at.CodeBadRequest, "Telepon atau nama atau solusi tidak diisi", "Isi lebih lengkap dahulu"))
 		return
 	}
 	//validasi eksistensi user di db
 	docuser, err := atdb.GetOneDoc[model.Userdomyikado](config.Mongoconn, "user", primitive.M{"phonenumber": payload.Id})
 	if err != nil {
-		var respn model.Response
-		respn.Status = "Error : Data user tidak di temukan"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusNotImplemented, respn)
+		at.WriteError(respw, at.NewError(at.CodeNotFound, "Data user tidak di temukan", err.Error()))
 		return
 	}
 	//ambil data project
 	prjobjectId, err := primitive.ObjectIDFromHex(lap.Kode)
 	if err != nil {
-		var respn model.Response
-		respn.Status = "Error : ObjectID Tidak Valid"
-		respn.Info = lap.Kode
-		respn.Location = "Encode Object ID Error"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusBadRequest, respn)
+		at.WriteError(respw, at.NewError(at.CodeBadRequest, "ObjectID Tidak Valid", err.Error()).WithInfo(lap.Kode))
 		return
 	}
 	prjuser, err := atdb.GetOneDoc[model.Project](config.Mongoconn, "project", primitive.M{"_id": prjobjectId})
 	if err != nil {
-		var respn model.Response
-		respn.Status = "Error : Data project tidak di temukan: " + lap.Kode
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusNotImplemented, respn)
+		at.WriteError(respw, at.NewError(at.CodeNotFound, "Data project tidak di temukan"+lap.Kode, err.Error()))
 		return
 	}
 	//lojik inputan post
@@ -669,18 +517,12 @@ func PostFeedback(respw http.ResponseWriter, req *http.Request) {
 
 	idlap, err := atdb.InsertOneDoc(config.Mongoconn, "uxlaporan", lap)
 	if err != nil {
-		var respn model.Response
-		respn.Status = "Gagal Insert Database"
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusNotModified, respn)
+		at.WriteError(respw, at.NewError(at.CodeInternal, "Gagal Insert Database", err.Error()))
 		return
 	}
 	_, err = report.TambahPoinLaporanbyPhoneNumber(config.Mongoconn, prjuser, docuser.PhoneNumber, 1, "feedback")
 	if err != nil {
-		var resp model.Response
-		resp.Info = "TambahPoinLaporanbyPhoneNumber gagal"
-		resp.Response = err.Error()
-		at.WriteJSON(respw, http.StatusExpectationFailed, resp)
+		at.WriteError(respw, at.NewError(at.CodeInternal, "Gagal menambah poin laporan", err.Error()))
 		return
 	}
 	message := "*Permintaan Feedback*\n" + "Petugas : " + docuser.Name + "\nDeskripsi:" + lap.Solusi + "\n Beri Nilai: " + "https://www.do.my.id/rate/#" + idlap.Hex()
@@ -689,11 +531,9 @@ func PostFeedback(respw http.ResponseWriter, req *http.Request) {
 		IsGroup:  false,
 		Messages: message,
 	}
-	_, resp, err := atapi.PostStructWithToken[model.Response]("Token", config.WAAPIToken, dt, config.WAAPIMessage)
+	_, _, err = atapi.PostStructWithToken[model.Response]("Token", config.WAAPIToken, dt, config.WAAPIMessage)
 	if err != nil {
-		resp.Info = "Tidak berhak"
-		resp.Response = err.Error()
-		at.WriteJSON(respw, http.StatusUnauthorized, resp)
+		at.WriteError(respw, at.NewError(at.CodeUpstream, "Pesan WhatsApp gagal dikirim", err.Error()))
 		return
 	}
 	at.WriteJSON(respw, http.StatusOK, lap)
@@ -0,0 +1,273 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gocroot/config"
+	"github.com/gocroot/helper/at"
+	"github.com/gocroot/helper/atdb"
+	"github.com/gocroot/helper/rbac"
+	"github.com/gocroot/helper/router"
+	"github.com/gocroot/helper/voucher"
+	"github.com/gocroot/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// ApplyVoucherRequest body untuk mencoba voucher ke cart atau checkout
+type ApplyVoucherRequest struct {
+	Code       string `json:"code"`
+	CheckoutID string `json:"checkoutid,omitempty"`
+}
+
+// PostVoucher owner lapak membuat voucher baru
+func PostVoucher(respw http.ResponseWriter, req *http.Request) {
+	var v voucher.Voucher
+	err := json.NewDecoder(req.Body).Decode(&v)
+	if err != nil {
+		at.WriteError(respw, at.NewError(at.CodeInvalidBody, "Body tidak valid", err.Error()))
+		return
+	}
+	v.Code = voucher.NormalizeCode(v.Code)
+	if err = voucher.Validate(v); err != nil {
+		at.WriteError(respw, at.NewError(at.CodeBadRequest, "Voucher tidak valid", err.Error()))
+		return
+	}
+	if err = checkLapakAccess(req, rbac.LapakManage, v.NamaLapak); err != nil {
+		writeForbidden(respw, err)
+		return
+	}
+	if _, err = atdb.GetOneDoc[voucher.Voucher](config.Mongoconn, "voucher", bson.M{"code": v.Code}); err == nil {
+		at.WriteError(respw, at.NewError(at.CodeConflict, "Kode voucher sudah dipakai", "Kode "+v.Code+" sudah ada"))
+		return
+	}
+	v.ID = primitive.NilObjectID
+	v.UsedCount = 0
+	v.Usage = nil
+	v.CreatedAt = time.Now()
+	v.ID, err = atdb.InsertOneDoc(config.Mongoconn, "voucher", v)
+	if mongo.IsDuplicateKeyError(err) {
+		at.WriteError(respw, at.NewError(at.CodeConflict, "Kode voucher sudah dipakai", "Kode "+v.Code+" sudah ada"))
+		return
+	}
+	if err != nil {
+		at.WriteError(respw, at.NewError(at.CodeInternal, "Gagal Insert Database", err.Error()))
+		return
+	}
+	at.WriteJSON(respw, http.StatusOK, v)
+}
+
+// PutVoucher owner lapak mengubah aturan voucher. Kode, lapak dan pemakaian tidak bisa diubah,
+// batas pemakaian tidak boleh lebih kecil dari pemakaian yang sudah terjadi.
+func PutVoucher(respw http.ResponseWriter, req *http.Request) {
+	existing, ok := getOwnedVoucher(respw, req)
+	if !ok {
+		return
+	}
+	var v voucher.Voucher
+	if err := json.NewDecoder(req.Body).Decode(&v); err != nil {
+		at.WriteError(respw, at.NewError(at.CodeInvalidBody, "Body tidak valid", err.Error()))
+		return
+	}
+	v.ID, v.Code, v.NamaLapak = existing.ID, existing.Code, existing.NamaLapak
+	v.UsedCount, v.Usage, v.CreatedAt = existing.UsedCount, existing.Usage, existing.CreatedAt
+	err := voucher.Validate(v)
+	if err == nil && v.UsageLimit > 0 && v.UsageLimit < existing.UsedCount {
+		err = errors.New("usage_limit lebih kecil dari voucher yang sudah dipakai")
+	}
+	if err != nil {
+		at.WriteError(respw, at.NewError(at.CodeBadRequest, "Voucher tidak valid", err.Error()))
+		return
+	}
+	updateFields := bson.M{
+		"type":           v.Type,
+		"value":          v.Value,
+		"max_discount":   v.MaxDiscount,
+		"min_order":      v.MinOrder,
+		"usage_limit":    v.UsageLimit,
+		"per_user_limit": v.PerUserLimit,
+		"start_at":       v.StartAt,
+		"end_at":         v.EndAt,
+		"product_ids":    v.ProductIDs,
+		"categories":     v.Categories,
+		"active":         v.Active,
+	}
+	if _, err = atdb.UpdateOneDoc(config.Mongoconn, "voucher", bson.M{"_id": v.ID}, updateFields); err != nil {
+		at.WriteError(respw, at.NewError(at.CodeInternal, "Gagal memperbarui voucher", err.Error()))
+		return
+	}
+	at.WriteJSON(respw, http.StatusOK, v)
+}
+
+// DeleteVoucher owner lapak menonaktifkan voucher, riwayat pemakaian tetap disimpan
+func DeleteVoucher(respw http.ResponseWriter, req *http.Request) {
+	v, ok := getOwnedVoucher(respw, req)
+	if !ok {
+		return
+	}
+	if _, err := atdb.UpdateOneDoc(config.Mongoconn, "voucher", bson.M{"_id": v.ID}, bson.M{"active": false}); err != nil {
+		at.WriteError(respw, at.NewError(at.CodeInternal, "Gagal menonaktifkan voucher", err.Error()))
+		return
+	}
+	v.Active = false
+	at.WriteJSON(respw, http.StatusOK, v)
+}
+
+// getOwnedVoucher mengambil voucher dari param url dan memastikan user dari token boleh mengelola lapaknya
+func getOwnedVoucher(respw http.ResponseWriter, req *http.Request) (v voucher.Voucher, ok bool) {
+	objectId, err := primitive.ObjectIDFromHex(router.Param(req, "id"))
+	if err != nil {
+		at.WriteError(respw, at.NewError(at.CodeBadRequest, "ObjectID Tidak Valid", err.Error()))
+		return
+	}
+	v, err = atdb.GetOneDoc[voucher.Voucher](config.Mongoconn, "voucher", bson.M{"_id": objectId})
+	if err != nil {
+		at.WriteError(respw, at.NewError(at.CodeNotFound, "Voucher tidak ditemukan", err.Error()))
+		return
+	}
+	if err = checkLapakAccess(req, rbac.LapakManage, v.NamaLapak); err != nil {
+		writeForbidden(respw, err)
+		return
+	}
+	return v, true
+}
+
+// GetVoucherLapak daftar voucher milik lapak, hanya untuk owner lapak
+func GetVoucherLapak(respw http.ResponseWriter, req *http.Request) {
+	namalapak := router.Param(req, "namalapak")
+	if err := checkLapakAccess(req, rbac.LapakManage, namalapak); err != nil {
+		writeForbidden(respw, err)
+		return
+	}
+	vouchers, err := atdb.GetAllDoc[[]voucher.Voucher](config.Mongoconn, "voucher", bson.M{"namalapak": namalapak})
+	if err != nil {
+		at.WriteError(respw, at.NewError(at.CodeInternal, "Data voucher tidak bisa diambil", err.Error()))
+		return
+	}
+	at.WriteJSON(respw, http.StatusOK, vouchers)
+}
+
+// PostApplyVoucher menghitung rincian potongan voucher untuk cart user atau checkout pending miliknya tanpa memakai kuota
+func PostApplyVoucher(respw http.ResponseWriter, req *http.Request) {
+	payload, err := decodeLoginToken(req)
+	if err != nil {
+		writeTokenError(respw, err)
+		return
+	}
+	var applyreq ApplyVoucherRequest
+	if err = json.NewDecoder(req.Body).Decode(&applyreq); err != nil {
+		at.WriteError(respw, at.NewError(at.CodeInvalidBody, "Body tidak valid", err.Error()))
+		return
+	}
+	var lines []model.CartLine
+	if applyreq.CheckoutID != "" {
+		objectId, err := primitive.ObjectIDFromHex(applyreq.CheckoutID)
+		if err != nil {
+			at.WriteError(respw, at.NewError(at.CodeBadRequest, "ObjectID Tidak Valid", err.Error()))
+			return
+		}
+		checkout, err := atdb.GetOneDoc[model.Checkout](config.Mongoconn, "checkout", bson.M{"_id": objectId, "phonenumber": payload.Id})
+		if err != nil {
+			at.WriteError(respw, at.NewError(at.CodeNotFound, "Data checkout tidak di temukan", err.Error()))
+			return
+		}
+		lines = checkout.Items
+	} else {
+		cart, err := atdb.GetOneDoc[model.Cart](config.Mongoconn, "cart", bson.M{"user_id": payload.Id})
+		if err != nil {
+			at.WriteError(respw, at.NewError(at.CodeNotFound, "Cart kosong", err.Error()))
+			return
+		}
+		summary, err := cart.Summary(config.Mongoconn.Collection(model.ProductCollection))
+		if err != nil {
+			at.WriteError(respw, at.NewError(at.CodeNotFound, "Produk di cart tidak ditemukan", err.Error()))
+			return
+		}
+		lines = summary.Available()
+	}
+	breakdown, _, err := applyVoucher(applyreq.Code, lines, payload.Id)
+	if err != nil {
+		at.WriteError(respw, at.NewError(at.CodeValidation, "Voucher tidak bisa dipakai", err.Error()))
+		return
+	}
+	at.WriteJSON(respw, http.StatusOK, breakdown)
+}
+
+// applyVoucher mengambil voucher berdasarkan kode lalu menghitung potongan untuk item belanja
+func applyVoucher(code string, lines []model.CartLine, userID string) (b voucher.Breakdown, v voucher.Voucher, err error) {
+	v, err = atdb.GetOneDoc[voucher.Voucher](config.Mongoconn, "voucher", bson.M{"code": voucher.NormalizeCode(code)})
+	if err != nil {
+		err = errors.New("voucher " + code + " tidak ditemukan")
+		return
+	}
+	var voucherLines []voucher.Line
+	for _, line := range lines {
+		voucherLines = append(voucherLines, voucher.Line{
+			ProductID: line.ProductID,
+			NamaLapak: line.NamaLapak,
+			Category:  line.Category,
+			Subtotal:  line.Subtotal,
+		})
+	}
+	b, err = voucher.Apply(v, voucherLines, userID, time.Now())
+	return
+}
+
+// redeemVoucher menambah pemakaian voucher secara atomik, kuota global dan per user dicek di filter yang sama
+// sehingga checkout bersamaan tidak bisa melewati batas
+func redeemVoucher(v voucher.Voucher, userID string, checkoutID primitive.ObjectID, discount int64) (err error) {
+	usageField := "usage." + userID
+	filter := bson.M{"_id": v.ID, "active": true}
+	if v.UsageLimit > 0 {
+		filter["used_count"] = bson.M{"$lt": v.UsageLimit}
+	}
+	if v.PerUserLimit > 0 {
+		filter[usageField] = bson.M{"$not": bson.M{"$gte": v.PerUserLimit}}
+	}
+	update := bson.M{"$inc": bson.M{"used_count": 1, usageField: 1}}
+	res, err := config.Mongoconn.Collection("voucher").UpdateOne(context.TODO(), filter, update)
+	if err != nil {
+		return
+	}
+	if res.ModifiedCount == 0 {
+		return errors.New("kuota voucher " + v.Code + " sudah habis")
+	}
+	redemption := voucher.Redemption{
+		VoucherID:  v.ID,
+		Code:       v.Code,
+		UserID:     userID,
+		CheckoutID: checkoutID,
+		Discount:   discount,
+		Status:     voucher.RedemptionUsed,
+		CreatedAt:  time.Now(),
+	}
+	if _, err = atdb.InsertOneDoc(config.Mongoconn, "voucherredemption", redemption); err != nil {
+		undoVoucherUsage(v.ID, userID)
+	}
+	return
+}
+
+// releaseVoucher mengembalikan kuota voucher dari checkout yang batal atau kedaluwarsa
+func releaseVoucher(checkoutID primitive.ObjectID) {
+	filter := bson.M{"checkout_id": checkoutID, "status": voucher.RedemptionUsed}
+	var redemption voucher.Redemption
+	err := config.Mongoconn.Collection("voucherredemption").FindOneAndUpdate(context.TODO(), filter,
+		bson.M{"$set": bson.M{"status": voucher.RedemptionReleased}}).Decode(&redemption)
+	if err != nil {
+		return
+	}
+	undoVoucherUsage(redemption.VoucherID, redemption.UserID)
+}
+
+func undoVoucherUsage(voucherID primitive.ObjectID, userID string) {
+	update := bson.M{"$inc": bson.M{"used_count": -1, "usage." + userID: -1}}
+	if _, err := config.Mongoconn.Collection("voucher").UpdateOne(context.TODO(), bson.M{"_id": voucherID}, update); err != nil {
+		log.Println("gagal mengembalikan kuota voucher " + voucherID.Hex() + ": " + err.Error())
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/gocroot/helper/at"
 	"github.com/gocroot/helper/atdb"
 	"github.com/gocroot/helper/report"
+	"github.com/gocroot/helper/router"
 	"github.com/gocroot/helper/whatsauth"
 	"github.com/gocroot/model"
 	"github.com/whatsauth/itmodel"
@@ -26,7 +27,7 @@ func PostInboxNomor(respw http.ResponseWriter, req *http.Request) {
 	var msg itmodel.IteungMessage
 	httpstatus := http.StatusUnauthorized
 	resp.Response = "Wrong Secret"
-	waphonenumber := at.GetParam(req)
+	waphonenumber := router.Param(req, "nomorwa")
 	prof, err := whatsauth.GetAppProfile(waphonenumber, config.Mongoconn)
 	if err != nil {
 		resp.Response = err.Error()
@@ -49,12 +50,11 @@ func PostInboxNomor(respw http.ResponseWriter, req *http.Request) {
 // jalan setiap jam 3 pagi
 func GetNewToken(respw http.ResponseWriter, req *http.Request) {
 	var resp model.Response
-	httpstatus := http.StatusServiceUnavailable
 
 	var wg sync.WaitGroup
 	wg.Add(3) // Menambahkan jumlah goroutine yang akan dijalankan
 
-	// Mutex untuk mengamankan akses ke variabel resp dan httpstatus
+	// Mutex untuk mengamankan akses ke variabel resp dan lastErr
 	var mu sync.Mutex
 	// Variabel untuk menyimpan kesalahan terakhir
 	var lastErr error
@@ -80,13 +80,11 @@ func GetNewToken(respw http.ResponseWriter, req *http.Request) {
 				mu.Lock()
 				lastErr = err
 				resp.Response = err.Error()
-				httpstatus = http.StatusInternalServerError
 				mu.Unlock()
 				continue // Lanjutkan ke iterasi berikutnya
 			}
 			mu.Lock()
 			resp.Response = at.Jsonstr(res.ModifiedCount)
-			httpstatus = http.StatusOK
 			mu.Unlock()
 		}
 	}()
@@ -98,7 +96,6 @@ func GetNewToken(respw http.ResponseWriter, req *http.Request) {
 			mu.Lock()
 			lastErr = err
 			resp.Response = err.Error()
-			httpstatus = http.StatusInternalServerError
 			mu.Unlock()
 		}
 	}()
@@ -110,23 +107,25 @@ func GetNewToken(respw http.ResponseWriter, req *http.Request) {
 			mu.Lock()
 			lastErr = err
 			resp.Response = err.Error()
-			httpstatus = http.StatusInternalServerError
 			mu.Unlock()
 		}
 	}()
 
 	wg.Wait() // Menunggu sampai semua goroutine selesai
 
-	// Menggunakan status yang benar dari kesalahan terakhir jika ada
+	// Kesalahan terakhir dikirim sebagai galat internal jika ada
 	if lastErr != nil {
-		at.WriteJSON(respw, httpstatus, resp)
+		at.WriteError(respw, at.NewError(at.CodeInternal, "Tugas harian gagal dijalankan", lastErr.Error()))
 	} else {
 		at.WriteJSON(respw, http.StatusOK, resp)
 	}
 }
 
 func NotFound(respw http.ResponseWriter, req *http.Request) {
-	var resp model.Response
-	resp.Response = "Not Found"
-	at.WriteJSON(respw, http.StatusNotFound, resp)
+	at.WriteError(respw, at.NewError(at.CodeNotFound, "Halaman tidak ditemukan", req.URL.Path))
+}
+
+// MethodNotAllowed path ada tapi method tidak didukung, daftar method ada di header Allow
+func MethodNotAllowed(respw http.ResponseWriter, req *http.Request) {
+	at.WriteError(respw, at.NewError(at.CodeMethodNotAllowed, "", "Method "+req.Method+" tidak didukung, gunakan "+respw.Header().Get("Allow")))
 }
@@ -0,0 +1,228 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gocroot/config"
+	"github.com/gocroot/helper/at"
+	"github.com/gocroot/helper/atapi"
+	"github.com/gocroot/helper/atdb"
+	"github.com/gocroot/helper/productevent"
+	"github.com/gocroot/helper/router"
+	"github.com/gocroot/model"
+	"github.com/whatsauth/itmodel"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// productEvents menyalurkan event perubahan produk dari UpdateProduct dan pelepasan stok checkout ke notifier wishlist
+var productEvents productevent.Bus
+
+func init() {
+	productEvents.Subscribe(notifyWishlist)
+}
+
+// GetWishlist daftar wishlist user dari token beserta data produknya
+func GetWishlist(respw http.ResponseWriter, req *http.Request) {
+	payload, err := decodeLoginToken(req)
+	if err != nil {
+		writeTokenError(respw, err)
+		return
+	}
+	pipeline := []bson.M{
+		{"$match": bson.M{"user_id": payload.Id}},
+		{"$sort": bson.M{"created_at": -1}},
+		{"$lookup": bson.M{"from": model.ProductCollection, "localField": "product_id", "foreignField": "_id", "as": "product"}},
+		{"$unwind": "$product"},
+	}
+	cur, err := config.Mongoconn.Collection("wishlist").Aggregate(context.TODO(), pipeline)
+	if err != nil {
+		at.WriteError(respw, at.NewError(at.CodeInternal, "Data wishlist tidak bisa diambil", err.Error()))
+		return
+	}
+	items := []model.WishlistItem{}
+	if err = cur.All(context.TODO(), &items); err != nil {
+		at.WriteError(respw, at.NewError(at.CodeInternal, "Data wishlist tidak bisa dibaca", err.Error()))
+		return
+	}
+	at.WriteJSON(respw, http.StatusOK, items)
+}
+
+// PostWishlist menyimpan produk ke wishlist user, produk yang sudah ada tidak diduplikasi
+func PostWishlist(respw http.ResponseWriter, req *http.Request) {
+	payload, err := decodeLoginToken(req)
+	if err != nil {
+		writeTokenError(respw, err)
+		return
+	}
+	var wish model.Wishlist
+	if err = json.NewDecoder(req.Body).Decode(&wish); err != nil {
+		at.WriteError(respw, at.NewError(at.CodeInvalidBody, "Body tidak valid", err.Error()))
+		return
+	}
+	if _, err = atdb.GetOneDoc[model.Product](config.Mongoconn, model.ProductCollection, bson.M{"_id": wish.ProductID}); err != nil {
+		at.WriteError(respw, at.NewError(at.CodeNotFound, "Data produk tidak di temukan", err.Error()))
+		return
+	}
+	filter := bson.M{"user_id": payload.Id, "product_id": wish.ProductID}
+	update := bson.M{"$setOnInsert": bson.M{"user_id": payload.Id, "product_id": wish.ProductID, "created_at": time.Now()}}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+	err = config.Mongoconn.Collection("wishlist").FindOneAndUpdate(context.TODO(), filter, update, opts).Decode(&wish)
+	if err != nil {
+		at.WriteError(respw, at.NewError(at.CodeInternal, "Gagal menyimpan wishlist", err.Error()))
+		return
+	}
+	at.WriteJSON(respw, http.StatusOK, wish)
+}
+
+// DeleteWishlist menghapus produk dari wishlist user
+func DeleteWishlist(respw http.ResponseWriter, req *http.Request) {
+	var respn model.Response
+	payload, err := decodeLoginToken(req)
+	if err != nil {
+		writeTokenError(respw, err)
+		return
+	}
+	productID, err := primitive.ObjectIDFromHex(router.Param(req, "productid"))
+	if err != nil {
+		at.WriteError(respw, at.NewError(at.CodeBadRequest, "ObjectID Tidak Valid", err.Error()))
+		return
+	}
+	res, err := atdb.DeleteOneDoc(config.Mongoconn, "wishlist", bson.M{"user_id": payload.Id, "product_id": productID})
+	if err != nil {
+		at.WriteError(respw, at.NewError(at.CodeInternal, "Gagal menghapus wishlist", err.Error()))
+		return
+	}
+	if res.DeletedCount == 0 {
+		at.WriteError(respw, at.NewError(at.CodeNotFound, "Produk tidak ada di wishlist", ""))
+		return
+	}
+	respn.Status = "Success"
+	respn.Response = "Produk dihapus dari wishlist"
+	at.WriteJSON(respw, http.StatusOK, respn)
+}
+
+// emitProductChange mencatat event perubahan produk lalu meneruskannya ke subscriber
+func emitProductChange(change productevent.Change) {
+	if _, err := atdb.InsertOneDoc(config.Mongoconn, "productevent", change); err != nil {
+		log.Println("gagal mencatat event produk " + change.ProductID.Hex() + ": " + err.Error())
+	}
+	productEvents.Publish(change)
+}
+
+const (
+	// jarak minimal dua notifikasi wishlist untuk produk yang sama ke user yang sama
+	wishlistNotifyGap = 24 * time.Hour
+	// jumlah notifikasi wishlist maksimal sekali jalan cron
+	wishlistNotifyBatch = 100
+)
+
+// notifyWishlist mengantrekan kabar diskon atau stok kembali ke wishlist produk tersebut, pesannya dikirim GetWishlistNotify.
+// Wishlist yang sudah diberi kabar dalam wishlistNotifyGap dilewati supaya stok yang naik turun tidak membuat pesan dobel,
+// perubahan yang lebih baru menimpa antrean yang belum terkirim.
+func notifyWishlist(change productevent.Change) {
+	filter := bson.M{
+		"product_id": change.ProductID,
+		"$or": bson.A{
+			bson.M{"notified_at": bson.M{"$exists": false}},
+			bson.M{"notified_at": bson.M{"$lt": change.At.Add(-wishlistNotifyGap)}},
+		},
+	}
+	_, err := config.Mongoconn.Collection("wishlist").UpdateMany(context.TODO(), filter, bson.M{"$set": bson.M{"pending_change": change}})
+	if err != nil {
+		log.Println("gagal mengantrekan notifikasi wishlist produk " + change.ProductID.Hex() + ": " + err.Error())
+	}
+}
+
+// GetWishlistNotify dipanggil dari cron untuk mengirim antrean notifikasi wishlist, paling banyak wishlistNotifyBatch sekali jalan.
+// Request wajib membawa header X-Cron-Secret.
+func GetWishlistNotify(respw http.ResponseWriter, req *http.Request) {
+	var resp model.Response
+	if !authorizeCron(respw, req) {
+		return
+	}
+	sent, err := sendWishlistNotifications()
+	if err != nil {
+		at.WriteError(respw, at.NewError(at.CodeInternal, "Notifikasi wishlist gagal", err.Error()))
+		return
+	}
+	resp.Status = "Success"
+	resp.Response = strconv.Itoa(sent) + " notifikasi wishlist terkirim"
+	at.WriteJSON(respw, http.StatusOK, resp)
+}
+
+// sendWishlistNotifications mengirim antrean notifikasi wishlist milik user yang tidak unsubscribe.
+// Wishlist diklaim dengan mengisi notified_at, jika pesan gagal terkirim klaim dikembalikan supaya dicoba lagi di cron berikutnya.
+func sendWishlistNotifications() (sent int, err error) {
+	unsubscribed, err := atdb.GetAllDistinct[string](config.Mongoconn, bson.M{}, "phone_number", "unsubscribe")
+	if err != nil {
+		return
+	}
+	col := config.Mongoconn.Collection("wishlist")
+	// antrean milik user yang sudah unsubscribe dibuang
+	if _, err = col.UpdateMany(context.TODO(), bson.M{"pending_change": bson.M{"$exists": true}, "user_id": bson.M{"$in": unsubscribed}},
+		bson.M{"$unset": bson.M{"pending_change": ""}}); err != nil {
+		return
+	}
+	opts := options.Find().SetSort(bson.M{"pending_change.at": 1}).SetLimit(wishlistNotifyBatch)
+	cur, err := col.Find(context.TODO(), bson.M{"pending_change": bson.M{"$exists": true}}, opts)
+	if err != nil {
+		return
+	}
+	var wishes []model.Wishlist
+	if err = cur.All(context.TODO(), &wishes); err != nil {
+		return
+	}
+	for _, wish := range wishes {
+		change := *wish.Pending
+		// klaim dengan update bersyarat supaya cron yang berjalan bersamaan tidak mengirim dua kali
+		claim := bson.M{"_id": wish.ID, "pending_change.at": change.At}
+		res, err := col.UpdateOne(context.TODO(), claim, bson.M{"$set": bson.M{"notified_at": change.At}, "$unset": bson.M{"pending_change": ""}})
+		if err != nil || res.ModifiedCount == 0 {
+			continue
+		}
+		dt := &itmodel.TextMessage{
+			To:       wish.UserID,
+			IsGroup:  false,
+			Messages: wishlistMessage(change),
+		}
+		_, _, err = atapi.PostStructWithToken[itmodel.Response]("Token", config.WAAPIToken, dt, config.WAAPIMessage)
+		if err == nil {
+			sent++
+			continue
+		}
+		log.Println("gagal mengirim notifikasi wishlist ke " + wish.UserID + ": " + err.Error())
+		unclaimWishlistNotify(wish)
+	}
+	return
+}
+
+// unclaimWishlistNotify mengembalikan antrean dan notified_at sebelum diklaim, kecuali sudah ada perubahan baru yang diantrekan
+func unclaimWishlistNotify(wish model.Wishlist) {
+	update := bson.M{"$set": bson.M{"pending_change": wish.Pending}, "$unset": bson.M{"notified_at": ""}}
+	if !wish.NotifiedAt.IsZero() {
+		update = bson.M{"$set": bson.M{"pending_change": wish.Pending, "notified_at": wish.NotifiedAt}}
+	}
+	filter := bson.M{"_id": wish.ID, "pending_change": bson.M{"$exists": false}}
+	if _, err := config.Mongoconn.Collection("wishlist").UpdateOne(context.TODO(), filter, update); err != nil {
+		log.Println("gagal mengembalikan antrean notifikasi wishlist " + wish.ID.Hex() + ": " + err.Error())
+	}
+}
+
+func wishlistMessage(change productevent.Change) string {
+	message := "*Kabar dari wishlist kamu*\n" + change.Name + " di " + change.NamaLapak
+	if change.Has(productevent.TypeBackInStock) {
+		message += "\nStok sudah tersedia lagi (" + strconv.Itoa(change.After.Stock) + " pcs)."
+	}
+	if change.Has(productevent.TypeDiscount) {
+		message += "\nSekarang diskon jadi Rp " + strconv.FormatInt(change.After.DiscountPrice, 10) +
+			" dari Rp " + strconv.FormatInt(change.After.OriginalPrice, 10) + "."
+	}
+	return message + "\n\n_Ketik unsubscribe jika tidak ingin menerima info lagi._"
+}
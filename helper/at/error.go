@@ -0,0 +1,149 @@
+package at
+
+import (
+	"errors"
+	"net/http"
+)
+
+// HeaderRequestID header id request, nilainya ikut ditulis di body galat agar mudah dicari di log
+const HeaderRequestID = "X-Request-ID"
+
+// Code kode galat yang dibaca frontend, nilainya tidak boleh diubah setelah dipakai client
+type Code string
+
+const (
+	CodeBadRequest       Code = "bad_request"
+	CodeInvalidBody      Code = "invalid_body"
+	CodeValidation       Code = "validation_failed"
+	CodeUnauthorized     Code = "unauthorized"
+	CodeInvalidToken     Code = "invalid_token"
+	CodeForbidden        Code = "forbidden"
+	CodeEmailUnverified  Code = "email_unverified"
+	CodeNotFound         Code = "not_found"
+	CodeMethodNotAllowed Code = "method_not_allowed"
+	CodeConflict         Code = "conflict"
+	CodeExpired          Code = "expired"
+	CodeRateLimited      Code = "rate_limited"
+	CodeInternal         Code = "internal_error"
+	CodeUpstream         Code = "upstream_error"
+	CodeUnavailable      Code = "unavailable"
+)
+
+// codeInfo status HTTP dan pesan bawaan untuk satu Code
+type codeInfo struct {
+	status int
+	id, en string
+}
+
+var codes = map[Code]codeInfo{
+	CodeBadRequest:       {http.StatusBadRequest, "Permintaan tidak valid", "Invalid request"},
+	CodeInvalidBody:      {http.StatusBadRequest, "Body tidak valid", "Invalid request body"},
+	CodeValidation:       {http.StatusUnprocessableEntity, "Isian tidak valid", "Validation failed"},
+	CodeUnauthorized:     {http.StatusUnauthorized, "Harus login terlebih dahulu", "Authentication required"},
+	CodeInvalidToken:     {http.StatusUnauthorized, "Token tidak valid", "Invalid or expired token"},
+	CodeForbidden:        {http.StatusForbidden, "Akses ditolak", "Access denied"},
+	CodeEmailUnverified:  {http.StatusForbidden, "Email belum diverifikasi", "Email address is not verified"},
+	CodeNotFound:         {http.StatusNotFound, "Data tidak ditemukan", "Resource not found"},
+	CodeMethodNotAllowed: {http.StatusMethodNotAllowed, "Method tidak didukung", "Method not allowed"},
+	CodeConflict:         {http.StatusConflict, "Data bentrok dengan data yang sudah ada", "Conflict with existing data"},
+	CodeExpired:          {http.StatusGone, "Sudah kedaluwarsa atau tidak berlaku", "Expired or no longer valid"},
+	CodeRateLimited:      {http.StatusTooManyRequests, "Terlalu banyak permintaan", "Too many requests"},
+	CodeInternal:         {http.StatusInternalServerError, "Terjadi kesalahan di server", "Internal server error"},
+	CodeUpstream:         {http.StatusBadGateway, "Layanan eksternal gagal merespon", "Upstream service failed"},
+	CodeUnavailable:      {http.StatusServiceUnavailable, "Layanan belum tersedia", "Service unavailable"},
+}
+
+// Status status HTTP untuk kode, kode yang tidak dikenal dianggap galat internal
+func (c Code) Status() int {
+	if info, ok := codes[c]; ok {
+		return info.status
+	}
+	return http.StatusInternalServerError
+}
+
+// Error galat API yang ditulis WriteError. Message pesan bahasa Indonesia khusus endpoint,
+// jika kosong dipakai pesan bawaan Code. Detail keterangan teknis seperti pesan error asli.
+type Error struct {
+	Code    Code
+	Message string
+	Detail  string
+	Info    string
+}
+
+// ErrorBody isi field error, frontend cukup membaca Code
+type ErrorBody struct {
+	Code      Code   `json:"code"`
+	Message   string `json:"message"`
+	MessageEN string `json:"message_en"`
+	Detail    string `json:"detail,omitempty"`
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// ErrorResponse bentuk JSON semua galat. Status, Response dan Info tetap diisi seperti model.Response
+// supaya client lama yang membaca teks Status masih jalan.
+type ErrorResponse struct {
+	Status   string    `json:"status"`
+	Response string    `json:"response"`
+	Info     string    `json:"info,omitempty"`
+	Error    ErrorBody `json:"error"`
+}
+
+// NewError membuat galat dengan pesan khusus dan detail teknis, keduanya boleh kosong
+func NewError(code Code, message, detail string) *Error {
+	return &Error{Code: code, Message: message, Detail: detail}
+}
+
+// WithInfo menambahkan keterangan tambahan seperti nama file atau nomor kwitansi
+func (e *Error) WithInfo(info string) *Error {
+	e.Info = info
+	return e
+}
+
+func (e *Error) Error() string {
+	if e.Detail == "" {
+		return e.message()
+	}
+	return e.message() + ": " + e.Detail
+}
+
+// Status status HTTP dari Code
+func (e *Error) Status() int {
+	return e.Code.Status()
+}
+
+func (e *Error) message() string {
+	if e.Message != "" {
+		return e.Message
+	}
+	return codes[e.Code].id
+}
+
+// Body envelope JSON untuk galat ini
+func (e *Error) Body(requestID string) ErrorResponse {
+	en := codes[e.Code].en
+	if en == "" {
+		en = codes[CodeInternal].en
+	}
+	return ErrorResponse{
+		Status:   "Error : " + e.message(),
+		Response: e.Detail,
+		Info:     e.Info,
+		Error: ErrorBody{
+			Code:      e.Code,
+			Message:   e.message(),
+			MessageEN: en,
+			Detail:    e.Detail,
+			RequestID: requestID,
+		},
+	}
+}
+
+// WriteError menulis galat dalam envelope standar. Error selain *Error ditulis sebagai galat internal.
+// Id request diambil dari header response yang sudah diisi middleware router.RequestID.
+func WriteError(respw http.ResponseWriter, err error) {
+	var apierr *Error
+	if !errors.As(err, &apierr) {
+		apierr = NewError(CodeInternal, "", err.Error())
+	}
+	WriteJSON(respw, apierr.Status(), apierr.Body(respw.Header().Get(HeaderRequestID)))
+}
@@ -0,0 +1,58 @@
+package at
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWriteError(t *testing.T) {
+	cases := []struct {
+		err     error
+		status  int
+		code    Code
+		message string
+		detail  string
+	}{
+		{NewError(CodeNotFound, "Data lapak tidak di temukan", "no documents"), http.StatusNotFound, CodeNotFound, "Data lapak tidak di temukan", "no documents"},
+		{NewError(CodeRateLimited, "", ""), http.StatusTooManyRequests, CodeRateLimited, "Terlalu banyak permintaan", ""},
+		{fmt.Errorf("bungkus: %w", NewError(CodeConflict, "Email sudah dipakai", "")), http.StatusConflict, CodeConflict, "Email sudah dipakai", ""},
+		{errors.New("koneksi putus"), http.StatusInternalServerError, CodeInternal, "Terjadi kesalahan di server", "koneksi putus"},
+		{NewError("kode_baru", "", ""), http.StatusInternalServerError, "kode_baru", "", ""},
+	}
+	for _, c := range cases {
+		rec := httptest.NewRecorder()
+		rec.Header().Set(HeaderRequestID, "req-1")
+		WriteError(rec, c.err)
+		if rec.Code != c.status {
+			t.Errorf("%v: status = %d, want %d", c.err, rec.Code, c.status)
+		}
+		var body ErrorResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatalf("%v: %v", c.err, err)
+		}
+		if body.Error.Code != c.code || body.Error.Message != c.message || body.Error.Detail != c.detail {
+			t.Errorf("%v: error = %+v", c.err, body.Error)
+		}
+		if body.Error.RequestID != "req-1" || body.Error.MessageEN == "" {
+			t.Errorf("%v: request id %q message_en %q", c.err, body.Error.RequestID, body.Error.MessageEN)
+		}
+		if body.Status != "Error : "+c.message || body.Response != c.detail {
+			t.Errorf("%v: legacy status %q response %q", c.err, body.Status, body.Response)
+		}
+	}
+}
+
+func TestErrorWithInfo(t *testing.T) {
+	err := NewError(CodeInternal, "Dokumen gagal di generate", "timeout").WithInfo("lapak-a")
+	body := err.Body("")
+	if body.Info != "lapak-a" || body.Error.RequestID != "" {
+		t.Fatalf("body = %+v", body)
+	}
+	if err.Error() != "Dokumen gagal di generate: timeout" {
+		t.Fatalf("Error() = %q", err.Error())
+	}
+}
@@ -17,6 +17,10 @@ import (
 func MongoConnect(mconn DBInfo) (db *mongo.Database, err error) {
 	client, err := mongo.Connect(context.TODO(), options.Client().ApplyURI(mconn.DBString))
 	if err != nil {
+		// lookup SRV manual hanya untuk uri mongodb+srv, uri lain yang tidak valid langsung dikembalikan errornya
+		if !strings.HasPrefix(mconn.DBString, "mongodb+srv://") {
+			return
+		}
 		mconn.DBString = SRVLookup(mconn.DBString)
 		client, err = mongo.Connect(context.TODO(), options.Client().ApplyURI(mconn.DBString))
 		if err != nil {
@@ -207,6 +211,16 @@ func UpdateOneDoc(db *mongo.Database, collection string, filter bson.M, updatefi
 	return
 }
 
+// CreateUniqueIndex membuat index unik pada keys, partial membatasi dokumen yang diindeks dan boleh nil.
+// Index yang sudah ada dengan definisi sama tidak dibuat ulang oleh Mongo.
+func CreateUniqueIndex(db *mongo.Database, collection string, keys bson.D, partial bson.M) (name string, err error) {
+	opts := options.Index().SetUnique(true)
+	if partial != nil {
+		opts.SetPartialFilterExpression(partial)
+	}
+	return db.Collection(collection).Indexes().CreateOne(context.TODO(), mongo.IndexModel{Keys: keys, Options: opts})
+}
+
 // With ReplaceOneDoc() you can only replace the entire document,
 // while UpdateOneDoc() allows for updating fields. Since ReplaceOneDoc() replaces the entire document - fields in the old document not contained in the new will be lost.
 func ReplaceOneDoc(db *mongo.Database, collection string, filter bson.M, doc interface{}) (updatereseult *mongo.UpdateResult, err error) {
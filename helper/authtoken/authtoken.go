@@ -0,0 +1,43 @@
+package authtoken
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gocroot/helper/at"
+	"github.com/gocroot/helper/session"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Filter filter Mongo yang hanya cocok dengan token yang belum dipakai dan belum kedaluwarsa
+func Filter(token, purpose string, now time.Time) bson.M {
+	return bson.M{"_id": session.HashToken(strings.TrimSpace(token)), "purpose": purpose,
+		"used_at": bson.M{"$exists": false}, "expires_at": bson.M{"$gt": now}}
+}
+
+// Usable padanan Filter untuk dokumen token yang sudah dibaca
+func Usable(doc Token, purpose string, now time.Time) bool {
+	return doc.Purpose == purpose && doc.UsedAt.IsZero() && doc.ExpiresAt.After(now)
+}
+
+// Consume menandai token terpakai secara atomik, token yang kedaluwarsa atau sudah dipakai ditolak
+func Consume(db *mongo.Database, token, purpose string, now time.Time) (doc Token, err error) {
+	err = db.Collection(Collection).FindOneAndUpdate(context.TODO(),
+		Filter(token, purpose, now), bson.M{"$set": bson.M{"used_at": now}}).Decode(&doc)
+	return
+}
+
+// Respond mengirim token lewat send hanya jika akun memenuhi syarat, response selalu sama
+// baik akun ada, tidak ada, maupun pengiriman gagal
+func Respond(w http.ResponseWriter, eligible bool, send func() error) {
+	if eligible {
+		if err := send(); err != nil {
+			log.Println("gagal mengirim token: " + err.Error())
+		}
+	}
+	at.WriteJSON(w, http.StatusOK, sentResponse{Response: SentMessage, Status: "Success"})
+}
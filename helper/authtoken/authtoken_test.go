@@ -0,0 +1,89 @@
+package authtoken
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gocroot/helper/session"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestUsableSingleUse(t *testing.T) {
+	now := time.Date(2024, 8, 9, 8, 0, 0, 0, time.UTC)
+	doc := Token{Purpose: ResetPassword, CreatedAt: now, ExpiresAt: now.Add(30 * time.Minute)}
+	if !Usable(doc, ResetPassword, now) {
+		t.Fatal("fresh token should be usable")
+	}
+	if Usable(doc, VerifyEmail, now) {
+		t.Error("token must not be usable for another purpose")
+	}
+	// Consume mengisi used_at, pemakaian kedua harus ditolak
+	doc.UsedAt = now.Add(time.Minute)
+	if Usable(doc, ResetPassword, now.Add(2*time.Minute)) {
+		t.Error("used token should not be usable again")
+	}
+}
+
+func TestUsableExpiry(t *testing.T) {
+	now := time.Date(2024, 8, 9, 8, 0, 0, 0, time.UTC)
+	doc := Token{Purpose: VerifyEmail, CreatedAt: now, ExpiresAt: now.Add(24 * time.Hour)}
+	if !Usable(doc, VerifyEmail, now.Add(24*time.Hour-time.Second)) {
+		t.Error("token should be usable before expiry")
+	}
+	if Usable(doc, VerifyEmail, now.Add(24*time.Hour)) {
+		t.Error("token should expire exactly at expires_at")
+	}
+	if Usable(doc, VerifyEmail, now.Add(25*time.Hour)) {
+		t.Error("expired token should not be usable")
+	}
+}
+
+func TestFilter(t *testing.T) {
+	now := time.Date(2024, 8, 9, 8, 0, 0, 0, time.UTC)
+	f := Filter(" rahasia \n", ResetPassword, now)
+	if f["_id"] != session.HashToken("rahasia") || f["purpose"] != ResetPassword {
+		t.Fatalf("filter = %v", f)
+	}
+	if used, _ := f["used_at"].(bson.M); used["$exists"] != false {
+		t.Errorf("filter must skip used tokens: %v", f["used_at"])
+	}
+	if exp, _ := f["expires_at"].(bson.M); exp["$gt"] != now {
+		t.Errorf("filter must skip expired tokens: %v", f["expires_at"])
+	}
+}
+
+func TestRespondUniform(t *testing.T) {
+	cases := []struct {
+		name     string
+		eligible bool
+		sendErr  error
+	}{
+		{"akun ada", true, nil},
+		{"pengiriman gagal", true, errors.New("smtp mati")},
+		{"akun tidak ada", false, nil},
+	}
+	var want []byte
+	for _, c := range cases {
+		sent := false
+		rec := httptest.NewRecorder()
+		Respond(rec, c.eligible, func() error {
+			sent = true
+			return c.sendErr
+		})
+		if sent != c.eligible {
+			t.Errorf("%s: sent = %v, want %v", c.name, sent, c.eligible)
+		}
+		if rec.Code != http.StatusOK {
+			t.Errorf("%s: status = %d", c.name, rec.Code)
+		}
+		if want == nil {
+			want = rec.Body.Bytes()
+		} else if !bytes.Equal(rec.Body.Bytes(), want) {
+			t.Errorf("%s: body %s differs from %s", c.name, rec.Body.Bytes(), want)
+		}
+	}
+}
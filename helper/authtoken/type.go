@@ -0,0 +1,36 @@
+package authtoken
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const Collection = "authtoken"
+
+// tujuan token sekali pakai
+const (
+	VerifyEmail   = "verify_email"
+	ResetPassword = "reset_password"
+)
+
+// SentMessage pesan umum supaya endpoint tidak bisa dipakai menebak email atau nomor yang terdaftar
+const SentMessage = "Jika akun terdaftar, tautan sudah dikirim"
+
+// Token token verifikasi email dan reset password, _id adalah hash token sehingga token asli tidak tersimpan
+type Token struct {
+	ID        string             `bson:"_id"`
+	Purpose   string             `bson:"purpose"`
+	UserID    primitive.ObjectID `bson:"user_id"`
+	Email     string             `bson:"email,omitempty"` // email yang diverifikasi, token batal jika email user sudah berubah
+	Channel   string             `bson:"channel"`         // email atau whatsapp
+	CreatedAt time.Time          `bson:"created_at"`
+	ExpiresAt time.Time          `bson:"expires_at"`
+	UsedAt    time.Time          `bson:"used_at,omitempty"`
+}
+
+// sentResponse sama dengan model.Response supaya bentuk response tidak berubah
+type sentResponse struct {
+	Response string `json:"response"`
+	Status   string `json:"status,omitempty"`
+}
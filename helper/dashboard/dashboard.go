@@ -0,0 +1,225 @@
+package dashboard
+
+import (
+	"encoding/csv"
+	"errors"
+	"io"
+	"strconv"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Zona waktu lapak untuk pengelompokan tanggal
+const TimeZone = "Asia/Jakarta"
+
+// RevenueStatus status pesanan yang dihitung sebagai pendapatan, checkout lunas berstatus paid
+var RevenueStatus = []string{"paid", "processing", "shipped", "done"}
+
+// BestSellerLimit jumlah barang terlaris yang ditampilkan
+const BestSellerLimit = 10
+
+// QueuePipeline aggregation antrian pesanan, dijalankan di collection order dan digabung dengan checkout lewat $unionWith
+func QueuePipeline(f Filter) []bson.M {
+	pipeline := unionPipeline(f, nil)
+	if f.Status != "" {
+		pipeline = append(pipeline, bson.M{"$match": bson.M{"status": f.Status}})
+	}
+	limit := f.Limit
+	if limit <= 0 {
+		limit = 50
+	}
+	return append(pipeline,
+		bson.M{"$sort": bson.D{{Key: "createdAt", Value: -1}}},
+		bson.M{"$limit": limit},
+	)
+}
+
+// SummaryPipeline aggregation ringkasan pendapatan per periode, barang terlaris dan rata-rata nilai pesanan
+func SummaryPipeline(f Filter, period string) ([]bson.M, error) {
+	format, err := periodFormat(period)
+	if err != nil {
+		return nil, err
+	}
+	pipeline := unionPipeline(f, RevenueStatus)
+	// pendapatan lapak dari subtotal item miliknya saja, ongkir dan item lapak lain tidak dihitung
+	pipeline = append(pipeline, bson.M{"$addFields": bson.M{"revenue": bson.M{"$sum": "$items.subtotal"}}})
+	pipeline = append(pipeline, bson.M{"$facet": bson.M{
+		"overall": bson.A{
+			bson.M{"$group": bson.M{
+				"_id":                 nil,
+				"total_orders":        bson.M{"$sum": 1},
+				"total_revenue":       bson.M{"$sum": "$revenue"},
+				"average_order_value": bson.M{"$avg": "$revenue"},
+			}},
+		},
+		"revenue": bson.A{
+			bson.M{"$group": bson.M{
+				"_id":     bson.M{"$dateToString": bson.M{"format": format, "date": "$createdAt", "timezone": TimeZone}},
+				"orders":  bson.M{"$sum": 1},
+				"revenue": bson.M{"$sum": "$revenue"},
+			}},
+			bson.M{"$sort": bson.M{"_id": 1}},
+		},
+		"best_sellers": bson.A{
+			bson.M{"$unwind": "$items"},
+			bson.M{"$group": bson.M{
+				"_id":      "$items.name",
+				"quantity": bson.M{"$sum": "$items.quantity"},
+				"revenue":  bson.M{"$sum": "$items.subtotal"},
+			}},
+			bson.M{"$sort": bson.D{{Key: "quantity", Value: -1}, {Key: "revenue", Value: -1}}},
+			bson.M{"$limit": BestSellerLimit},
+		},
+	}})
+	return pipeline, nil
+}
+
+// DefaultRange rentang waktu bawaan jika from dan to tidak diisi
+func DefaultRange(period string, now time.Time) (from, to time.Time) {
+	to = now
+	switch period {
+	case PeriodWeekly:
+		from = now.AddDate(0, 0, -7*12)
+	case PeriodMonthly:
+		from = now.AddDate(-1, 0, 0)
+	default:
+		from = now.AddDate(0, 0, -30)
+	}
+	return
+}
+
+// WriteQueueCSV menulis antrian pesanan sebagai csv
+func WriteQueueCSV(w io.Writer, rows []Row) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"id", "source", "created_at", "status", "buyer", "item", "quantity", "subtotal", "total"})
+	for _, row := range rows {
+		items := row.Items
+		if len(items) == 0 {
+			items = []Item{{}}
+		}
+		for _, item := range items {
+			cw.Write([]string{
+				row.ID,
+				row.Source,
+				row.CreatedAt.Format(time.RFC3339),
+				row.Status,
+				row.Buyer,
+				item.Name,
+				strconv.Itoa(item.Quantity),
+				formatAmount(item.Subtotal),
+				formatAmount(row.Total),
+			})
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteSummaryCSV menulis ringkasan penjualan sebagai csv dengan kolom section untuk membedakan bagian
+func WriteSummaryCSV(w io.Writer, s Summary) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"section", "key", "orders", "quantity", "revenue"})
+	cw.Write([]string{"total", s.Period, strconv.Itoa(s.TotalOrders), "", formatAmount(s.TotalRevenue)})
+	cw.Write([]string{"average_order_value", s.Period, "", "", formatAmount(s.AverageOrderValue)})
+	for _, b := range s.Revenue {
+		cw.Write([]string{"revenue", b.Period, strconv.Itoa(b.Orders), "", formatAmount(b.Revenue)})
+	}
+	for _, b := range s.BestSellers {
+		cw.Write([]string{"best_seller", b.Name, "", strconv.Itoa(b.Quantity), formatAmount(b.Revenue)})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// unionPipeline menyeragamkan dokumen order dan checkout ke bentuk Row. Checkout dicari dari lapak tiap item
+// dan hanya item milik lapak tersebut yang diambil, karena satu checkout lama bisa berisi produk beberapa lapak.
+func unionPipeline(f Filter, status []string) []bson.M {
+	orderMatch := f.match("namalapak", "createdAt")
+	checkoutMatch := f.match("items.namalapak", "createdAt")
+	if len(status) > 0 {
+		orderMatch["status"] = bson.M{"$in": status}
+		checkoutMatch["status"] = bson.M{"$in": status}
+	}
+	orderStages := []bson.M{
+		{"$match": orderMatch},
+		{"$project": bson.M{
+			"source":    SourceOrder,
+			"createdAt": 1,
+			"status":    bson.M{"$ifNull": bson.A{"$status", "pending"}},
+			"buyer":     "$user.whatsapp",
+			"total":     "$total",
+			"items": bson.M{"$map": bson.M{
+				"input": bson.M{"$ifNull": bson.A{"$orders", bson.A{}}},
+				"as":    "o",
+				"in": bson.M{
+					"name":     "$$o.name",
+					"quantity": "$$o.quantity",
+					"subtotal": bson.M{"$multiply": bson.A{"$$o.price", "$$o.quantity"}},
+				},
+			}},
+		}},
+	}
+	checkoutStages := []bson.M{
+		{"$match": checkoutMatch},
+		{"$project": bson.M{
+			"source":    SourceCheckout,
+			"createdAt": 1,
+			"status":    1,
+			"buyer":     "$phonenumber",
+			"total":     "$totalprice",
+			"items": bson.M{"$map": bson.M{
+				"input": bson.M{"$filter": bson.M{
+					"input": bson.M{"$ifNull": bson.A{"$items", bson.A{}}},
+					"as":    "i",
+					"cond":  bson.M{"$eq": bson.A{"$$i.namalapak", f.NamaLapak}},
+				}},
+				"as": "i",
+				"in": bson.M{
+					"name":     "$$i.name",
+					"quantity": "$$i.quantity",
+					"subtotal": "$$i.subtotal",
+				},
+			}},
+		}},
+	}
+	switch f.Source {
+	case SourceOrder:
+		return orderStages
+	case SourceCheckout:
+		// $unionWith dari collection order tanpa dokumen order sama sekali
+		return append([]bson.M{{"$match": bson.M{"_id": nil}}}, bson.M{"$unionWith": bson.M{"coll": "checkout", "pipeline": checkoutStages}})
+	}
+	return append(orderStages, bson.M{"$unionWith": bson.M{"coll": "checkout", "pipeline": checkoutStages}})
+}
+
+func (f Filter) match(lapakField, dateField string) bson.M {
+	match := bson.M{lapakField: f.NamaLapak}
+	date := bson.M{}
+	if !f.From.IsZero() {
+		date["$gte"] = f.From
+	}
+	if !f.To.IsZero() {
+		date["$lt"] = f.To
+	}
+	if len(date) > 0 {
+		match[dateField] = date
+	}
+	return match
+}
+
+func periodFormat(period string) (string, error) {
+	switch period {
+	case PeriodDaily, "":
+		return "%Y-%m-%d", nil
+	case PeriodWeekly:
+		return "%G-W%V", nil
+	case PeriodMonthly:
+		return "%Y-%m", nil
+	}
+	return "", errors.New("period harus daily, weekly atau monthly")
+}
+
+func formatAmount(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
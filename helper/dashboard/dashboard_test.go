@@ -0,0 +1,54 @@
+package dashboard
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestWriteQueueCSV(t *testing.T) {
+	rows := []Row{{
+		ID:        "abc",
+		Source:    SourceOrder,
+		CreatedAt: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		Status:    "paid",
+		Buyer:     "62811",
+		Total:     25000,
+		Items:     []Item{{Name: "Kopi, susu", Quantity: 2, Subtotal: 20000}, {Name: "Roti", Quantity: 1, Subtotal: 5000}},
+	}}
+	var sb strings.Builder
+	if err := WriteQueueCSV(&sb, rows); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(sb.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("jumlah baris csv %d, harusnya 3", len(lines))
+	}
+	if lines[1] != `abc,order,2024-01-02T03:04:05Z,paid,62811,"Kopi, susu",2,20000,25000` {
+		t.Errorf("baris csv tidak sesuai: %s", lines[1])
+	}
+}
+
+func TestSummaryPipeline(t *testing.T) {
+	if _, err := SummaryPipeline(Filter{NamaLapak: "kopi"}, "yearly"); err == nil {
+		t.Error("period tidak dikenal harus ditolak")
+	}
+	pipeline, err := SummaryPipeline(Filter{NamaLapak: "kopi", Source: SourceCheckout}, PeriodWeekly)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := pipeline[len(pipeline)-1]["$facet"]; !ok {
+		t.Error("tahap terakhir harus $facet")
+	}
+	// checkout dicari dari lapak item, bukan lapak checkout
+	union := pipeline[1]["$unionWith"].(bson.M)["pipeline"].([]bson.M)
+	if _, ok := union[0]["$match"].(bson.M)["items.namalapak"]; !ok {
+		t.Errorf("match checkout harus memakai items.namalapak: %v", union[0])
+	}
+	revenue := pipeline[len(pipeline)-2]["$addFields"].(bson.M)["revenue"]
+	if revenue.(bson.M)["$sum"] != "$items.subtotal" {
+		t.Errorf("pendapatan harus dari subtotal item: %v", revenue)
+	}
+}
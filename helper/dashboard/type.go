@@ -0,0 +1,71 @@
+package dashboard
+
+import "time"
+
+// Periode ringkasan penjualan
+const (
+	PeriodDaily   = "daily"
+	PeriodWeekly  = "weekly"
+	PeriodMonthly = "monthly"
+)
+
+// Sumber pesanan
+const (
+	SourceOrder    = "order"
+	SourceCheckout = "checkout"
+)
+
+// Filter antrian pesanan dan ringkasan penjualan satu lapak
+type Filter struct {
+	NamaLapak string
+	Source    string // kosong berarti order dan checkout
+	Status    string
+	From      time.Time
+	To        time.Time
+	Limit     int64
+}
+
+// Item baris barang di pesanan yang sudah diseragamkan dari order dan checkout
+type Item struct {
+	Name     string  `json:"name" bson:"name"`
+	Quantity int     `json:"quantity" bson:"quantity"`
+	Subtotal float64 `json:"subtotal" bson:"subtotal"`
+}
+
+// Row satu pesanan di antrian lapak
+type Row struct {
+	ID        string    `json:"id" bson:"_id"`
+	Source    string    `json:"source" bson:"source"`
+	CreatedAt time.Time `json:"created_at" bson:"createdAt"`
+	Status    string    `json:"status" bson:"status"`
+	Buyer     string    `json:"buyer" bson:"buyer"`
+	Total     float64   `json:"total" bson:"total"`
+	Items     []Item    `json:"items" bson:"items"`
+}
+
+// Bucket pendapatan per hari, minggu atau bulan
+type Bucket struct {
+	Period  string  `json:"period" bson:"_id"`
+	Orders  int     `json:"orders" bson:"orders"`
+	Revenue float64 `json:"revenue" bson:"revenue"`
+}
+
+// BestSeller barang terlaris
+type BestSeller struct {
+	Name     string  `json:"name" bson:"_id"`
+	Quantity int     `json:"quantity" bson:"quantity"`
+	Revenue  float64 `json:"revenue" bson:"revenue"`
+}
+
+// Summary ringkasan penjualan lapak
+type Summary struct {
+	NamaLapak         string       `json:"namalapak" bson:"-"`
+	Period            string       `json:"period" bson:"-"`
+	From              time.Time    `json:"from" bson:"-"`
+	To                time.Time    `json:"to" bson:"-"`
+	TotalOrders       int          `json:"total_orders" bson:"total_orders"`
+	TotalRevenue      float64      `json:"total_revenue" bson:"total_revenue"`
+	AverageOrderValue float64      `json:"average_order_value" bson:"average_order_value"`
+	Revenue           []Bucket     `json:"revenue" bson:"revenue"`
+	BestSellers       []BestSeller `json:"best_sellers" bson:"best_sellers"`
+}
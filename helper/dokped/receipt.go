@@ -0,0 +1,117 @@
+package dokped
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/gocroot/model"
+	"github.com/jung-kurt/gofpdf"
+)
+
+// GenerateReceipt membuat kwitansi pdf untuk checkout yang pembayarannya sudah di approve
+func GenerateReceipt(checkout model.Checkout, conf model.Confirmation) (filecontent []byte, err error) {
+	if conf.Status != model.ConfirmationApproved {
+		err = errors.New("pembayaran belum di approve")
+		return
+	}
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.AddPage()
+
+	// Judul kwitansi
+	pdf.SetFont("Arial", "B", 16)
+	pdf.CellFormat(0, 10, "KWITANSI PEMBAYARAN", "", 1, "C", false, 0, "")
+	pdf.Ln(5)
+
+	// Informasi checkout
+	pdf.SetFont("Arial", "", 11)
+	pdf.CellFormat(40, 6, "No. Kwitansi", "", 0, "L", false, 0, "")
+	pdf.CellFormat(5, 6, ":", "", 0, "L", false, 0, "")
+	pdf.CellFormat(0, 6, checkout.ReceiptNumber, "", 1, "L", false, 0, "")
+	pdf.CellFormat(40, 6, "ID Checkout", "", 0, "L", false, 0, "")
+	pdf.CellFormat(5, 6, ":", "", 0, "L", false, 0, "")
+	pdf.CellFormat(0, 6, checkout.ID.Hex(), "", 1, "L", false, 0, "")
+	pdf.CellFormat(40, 6, "Tanggal", "", 0, "L", false, 0, "")
+	pdf.CellFormat(5, 6, ":", "", 0, "L", false, 0, "")
+	pdf.CellFormat(0, 6, getTodayFormattedDate(), "", 1, "L", false, 0, "")
+	pdf.CellFormat(40, 6, "Lapak", "", 0, "L", false, 0, "")
+	pdf.CellFormat(5, 6, ":", "", 0, "L", false, 0, "")
+	pdf.CellFormat(0, 6, checkout.NamaLapak, "", 1, "L", false, 0, "")
+	pdf.CellFormat(40, 6, "Pembeli", "", 0, "L", false, 0, "")
+	pdf.CellFormat(5, 6, ":", "", 0, "L", false, 0, "")
+	pdf.CellFormat(0, 6, checkout.PhoneNumber, "", 1, "L", false, 0, "")
+	pdf.CellFormat(40, 6, "Alamat", "", 0, "L", false, 0, "")
+	pdf.CellFormat(5, 6, ":", "", 0, "L", false, 0, "")
+	pdf.MultiCell(0, 6, checkout.Address, "", "L", false)
+	pdf.CellFormat(40, 6, "Metode Bayar", "", 0, "L", false, 0, "")
+	pdf.CellFormat(5, 6, ":", "", 0, "L", false, 0, "")
+	pdf.CellFormat(0, 6, checkout.PaymentMethod, "", 1, "L", false, 0, "")
+	pdf.Ln(5)
+
+	// Tabel produk, checkout dari cart memakai items dengan harga yang sudah dikunci
+	pdf.SetFont("Arial", "B", 11)
+	pdf.CellFormat(10, 7, "No", "1", 0, "C", false, 0, "")
+	pdf.CellFormat(90, 7, "Produk", "1", 0, "L", false, 0, "")
+	pdf.CellFormat(30, 7, "Jumlah", "1", 0, "C", false, 0, "")
+	pdf.CellFormat(50, 7, "Harga", "1", 1, "R", false, 0, "")
+	pdf.SetFont("Arial", "", 11)
+	if len(checkout.Items) > 0 {
+		for i, item := range checkout.Items {
+			pdf.CellFormat(10, 7, fmt.Sprint(i+1), "1", 0, "C", false, 0, "")
+			pdf.CellFormat(90, 7, item.Name, "1", 0, "L", false, 0, "")
+			pdf.CellFormat(30, 7, fmt.Sprintf("%d x %s", item.Quantity, formatRupiah(float64(item.UnitPrice))), "1", 0, "C", false, 0, "")
+			pdf.CellFormat(50, 7, formatRupiah(float64(item.Subtotal)), "1", 1, "R", false, 0, "")
+		}
+	} else {
+		for i, product := range checkout.Product {
+			pdf.CellFormat(10, 7, fmt.Sprint(i+1), "1", 0, "C", false, 0, "")
+			pdf.CellFormat(90, 7, product.Name, "1", 0, "L", false, 0, "")
+			pdf.CellFormat(30, 7, "1", "1", 0, "C", false, 0, "")
+			pdf.CellFormat(50, 7, formatRupiah(float64(model.UnitPrice(product))), "1", 1, "R", false, 0, "")
+		}
+	}
+	if checkout.Discount > 0 || checkout.ShippingFee > 0 {
+		pdf.CellFormat(130, 7, "Subtotal", "1", 0, "R", false, 0, "")
+		pdf.CellFormat(50, 7, formatRupiah(checkout.Subtotal), "1", 1, "R", false, 0, "")
+	}
+	if checkout.Discount > 0 {
+		pdf.CellFormat(130, 7, "Voucher "+checkout.VoucherCode, "1", 0, "R", false, 0, "")
+		pdf.CellFormat(50, 7, "- "+formatRupiah(checkout.Discount), "1", 1, "R", false, 0, "")
+	}
+	if checkout.ShippingFee > 0 {
+		pdf.CellFormat(130, 7, "Ongkos Kirim", "1", 0, "R", false, 0, "")
+		pdf.CellFormat(50, 7, formatRupiah(checkout.ShippingFee), "1", 1, "R", false, 0, "")
+	}
+	pdf.SetFont("Arial", "B", 11)
+	pdf.CellFormat(130, 7, "Total", "1", 0, "R", false, 0, "")
+	pdf.CellFormat(50, 7, formatRupiah(checkout.TotalPrice), "1", 1, "R", false, 0, "")
+	pdf.Ln(10)
+
+	pdf.SetFont("Arial", "I", 9)
+	pdf.MultiCell(0, 5, "Kwitansi ini dibuat otomatis setelah bukti pembayaran di approve oleh penjual dan sah tanpa tanda tangan.", "", "L", false)
+
+	var buf bytes.Buffer
+	err = pdf.Output(&buf)
+	if err != nil {
+		return
+	}
+	filecontent = buf.Bytes()
+	return
+}
+
+// GenerateReceiptNumber membuat nomor kwitansi dengan format KWT + YYYYMMDDHHMMSS
+func GenerateReceiptNumber() string {
+	return "KWT" + generateNomorSurat()
+}
+
+func formatRupiah(nilai float64) string {
+	str := fmt.Sprintf("%.0f", nilai)
+	var hasil []byte
+	for i := range str {
+		if i > 0 && (len(str)-i)%3 == 0 && str[i-1] != '-' {
+			hasil = append(hasil, '.')
+		}
+		hasil = append(hasil, str[i])
+	}
+	return "Rp " + string(hasil)
+}
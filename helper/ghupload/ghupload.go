@@ -80,3 +80,29 @@ func GithubGetFile(GitHubAccessToken, githubOrg, githubRepo, pathFile string) (f
 
 	return
 }
+
+// Function to delete file from GitHub repository
+func GithubDeleteFile(GitHubAccessToken, GitHubAuthorName, GitHubAuthorEmail string, githubOrg string, githubRepo string, pathFile string) (err error) {
+	ctx := context.Background()
+	ts := oauth2.StaticTokenSource(
+		&oauth2.Token{AccessToken: GitHubAccessToken},
+	)
+	tc := oauth2.NewClient(ctx, ts)
+	client := github.NewClient(tc)
+
+	currentContent, _, _, err := client.Repositories.GetContents(ctx, githubOrg, githubRepo, pathFile, nil)
+	if err != nil {
+		return errors.New("error GetContents " + err.Error())
+	}
+	opts := &github.RepositoryContentFileOptions{
+		Message: github.String("Delete file"),
+		SHA:     github.String(currentContent.GetSHA()),
+		Branch:  github.String("main"),
+		Author: &github.CommitAuthor{
+			Name:  github.String(GitHubAuthorName),
+			Email: github.String(GitHubAuthorEmail),
+		},
+	}
+	_, _, err = client.Repositories.DeleteFile(ctx, githubOrg, githubRepo, pathFile, opts)
+	return
+}
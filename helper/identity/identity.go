@@ -0,0 +1,108 @@
+package identity
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	emailPattern  = regexp.MustCompile(`^[^@\s]+@[^@\s]+\.[^@\s]+$`)
+	githubPattern = regexp.MustCompile(`^[a-z0-9](?:[a-z0-9]|-[a-z0-9]){0,38}$`)
+)
+
+// Normalize menyeragamkan subject supaya identitas yang sama selalu tersimpan dengan nilai yang sama.
+// Nomor telepon diubah ke format 62xxx, email dan username GitHub dijadikan huruf kecil.
+func Normalize(typ, subject string) (string, error) {
+	subject = strings.TrimSpace(subject)
+	switch typ {
+	case Phone:
+		var b strings.Builder
+		for _, r := range subject {
+			switch {
+			case r >= '0' && r <= '9':
+				b.WriteRune(r)
+			case r == '+' || r == '-' || r == ' ' || r == '(' || r == ')':
+			default:
+				return "", ErrInvalidSubject
+			}
+		}
+		phone := b.String()
+		if strings.HasPrefix(phone, "0") {
+			phone = "62" + phone[1:]
+		}
+		if len(phone) < 9 || len(phone) > 15 {
+			return "", ErrInvalidSubject
+		}
+		return phone, nil
+	case Email:
+		email := strings.ToLower(subject)
+		if !emailPattern.MatchString(email) {
+			return "", ErrInvalidSubject
+		}
+		return email, nil
+	case GitHub:
+		username := strings.ToLower(strings.TrimPrefix(subject, "@"))
+		if !githubPattern.MatchString(username) {
+			return "", ErrInvalidSubject
+		}
+		return username, nil
+	case Google:
+		if subject == "" {
+			return "", ErrInvalidSubject
+		}
+		return subject, nil
+	}
+	return "", ErrUnknownType
+}
+
+// CanLogin identitas yang bisa dipakai login, hanya identitas ini yang boleh memicu penggabungan akun
+func CanLogin(typ string) bool {
+	return typ == Phone || typ == Google || typ == Email
+}
+
+// Contains apakah list memuat identitas dengan jenis dan subject tersebut
+func Contains(list []Identity, typ, subject string) bool {
+	for _, id := range list {
+		if id.Type == typ && id.Subject == subject {
+			return true
+		}
+	}
+	return false
+}
+
+// Add menambahkan identitas jika belum ada
+func Add(list []Identity, id Identity) ([]Identity, bool) {
+	if id.Subject == "" || Contains(list, id.Type, id.Subject) {
+		return list, false
+	}
+	return append(list, id), true
+}
+
+// Remove menghapus identitas dengan jenis dan subject tersebut
+func Remove(list []Identity, typ, subject string) ([]Identity, bool) {
+	for i, id := range list {
+		if id.Type == typ && id.Subject == subject {
+			return append(list[:i:i], list[i+1:]...), true
+		}
+	}
+	return list, false
+}
+
+// Union gabungan dua list tanpa duplikat, urutan a dipertahankan
+func Union(a, b []Identity) []Identity {
+	merged := append([]Identity{}, a...)
+	for _, id := range b {
+		merged, _ = Add(merged, id)
+	}
+	return merged
+}
+
+// CountLogin jumlah identitas yang bisa dipakai login
+func CountLogin(list []Identity) (n int) {
+	for _, id := range list {
+		if CanLogin(id.Type) {
+			n++
+		}
+	}
+	return
+}
@@ -0,0 +1,44 @@
+package identity
+
+import "testing"
+
+func TestNormalize(t *testing.T) {
+	cases := []struct {
+		typ, in, want string
+		ok            bool
+	}{
+		{Phone, "0812-3456-789", "628123456789", true},
+		{Phone, "+62 812 3456 789", "628123456789", true},
+		{Phone, "08abc", "", false},
+		{Email, " Budi@Example.COM ", "budi@example.com", true},
+		{Email, "budi@", "", false},
+		{GitHub, "@Awangga", "awangga", true},
+		{GitHub, "bad--name", "", false},
+		{Google, "1234567890", "1234567890", true},
+		{"twitter", "x", "", false},
+	}
+	for _, c := range cases {
+		got, err := Normalize(c.typ, c.in)
+		if (err == nil) != c.ok || got != c.want {
+			t.Errorf("Normalize(%s, %q) = %q, %v", c.typ, c.in, got, err)
+		}
+	}
+}
+
+func TestListOperations(t *testing.T) {
+	list, added := Add(nil, Identity{Type: Phone, Subject: "6281"})
+	if !added {
+		t.Fatal("first add should succeed")
+	}
+	if _, added = Add(list, Identity{Type: Phone, Subject: "6281"}); added {
+		t.Fatal("duplicate add should be ignored")
+	}
+	list = Union(list, []Identity{{Type: GitHub, Subject: "budi"}, {Type: Phone, Subject: "6281"}, {Type: Email, Subject: "b@x.id"}})
+	if len(list) != 3 || CountLogin(list) != 2 {
+		t.Fatalf("unexpected union %v", list)
+	}
+	rest, removed := Remove(list, GitHub, "budi")
+	if !removed || len(rest) != 2 || Contains(rest, GitHub, "budi") || !Contains(list, GitHub, "budi") {
+		t.Fatalf("Remove should not modify the original list: %v %v", list, rest)
+	}
+}
@@ -0,0 +1,26 @@
+package identity
+
+import (
+	"errors"
+	"time"
+)
+
+// jenis identitas yang bisa dimiliki satu user
+const (
+	Phone  = "phone"  // nomor WhatsApp, juga id di token login
+	Google = "google" // sub dari Google ID token
+	Email  = "email"  // email untuk login email dan password
+	GitHub = "github" // username GitHub untuk repo buku
+)
+
+var (
+	ErrUnknownType    = errors.New("jenis identitas tidak dikenal")
+	ErrInvalidSubject = errors.New("format identitas tidak valid")
+)
+
+// Identity satu cara mengenali user, Subject sudah dinormalisasi dengan Normalize
+type Identity struct {
+	Type     string    `bson:"type" json:"type"`
+	Subject  string    `bson:"subject" json:"subject"`
+	LinkedAt time.Time `bson:"linked_at,omitempty" json:"linked_at,omitempty"`
+}
@@ -0,0 +1,38 @@
+package jualin
+
+import (
+	"testing"
+)
+
+func TestCekTotal(t *testing.T) {
+	menu := []MenuItem{
+		{ID: "1", Name: "Bakso", Price: 15000},
+		{ID: "2", Name: "Es Teh", Price: 5000},
+	}
+	req := PaymentRequest{
+		Orders: []Order{
+			{ID: "1", Name: "Bakso", Quantity: 2, Price: 1},
+			{Name: "Es Teh", Quantity: 1, Price: 1},
+		},
+		Total: 35000,
+	}
+	if err := CekTotal(&req, menu); err != nil {
+		t.Fatal(err)
+	}
+	if req.Orders[0].Price != 15000 || req.Orders[1].ID != "2" {
+		t.Errorf("harga item tidak diganti dari menu: %+v", req.Orders)
+	}
+	req.Total = 2
+	if err := CekTotal(&req, menu); err == nil {
+		t.Error("total dari client yang salah harus ditolak")
+	}
+}
+
+func TestBisaPindahStatus(t *testing.T) {
+	if !BisaPindahStatus(StatusPending, StatusPaid) {
+		t.Error("pending ke paid harus boleh")
+	}
+	if BisaPindahStatus(StatusDone, StatusPending) {
+		t.Error("done tidak boleh kembali ke pending")
+	}
+}
@@ -1 +1,89 @@
 package jualin
+
+import (
+	"errors"
+	"strconv"
+	"time"
+)
+
+// transisi status yang diperbolehkan, selain itu ditolak
+var nextStatus = map[string][]string{
+	StatusPending:    {StatusPaid, StatusCancelled},
+	StatusPaid:       {StatusProcessing, StatusCancelled},
+	StatusProcessing: {StatusShipped, StatusCancelled},
+	StatusShipped:    {StatusDone},
+}
+
+// HitungOrder mencocokkan setiap item pesanan dengan menu lapak berdasarkan id (atau nama jika id kosong),
+// mengganti harga dari client dengan harga menu dan mengembalikan total yang dihitung server
+func HitungOrder(orders []Order, menu []MenuItem) (priced []Order, total int, err error) {
+	if len(orders) == 0 {
+		err = errors.New("pesanan kosong")
+		return
+	}
+	for _, order := range orders {
+		if order.Quantity <= 0 {
+			err = errors.New("jumlah pesanan " + order.Name + " tidak valid")
+			return
+		}
+		item, ok := cariMenu(order, menu)
+		if !ok {
+			err = errors.New("menu " + order.Name + " tidak ditemukan di lapak")
+			return
+		}
+		order.ID = item.ID
+		order.Name = item.Name
+		order.Price = item.Price
+		priced = append(priced, order)
+		total += item.Price * order.Quantity
+	}
+	return
+}
+
+// CekTotal menghitung ulang total pesanan dan menolak jika tidak sama dengan total dari client
+func CekTotal(req *PaymentRequest, menu []MenuItem) (err error) {
+	priced, total, err := HitungOrder(req.Orders, menu)
+	if err != nil {
+		return
+	}
+	if total != req.Total {
+		err = errors.New("total pesanan tidak sesuai, seharusnya " + strconv.Itoa(total))
+		return
+	}
+	req.Orders = priced
+	return
+}
+
+// BisaPindahStatus mengecek apakah status pesanan boleh berpindah dari from ke to
+func BisaPindahStatus(from, to string) bool {
+	for _, s := range nextStatus[from] {
+		if s == to {
+			return true
+		}
+	}
+	return false
+}
+
+// SetStatus mengubah status pesanan dan mencatat waktunya di riwayat status
+func SetStatus(req *PaymentRequest, status, updatedby string) {
+	now := time.Now()
+	req.Status = status
+	req.UpdatedAt = now
+	req.StatusHistory = append(req.StatusHistory, StatusLog{
+		Status:    status,
+		UpdatedBy: updatedby,
+		At:        now,
+	})
+}
+
+func cariMenu(order Order, menu []MenuItem) (item MenuItem, ok bool) {
+	for _, m := range menu {
+		if order.ID != "" && m.ID == order.ID {
+			return m, true
+		}
+		if order.ID == "" && m.Name == order.Name {
+			return m, true
+		}
+	}
+	return
+}
@@ -1,6 +1,23 @@
 package jualin
 
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Status pesanan pada collection order
+const (
+	StatusPending    = "pending"
+	StatusPaid       = "paid"
+	StatusProcessing = "processing"
+	StatusShipped    = "shipped"
+	StatusDone       = "done"
+	StatusCancelled  = "cancelled"
+)
+
 type Order struct {
+	ID       string `json:"id,omitempty" bson:"id,omitempty"`
 	Name     string `json:"name" bson:"name"`
 	Quantity int    `json:"quantity" bson:"quantity"`
 	Price    int    `json:"price" bson:"price"`
@@ -12,12 +29,29 @@ type User struct {
 	Address  string `json:"address" bson:"address"`
 }
 
+type StatusLog struct {
+	Status    string    `json:"status" bson:"status"`
+	UpdatedBy string    `json:"updatedby,omitempty" bson:"updatedby,omitempty"`
+	At        time.Time `json:"at" bson:"at"`
+}
+
 type PaymentRequest struct {
-	Orders        []Order `json:"orders" bson:"orders"`
-	Total         int     `json:"total" bson:"total"`
-	User          User    `json:"user" bson:"user"`
-	Payment       string  `json:"payment" bson:"payment"`
-	PaymentMethod string  `json:"paymentMethod" bson:"paymentMethod"`
+	ID            primitive.ObjectID `json:"_id,omitempty" bson:"_id,omitempty"`
+	NamaLapak     string             `json:"namalapak,omitempty" bson:"namalapak,omitempty"`
+	Orders        []Order            `json:"orders" bson:"orders"`
+	Total         int                `json:"total" bson:"total"`
+	User          User               `json:"user" bson:"user"`
+	Payment       string             `json:"payment" bson:"payment"`
+	PaymentMethod string             `json:"paymentMethod" bson:"paymentMethod"`
+	Status        string             `json:"status,omitempty" bson:"status,omitempty"`
+	StatusHistory []StatusLog        `json:"statushistory,omitempty" bson:"statushistory,omitempty"`
+	CreatedAt     time.Time          `json:"createdAt,omitempty" bson:"createdAt,omitempty"`
+	UpdatedAt     time.Time          `json:"updatedAt,omitempty" bson:"updatedAt,omitempty"`
+}
+
+type StatusRequest struct {
+	ID     string `json:"_id"`
+	Status string `json:"status"`
 }
 
 type MenuItem struct {
@@ -0,0 +1,189 @@
+package katalog
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"regexp"
+	"strconv"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+const (
+	DefaultLimit = 20
+	MaxLimit     = 100
+)
+
+// ParseQuery membaca parameter q, category, lapak, min_price, max_price, discount, sort, limit dan cursor
+func ParseQuery(values url.Values) (q Query, err error) {
+	q.Search = values.Get("q")
+	q.Category = values.Get("category")
+	q.NamaLapak = values.Get("lapak")
+	q.Sort = values.Get("sort")
+	if q.Sort == "" {
+		q.Sort = SortNewest
+	}
+	switch q.Sort {
+	case SortNewest, SortPriceAsc, SortPriceDesc, SortName:
+	default:
+		err = errors.New("sort tidak dikenal: " + q.Sort)
+		return
+	}
+	if q.MinPrice, err = parseInt(values, "min_price"); err != nil {
+		return
+	}
+	if q.MaxPrice, err = parseInt(values, "max_price"); err != nil {
+		return
+	}
+	if q.MaxPrice > 0 && q.MinPrice > q.MaxPrice {
+		err = errors.New("min_price lebih besar dari max_price")
+		return
+	}
+	if discount := values.Get("discount"); discount != "" {
+		if q.DiscountOnly, err = strconv.ParseBool(discount); err != nil {
+			err = errors.New("discount harus true atau false")
+			return
+		}
+	}
+	if q.Limit, err = parseInt(values, "limit"); err != nil {
+		return
+	}
+	if q.Limit <= 0 {
+		q.Limit = DefaultLimit
+	}
+	if q.Limit > MaxLimit {
+		q.Limit = MaxLimit
+	}
+	if cursor := values.Get("cursor"); cursor != "" {
+		var c Cursor
+		if c, err = DecodeCursor(cursor); err != nil {
+			return
+		}
+		if c.Sort != q.Sort {
+			err = errors.New("cursor tidak cocok dengan sort")
+			return
+		}
+		q.After = &c
+	}
+	return
+}
+
+// Pipeline membuat aggregation pipeline katalog. Field price adalah harga jual (discount_price jika ada, selain itu original_price)
+// dan limit diambil satu lebih banyak untuk mengetahui apakah masih ada halaman berikutnya
+func Pipeline(q Query) []bson.M {
+	pipeline := []bson.M{
+		{"$addFields": bson.M{"price": bson.M{"$cond": bson.A{
+			bson.M{"$gt": bson.A{"$discount_price", 0}}, "$discount_price", "$original_price",
+		}}}},
+	}
+	if filter := filter(q); len(filter) > 0 {
+		pipeline = append(pipeline, bson.M{"$match": filter})
+	}
+	if q.After != nil {
+		pipeline = append(pipeline, bson.M{"$match": afterCursor(*q.After)})
+	}
+	return append(pipeline,
+		bson.M{"$sort": sortOrder(q.Sort)},
+		bson.M{"$limit": q.Limit + 1},
+	)
+}
+
+// NextCursor membuat cursor dari produk terakhir di halaman
+func NextCursor(q Query, last Cursor) string {
+	last.Sort = q.Sort
+	return EncodeCursor(last)
+}
+
+func EncodeCursor(c Cursor) string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func DecodeCursor(s string) (c Cursor, err error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		err = errors.New("cursor tidak valid")
+		return
+	}
+	if err = json.Unmarshal(b, &c); err != nil || c.ID.IsZero() {
+		err = errors.New("cursor tidak valid")
+	}
+	return
+}
+
+func filter(q Query) bson.M {
+	filter := bson.M{}
+	if q.Search != "" {
+		pattern := bson.M{"$regex": regexp.QuoteMeta(q.Search), "$options": "i"}
+		filter["$or"] = bson.A{bson.M{"name": pattern}, bson.M{"description": pattern}}
+	}
+	if q.Category != "" {
+		filter["category"] = q.Category
+	}
+	if q.NamaLapak != "" {
+		filter["namalapak"] = q.NamaLapak
+	}
+	if q.DiscountOnly {
+		filter["discount_price"] = bson.M{"$gt": 0}
+	}
+	price := bson.M{}
+	if q.MinPrice > 0 {
+		price["$gte"] = q.MinPrice
+	}
+	if q.MaxPrice > 0 {
+		price["$lte"] = q.MaxPrice
+	}
+	if len(price) > 0 {
+		filter["price"] = price
+	}
+	return filter
+}
+
+// sortOrder selalu diakhiri _id supaya urutan stabil untuk cursor
+func sortOrder(sort string) bson.D {
+	switch sort {
+	case SortPriceAsc:
+		return bson.D{{Key: "price", Value: 1}, {Key: "_id", Value: 1}}
+	case SortPriceDesc:
+		return bson.D{{Key: "price", Value: -1}, {Key: "_id", Value: 1}}
+	case SortName:
+		return bson.D{{Key: "name", Value: 1}, {Key: "_id", Value: 1}}
+	default:
+		return bson.D{{Key: "_id", Value: -1}}
+	}
+}
+
+// afterCursor mengambil produk setelah posisi cursor sesuai urutan sortOrder
+func afterCursor(c Cursor) bson.M {
+	var field string
+	var value any
+	op := "$gt"
+	switch c.Sort {
+	case SortPriceAsc:
+		field, value = "price", c.Price
+	case SortPriceDesc:
+		field, value, op = "price", c.Price, "$lt"
+	case SortName:
+		field, value = "name", c.Name
+	default:
+		return bson.M{"_id": bson.M{"$lt": c.ID}}
+	}
+	return bson.M{"$or": bson.A{
+		bson.M{field: bson.M{op: value}},
+		bson.M{field: value, "_id": bson.M{"$gt": c.ID}},
+	}}
+}
+
+func parseInt(values url.Values, key string) (n int64, err error) {
+	s := values.Get(key)
+	if s == "" {
+		return
+	}
+	n, err = strconv.ParseInt(s, 10, 64)
+	if err != nil || n < 0 {
+		err = errors.New(key + " harus angka positif")
+	}
+	return
+}
@@ -0,0 +1,39 @@
+package katalog
+
+import (
+	"net/url"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestParseQuery(t *testing.T) {
+	q, err := ParseQuery(url.Values{"q": {"kopi"}, "min_price": {"1000"}, "max_price": {"5000"}, "limit": {"500"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if q.Sort != SortNewest || q.Limit != MaxLimit || q.MinPrice != 1000 || q.MaxPrice != 5000 {
+		t.Errorf("query tidak sesuai: %+v", q)
+	}
+	if _, err = ParseQuery(url.Values{"min_price": {"5000"}, "max_price": {"1000"}}); err == nil {
+		t.Error("min_price > max_price harus ditolak")
+	}
+	if _, err = ParseQuery(url.Values{"sort": {"acak"}}); err == nil {
+		t.Error("sort tidak dikenal harus ditolak")
+	}
+}
+
+func TestCursor(t *testing.T) {
+	q := Query{Sort: SortPriceAsc}
+	s := NextCursor(q, Cursor{ID: primitive.NewObjectID(), Price: 15000})
+	q2, err := ParseQuery(url.Values{"sort": {SortPriceAsc}, "cursor": {s}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if q2.After == nil || q2.After.Price != 15000 {
+		t.Errorf("cursor tidak terbaca: %+v", q2.After)
+	}
+	if _, err = ParseQuery(url.Values{"sort": {SortName}, "cursor": {s}}); err == nil {
+		t.Error("cursor dengan sort berbeda harus ditolak")
+	}
+}
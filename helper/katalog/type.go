@@ -0,0 +1,38 @@
+package katalog
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+// Urutan katalog yang didukung
+const (
+	SortNewest    = "newest"
+	SortPriceAsc  = "price_asc"
+	SortPriceDesc = "price_desc"
+	SortName      = "name"
+)
+
+// Query adalah parameter pencarian katalog dari query string GET /product
+type Query struct {
+	Search       string
+	Category     string
+	NamaLapak    string
+	MinPrice     int64
+	MaxPrice     int64
+	DiscountOnly bool
+	Sort         string
+	Limit        int64
+	After        *Cursor
+}
+
+// Cursor menyimpan posisi produk terakhir di halaman sebelumnya
+type Cursor struct {
+	Sort  string             `json:"s"`
+	ID    primitive.ObjectID `json:"i"`
+	Price int64              `json:"p,omitempty"`
+	Name  string             `json:"n,omitempty"`
+}
+
+// Page adalah response katalog, next_cursor kosong jika sudah halaman terakhir
+type Page[T any] struct {
+	Data       []T    `json:"data"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
@@ -6,7 +6,7 @@ import (
 )
 
 func GetNamadanDesaFromAPI(phonenumber string) (namadandesa string) {
-	statuscode, res, err := atapi.GetStructWithToken[ResponseAPIPD]("token", config.APITOKENPD, config.APIGETPDLMS+phonenumber)
+	statuscode, res, err := atapi.GetStructWithToken[ResponseAPIPD]("token", config.APITOKENPD(), config.APIGETPDLMS+phonenumber)
 	if err != nil {
 		return
 	}
@@ -23,7 +23,7 @@ func GetNamadanDesaFromAPI(phonenumber string) (namadandesa string) {
 }
 
 func GetDataFromAPI(phonenumber string) (data ResponseAPIPD) {
-	statuscode, res, err := atapi.GetStructWithToken[ResponseAPIPD]("token", config.APITOKENPD, config.APIGETPDLMS+phonenumber)
+	statuscode, res, err := atapi.GetStructWithToken[ResponseAPIPD]("token", config.APITOKENPD(), config.APIGETPDLMS+phonenumber)
 	if err != nil {
 		return
 	}
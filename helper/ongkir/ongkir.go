@@ -0,0 +1,123 @@
+package ongkir
+
+import (
+	"errors"
+	"math"
+	"strings"
+)
+
+// Validate memeriksa isian tabel tarif sebelum disimpan
+func Validate(rt RateTable) error {
+	if rt.NamaLapak == "" {
+		return errors.New("namalapak wajib diisi")
+	}
+	if rt.Origin.Longitude == 0 && rt.Origin.Latitude == 0 {
+		return errors.New("titik asal pengiriman (origin long dan lat) wajib diisi")
+	}
+	switch rt.Type {
+	case TypePerKm:
+		if rt.PerKm <= 0 {
+			return errors.New("per_km harus lebih dari 0")
+		}
+	case TypeZone:
+		if rt.Zone.SameDistrict <= 0 && rt.Zone.SameProvince <= 0 && rt.Zone.Other <= 0 {
+			return errors.New("tarif zona belum diisi")
+		}
+	case TypeFlatKabupaten:
+		if len(rt.Flat) == 0 && rt.DefaultFee <= 0 {
+			return errors.New("tarif flat kabupaten belum diisi")
+		}
+		for _, flat := range rt.Flat {
+			if flat.District == "" || flat.Fee <= 0 {
+				return errors.New("setiap tarif flat harus punya district dan fee")
+			}
+		}
+	default:
+		return errors.New("type tarif harus per_km, zone atau flat_kabupaten")
+	}
+	if rt.BaseFee < 0 || rt.MinFee < 0 || rt.MaxKm < 0 || rt.DefaultFee < 0 {
+		return errors.New("tarif tidak boleh negatif")
+	}
+	return nil
+}
+
+// Calculate menghitung ongkir dari titik asal ke tujuan berdasarkan tabel tarif
+func Calculate(rt RateTable, origin, dest Point) (q Quote, err error) {
+	q = Quote{
+		Type:       rt.Type,
+		Origin:     origin,
+		Dest:       dest,
+		DistanceKm: math.Round(DistanceKm(origin, dest)*100) / 100,
+	}
+	switch rt.Type {
+	case TypePerKm:
+		if rt.MaxKm > 0 && q.DistanceKm > rt.MaxKm {
+			err = errors.New("jarak pengiriman melebihi batas lapak")
+			return
+		}
+		q.Fee = rt.BaseFee + int64(math.Ceil(q.DistanceKm))*rt.PerKm
+	case TypeZone:
+		switch {
+		case sameRegion(origin.Province, dest.Province) && sameRegion(origin.District, dest.District):
+			q.Zone, q.Fee = "same_district", rt.Zone.SameDistrict
+		case sameRegion(origin.Province, dest.Province):
+			q.Zone, q.Fee = "same_province", rt.Zone.SameProvince
+		default:
+			q.Zone, q.Fee = "other", rt.Zone.Other
+		}
+		if q.Fee <= 0 {
+			err = errors.New("zona " + q.Zone + " tidak dilayani lapak")
+			return
+		}
+	case TypeFlatKabupaten:
+		q.Fee = rt.DefaultFee
+		for _, flat := range rt.Flat {
+			if sameRegion(flat.District, dest.District) && (flat.Province == "" || sameRegion(flat.Province, dest.Province)) {
+				q.Fee = flat.Fee
+				break
+			}
+		}
+		if q.Fee <= 0 {
+			err = errors.New("pengiriman ke " + dest.District + " tidak dilayani lapak")
+			return
+		}
+	default:
+		err = errors.New("type tarif tidak dikenal: " + rt.Type)
+		return
+	}
+	if q.Fee < rt.MinFee {
+		q.Fee = rt.MinFee
+	}
+	return
+}
+
+// DistanceKm jarak garis lurus dua titik dengan rumus haversine
+func DistanceKm(a, b Point) float64 {
+	const earthRadiusKm = 6371.0
+	lat1 := a.Latitude * math.Pi / 180
+	lat2 := b.Latitude * math.Pi / 180
+	dLat := lat2 - lat1
+	dLong := (b.Longitude - a.Longitude) * math.Pi / 180
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLong/2)*math.Sin(dLong/2)
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(h))
+}
+
+// Centroid titik tengah kasar dari ring pertama polygon border region
+func Centroid(coordinates [][][]float64) (long, lat float64, ok bool) {
+	if len(coordinates) == 0 || len(coordinates[0]) == 0 {
+		return
+	}
+	for _, coord := range coordinates[0] {
+		if len(coord) < 2 {
+			return 0, 0, false
+		}
+		long += coord[0]
+		lat += coord[1]
+	}
+	n := float64(len(coordinates[0]))
+	return long / n, lat / n, true
+}
+
+func sameRegion(a, b string) bool {
+	return strings.EqualFold(strings.TrimSpace(a), strings.TrimSpace(b))
+}
@@ -0,0 +1,33 @@
+package ongkir
+
+import "testing"
+
+func TestCalculate(t *testing.T) {
+	bandung := Point{Longitude: 107.6191, Latitude: -6.9175, Province: "Jawa Barat", District: "Kota Bandung"}
+	cimahi := Point{Longitude: 107.5420, Latitude: -6.8722, Province: "Jawa Barat", District: "Kota Cimahi"}
+	jakarta := Point{Longitude: 106.8456, Latitude: -6.2088, Province: "DKI Jakarta", District: "Jakarta Pusat"}
+
+	q, err := Calculate(RateTable{Type: TypePerKm, BaseFee: 5000, PerKm: 2000, MinFee: 8000}, bandung, cimahi)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if q.DistanceKm < 9 || q.DistanceKm > 11 || q.Fee != 5000+10*2000 {
+		t.Errorf("ongkir per km tidak sesuai: %+v", q)
+	}
+
+	zone := RateTable{Type: TypeZone, Zone: ZoneFee{SameDistrict: 8000, SameProvince: 12000}}
+	if q, _ = Calculate(zone, bandung, cimahi); q.Zone != "same_province" || q.Fee != 12000 {
+		t.Errorf("ongkir zona tidak sesuai: %+v", q)
+	}
+	if _, err = Calculate(zone, bandung, jakarta); err == nil {
+		t.Error("zona tanpa tarif harus ditolak")
+	}
+
+	flat := RateTable{Type: TypeFlatKabupaten, Flat: []FlatFee{{District: "kota cimahi", Fee: 9000}}}
+	if q, _ = Calculate(flat, bandung, cimahi); q.Fee != 9000 {
+		t.Errorf("ongkir flat tidak sesuai: %+v", q)
+	}
+	if _, err = Calculate(flat, bandung, jakarta); err == nil {
+		t.Error("kabupaten tanpa tarif harus ditolak")
+	}
+}
@@ -0,0 +1,64 @@
+package ongkir
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Jenis tabel tarif ongkir
+const (
+	TypePerKm         = "per_km"
+	TypeZone          = "zone"
+	TypeFlatKabupaten = "flat_kabupaten"
+)
+
+// Point titik asal atau tujuan pengiriman beserta wilayahnya dari collection region
+type Point struct {
+	Longitude   float64 `json:"long" bson:"long"`
+	Latitude    float64 `json:"lat" bson:"lat"`
+	Province    string  `json:"province" bson:"province"`
+	District    string  `json:"district" bson:"district"`
+	SubDistrict string  `json:"sub_district,omitempty" bson:"sub_district,omitempty"`
+	Village     string  `json:"village,omitempty" bson:"village,omitempty"`
+}
+
+// ZoneFee tarif per zona berdasarkan kedekatan wilayah asal dan tujuan
+type ZoneFee struct {
+	SameDistrict int64 `json:"same_district" bson:"same_district"`
+	SameProvince int64 `json:"same_province" bson:"same_province"`
+	Other        int64 `json:"other" bson:"other"`
+}
+
+// FlatFee tarif tetap ke satu kabupaten/kota tujuan
+type FlatFee struct {
+	Province string `json:"province" bson:"province"`
+	District string `json:"district" bson:"district"`
+	Fee      int64  `json:"fee" bson:"fee"`
+}
+
+// RateTable tabel tarif ongkir satu lapak di collection shippingrate beserta titik asal pengiriman
+type RateTable struct {
+	ID         primitive.ObjectID `json:"_id,omitempty" bson:"_id,omitempty"`
+	NamaLapak  string             `json:"namalapak" bson:"namalapak"`
+	Origin     Point              `json:"origin" bson:"origin"`
+	Type       string             `json:"type" bson:"type"`
+	BaseFee    int64              `json:"base_fee,omitempty" bson:"base_fee,omitempty"`
+	PerKm      int64              `json:"per_km,omitempty" bson:"per_km,omitempty"`
+	MinFee     int64              `json:"min_fee,omitempty" bson:"min_fee,omitempty"`
+	MaxKm      float64            `json:"max_km,omitempty" bson:"max_km,omitempty"` // 0 tanpa batas jarak
+	Zone       ZoneFee            `json:"zone,omitempty" bson:"zone,omitempty"`
+	Flat       []FlatFee          `json:"flat,omitempty" bson:"flat,omitempty"`
+	DefaultFee int64              `json:"default_fee,omitempty" bson:"default_fee,omitempty"` // tarif kabupaten yang tidak ada di flat, 0 berarti tidak dilayani
+	UpdatedAt  time.Time          `json:"updated_at,omitempty" bson:"updated_at,omitempty"`
+}
+
+// Quote hasil perhitungan ongkir
+type Quote struct {
+	Type       string  `json:"type" bson:"type"`
+	Origin     Point   `json:"origin" bson:"origin"`
+	Dest       Point   `json:"dest" bson:"dest"`
+	DistanceKm float64 `json:"distance_km" bson:"distance_km"`
+	Zone       string  `json:"zone,omitempty" bson:"zone,omitempty"`
+	Fee        int64   `json:"fee" bson:"fee"`
+}
@@ -0,0 +1,212 @@
+package payment
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Midtrans adaptor untuk Core API midtrans (charge bank_transfer / qris dan notifikasi HTTP)
+type Midtrans struct {
+	BaseURL   string
+	ServerKey string
+	Client    *http.Client
+}
+
+func (m *Midtrans) Name() string { return "midtrans" }
+
+type midtransCharge struct {
+	PaymentType        string            `json:"payment_type"`
+	TransactionDetails midtransDetails   `json:"transaction_details"`
+	BankTransfer       *midtransBank     `json:"bank_transfer,omitempty"`
+	CustomerDetails    *midtransCustomer `json:"customer_details,omitempty"`
+	CustomExpiry       *midtransExpiry   `json:"custom_expiry,omitempty"`
+}
+
+type midtransDetails struct {
+	OrderID     string `json:"order_id"`
+	GrossAmount int64  `json:"gross_amount"`
+}
+
+type midtransBank struct {
+	Bank string `json:"bank"`
+}
+
+type midtransCustomer struct {
+	FirstName string `json:"first_name,omitempty"`
+	Phone     string `json:"phone,omitempty"`
+}
+
+type midtransExpiry struct {
+	ExpiryDuration int    `json:"expiry_duration"`
+	Unit           string `json:"unit"`
+}
+
+type midtransResponse struct {
+	StatusCode        string `json:"status_code"`
+	StatusMessage     string `json:"status_message"`
+	TransactionID     string `json:"transaction_id"`
+	OrderID           string `json:"order_id"`
+	GrossAmount       string `json:"gross_amount"`
+	TransactionStatus string `json:"transaction_status"`
+	VANumbers         []struct {
+		Bank     string `json:"bank"`
+		VANumber string `json:"va_number"`
+	} `json:"va_numbers"`
+	QRString string `json:"qr_string"`
+	Actions  []struct {
+		Name string `json:"name"`
+		URL  string `json:"url"`
+	} `json:"actions"`
+}
+
+type midtransNotification struct {
+	TransactionID     string `json:"transaction_id"`
+	TransactionStatus string `json:"transaction_status"`
+	TransactionTime   string `json:"transaction_time"`
+	SettlementTime    string `json:"settlement_time"`
+	OrderID           string `json:"order_id"`
+	StatusCode        string `json:"status_code"`
+	GrossAmount       string `json:"gross_amount"`
+	SignatureKey      string `json:"signature_key"`
+	FraudStatus       string `json:"fraud_status"`
+}
+
+// CreateInvoice membuat VA atau QRIS lewat endpoint /v2/charge
+func (m *Midtrans) CreateInvoice(ctx context.Context, req InvoiceRequest) (inv Invoice, err error) {
+	charge := midtransCharge{
+		TransactionDetails: midtransDetails{OrderID: req.OrderID, GrossAmount: req.Amount},
+		CustomerDetails:    &midtransCustomer{FirstName: req.CustomerName, Phone: req.CustomerPhone},
+	}
+	switch req.Method {
+	case MethodVA:
+		if req.Bank == "" {
+			return inv, errors.New("bank wajib diisi untuk virtual account")
+		}
+		charge.PaymentType = "bank_transfer"
+		charge.BankTransfer = &midtransBank{Bank: strings.ToLower(req.Bank)}
+	case MethodQRIS:
+		charge.PaymentType = "qris"
+	default:
+		return inv, errors.New("metode " + req.Method + " tidak didukung midtrans")
+	}
+	if !req.ExpiresAt.IsZero() {
+		if minutes := int(time.Until(req.ExpiresAt).Minutes()); minutes > 0 {
+			charge.CustomExpiry = &midtransExpiry{ExpiryDuration: minutes, Unit: "minute"}
+		}
+	}
+	body, err := json.Marshal(charge)
+	if err != nil {
+		return
+	}
+	httpreq, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimRight(m.BaseURL, "/")+"/v2/charge", bytes.NewReader(body))
+	if err != nil {
+		return
+	}
+	httpreq.SetBasicAuth(m.ServerKey, "")
+	httpreq.Header.Set("Content-Type", "application/json")
+	httpreq.Header.Set("Accept", "application/json")
+	resp, err := m.client().Do(httpreq)
+	if err != nil {
+		return
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return
+	}
+	var mr midtransResponse
+	if err = json.Unmarshal(respBody, &mr); err != nil {
+		return inv, errors.New("response midtrans tidak valid: " + err.Error())
+	}
+	if resp.StatusCode >= 300 || !strings.HasPrefix(mr.StatusCode, "2") {
+		return inv, errors.New("midtrans menolak tagihan: " + mr.StatusCode + " " + mr.StatusMessage)
+	}
+	inv = Invoice{
+		Provider:   m.Name(),
+		ExternalID: mr.TransactionID,
+		OrderID:    mr.OrderID,
+		Method:     req.Method,
+		Amount:     req.Amount,
+		Status:     StatusPending,
+		QRString:   mr.QRString,
+		ExpiresAt:  req.ExpiresAt,
+	}
+	if len(mr.VANumbers) > 0 {
+		inv.Bank = mr.VANumbers[0].Bank
+		inv.VANumber = mr.VANumbers[0].VANumber
+	}
+	for _, action := range mr.Actions {
+		if action.Name == "generate-qr-code" {
+			inv.PaymentURL = action.URL
+		}
+	}
+	return
+}
+
+// ParseCallback memverifikasi signature_key = SHA512(order_id + status_code + gross_amount + server key)
+func (m *Midtrans) ParseCallback(header http.Header, body []byte) (cb Callback, err error) {
+	var notif midtransNotification
+	if err = json.Unmarshal(body, &notif); err != nil {
+		return cb, errors.New("body callback tidak valid: " + err.Error())
+	}
+	sum := sha512.Sum512([]byte(notif.OrderID + notif.StatusCode + notif.GrossAmount + m.ServerKey))
+	expected := hex.EncodeToString(sum[:])
+	if subtle.ConstantTimeCompare([]byte(expected), []byte(strings.ToLower(notif.SignatureKey))) != 1 {
+		return cb, ErrInvalidSignature
+	}
+	amount, err := strconv.ParseFloat(notif.GrossAmount, 64)
+	if err != nil {
+		return cb, errors.New("gross_amount tidak valid")
+	}
+	cb = Callback{
+		EventID:    notif.TransactionID + ":" + notif.TransactionStatus,
+		ExternalID: notif.TransactionID,
+		OrderID:    notif.OrderID,
+		Amount:     int64(amount),
+	}
+	switch notif.TransactionStatus {
+	case "settlement":
+		cb.Status = StatusPaid
+	case "capture":
+		cb.Status = StatusPaid
+		if notif.FraudStatus == "challenge" {
+			cb.Status = StatusPending
+		}
+	case "expire":
+		cb.Status = StatusExpired
+	case "deny", "cancel", "failure":
+		cb.Status = StatusFailed
+	default:
+		cb.Status = StatusPending
+	}
+	if cb.Status == StatusPaid {
+		cb.PaidAt, _ = time.Parse("2006-01-02 15:04:05", firstNonEmpty(notif.SettlementTime, notif.TransactionTime))
+	}
+	return
+}
+
+func (m *Midtrans) client() *http.Client {
+	if m.Client != nil {
+		return m.Client
+	}
+	return &http.Client{Timeout: 30 * time.Second}
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
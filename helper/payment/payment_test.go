@@ -0,0 +1,73 @@
+package payment
+
+import (
+	"context"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestFakeCallback(t *testing.T) {
+	f := &Fake{Secret: "rahasia"}
+	inv, err := f.CreateInvoice(context.Background(), InvoiceRequest{OrderID: "CO1", Amount: 50000, Method: MethodVA, Bank: "bca"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	header, body := f.Callback(inv, StatusPaid)
+	cb, err := f.ParseCallback(header, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cb.OrderID != "CO1" || cb.Status != StatusPaid || cb.Amount != 50000 {
+		t.Errorf("callback tidak sesuai: %+v", cb)
+	}
+	body[len(body)-2] = ' '
+	if _, err = f.ParseCallback(header, body); err != ErrInvalidSignature {
+		t.Errorf("body yang diubah harus ditolak, dapat %v", err)
+	}
+}
+
+func TestMidtrans(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, _, _ := r.BasicAuth(); user != "server-key" || r.URL.Path != "/v2/charge" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"status_code":"201","transaction_id":"trx-1","order_id":"CO1","transaction_status":"pending","va_numbers":[{"bank":"bca","va_number":"12345"}]}`))
+	}))
+	defer srv.Close()
+	m := &Midtrans{BaseURL: srv.URL, ServerKey: "server-key"}
+	inv, err := m.CreateInvoice(context.Background(), InvoiceRequest{OrderID: "CO1", Amount: 50000, Method: MethodVA, Bank: "BCA"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if inv.ExternalID != "trx-1" || inv.VANumber != "12345" {
+		t.Errorf("invoice tidak sesuai: %+v", inv)
+	}
+
+	sum := sha512.Sum512([]byte("CO1" + "200" + "50000.00" + "server-key"))
+	notif := map[string]string{
+		"transaction_id":     "trx-1",
+		"transaction_status": "settlement",
+		"order_id":           "CO1",
+		"status_code":        "200",
+		"gross_amount":       "50000.00",
+		"signature_key":      hex.EncodeToString(sum[:]),
+	}
+	body, _ := json.Marshal(notif)
+	cb, err := m.ParseCallback(http.Header{}, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cb.Status != StatusPaid || cb.Amount != 50000 || cb.EventID != "trx-1:settlement" {
+		t.Errorf("callback tidak sesuai: %+v", cb)
+	}
+	notif["signature_key"] = "salah"
+	body, _ = json.Marshal(notif)
+	if _, err = m.ParseCallback(http.Header{}, body); err != ErrInvalidSignature {
+		t.Errorf("signature salah harus ditolak, dapat %v", err)
+	}
+}
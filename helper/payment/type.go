@@ -0,0 +1,71 @@
+package payment
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+)
+
+// Metode pembayaran yang bisa dibuat lewat provider
+const (
+	MethodVA      = "va"
+	MethodQRIS    = "qris"
+	MethodInvoice = "invoice"
+)
+
+// Status pembayaran dari callback provider
+const (
+	StatusPending = "pending"
+	StatusPaid    = "paid"
+	StatusExpired = "expired"
+	StatusFailed  = "failed"
+)
+
+// ErrInvalidSignature callback yang tanda tangannya tidak cocok
+var ErrInvalidSignature = errors.New("signature callback tidak valid")
+
+// Provider adaptor payment gateway. Setiap provider membuat tagihan dan memverifikasi callback-nya sendiri
+type Provider interface {
+	Name() string
+	CreateInvoice(ctx context.Context, req InvoiceRequest) (Invoice, error)
+	// ParseCallback memverifikasi tanda tangan lalu membaca callback, body sudah dibaca oleh pemanggil
+	ParseCallback(header http.Header, body []byte) (Callback, error)
+}
+
+// InvoiceRequest permintaan tagihan untuk satu checkout
+type InvoiceRequest struct {
+	OrderID       string    `json:"order_id"`
+	Amount        int64     `json:"amount"`
+	Method        string    `json:"method"`
+	Bank          string    `json:"bank,omitempty"`
+	CustomerName  string    `json:"customer_name,omitempty"`
+	CustomerPhone string    `json:"customer_phone,omitempty"`
+	Description   string    `json:"description,omitempty"`
+	ExpiresAt     time.Time `json:"expires_at,omitempty"`
+}
+
+// Invoice tagihan yang dibuat provider
+type Invoice struct {
+	Provider   string    `json:"provider" bson:"provider"`
+	ExternalID string    `json:"external_id" bson:"external_id"`
+	OrderID    string    `json:"order_id" bson:"order_id"`
+	Method     string    `json:"method" bson:"method"`
+	Amount     int64     `json:"amount" bson:"amount"`
+	Status     string    `json:"status" bson:"status"`
+	PaymentURL string    `json:"payment_url,omitempty" bson:"payment_url,omitempty"`
+	Bank       string    `json:"bank,omitempty" bson:"bank,omitempty"`
+	VANumber   string    `json:"va_number,omitempty" bson:"va_number,omitempty"`
+	QRString   string    `json:"qr_string,omitempty" bson:"qr_string,omitempty"`
+	ExpiresAt  time.Time `json:"expires_at,omitempty" bson:"expires_at,omitempty"`
+}
+
+// Callback notifikasi status pembayaran yang sudah diverifikasi
+type Callback struct {
+	EventID    string    `json:"event_id" bson:"event_id"`
+	ExternalID string    `json:"external_id" bson:"external_id"`
+	OrderID    string    `json:"order_id" bson:"order_id"`
+	Status     string    `json:"status" bson:"status"`
+	Amount     int64     `json:"amount" bson:"amount"`
+	PaidAt     time.Time `json:"paid_at,omitempty" bson:"paid_at,omitempty"`
+}
@@ -0,0 +1,83 @@
+package productevent
+
+import (
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Jenis perubahan produk yang menarik untuk pelanggan
+const (
+	TypeDiscount    = "discount"
+	TypeBackInStock = "back_in_stock"
+)
+
+// Snapshot nilai produk yang dibandingkan sebelum dan sesudah update
+type Snapshot struct {
+	Name          string
+	NamaLapak     string
+	OriginalPrice int64
+	DiscountPrice int64
+	Stock         int
+}
+
+// Change event perubahan produk yang dikirim ke subscriber
+type Change struct {
+	ProductID primitive.ObjectID `json:"product_id" bson:"product_id"`
+	Name      string             `json:"name" bson:"name"`
+	NamaLapak string             `json:"namalapak" bson:"namalapak"`
+	Types     []string           `json:"types" bson:"types"`
+	Before    Snapshot           `json:"before" bson:"before"`
+	After     Snapshot           `json:"after" bson:"after"`
+	At        time.Time          `json:"at" bson:"at"`
+}
+
+// Has apakah event berisi jenis perubahan tersebut
+func (c Change) Has(changeType string) bool {
+	for _, t := range c.Types {
+		if t == changeType {
+			return true
+		}
+	}
+	return false
+}
+
+// Diff membandingkan produk sebelum dan sesudah update. Diskon baru atau diskon yang lebih murah dianggap TypeDiscount,
+// stok dari habis menjadi ada dianggap TypeBackInStock
+func Diff(productID primitive.ObjectID, before, after Snapshot) (c Change, changed bool) {
+	c = Change{ProductID: productID, Name: after.Name, NamaLapak: after.NamaLapak, Before: before, After: after, At: time.Now()}
+	if after.DiscountPrice > 0 && (before.DiscountPrice == 0 || after.DiscountPrice < before.DiscountPrice) {
+		c.Types = append(c.Types, TypeDiscount)
+	}
+	if before.Stock <= 0 && after.Stock > 0 {
+		c.Types = append(c.Types, TypeBackInStock)
+	}
+	return c, len(c.Types) > 0
+}
+
+// Handler subscriber event perubahan produk
+type Handler func(Change)
+
+// Bus penyalur event perubahan produk ke subscriber di proses yang sama
+type Bus struct {
+	mu       sync.RWMutex
+	handlers []Handler
+}
+
+// Subscribe mendaftarkan handler yang dipanggil setiap ada event
+func (b *Bus) Subscribe(h Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers = append(b.handlers, h)
+}
+
+// Publish memanggil semua subscriber secara berurutan
+func (b *Bus) Publish(c Change) {
+	b.mu.RLock()
+	handlers := append([]Handler(nil), b.handlers...)
+	b.mu.RUnlock()
+	for _, h := range handlers {
+		h(c)
+	}
+}
@@ -0,0 +1,28 @@
+package productevent
+
+import (
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestDiff(t *testing.T) {
+	id := primitive.NewObjectID()
+	c, changed := Diff(id, Snapshot{OriginalPrice: 20000, Stock: 0}, Snapshot{OriginalPrice: 20000, DiscountPrice: 15000, Stock: 5})
+	if !changed || !c.Has(TypeDiscount) || !c.Has(TypeBackInStock) {
+		t.Errorf("harus ada event diskon dan stok kembali: %+v", c.Types)
+	}
+	if _, changed = Diff(id, Snapshot{DiscountPrice: 15000, Stock: 3}, Snapshot{DiscountPrice: 17000, Stock: 2}); changed {
+		t.Error("diskon naik dan stok berkurang bukan event")
+	}
+}
+
+func TestBus(t *testing.T) {
+	var bus Bus
+	var got []Change
+	bus.Subscribe(func(c Change) { got = append(got, c) })
+	bus.Publish(Change{Name: "kopi", Types: []string{TypeDiscount}})
+	if len(got) != 1 || got[0].Name != "kopi" {
+		t.Errorf("subscriber tidak menerima event: %+v", got)
+	}
+}
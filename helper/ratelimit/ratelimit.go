@@ -0,0 +1,129 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strconv"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Limiter rate limit sliding window yang disimpan di Mongo sehingga berlaku lintas instance dan cold start
+type Limiter struct {
+	coll *mongo.Collection
+}
+
+func New(db *mongo.Database) *Limiter {
+	return &Limiter{coll: db.Collection(Collection)}
+}
+
+// EnsureIndexes membuat TTL index supaya bucket yang kedaluwarsa dihapus Mongo
+func (l *Limiter) EnsureIndexes(ctx context.Context) error {
+	_, err := l.coll.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.M{"expires_at": 1},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+	return err
+}
+
+// Estimate perkiraan jumlah request di jendela geser dari bucket sebelumnya dan bucket sekarang
+func Estimate(prev, cur int, elapsed, window time.Duration) float64 {
+	weight := 1 - float64(elapsed)/float64(window)
+	if weight < 0 {
+		weight = 0
+	}
+	return float64(prev)*weight + float64(cur)
+}
+
+// bucketStart awal jendela tetap yang memuat now
+func bucketStart(now time.Time, window time.Duration) time.Time {
+	return now.Truncate(window)
+}
+
+func bucketID(rule Rule, key string, start time.Time) string {
+	return rule.Name + ":" + key + ":" + strconv.FormatInt(start.Unix(), 10)
+}
+
+// Allow mencatat satu request untuk key lalu memutuskan apakah masih di bawah batas
+func (l *Limiter) Allow(ctx context.Context, rule Rule, key string, now time.Time) (d Decision, err error) {
+	start := bucketStart(now, rule.Window)
+	var cur bucket
+	err = l.coll.FindOneAndUpdate(ctx,
+		bson.M{"_id": bucketID(rule, key, start)},
+		bson.M{"$inc": bson.M{"count": 1}, "$setOnInsert": bson.M{"expires_at": start.Add(2 * rule.Window)}},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)).Decode(&cur)
+	if err != nil {
+		return
+	}
+	var prev bucket
+	err = l.coll.FindOne(ctx, bson.M{"_id": bucketID(rule, key, start.Add(-rule.Window))}).Decode(&prev)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		return
+	}
+	err = nil
+	elapsed := now.Sub(start)
+	count := Estimate(prev.Count, cur.Count, elapsed, rule.Window)
+	d.Allowed = count <= float64(rule.Limit)
+	d.Remaining = int(math.Max(0, math.Floor(float64(rule.Limit)-count)))
+	if !d.Allowed {
+		d.RetryAfter = retryAfter(prev.Count, cur.Count, elapsed, rule)
+	}
+	return
+}
+
+// retryAfter waktu tunggu sampai perkiraan jumlah request turun ke bawah batas
+func retryAfter(prev, cur int, elapsed time.Duration, rule Rule) time.Duration {
+	if cur > rule.Limit || prev == 0 {
+		return rule.Window - elapsed
+	}
+	// prev*(1-t/window) + cur <= limit
+	t := time.Duration(float64(rule.Window) * (1 - float64(rule.Limit-cur)/float64(prev)))
+	if t <= elapsed {
+		return time.Second
+	}
+	return t - elapsed
+}
+
+func failureID(name, key string) string {
+	return "fail:" + name + ":" + key
+}
+
+// Locked sisa waktu kunci untuk key, nol berarti tidak terkunci
+func (l *Limiter) Locked(ctx context.Context, name, key string, now time.Time) (time.Duration, error) {
+	var f failure
+	err := l.coll.FindOne(ctx, bson.M{"_id": failureID(name, key)}).Decode(&f)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return 0, nil
+	}
+	if err != nil || !f.LockedUntil.After(now) {
+		return 0, err
+	}
+	return f.LockedUntil.Sub(now), nil
+}
+
+// Fail mencatat satu kegagalan dan mengunci key jika sudah mencapai MaxFailures
+func (l *Limiter) Fail(ctx context.Context, lock Lockout, name, key string, now time.Time) (lockedFor time.Duration, err error) {
+	var f failure
+	err = l.coll.FindOneAndUpdate(ctx,
+		bson.M{"_id": failureID(name, key)},
+		bson.M{"$inc": bson.M{"count": 1}, "$set": bson.M{"expires_at": now.Add(lock.Window)}},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)).Decode(&f)
+	if err != nil || f.Count < lock.MaxFailures {
+		return
+	}
+	until := now.Add(lock.Duration)
+	_, err = l.coll.UpdateOne(ctx, bson.M{"_id": f.ID}, bson.M{
+		"$set": bson.M{"count": 0, "locked_until": until, "expires_at": until},
+	})
+	return lock.Duration, err
+}
+
+// Reset menghapus hitungan kegagalan setelah login berhasil
+func (l *Limiter) Reset(ctx context.Context, name, key string) error {
+	_, err := l.coll.DeleteOne(ctx, bson.M{"_id": failureID(name, key)})
+	return err
+}
@@ -0,0 +1,43 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEstimate(t *testing.T) {
+	w := time.Minute
+	if got := Estimate(10, 2, 0, w); got != 12 {
+		t.Errorf("start of window = %v, want 12", got)
+	}
+	if got := Estimate(10, 2, 30*time.Second, w); got != 7 {
+		t.Errorf("half window = %v, want 7", got)
+	}
+	if got := Estimate(10, 2, 2*w, w); got != 2 {
+		t.Errorf("past window = %v, want 2", got)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	rule := Rule{Name: "otp", Limit: 5, Window: time.Minute}
+	// bucket sekarang sudah lewat batas, tunggu sampai jendela berikutnya
+	if got := retryAfter(0, 6, 20*time.Second, rule); got != 40*time.Second {
+		t.Errorf("retryAfter = %v, want 40s", got)
+	}
+	// 10*(1-t/60) + 3 <= 5 saat t >= 48s
+	if got := retryAfter(10, 3, 30*time.Second, rule); got != 18*time.Second {
+		t.Errorf("retryAfter = %v, want 18s", got)
+	}
+}
+
+func TestBucketID(t *testing.T) {
+	rule := Rule{Name: "login", Limit: 5, Window: 15 * time.Minute}
+	now := time.Date(2024, 8, 9, 8, 34, 29, 0, time.UTC)
+	start := bucketStart(now, rule.Window)
+	if !start.Equal(time.Date(2024, 8, 9, 8, 30, 0, 0, time.UTC)) {
+		t.Fatalf("bucketStart = %v", start)
+	}
+	if id := bucketID(rule, "628111", start); id != "login:628111:1723192200" {
+		t.Errorf("bucketID = %s", id)
+	}
+}
@@ -0,0 +1,42 @@
+package ratelimit
+
+import "time"
+
+// Collection collection bucket rate limit, dokumen dihapus otomatis oleh TTL index di expires_at
+const Collection = "ratelimit"
+
+// Rule batas jumlah request dalam satu jendela waktu geser
+type Rule struct {
+	Name   string
+	Limit  int
+	Window time.Duration
+}
+
+// Lockout kunci sementara setelah MaxFailures kegagalan dalam Window
+type Lockout struct {
+	MaxFailures int
+	Window      time.Duration
+	Duration    time.Duration
+}
+
+// Decision hasil pengecekan rate limit
+type Decision struct {
+	Allowed    bool
+	Remaining  int
+	RetryAfter time.Duration
+}
+
+// bucket hitungan request satu key dalam satu jendela tetap
+type bucket struct {
+	ID        string    `bson:"_id"`
+	Count     int       `bson:"count"`
+	ExpiresAt time.Time `bson:"expires_at"`
+}
+
+// failure hitungan kegagalan beruntun dan waktu kunci berakhir
+type failure struct {
+	ID          string    `bson:"_id"`
+	Count       int       `bson:"count"`
+	LockedUntil time.Time `bson:"locked_until,omitempty"`
+	ExpiresAt   time.Time `bson:"expires_at"`
+}
@@ -0,0 +1,136 @@
+package rbac
+
+import (
+	"context"
+	"strings"
+)
+
+// DefaultPolicy pemetaan role ke permission yang dipakai aplikasi
+var DefaultPolicy = Policy{
+	RoleAdmin: {
+		{Permission: "*", Scope: ScopeAll},
+	},
+	// manager hanya publish dan membaca draft semua project, dokumen SPK/SPI berisi NIK tetap milik owner
+	RoleManager: {
+		{Permission: ProjectPublish, Scope: ScopeAll},
+		{Permission: ProjectDraft, Scope: ScopeAll},
+		{Permission: LapakReport, Scope: ScopeAll},
+		{Permission: RoleRead, Scope: ScopeAll},
+	},
+	RoleEditor: {
+		{Permission: ProjectRead, Scope: ScopeOwn},
+		{Permission: ProjectDraft, Scope: ScopeOwn},
+		{Permission: ProjectApprove, Scope: ScopeOwn},
+	},
+	RoleAuthor: {
+		{Permission: ProjectRead, Scope: ScopeOwn},
+		{Permission: ProjectDraft, Scope: ScopeOwn},
+		{Permission: ProjectWrite, Scope: ScopeOwn},
+	},
+	RoleSeller: {
+		{Permission: ProductWrite, Scope: ScopeOwn},
+		{Permission: "lapak:*", Scope: ScopeOwn},
+		{Permission: CheckoutRead, Scope: ScopeOwn},
+	},
+	RoleBuyer: {
+		{Permission: CheckoutRead, Scope: ScopeOwn},
+	},
+	RoleHelpdesk: {
+		{Permission: "helpdesk:*", Scope: ScopeAll},
+	},
+}
+
+// DefaultRoles role yang dimiliki setiap user yang login, siapa saja boleh belanja, membuka lapak dan menulis buku
+var DefaultRoles = []Role{RoleBuyer, RoleSeller, RoleAuthor}
+
+// AllRoles semua role yang dikenal
+var AllRoles = []Role{RoleAdmin, RoleManager, RoleEditor, RoleAuthor, RoleSeller, RoleBuyer, RoleHelpdesk}
+
+// ParseRole mengubah string menjadi Role yang dikenal
+func ParseRole(s string) (Role, bool) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	for _, role := range AllRoles {
+		if string(role) == s {
+			return role, true
+		}
+	}
+	return "", false
+}
+
+// Resource bagian resource dari permission
+func (p Permission) Resource() string {
+	resource, _, _ := strings.Cut(string(p), ":")
+	return resource
+}
+
+// Match apakah permission grant mencakup permission yang diminta
+func (p Permission) Match(perm Permission) bool {
+	if p == "*" || p == perm {
+		return true
+	}
+	resource, action, _ := strings.Cut(string(p), ":")
+	return action == "*" && resource == perm.Resource()
+}
+
+// HasRole apakah subject memiliki role tersebut
+func (s Subject) HasRole(role Role) bool {
+	for _, r := range s.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// scope jangkauan terluas permission yang dimiliki subject
+func (p Policy) scope(sub Subject, perm Permission) (scope Scope, ok bool) {
+	for _, role := range sub.Roles {
+		for _, grant := range p[role] {
+			if !grant.Permission.Match(perm) {
+				continue
+			}
+			if grant.Scope == ScopeAll {
+				return ScopeAll, true
+			}
+			scope, ok = ScopeOwn, true
+		}
+	}
+	return
+}
+
+// Authorize pengecekan di level route, lolos jika subject punya permission di scope mana pun
+func (p Policy) Authorize(sub Subject, perm Permission) error {
+	if _, ok := p.scope(sub, perm); !ok {
+		return &DeniedError{Subject: sub.ID, Permission: perm}
+	}
+	return nil
+}
+
+// AuthorizeOwner pengecekan di level resource, grant ScopeOwn hanya lolos jika subject termasuk owners
+func (p Policy) AuthorizeOwner(sub Subject, perm Permission, owners ...string) error {
+	scope, ok := p.scope(sub, perm)
+	if ok && scope == ScopeAll {
+		return nil
+	}
+	if ok && sub.ID != "" {
+		for _, owner := range owners {
+			if owner == sub.ID {
+				return nil
+			}
+		}
+	}
+	return &DeniedError{Subject: sub.ID, Permission: perm}
+}
+
+type subjectKey struct{}
+
+// WithSubject menyimpan subject di context request
+func WithSubject(ctx context.Context, sub Subject) context.Context {
+	return context.WithValue(ctx, subjectKey{}, sub)
+}
+
+// SubjectFrom mengambil subject yang disimpan oleh WithSubject
+func SubjectFrom(ctx context.Context) (sub Subject, ok bool) {
+	sub, ok = ctx.Value(subjectKey{}).(Subject)
+	return
+}
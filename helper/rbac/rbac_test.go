@@ -0,0 +1,73 @@
+package rbac
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestAuthorize(t *testing.T) {
+	seller := Subject{ID: "628111", Roles: DefaultRoles}
+	manager := Subject{ID: "628222", Roles: append([]Role{RoleManager}, DefaultRoles...)}
+	admin := Subject{ID: "628333", Roles: []Role{RoleAdmin}}
+
+	if err := DefaultPolicy.Authorize(seller, ProductWrite); err != nil {
+		t.Fatalf("seller should pass route check: %v", err)
+	}
+	if err := DefaultPolicy.Authorize(seller, ProjectPublish); err == nil {
+		t.Fatal("seller should not publish projects")
+	}
+	var denied *DeniedError
+	if err := DefaultPolicy.Authorize(seller, RoleGrant); !errors.As(err, &denied) || denied.Permission != RoleGrant {
+		t.Fatalf("expected DeniedError for role:grant, got %v", err)
+	}
+	if err := DefaultPolicy.Authorize(manager, ProjectPublish); err != nil {
+		t.Fatalf("manager should publish: %v", err)
+	}
+	if err := DefaultPolicy.Authorize(admin, RoleGrant); err != nil {
+		t.Fatalf("admin should have every permission: %v", err)
+	}
+}
+
+func TestAuthorizeOwner(t *testing.T) {
+	seller := Subject{ID: "628111", Roles: DefaultRoles}
+	manager := Subject{ID: "628222", Roles: []Role{RoleManager}}
+
+	if err := DefaultPolicy.AuthorizeOwner(seller, LapakManage, "628111"); err != nil {
+		t.Fatalf("owner should manage own lapak: %v", err)
+	}
+	if err := DefaultPolicy.AuthorizeOwner(seller, LapakManage, "628999"); err == nil {
+		t.Fatal("seller should not manage someone else's lapak")
+	}
+	if err := DefaultPolicy.AuthorizeOwner(manager, LapakReport, "628999"); err != nil {
+		t.Fatalf("manager should see every lapak report: %v", err)
+	}
+	if err := DefaultPolicy.AuthorizeOwner(manager, LapakManage, "628999"); err == nil {
+		t.Fatal("manager has no lapak:manage grant")
+	}
+	if err := DefaultPolicy.AuthorizeOwner(manager, ProjectDraft, "628999"); err != nil {
+		t.Fatalf("manager should read every draft: %v", err)
+	}
+	for _, perm := range []Permission{ProjectRead, ProjectWrite, ProjectApprove} {
+		if err := DefaultPolicy.AuthorizeOwner(manager, perm, "628999"); err == nil {
+			t.Fatalf("manager must not get %s on someone else's project", perm)
+		}
+	}
+	if err := DefaultPolicy.AuthorizeOwner(Subject{Roles: DefaultRoles}, ProductWrite, ""); err == nil {
+		t.Fatal("empty subject must not match empty owner")
+	}
+}
+
+func TestParseRoleAndContext(t *testing.T) {
+	if role, ok := ParseRole(" Helpdesk "); !ok || role != RoleHelpdesk {
+		t.Fatalf("ParseRole = %q, %v", role, ok)
+	}
+	if _, ok := ParseRole("superuser"); ok {
+		t.Fatal("unknown role should not parse")
+	}
+	sub := Subject{ID: "628111", Roles: []Role{RoleBuyer}}
+	got, ok := SubjectFrom(WithSubject(context.Background(), sub))
+	if !ok || got.ID != sub.ID || !got.HasRole(RoleBuyer) {
+		t.Fatalf("SubjectFrom = %+v, %v", got, ok)
+	}
+}
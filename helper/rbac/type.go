@@ -0,0 +1,69 @@
+package rbac
+
+// Role peran user di aplikasi
+type Role string
+
+const (
+	RoleAdmin    Role = "admin"
+	RoleManager  Role = "manager"
+	RoleEditor   Role = "editor"
+	RoleAuthor   Role = "author"
+	RoleSeller   Role = "seller"
+	RoleBuyer    Role = "buyer"
+	RoleHelpdesk Role = "helpdesk" // operator helpdesk
+)
+
+// Permission izin berformat resource:action, action * berarti semua action di resource tersebut
+type Permission string
+
+const (
+	ProjectRead    Permission = "project:read"    // baca dan download dokumen project
+	ProjectDraft   Permission = "project:draft"   // baca draft naskah di repo, manager untuk semua project
+	ProjectWrite   Permission = "project:write"   // ubah data dan upload file project
+	ProjectApprove Permission = "project:approve" // approve naskah oleh editor
+	ProjectPublish Permission = "project:publish" // publish buku oleh manager
+	ProductWrite   Permission = "product:write"   // kelola produk dan galeri
+	LapakManage    Permission = "lapak:manage"    // voucher, ongkir, status pesanan, konfirmasi bayar dan balasan ulasan
+	LapakReport    Permission = "lapak:report"    // antrian pesanan dan ringkasan penjualan
+	CheckoutRead   Permission = "checkout:read"   // kwitansi checkout
+	HelpdeskRead   Permission = "helpdesk:read"   // rekap tiket operator helpdesk
+	RoleRead       Permission = "role:read"       // lihat role dan audit role
+	RoleGrant      Permission = "role:grant"      // beri dan cabut role
+	KeyManage      Permission = "key:manage"      // rotasi dan pencabutan kunci token
+	SessionRevoke  Permission = "session:revoke"  // cabut semua sesi user lain
+	SecretManage   Permission = "secret:manage"   // lihat status, ubah dan muat ulang secret aplikasi
+	UserMerge      Permission = "user:merge"      // gabungkan akun duplikat
+)
+
+// Scope jangkauan grant, ScopeOwn hanya untuk resource milik user sendiri
+type Scope int
+
+const (
+	ScopeOwn Scope = iota
+	ScopeAll
+)
+
+// Grant satu permission yang dimiliki role
+type Grant struct {
+	Permission Permission
+	Scope      Scope
+}
+
+// Policy daftar grant per role
+type Policy map[Role][]Grant
+
+// Subject user yang sedang mengakses beserta role efektifnya
+type Subject struct {
+	ID    string // nomor telepon user dari token
+	Roles []Role
+}
+
+// DeniedError error ketika subject tidak punya permission
+type DeniedError struct {
+	Subject    string
+	Permission Permission
+}
+
+func (e *DeniedError) Error() string {
+	return "akses ditolak: " + e.Subject + " tidak memiliki izin " + string(e.Permission)
+}
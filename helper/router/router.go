@@ -0,0 +1,178 @@
+package router
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gocroot/helper/at"
+)
+
+// Router mencocokkan method dan path ke handler. Pola memakai segmen static, :param untuk satu segmen
+// dan *param di akhir untuk sisa path. Segmen static selalu dicoba lebih dulu dari parameter.
+type Router struct {
+	root       node
+	middleware []Middleware
+
+	NotFound         http.Handler // dipakai jika tidak ada pola yang cocok, default galat not_found
+	MethodNotAllowed http.Handler // dipakai jika path cocok tapi method tidak, header Allow sudah diisi
+}
+
+// New membuat router kosong
+func New() *Router {
+	return &Router{}
+}
+
+// Use menambahkan middleware global, yang pertama didaftarkan menjadi lapisan terluar.
+// Middleware global juga berjalan untuk request yang berakhir 404 atau 405, misalnya preflight CORS.
+func (rt *Router) Use(mw ...Middleware) {
+	rt.middleware = append(rt.middleware, mw...)
+}
+
+// Handle mendaftarkan handler untuk method dan pola, mw dijalankan setelah parameter path tersedia.
+// Pola yang bentrok atau ganda membuat panic karena itu kesalahan saat menyusun route.
+func (rt *Router) Handle(method, pattern string, h http.Handler, mw ...Middleware) {
+	if !strings.HasPrefix(pattern, "/") {
+		panic("router: pola harus diawali /: " + pattern)
+	}
+	n := &rt.root
+	segs := split(pattern)
+	for i, seg := range segs {
+		switch {
+		case strings.HasPrefix(seg, ":"):
+			n = n.child(&n.param, seg[1:], pattern)
+		case strings.HasPrefix(seg, "*"):
+			if i != len(segs)-1 {
+				panic("router: *param harus segmen terakhir: " + pattern)
+			}
+			n = n.child(&n.wildcard, seg[1:], pattern)
+		default:
+			if n.static == nil {
+				n.static = make(map[string]*node)
+			}
+			if n.static[seg] == nil {
+				n.static[seg] = &node{}
+			}
+			n = n.static[seg]
+		}
+	}
+	if n.handlers == nil {
+		n.handlers = make(map[string]http.Handler)
+	}
+	if _, dup := n.handlers[method]; dup {
+		panic("router: route ganda " + method + " " + pattern)
+	}
+	n.handlers[method] = chain(h, mw)
+}
+
+// HandleFunc sama dengan Handle untuk fungsi handler biasa
+func (rt *Router) HandleFunc(method, pattern string, h http.HandlerFunc, mw ...Middleware) {
+	rt.Handle(method, pattern, h, mw...)
+}
+
+// ServeHTTP menjalankan middleware global lalu handler yang cocok
+func (rt *Router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	chain(http.HandlerFunc(rt.dispatch), rt.middleware).ServeHTTP(w, r)
+}
+
+func (rt *Router) dispatch(w http.ResponseWriter, r *http.Request) {
+	params := Params{}
+	allow := make(map[string]bool)
+	h := rt.root.match(split(r.URL.Path), r.Method, params, allow)
+	switch {
+	case h != nil:
+		if len(params) > 0 {
+			r = r.WithContext(context.WithValue(r.Context(), paramsKey, params))
+		}
+		h.ServeHTTP(w, r)
+	case len(allow) > 0:
+		methods := make([]string, 0, len(allow))
+		for m := range allow {
+			methods = append(methods, m)
+		}
+		sort.Strings(methods)
+		w.Header().Set("Allow", strings.Join(methods, ", "))
+		if rt.MethodNotAllowed != nil {
+			rt.MethodNotAllowed.ServeHTTP(w, r)
+			return
+		}
+		at.WriteError(w, at.NewError(at.CodeMethodNotAllowed, "", r.Method+" "+r.URL.Path))
+	case rt.NotFound != nil:
+		rt.NotFound.ServeHTTP(w, r)
+	default:
+		at.WriteError(w, at.NewError(at.CodeNotFound, "", r.URL.Path))
+	}
+}
+
+// Param nilai parameter path bernama dari route yang cocok, kosong jika tidak ada
+func Param(r *http.Request, name string) string {
+	params, _ := r.Context().Value(paramsKey).(Params)
+	return params[name]
+}
+
+// child mengambil atau membuat node parameter, nama parameter pada posisi yang sama harus seragam
+func (n *node) child(slot **node, name, pattern string) *node {
+	if name == "" {
+		panic("router: parameter tanpa nama: " + pattern)
+	}
+	if *slot == nil {
+		*slot = &node{name: name}
+	} else if (*slot).name != name {
+		panic("router: parameter " + name + " bentrok dengan " + (*slot).name + ": " + pattern)
+	}
+	return *slot
+}
+
+// match mencari handler untuk method, method lain pada path yang cocok dikumpulkan di allow untuk 405.
+// Jika node static cocok tapi tidak punya method yang diminta, pencarian lanjut ke parameter.
+func (n *node) match(segs []string, method string, params Params, allow map[string]bool) http.Handler {
+	if len(segs) == 0 {
+		if h, ok := n.handlers[method]; ok {
+			return h
+		}
+		for m := range n.handlers {
+			allow[m] = true
+		}
+		return nil
+	}
+	seg := segs[0]
+	if child := n.static[seg]; child != nil {
+		if h := child.match(segs[1:], method, params, allow); h != nil {
+			return h
+		}
+	}
+	if n.param != nil && seg != "" {
+		params[n.param.name] = seg
+		if h := n.param.match(segs[1:], method, params, allow); h != nil {
+			return h
+		}
+		delete(params, n.param.name)
+	}
+	if n.wildcard != nil && seg != "" {
+		if h, ok := n.wildcard.handlers[method]; ok {
+			params[n.wildcard.name] = strings.Join(segs, "/")
+			return h
+		}
+		for m := range n.wildcard.handlers {
+			allow[m] = true
+		}
+	}
+	return nil
+}
+
+// split memecah path menjadi segmen, path / tidak punya segmen
+func split(path string) []string {
+	path = strings.TrimPrefix(path, "/")
+	if path == "" {
+		return nil
+	}
+	return strings.Split(path, "/")
+}
+
+func chain(h http.Handler, mw []Middleware) http.Handler {
+	for i := len(mw) - 1; i >= 0; i-- {
+		h = mw[i](h)
+	}
+	return h
+}
@@ -0,0 +1,125 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func reply(body string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(body + "|" + Param(r, "id") + "|" + Param(r, "sub") + "|" + Param(r, "path")))
+	}
+}
+
+func serve(h http.Handler, method, path string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(method, path, nil))
+	return rec
+}
+
+func TestRouterMatch(t *testing.T) {
+	rt := New()
+	rt.HandleFunc("GET", "/", reply("home"))
+	rt.HandleFunc("GET", "/product/categories", reply("categories"))
+	rt.HandleFunc("GET", "/product/:id", reply("product"))
+	rt.HandleFunc("PUT", "/product/:id", reply("update"))
+	rt.HandleFunc("GET", "/product/:id/review/:sub", reply("review"))
+	rt.HandleFunc("POST", "/cart/item", reply("item"))
+	rt.HandleFunc("GET", "/cart/:id", reply("cart"))
+	rt.HandleFunc("GET", "/download/*path", reply("download"))
+
+	cases := []struct {
+		method, path string
+		status       int
+		body         string
+	}{
+		{"GET", "/", 200, "home|||"},
+		{"GET", "/product/categories", 200, "categories|||"},
+		{"GET", "/product/65f0", 200, "product|65f0||"},
+		{"PUT", "/product/65f0", 200, "update|65f0||"},
+		{"GET", "/product/65f0/review/7", 200, "review|65f0|7|"},
+		{"GET", "/cart/item", 200, "cart|item||"}, // static tanpa GET jatuh ke parameter
+		{"POST", "/cart/item", 200, "item|||"},
+		{"GET", "/download/a/b/c.pdf", 200, "download|||a/b/c.pdf"},
+		{"GET", "/product/", 404, ""},
+		{"GET", "/tidak/ada", 404, ""},
+	}
+	for _, c := range cases {
+		rec := serve(rt, c.method, c.path)
+		if rec.Code != c.status {
+			t.Errorf("%s %s status = %d, want %d", c.method, c.path, rec.Code, c.status)
+			continue
+		}
+		if c.body != "" && rec.Body.String() != c.body {
+			t.Errorf("%s %s body = %q, want %q", c.method, c.path, rec.Body.String(), c.body)
+		}
+	}
+
+	rec := serve(rt, "DELETE", "/product/65f0")
+	if rec.Code != http.StatusMethodNotAllowed || rec.Header().Get("Allow") != "GET, PUT" {
+		t.Fatalf("405 = %d, Allow %q", rec.Code, rec.Header().Get("Allow"))
+	}
+}
+
+func TestRouterConflict(t *testing.T) {
+	for _, patterns := range [][]string{
+		{"/product/:id", "/product/:productid/media"},
+		{"/cart", "/cart"},
+		{"/file/*path/x"},
+		{"tanpa-slash"},
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%v should panic", patterns)
+				}
+			}()
+			rt := New()
+			for _, p := range patterns {
+				rt.HandleFunc("GET", p, reply("x"))
+			}
+		}()
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	var order []string
+	trace := func(name string) Middleware {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				order = append(order, name)
+				next.ServeHTTP(w, r)
+			})
+		}
+	}
+	rt := New()
+	rt.Use(RequestID, Recovery, trace("global"))
+	rt.HandleFunc("GET", "/ok/:id", reply("ok"), trace("route"))
+	rt.HandleFunc("GET", "/panic", func(w http.ResponseWriter, r *http.Request) { panic("boom") })
+
+	rec := serve(rt, "GET", "/ok/1")
+	if strings.Join(order, ",") != "global,route" || rec.Body.String() != "ok|1||" {
+		t.Fatalf("order %v body %q", order, rec.Body.String())
+	}
+	if len(rec.Header().Get(HeaderRequestID)) != 24 {
+		t.Fatalf("request id %q", rec.Header().Get(HeaderRequestID))
+	}
+
+	req := httptest.NewRequest("GET", "/panic", nil)
+	req.Header.Set(HeaderRequestID, "abc-123")
+	rec = httptest.NewRecorder()
+	rt.ServeHTTP(rec, req)
+	if rec.Code != http.StatusInternalServerError || !strings.Contains(rec.Body.String(), "abc-123") {
+		t.Fatalf("recovery = %d %q", rec.Code, rec.Body.String())
+	}
+
+	req = httptest.NewRequest("GET", "/ok/1", nil)
+	req.Header.Set(HeaderRequestID, "bukan id\nvalid")
+	rec = httptest.NewRecorder()
+	rt.ServeHTTP(rec, req)
+	if id := rec.Header().Get(HeaderRequestID); id == "" || strings.Contains(id, " ") {
+		t.Fatalf("invalid request id kept: %q", id)
+	}
+}
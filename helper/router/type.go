@@ -0,0 +1,33 @@
+package router
+
+import (
+	"net/http"
+
+	"github.com/gocroot/helper/at"
+)
+
+// HeaderRequestID header yang dibaca dan dikirim balik oleh middleware RequestID,
+// sama dengan header yang dibaca at.WriteError
+const HeaderRequestID = at.HeaderRequestID
+
+// Middleware membungkus handler, dipasang lewat Router.Use atau per route
+type Middleware func(http.Handler) http.Handler
+
+type contextKey int
+
+const (
+	paramsKey contextKey = iota
+	requestIDKey
+)
+
+// Params parameter bernama hasil pencocokan pola seperti /review/product/:id
+type Params map[string]string
+
+// node satu segmen path, anak static dicoba lebih dulu sebelum :param lalu *wildcard
+type node struct {
+	static   map[string]*node
+	param    *node
+	wildcard *node
+	name     string // nama parameter untuk node param dan wildcard
+	handlers map[string]http.Handler
+}
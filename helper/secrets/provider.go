@@ -0,0 +1,136 @@
+package secrets
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// EnvProvider membaca secret dari environment variable dengan nama yang sama
+type EnvProvider struct{}
+
+func (EnvProvider) Name() string { return "env" }
+
+func (EnvProvider) Load(ctx context.Context, names []string) (map[string]string, error) {
+	values := map[string]string{}
+	for _, name := range names {
+		if v, ok := os.LookupEnv(name); ok && v != "" {
+			values[name] = v
+		}
+	}
+	return values, nil
+}
+
+// FileProvider membaca secret dari file berformat NAMA=nilai per baris, baris kosong dan diawali # diabaikan.
+// Path kosong berarti provider tidak dipakai.
+type FileProvider struct {
+	Path string
+}
+
+func (p FileProvider) Name() string { return "file" }
+
+func (p FileProvider) Load(ctx context.Context, names []string) (map[string]string, error) {
+	values := map[string]string{}
+	if p.Path == "" {
+		return values, nil
+	}
+	f, err := os.Open(p.Path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	all, err := ParseFile(f)
+	if err != nil {
+		return nil, errors.New(p.Path + ": " + err.Error())
+	}
+	for _, name := range names {
+		if v := all[name]; v != "" {
+			values[name] = v
+		}
+	}
+	return values, nil
+}
+
+// ParseFile membaca isi file secret berformat NAMA=nilai, nilai boleh diapit tanda kutip
+func ParseFile(r io.Reader) (map[string]string, error) {
+	values := map[string]string{}
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		name, value, ok := strings.Cut(text, "=")
+		name = strings.TrimSpace(strings.TrimPrefix(name, "export "))
+		if !ok || name == "" {
+			return nil, errors.New("baris " + strconv.Itoa(line) + ": format harus NAMA=nilai")
+		}
+		value = strings.TrimSpace(value)
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+		values[name] = value
+	}
+	return values, scanner.Err()
+}
+
+// MongoProvider membaca secret terenkripsi dari collection secrets, didekripsi dengan Key
+type MongoProvider struct {
+	DB  *mongo.Database
+	Key string
+}
+
+func (p MongoProvider) Name() string { return "mongo" }
+
+func (p MongoProvider) Load(ctx context.Context, names []string) (map[string]string, error) {
+	if p.DB == nil {
+		return nil, errors.New("koneksi mongo belum tersedia")
+	}
+	cur, err := p.DB.Collection(SecretCollection).Find(ctx, bson.M{"_id": bson.M{"$in": names}})
+	if err != nil {
+		return nil, err
+	}
+	var docs []mongoSecret
+	if err = cur.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+	values := map[string]string{}
+	for _, doc := range docs {
+		plain, err := Decrypt(doc.Value, p.Key)
+		if err != nil {
+			return nil, errors.New("secret " + doc.Name + " tidak bisa didekripsi: " + err.Error())
+		}
+		if plain != "" {
+			values[doc.Name] = plain
+		}
+	}
+	return values, nil
+}
+
+// Put menyimpan secret terenkripsi ke collection secrets
+func (p MongoProvider) Put(ctx context.Context, name, value string) error {
+	sealed, err := Encrypt(value, p.Key)
+	if err != nil {
+		return err
+	}
+	_, err = p.DB.Collection(SecretCollection).UpdateOne(ctx, bson.M{"_id": name},
+		bson.M{"$set": bson.M{"value": sealed, "updated_at": time.Now()}}, options.Update().SetUpsert(true))
+	return err
+}
+
+// Delete menghapus secret dari collection secrets
+func (p MongoProvider) Delete(ctx context.Context, name string) error {
+	_, err := p.DB.Collection(SecretCollection).DeleteOne(ctx, bson.M{"_id": name})
+	return err
+}
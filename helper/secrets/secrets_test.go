@@ -0,0 +1,89 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+type mapProvider struct {
+	name   string
+	values map[string]string
+	err    error
+}
+
+func (p *mapProvider) Name() string { return p.name }
+
+func (p *mapProvider) Load(ctx context.Context, names []string) (map[string]string, error) {
+	return p.values, p.err
+}
+
+func TestStorePriorityAndDefaults(t *testing.T) {
+	env := &mapProvider{name: "env", values: map[string]string{"A": "env"}}
+	mongo := &mapProvider{name: "mongo", values: map[string]string{"A": "mongo", "B": "mongo"}}
+	store := NewStore([]Spec{{Name: "A", Required: true}, {Name: "B"}, {Name: "C", Default: "c"}}, env, mongo)
+	if err := store.Load(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if store.Get("A") != "env" || store.Get("B") != "mongo" || store.Get("C") != "c" {
+		t.Fatalf("unexpected values %v", store.values)
+	}
+	for _, st := range store.Status() {
+		if st.Name == "B" && st.Source != "mongo" {
+			t.Fatalf("B source = %s", st.Source)
+		}
+	}
+}
+
+func TestStoreMissingAndProviderFailure(t *testing.T) {
+	mongo := &mapProvider{name: "mongo", values: map[string]string{"A": "old"}}
+	store := NewStore([]Spec{{Name: "A", Required: true}, {Name: "B", Required: true}}, mongo)
+	err := store.Load(context.Background())
+	var missing *MissingError
+	if !errors.As(err, &missing) || len(missing.Names) != 1 || missing.Names[0] != "B" {
+		t.Fatalf("expected B missing, got %v", err)
+	}
+	// reload gagal tetap memakai nilai lama dari provider tersebut
+	mongo.values, mongo.err = nil, errors.New("down")
+	err = store.Load(context.Background())
+	var perr *ProviderError
+	if !errors.As(err, &perr) || perr.Provider != "mongo" {
+		t.Fatalf("expected provider error, got %v", err)
+	}
+	if store.Get("A") != "old" {
+		t.Fatalf("A should keep old value, got %q", store.Get("A"))
+	}
+	if _, err = store.Require("B"); err == nil {
+		t.Fatal("Require B should fail")
+	}
+}
+
+func TestParseFile(t *testing.T) {
+	values, err := ParseFile(strings.NewReader("# komentar\nA=1\nexport B = \"dua = 2\"\n\nC='tiga'\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if values["A"] != "1" || values["B"] != "dua = 2" || values["C"] != "tiga" {
+		t.Fatalf("unexpected values %v", values)
+	}
+	if _, err = ParseFile(strings.NewReader("A=1\nrusak\n")); err == nil || !strings.Contains(err.Error(), "baris 2") {
+		t.Fatalf("expected line error, got %v", err)
+	}
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	sealed, err := Encrypt("RAHASIA", "aeskey")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plain, err := Decrypt(sealed, "aeskey"); err != nil || plain != "RAHASIA" {
+		t.Fatalf("Decrypt = %q, %v", plain, err)
+	}
+	if _, err = Decrypt(sealed, "kunci lain"); err == nil {
+		t.Fatal("Decrypt with wrong key should fail")
+	}
+	if _, err = Encrypt("x", ""); err == nil {
+		t.Fatal("empty key should fail")
+	}
+}
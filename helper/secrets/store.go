@@ -0,0 +1,133 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// Store snapshot secret dari beberapa provider yang bisa dimuat ulang tanpa restart.
+// Provider disusun berdasarkan prioritas, nilai dari provider pertama yang punya secret tersebut yang dipakai.
+type Store struct {
+	specs     []Spec
+	providers []Provider
+
+	mu       sync.RWMutex
+	values   map[string]string
+	sources  map[string]string
+	loadedAt time.Time
+}
+
+// NewStore membuat store kosong, panggil Load untuk mengisi
+func NewStore(specs []Spec, providers ...Provider) *Store {
+	return &Store{
+		specs:     specs,
+		providers: providers,
+		values:    map[string]string{},
+		sources:   map[string]string{},
+	}
+}
+
+// Load membaca ulang semua provider lalu mengganti snapshot.
+// Provider yang gagal tidak menghapus nilai lama yang berasal darinya, sehingga reload saat Mongo bermasalah
+// tidak membuat secret hilang. Error berisi ProviderError dan MissingError, snapshot tetap diganti.
+func (s *Store) Load(ctx context.Context) error {
+	names := make([]string, len(s.specs))
+	for i, spec := range s.specs {
+		names[i] = spec.Name
+	}
+	s.mu.RLock()
+	prevValues, prevSources := s.values, s.sources
+	s.mu.RUnlock()
+
+	values := map[string]string{}
+	sources := map[string]string{}
+	var errs []error
+	for _, p := range s.providers {
+		loaded, err := p.Load(ctx, names)
+		if err != nil {
+			errs = append(errs, &ProviderError{Provider: p.Name(), Err: err})
+			loaded = map[string]string{}
+			for name, source := range prevSources {
+				if source == p.Name() {
+					loaded[name] = prevValues[name]
+				}
+			}
+		}
+		for name, v := range loaded {
+			if _, ok := values[name]; !ok && v != "" {
+				values[name], sources[name] = v, p.Name()
+			}
+		}
+	}
+	var missing []string
+	for _, spec := range s.specs {
+		if _, ok := values[spec.Name]; ok {
+			continue
+		}
+		if spec.Default != "" {
+			values[spec.Name], sources[spec.Name] = spec.Default, "default"
+		} else if spec.Required {
+			missing = append(missing, spec.Name)
+		}
+	}
+	if len(missing) > 0 {
+		errs = append(errs, &MissingError{Names: missing})
+	}
+
+	s.mu.Lock()
+	s.values, s.sources, s.loadedAt = values, sources, time.Now()
+	s.mu.Unlock()
+	return errors.Join(errs...)
+}
+
+// Get nilai secret, kosong jika tidak diset
+func (s *Store) Get(name string) string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.values[name]
+}
+
+// Require nilai secret atau MissingError jika kosong, dipakai handler supaya error-nya jelas
+func (s *Store) Require(name string) (string, error) {
+	if v := s.Get(name); v != "" {
+		return v, nil
+	}
+	return "", &MissingError{Names: []string{name}}
+}
+
+// LoadedAt waktu Load terakhir
+func (s *Store) LoadedAt() time.Time {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.loadedAt
+}
+
+// Status daftar secret beserta sumbernya tanpa nilai
+func (s *Store) Status() []Status {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	list := make([]Status, 0, len(s.specs))
+	for _, spec := range s.specs {
+		_, set := s.values[spec.Name]
+		list = append(list, Status{
+			Name:        spec.Name,
+			Required:    spec.Required,
+			Set:         set,
+			Source:      s.sources[spec.Name],
+			Description: spec.Description,
+		})
+	}
+	return list
+}
+
+// Known apakah nama secret terdaftar di spec
+func (s *Store) Known(name string) bool {
+	for _, spec := range s.specs {
+		if spec.Name == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,61 @@
+package secrets
+
+import (
+	"context"
+	"strings"
+)
+
+// SecretCollection collection secret terenkripsi di Mongo, _id adalah nama secret
+const SecretCollection = "secrets"
+
+// Provider sumber nilai secret. Load hanya mengembalikan nama yang diminta dan yang memang ada di sumbernya.
+type Provider interface {
+	Name() string
+	Load(ctx context.Context, names []string) (map[string]string, error)
+}
+
+// Spec definisi satu secret yang dipakai aplikasi
+type Spec struct {
+	Name        string
+	Required    bool
+	Default     string
+	Description string
+}
+
+// Status keadaan satu secret tanpa nilainya, aman untuk ditampilkan
+type Status struct {
+	Name        string `json:"name"`
+	Required    bool   `json:"required"`
+	Set         bool   `json:"set"`
+	Source      string `json:"source,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// MissingError secret wajib yang tidak ditemukan di provider mana pun
+type MissingError struct {
+	Names []string
+}
+
+func (e *MissingError) Error() string {
+	return "secret wajib belum diset: " + strings.Join(e.Names, ", ")
+}
+
+// ProviderError provider yang gagal dibaca, nilai dari provider lain tetap dipakai
+type ProviderError struct {
+	Provider string
+	Err      error
+}
+
+func (e *ProviderError) Error() string {
+	return "gagal membaca secret dari " + e.Provider + ": " + e.Err.Error()
+}
+
+func (e *ProviderError) Unwrap() error {
+	return e.Err
+}
+
+// mongoSecret dokumen di collection secrets
+type mongoSecret struct {
+	Name  string `bson:"_id"`
+	Value string `bson:"value"` // terenkripsi AES-GCM lalu base64
+}
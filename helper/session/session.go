@@ -0,0 +1,146 @@
+package session
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// NewRefreshToken membuat refresh token berformat <sessionid>.<acak> beserta hash yang disimpan di Mongo
+func NewRefreshToken(sessionID string) (token, hash string, err error) {
+	secret := make([]byte, 32)
+	if _, err = rand.Read(secret); err != nil {
+		return
+	}
+	token = sessionID + "." + base64.RawURLEncoding.EncodeToString(secret)
+	return token, HashToken(token), nil
+}
+
+// ParseRefreshToken mengambil id sesi dari refresh token
+func ParseRefreshToken(token string) (sessionID string, ok bool) {
+	sessionID, secret, found := strings.Cut(token, ".")
+	return sessionID, found && sessionID != "" && secret != ""
+}
+
+// HashToken hash sha256 hex dari refresh token
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// Create membuat sesi baru dan mengembalikan refresh token pertamanya
+func Create(db *mongo.Database, userID, alias, userAgent string, now time.Time) (sess Session, refreshToken string, err error) {
+	sess = Session{
+		ID:        primitive.NewObjectID().Hex(),
+		UserID:    userID,
+		Alias:     alias,
+		UserAgent: userAgent,
+		CreatedAt: now,
+		ExpiresAt: now.Add(RefreshTTL),
+	}
+	if refreshToken, sess.RefreshHash, err = NewRefreshToken(sess.ID); err != nil {
+		return
+	}
+	_, err = db.Collection(SessionCollection).InsertOne(context.TODO(), sess)
+	return
+}
+
+// Rotate menukar refresh token dengan yang baru secara atomik.
+// Refresh token sebelumnya yang dipakai lagi dianggap dicuri sehingga seluruh sesi dicabut.
+func Rotate(db *mongo.Database, refreshToken string, now time.Time) (sess Session, newToken string, err error) {
+	sessionID, ok := ParseRefreshToken(refreshToken)
+	if !ok {
+		return sess, "", ErrInvalidRefresh
+	}
+	hash := HashToken(refreshToken)
+	newToken, newHash, err := NewRefreshToken(sessionID)
+	if err != nil {
+		return
+	}
+	filter := bson.M{
+		"_id":          sessionID,
+		"refresh_hash": hash,
+		"revoked_at":   bson.M{"$exists": false},
+		"expires_at":   bson.M{"$gt": now},
+	}
+	update := bson.M{"$set": bson.M{
+		"refresh_hash": newHash,
+		"prev_hash":    hash,
+		"refreshed_at": now,
+		"expires_at":   now.Add(RefreshTTL),
+	}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	err = db.Collection(SessionCollection).FindOneAndUpdate(context.TODO(), filter, update, opts).Decode(&sess)
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		return
+	}
+	reused, cerr := db.Collection(SessionCollection).CountDocuments(context.TODO(), bson.M{"_id": sessionID, "prev_hash": hash})
+	if cerr == nil && reused > 0 {
+		db.Collection(SessionCollection).UpdateOne(context.TODO(),
+			bson.M{"_id": sessionID, "revoked_at": bson.M{"$exists": false}},
+			bson.M{"$set": bson.M{"revoked_at": now, "revoke_reason": "refresh token dipakai ulang"}})
+		return sess, "", ErrReused
+	}
+	return sess, "", ErrInvalidRefresh
+}
+
+// Revoke mencabut satu sesi milik user, token akses sesi tersebut langsung tidak berlaku
+func Revoke(db *mongo.Database, userID, sessionID, reason string, now time.Time) (bool, error) {
+	res, err := db.Collection(SessionCollection).UpdateOne(context.TODO(),
+		bson.M{"_id": sessionID, "user_id": userID, "revoked_at": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"revoked_at": now, "revoke_reason": reason}})
+	if err != nil {
+		return false, err
+	}
+	return res.ModifiedCount > 0, nil
+}
+
+// RevokeAll mencabut semua sesi user dan semua token tanpa sesi yang terbit sebelum now
+func RevokeAll(db *mongo.Database, userID, reason string, now time.Time) (count int64, err error) {
+	res, err := db.Collection(SessionCollection).UpdateMany(context.TODO(),
+		bson.M{"user_id": userID, "revoked_at": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"revoked_at": now, "revoke_reason": reason}})
+	if err != nil {
+		return
+	}
+	_, err = db.Collection(RevocationCollection).UpdateOne(context.TODO(),
+		bson.M{"_id": userID},
+		bson.M{"$set": bson.M{"before": now, "reason": reason}},
+		options.Update().SetUpsert(true))
+	return res.ModifiedCount, err
+}
+
+// Active daftar sesi user yang belum dicabut dan belum kedaluwarsa
+func Active(db *mongo.Database, userID string, now time.Time) (sessions []Session, err error) {
+	filter := bson.M{"user_id": userID, "revoked_at": bson.M{"$exists": false}, "expires_at": bson.M{"$gt": now}}
+	cur, err := db.Collection(SessionCollection).Find(context.TODO(), filter, options.Find().SetSort(bson.M{"created_at": -1}))
+	if err != nil {
+		return
+	}
+	sessions = []Session{}
+	err = cur.All(context.TODO(), &sessions)
+	return
+}
+
+// IsRevoked dipakai keyring token: token bersesi berlaku selama sesinya belum dicabut,
+// token tanpa sesi (token lama dan token perangkat) dicek ke waktu pencabutan user
+func IsRevoked(db *mongo.Database, userID, sessionID string, issuedAt time.Time) (bool, error) {
+	if sessionID != "" {
+		n, err := db.Collection(SessionCollection).CountDocuments(context.TODO(),
+			bson.M{"_id": sessionID, "user_id": userID, "revoked_at": bson.M{"$exists": false}})
+		return n == 0, err
+	}
+	n, err := db.Collection(RevocationCollection).CountDocuments(context.TODO(),
+		bson.M{"_id": userID, "before": bson.M{"$gt": issuedAt}})
+	return n > 0, err
+}
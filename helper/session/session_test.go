@@ -0,0 +1,25 @@
+package session
+
+import "testing"
+
+func TestRefreshToken(t *testing.T) {
+	token, hash, err := NewRefreshToken("65f0c0ffee")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hash != HashToken(token) || len(hash) != 64 {
+		t.Fatalf("hash mismatch: %s", hash)
+	}
+	other, _, _ := NewRefreshToken("65f0c0ffee")
+	if other == token {
+		t.Fatal("refresh tokens must be random")
+	}
+	if sid, ok := ParseRefreshToken(token); !ok || sid != "65f0c0ffee" {
+		t.Fatalf("ParseRefreshToken = %q, %v", sid, ok)
+	}
+	for _, bad := range []string{"", "tanpa-titik", ".rahasia", "sesi."} {
+		if _, ok := ParseRefreshToken(bad); ok {
+			t.Errorf("%q should not parse", bad)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package session
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	SessionCollection    = "loginsession" // collection session dipakai menu whatsapp
+	RevocationCollection = "revocation"
+
+	// AccessTTL umur token akses, dibuat pendek karena tiap request tetap dicek ke daftar pencabutan
+	AccessTTL = 15 * time.Minute
+	// RefreshTTL umur refresh token sejak terakhir dirotasi
+	RefreshTTL = 30 * 24 * time.Hour
+)
+
+var (
+	ErrInvalidRefresh = errors.New("refresh token tidak valid atau sudah kedaluwarsa")
+	ErrReused         = errors.New("refresh token lama dipakai ulang, sesi dicabut")
+)
+
+// Session satu login di satu perangkat, refresh token hanya disimpan hash-nya
+type Session struct {
+	ID           string    `bson:"_id" json:"id"`
+	UserID       string    `bson:"user_id" json:"user_id"`
+	Alias        string    `bson:"alias,omitempty" json:"alias,omitempty"`
+	RefreshHash  string    `bson:"refresh_hash" json:"-"`
+	PrevHash     string    `bson:"prev_hash,omitempty" json:"-"` // hash refresh token sebelumnya untuk deteksi pemakaian ulang
+	UserAgent    string    `bson:"user_agent,omitempty" json:"user_agent,omitempty"`
+	CreatedAt    time.Time `bson:"created_at" json:"created_at"`
+	RefreshedAt  time.Time `bson:"refreshed_at,omitempty" json:"refreshed_at,omitempty"`
+	ExpiresAt    time.Time `bson:"expires_at" json:"expires_at"`
+	RevokedAt    time.Time `bson:"revoked_at,omitempty" json:"revoked_at,omitempty"`
+	RevokeReason string    `bson:"revoke_reason,omitempty" json:"revoke_reason,omitempty"`
+}
+
+// Revocation semua token user yang terbit sebelum Before tidak berlaku, dipakai untuk token tanpa sesi
+type Revocation struct {
+	UserID string    `bson:"_id" json:"user_id"`
+	Before time.Time `bson:"before" json:"before"`
+	Reason string    `bson:"reason,omitempty" json:"reason,omitempty"`
+}
+
+// Tokens pasangan token yang dikirim ke client setelah login atau refresh
+type Tokens struct {
+	AccessToken  string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int64  `json:"expires_in"` // detik sampai token akses kedaluwarsa
+	SessionID    string `json:"session_id"`
+}
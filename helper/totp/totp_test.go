@@ -0,0 +1,64 @@
+package totp
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+)
+
+// secret uji SHA1 dari RFC 6238 lampiran B
+var rfcSecret = base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+func TestCodeAtRFC6238(t *testing.T) {
+	// 8 digit di RFC, di sini diambil 6 digit terakhir
+	cases := map[int64]string{
+		59:          "287082",
+		1111111109:  "081804",
+		1111111111:  "050471",
+		1234567890:  "005924",
+		2000000000:  "279037",
+		20000000000: "353130",
+	}
+	for unix, want := range cases {
+		got, err := CodeAt(rfcSecret, Step(time.Unix(unix, 0)))
+		if err != nil || got != want {
+			t.Errorf("CodeAt(%d) = %s, %v; want %s", unix, got, err, want)
+		}
+	}
+}
+
+func TestVerify(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	code, _ := CodeAt(rfcSecret, Step(now)-1)
+	step, ok := Verify(rfcSecret, code, now, 0)
+	if !ok || step != Step(now)-1 {
+		t.Fatalf("previous step should be accepted, got %d %v", step, ok)
+	}
+	if _, ok = Verify(rfcSecret, code, now, step); ok {
+		t.Fatal("replayed code must be rejected")
+	}
+	if _, ok = Verify(rfcSecret, "000000", now, 0); ok {
+		t.Fatal("wrong code accepted")
+	}
+	if _, ok = Verify(rfcSecret, code, now.Add(5*Period), 0); ok {
+		t.Fatal("code outside skew accepted")
+	}
+}
+
+func TestProvisioningURI(t *testing.T) {
+	uri := ProvisioningURI("Bukupedia", "628111", "ABC")
+	if !strings.HasPrefix(uri, "otpauth://totp/Bukupedia:628111?") || !strings.Contains(uri, "secret=ABC") {
+		t.Fatalf("unexpected uri %s", uri)
+	}
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	codes, hashes, err := GenerateRecoveryCodes(8)
+	if err != nil || len(codes) != 8 || len(hashes) != 8 {
+		t.Fatalf("GenerateRecoveryCodes: %v %v", codes, err)
+	}
+	if HashRecoveryCode(strings.ToUpper(codes[0])) != hashes[0] {
+		t.Fatal("recovery hash should ignore case")
+	}
+}
@@ -0,0 +1,77 @@
+package voucher
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Jenis potongan voucher
+const (
+	TypePercent = "percent"
+	TypeFixed   = "fixed"
+)
+
+// Status redemption voucher
+const (
+	RedemptionUsed     = "used"
+	RedemptionReleased = "released"
+)
+
+// Voucher kode promo milik lapak, disimpan di collection voucher
+type Voucher struct {
+	ID           primitive.ObjectID   `json:"_id,omitempty" bson:"_id,omitempty"`
+	Code         string               `json:"code" bson:"code"`
+	NamaLapak    string               `json:"namalapak" bson:"namalapak"`
+	Type         string               `json:"type" bson:"type"`
+	Value        int64                `json:"value" bson:"value"`                                   // persen atau nominal rupiah
+	MaxDiscount  int64                `json:"max_discount,omitempty" bson:"max_discount,omitempty"` // batas potongan untuk tipe percent, 0 tanpa batas
+	MinOrder     int64                `json:"min_order,omitempty" bson:"min_order,omitempty"`
+	UsageLimit   int                  `json:"usage_limit,omitempty" bson:"usage_limit,omitempty"`       // 0 tanpa batas
+	PerUserLimit int                  `json:"per_user_limit,omitempty" bson:"per_user_limit,omitempty"` // 0 tanpa batas
+	UsedCount    int                  `json:"used_count" bson:"used_count"`
+	Usage        map[string]int       `json:"-" bson:"usage,omitempty"` // jumlah pemakaian per nomor user
+	StartAt      time.Time            `json:"start_at,omitempty" bson:"start_at,omitempty"`
+	EndAt        time.Time            `json:"end_at,omitempty" bson:"end_at,omitempty"`
+	ProductIDs   []primitive.ObjectID `json:"product_ids,omitempty" bson:"product_ids,omitempty"`
+	Categories   []string             `json:"categories,omitempty" bson:"categories,omitempty"`
+	Active       bool                 `json:"active" bson:"active"`
+	CreatedAt    time.Time            `json:"created_at,omitempty" bson:"created_at,omitempty"`
+}
+
+// Line item belanja yang akan dihitung potongannya
+type Line struct {
+	ProductID primitive.ObjectID
+	NamaLapak string
+	Category  string
+	Subtotal  int64
+}
+
+// LineDiscount potongan yang dibebankan ke satu item
+type LineDiscount struct {
+	ProductID primitive.ObjectID `json:"product_id" bson:"product_id"`
+	Subtotal  int64              `json:"subtotal" bson:"subtotal"`
+	Discount  int64              `json:"discount" bson:"discount"`
+}
+
+// Breakdown rincian hasil penerapan voucher
+type Breakdown struct {
+	Code             string         `json:"code" bson:"code"`
+	Subtotal         int64          `json:"subtotal" bson:"subtotal"`
+	EligibleSubtotal int64          `json:"eligible_subtotal" bson:"eligible_subtotal"`
+	Discount         int64          `json:"discount" bson:"discount"`
+	Total            int64          `json:"total" bson:"total"`
+	Items            []LineDiscount `json:"items" bson:"items"`
+}
+
+// Redemption catatan pemakaian voucher untuk satu checkout, disimpan di collection voucherredemption
+type Redemption struct {
+	ID         primitive.ObjectID `json:"_id,omitempty" bson:"_id,omitempty"`
+	VoucherID  primitive.ObjectID `json:"voucher_id" bson:"voucher_id"`
+	Code       string             `json:"code" bson:"code"`
+	UserID     string             `json:"user_id" bson:"user_id"`
+	CheckoutID primitive.ObjectID `json:"checkout_id" bson:"checkout_id"`
+	Discount   int64              `json:"discount" bson:"discount"`
+	Status     string             `json:"status" bson:"status"`
+	CreatedAt  time.Time          `json:"created_at" bson:"created_at"`
+}
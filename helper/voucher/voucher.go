@@ -0,0 +1,133 @@
+package voucher
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// NormalizeCode kode voucher tidak membedakan huruf besar kecil
+func NormalizeCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// Validate memeriksa isian voucher sebelum disimpan
+func Validate(v Voucher) error {
+	if v.Code == "" || v.NamaLapak == "" {
+		return errors.New("code dan namalapak wajib diisi")
+	}
+	switch v.Type {
+	case TypePercent:
+		if v.Value <= 0 || v.Value > 100 {
+			return errors.New("value voucher persen harus 1 sampai 100")
+		}
+	case TypeFixed:
+		if v.Value <= 0 {
+			return errors.New("value voucher nominal harus lebih dari 0")
+		}
+	default:
+		return errors.New("type voucher harus percent atau fixed")
+	}
+	if v.MinOrder < 0 || v.MaxDiscount < 0 || v.UsageLimit < 0 || v.PerUserLimit < 0 {
+		return errors.New("batas voucher tidak boleh negatif")
+	}
+	if !v.StartAt.IsZero() && !v.EndAt.IsZero() && v.EndAt.Before(v.StartAt) {
+		return errors.New("end_at lebih awal dari start_at")
+	}
+	return nil
+}
+
+// Apply menghitung potongan voucher untuk item belanja milik user tanpa mencatat pemakaian
+func Apply(v Voucher, lines []Line, userID string, now time.Time) (b Breakdown, err error) {
+	b = Breakdown{Code: v.Code, Items: []LineDiscount{}}
+	if !v.Active {
+		err = errors.New("voucher " + v.Code + " tidak aktif")
+		return
+	}
+	if !v.StartAt.IsZero() && now.Before(v.StartAt) {
+		err = errors.New("voucher " + v.Code + " belum berlaku")
+		return
+	}
+	if !v.EndAt.IsZero() && now.After(v.EndAt) {
+		err = errors.New("voucher " + v.Code + " sudah berakhir")
+		return
+	}
+	if v.UsageLimit > 0 && v.UsedCount >= v.UsageLimit {
+		err = errors.New("kuota voucher " + v.Code + " sudah habis")
+		return
+	}
+	if v.PerUserLimit > 0 && v.Usage[userID] >= v.PerUserLimit {
+		err = errors.New("voucher " + v.Code + " sudah dipakai maksimal " + strconv.Itoa(v.PerUserLimit) + " kali")
+		return
+	}
+
+	var eligible []int
+	for i, line := range lines {
+		b.Subtotal += line.Subtotal
+		if v.covers(line) {
+			eligible = append(eligible, i)
+			b.EligibleSubtotal += line.Subtotal
+		}
+	}
+	b.Total = b.Subtotal
+	if b.Subtotal < v.MinOrder {
+		err = errors.New("minimal belanja untuk voucher " + v.Code + " adalah " + strconv.FormatInt(v.MinOrder, 10))
+		return
+	}
+	if b.EligibleSubtotal == 0 {
+		err = errors.New("tidak ada produk yang bisa memakai voucher " + v.Code)
+		return
+	}
+
+	switch v.Type {
+	case TypePercent:
+		b.Discount = b.EligibleSubtotal * v.Value / 100
+		if v.MaxDiscount > 0 && b.Discount > v.MaxDiscount {
+			b.Discount = v.MaxDiscount
+		}
+	case TypeFixed:
+		b.Discount = v.Value
+	}
+	if b.Discount > b.EligibleSubtotal {
+		b.Discount = b.EligibleSubtotal
+	}
+	b.Total = b.Subtotal - b.Discount
+
+	// potongan dibagi proporsional ke item yang memenuhi syarat, sisa pembulatan ke item terakhir
+	remaining := b.Discount
+	for n, i := range eligible {
+		share := b.Discount * lines[i].Subtotal / b.EligibleSubtotal
+		if n == len(eligible)-1 {
+			share = remaining
+		}
+		remaining -= share
+		b.Items = append(b.Items, LineDiscount{
+			ProductID: lines[i].ProductID,
+			Subtotal:  lines[i].Subtotal,
+			Discount:  share,
+		})
+	}
+	return
+}
+
+// covers item masuk cakupan voucher jika dari lapak yang sama dan cocok dengan produk atau kategori yang ditentukan
+func (v Voucher) covers(line Line) bool {
+	if line.NamaLapak != v.NamaLapak {
+		return false
+	}
+	if len(v.ProductIDs) == 0 && len(v.Categories) == 0 {
+		return true
+	}
+	for _, id := range v.ProductIDs {
+		if id == line.ProductID {
+			return true
+		}
+	}
+	for _, category := range v.Categories {
+		if strings.EqualFold(category, line.Category) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,48 @@
+package voucher
+
+import (
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestApply(t *testing.T) {
+	now := time.Now()
+	lines := []Line{
+		{ProductID: primitive.NewObjectID(), NamaLapak: "kopi", Category: "minuman", Subtotal: 30000},
+		{ProductID: primitive.NewObjectID(), NamaLapak: "kopi", Category: "makanan", Subtotal: 20000},
+	}
+	v := Voucher{Code: "HEMAT", NamaLapak: "kopi", Type: TypePercent, Value: 10, Active: true, Categories: []string{"Minuman"}}
+	b, err := Apply(v, lines, "62811", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.Subtotal != 50000 || b.EligibleSubtotal != 30000 || b.Discount != 3000 || b.Total != 47000 || len(b.Items) != 1 {
+		t.Errorf("breakdown tidak sesuai: %+v", b)
+	}
+
+	v = Voucher{Code: "POTONG", NamaLapak: "kopi", Type: TypeFixed, Value: 10000, Active: true, MinOrder: 60000}
+	if _, err = Apply(v, lines, "62811", now); err == nil {
+		t.Error("minimal belanja harus ditolak")
+	}
+	v.MinOrder = 0
+	b, err = Apply(v, lines, "62811", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.Items[0].Discount+b.Items[1].Discount != 10000 {
+		t.Errorf("pembagian potongan tidak sesuai: %+v", b.Items)
+	}
+
+	v.PerUserLimit = 1
+	v.Usage = map[string]int{"62811": 1}
+	if _, err = Apply(v, lines, "62811", now); err == nil {
+		t.Error("batas pemakaian per user harus ditolak")
+	}
+	v.Usage = nil
+	v.EndAt = now.Add(-time.Hour)
+	if _, err = Apply(v, lines, "62811", now); err == nil {
+		t.Error("voucher yang sudah berakhir harus ditolak")
+	}
+}
@@ -0,0 +1,246 @@
+package watoken
+
+import (
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"aidanwoods.dev/go-paseto"
+)
+
+var (
+	ErrNoSigningKey = errors.New("keyring belum punya signing key")
+	ErrUnknownKey   = errors.New("kid token tidak dikenal")
+	ErrNoKey        = errors.New("keyring belum punya kunci verifikasi")
+	ErrRevoked      = errors.New("token sudah dicabut")
+)
+
+// RevocationList dicek setelah tanda tangan token valid, sessionID kosong untuk token tanpa jti
+type RevocationList interface {
+	Revoked(id, sessionID string, issuedAt time.Time) (bool, error)
+}
+
+// Key kunci PASETO v4 public dengan id, PrivateKey kosong berarti hanya untuk verifikasi
+type Key struct {
+	ID         string `bson:"kid" json:"kid"`
+	PublicKey  string `bson:"publickey" json:"publickey"`
+	PrivateKey string `bson:"privatekey,omitempty" json:"-"`
+}
+
+// Footer footer token yang berisi id kunci penanda tangan
+type Footer struct {
+	Kid string `json:"kid"`
+}
+
+// Keyring kumpulan kunci verifikasi dan satu signing key, aman dipakai bersamaan dari banyak request.
+// Token tanpa kid (token lama atau dari whatsauth) dicoba ke semua kunci verifikasi.
+type Keyring struct {
+	mu      sync.RWMutex
+	keys    map[string]paseto.V4AsymmetricPublicKey
+	order   []string
+	signing string
+	secret  paseto.V4AsymmetricSecretKey
+	revoked RevocationList
+}
+
+func NewKeyring() *Keyring {
+	return &Keyring{keys: map[string]paseto.V4AsymmetricPublicKey{}}
+}
+
+// GenerateKeyWithID membuat pasangan kunci baru dengan kid acak
+func GenerateKeyWithID() Key {
+	privateKey, publicKey := GenerateKey()
+	return Key{ID: time.Now().Format("20060102") + "-" + RandomString(8), PublicKey: publicKey, PrivateKey: privateKey}
+}
+
+// Replace mengganti seluruh isi keyring sekaligus, signingKid kosong berarti keyring hanya bisa verifikasi
+func (k *Keyring) Replace(keys []Key, signingKid string) error {
+	pubs := make(map[string]paseto.V4AsymmetricPublicKey, len(keys))
+	order := make([]string, 0, len(keys))
+	var secret paseto.V4AsymmetricSecretKey
+	signing := ""
+	for _, key := range keys {
+		pub, sec, err := parseKey(key)
+		if err != nil {
+			return errors.New("kunci " + key.ID + ": " + err.Error())
+		}
+		if _, ok := pubs[key.ID]; !ok {
+			order = append(order, key.ID)
+		}
+		pubs[key.ID] = pub
+		if key.ID == signingKid && sec != nil {
+			secret, signing = *sec, key.ID
+		}
+	}
+	if signingKid != "" && signing == "" {
+		return errors.New("signing key " + signingKid + " tidak ada atau tidak punya private key")
+	}
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.keys, k.order, k.signing, k.secret = pubs, order, signing, secret
+	return nil
+}
+
+// Add menambah atau mengganti satu kunci verifikasi
+func (k *Keyring) Add(key Key) error {
+	pub, _, err := parseKey(key)
+	if err != nil {
+		return err
+	}
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if _, ok := k.keys[key.ID]; !ok {
+		k.order = append(k.order, key.ID)
+	}
+	k.keys[key.ID] = pub
+	return nil
+}
+
+// SetRevocationList memasang daftar pencabutan yang dicek setiap Decode
+func (k *Keyring) SetRevocationList(rl RevocationList) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.revoked = rl
+}
+
+// SigningKid id kunci yang dipakai untuk sign token baru
+func (k *Keyring) SigningKid() string {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return k.signing
+}
+
+// KeyIDs daftar id kunci verifikasi sesuai urutan ditambahkan
+func (k *Keyring) KeyIDs() []string {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return append([]string{}, k.order...)
+}
+
+// parseKey membaca kunci hex, public key diturunkan dari private key jika kosong
+func parseKey(key Key) (pub paseto.V4AsymmetricPublicKey, sec *paseto.V4AsymmetricSecretKey, err error) {
+	if key.ID == "" {
+		err = errors.New("kid kosong")
+		return
+	}
+	if key.PrivateKey != "" {
+		var s paseto.V4AsymmetricSecretKey
+		if s, err = paseto.NewV4AsymmetricSecretKeyFromHex(key.PrivateKey); err != nil {
+			return
+		}
+		sec = &s
+		pub = s.Public()
+		if key.PublicKey != "" && key.PublicKey != pub.ExportHex() {
+			err = errors.New("public key tidak cocok dengan private key")
+		}
+		return
+	}
+	pub, err = paseto.NewV4AsymmetricPublicKeyFromHex(key.PublicKey)
+	return
+}
+
+// sign menandatangani token dengan signing key dan menaruh kid di footer
+func (k *Keyring) sign(token paseto.Token) (string, error) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	if k.signing == "" {
+		return "", ErrNoSigningKey
+	}
+	footer, err := json.Marshal(Footer{Kid: k.signing})
+	if err != nil {
+		return "", err
+	}
+	token.SetFooter(footer)
+	return token.V4Sign(k.secret, nil), nil
+}
+
+// Encode sama dengan Encode tapi memakai signing key dari keyring
+func (k *Keyring) Encode(id string) (string, error) {
+	token := paseto.NewToken()
+	token.SetIssuedAt(time.Now())
+	token.SetNotBefore(time.Now())
+	token.SetExpiration(time.Now().Add(2 * time.Hour))
+	token.SetString("id", id)
+	return k.sign(token)
+}
+
+// EncodeforHours sama dengan EncodeforHours tapi memakai signing key dari keyring
+func (k *Keyring) EncodeforHours(id, alias string, hours int32) (string, error) {
+	token := paseto.NewToken()
+	token.SetIssuedAt(time.Now())
+	token.SetNotBefore(time.Now())
+	token.SetExpiration(time.Now().Add(time.Duration(hours) * time.Hour))
+	token.SetString("id", id)
+	token.SetString("alias", alias)
+	return k.sign(token)
+}
+
+// EncodeSession token akses berumur pendek yang terikat ke satu sesi lewat jti
+func (k *Keyring) EncodeSession(id, alias, sessionID string, dur time.Duration) (string, error) {
+	token := paseto.NewToken()
+	token.SetIssuedAt(time.Now())
+	token.SetNotBefore(time.Now())
+	token.SetExpiration(time.Now().Add(dur))
+	token.SetJti(sessionID)
+	token.SetString("id", id)
+	token.SetString("alias", alias)
+	return k.sign(token)
+}
+
+// Decode memverifikasi token dengan kunci sesuai kid di footer, token tanpa kid dicoba ke semua kunci
+func (k *Keyring) Decode(tokenstring string) (payload Payload[any], err error) {
+	return DecodeWithKeyring[any](k, tokenstring)
+}
+
+// DecodeWithKeyring versi generic dari Keyring.Decode
+func DecodeWithKeyring[T any](k *Keyring, tokenstring string) (payload Payload[T], err error) {
+	keys, revoked, err := k.candidates(tokenstring)
+	if err != nil {
+		return
+	}
+	parser := paseto.NewParser()
+	var token *paseto.Token
+	for _, key := range keys {
+		if token, err = parser.ParseV4Public(key, tokenstring, nil); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return
+	}
+	if err = json.Unmarshal(token.ClaimsJSON(), &payload); err != nil || revoked == nil {
+		return
+	}
+	var isRevoked bool
+	if isRevoked, err = revoked.Revoked(payload.Id, payload.Jti, payload.Iat); err == nil && isRevoked {
+		err = ErrRevoked
+	}
+	return
+}
+
+// candidates kunci yang perlu dicoba untuk token tersebut beserta daftar pencabutan
+func (k *Keyring) candidates(tokenstring string) ([]paseto.V4AsymmetricPublicKey, RevocationList, error) {
+	var footer Footer
+	raw, _ := paseto.NewParser().UnsafeParseFooter(paseto.V4Public, tokenstring)
+	if len(raw) > 0 {
+		json.Unmarshal(raw, &footer)
+	}
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	if footer.Kid != "" {
+		key, ok := k.keys[footer.Kid]
+		if !ok {
+			return nil, nil, ErrUnknownKey
+		}
+		return []paseto.V4AsymmetricPublicKey{key}, k.revoked, nil
+	}
+	if len(k.order) == 0 {
+		return nil, nil, ErrNoKey
+	}
+	keys := make([]paseto.V4AsymmetricPublicKey, 0, len(k.order))
+	for _, kid := range k.order {
+		keys = append(keys, k.keys[kid])
+	}
+	return keys, k.revoked, nil
+}
@@ -0,0 +1,97 @@
+package watoken
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestKeyringRotation(t *testing.T) {
+	oldKey := GenerateKeyWithID()
+	newKey := GenerateKeyWithID()
+	ring := NewKeyring()
+	if err := ring.Replace([]Key{oldKey}, oldKey.ID); err != nil {
+		t.Fatal(err)
+	}
+	oldToken, err := ring.EncodeforHours("628111", "Budi", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// rotasi: kunci baru jadi signing key, kunci lama tetap dipakai verifikasi
+	if err = ring.Replace([]Key{oldKey, newKey}, newKey.ID); err != nil {
+		t.Fatal(err)
+	}
+	newToken, err := ring.Encode("628222")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if payload, err := ring.Decode(oldToken); err != nil || payload.Id != "628111" || payload.Alias != "Budi" {
+		t.Fatalf("old token after rotation: %+v, %v", payload, err)
+	}
+	if payload, err := ring.Decode(newToken); err != nil || payload.Id != "628222" {
+		t.Fatalf("new token: %+v, %v", payload, err)
+	}
+
+	// kunci lama dicabut, token lama tidak berlaku lagi
+	if err = ring.Replace([]Key{{ID: newKey.ID, PublicKey: newKey.PublicKey}}, ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = ring.Decode(oldToken); !errors.Is(err, ErrUnknownKey) {
+		t.Fatalf("expected ErrUnknownKey, got %v", err)
+	}
+	if _, err = ring.Encode("628222"); !errors.Is(err, ErrNoSigningKey) {
+		t.Fatalf("expected ErrNoSigningKey, got %v", err)
+	}
+}
+
+func TestKeyringLegacyToken(t *testing.T) {
+	privateKey, publicKey := GenerateKey()
+	legacy, err := EncodeforHours("628333", "Siti", privateKey, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ring := NewKeyring()
+	if err = ring.Add(GenerateKeyWithID()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = ring.Decode(legacy); err == nil {
+		t.Fatal("legacy token should fail without its key")
+	}
+	if err = ring.Add(Key{ID: "whatsauth", PublicKey: publicKey}); err != nil {
+		t.Fatal(err)
+	}
+	if payload, err := ring.Decode(legacy); err != nil || payload.Id != "628333" {
+		t.Fatalf("legacy token: %+v, %v", payload, err)
+	}
+	if err = ring.Add(Key{ID: "bad", PublicKey: publicKey, PrivateKey: privateKey[:64] + "00"}); err == nil {
+		t.Fatal("invalid private key should be rejected")
+	}
+}
+
+type fakeRevocation map[string]bool
+
+func (f fakeRevocation) Revoked(id, sessionID string, issuedAt time.Time) (bool, error) {
+	return f[sessionID] || f[id], nil
+}
+
+func TestKeyringRevocation(t *testing.T) {
+	key := GenerateKeyWithID()
+	ring := NewKeyring()
+	if err := ring.Replace([]Key{key}, key.ID); err != nil {
+		t.Fatal(err)
+	}
+	revoked := fakeRevocation{}
+	ring.SetRevocationList(revoked)
+	access, err := ring.EncodeSession("628111", "Budi", "sesi-1", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if payload, err := ring.Decode(access); err != nil || payload.Jti != "sesi-1" {
+		t.Fatalf("session token: %+v, %v", payload, err)
+	}
+	revoked["sesi-1"] = true
+	if _, err = ring.Decode(access); !errors.Is(err, ErrRevoked) {
+		t.Fatalf("expected ErrRevoked, got %v", err)
+	}
+}
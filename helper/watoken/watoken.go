@@ -13,6 +13,7 @@ type Payload[T any] struct {
 	Exp   time.Time `json:"exp"`
 	Iat   time.Time `json:"iat"`
 	Nbf   time.Time `json:"nbf"`
+	Jti   string    `json:"jti,omitempty"` // id sesi untuk token yang dibuat lewat Keyring.EncodeSession
 	Data  T         `json:"data"`
 }
 
@@ -2,6 +2,9 @@ package model
 
 import "go.mongodb.org/mongo-driver/bson/primitive"
 
+// ProductCollection satu-satunya collection penyimpanan produk
+const ProductCollection = "product"
+
 // Product represents a product in the database
 type Product struct {
 	ID            primitive.ObjectID `bson:"_id,omitempty" json:"_id"`
@@ -9,7 +12,101 @@ type Product struct {
 	Description   string             `bson:"description" json:"description"`
 	DiscountPrice int64              `bson:"discount_price" json:"discount_price"`
 	OriginalPrice int64              `bson:"original_price" json:"original_price"`
-	Image         string             `bson:"image" json:"image"`
+	Image         string             `bson:"image" json:"image"` // url gambar utama, sama dengan Images yang Primary
+	Images        []ProductImage     `bson:"images,omitempty" json:"images,omitempty"`
+	Stock         int                `bson:"stock" json:"stock"`
+	RatingAvg     float64            `bson:"rating_avg" json:"rating_avg"`
+	RatingCount   int                `bson:"rating_count" json:"rating_count"`
+	Category      string             `bson:"category" json:"category"`
+	NamaLapak     string             `bson:"namalapak" json:"namalapak"` // nama project lapak pemilik produk
 	CreatedAt     primitive.DateTime `json:"created_at" bson:"created_at"`
 	UpdatedAt     primitive.DateTime `json:"updated_at" bson:"updated_at"`
 }
+
+// ProductImage gambar di galeri produk yang disimpan di repo github
+type ProductImage struct {
+	ID      string `bson:"id" json:"id"`
+	URL     string `bson:"url" json:"url"`
+	Path    string `bson:"path" json:"path"`
+	Primary bool   `bson:"primary" json:"primary"`
+}
+
+// AddImage menambahkan gambar di akhir galeri, gambar pertama otomatis jadi gambar utama
+func (p *Product) AddImage(img ProductImage) {
+	for i, existing := range p.Images {
+		if existing.ID == img.ID {
+			p.Images[i].URL = img.URL
+			p.Images[i].Path = img.Path
+			return
+		}
+	}
+	img.Primary = false
+	p.Images = append(p.Images, img)
+	p.SyncPrimary()
+}
+
+// RemoveImage menghapus gambar dari galeri, jika gambar utama yang dihapus gambar berikutnya jadi utama
+func (p *Product) RemoveImage(id string) (removed ProductImage, ok bool) {
+	for i, img := range p.Images {
+		if img.ID == id {
+			p.Images = append(p.Images[:i], p.Images[i+1:]...)
+			p.SyncPrimary()
+			return img, true
+		}
+	}
+	return
+}
+
+// SetPrimaryImage menjadikan gambar dengan id tersebut sebagai gambar utama
+func (p *Product) SetPrimaryImage(id string) bool {
+	found := false
+	for i := range p.Images {
+		p.Images[i].Primary = p.Images[i].ID == id
+		found = found || p.Images[i].Primary
+	}
+	p.SyncPrimary()
+	return found
+}
+
+// ReorderImages mengurutkan galeri sesuai urutan id, semua id gambar harus ada tepat satu kali
+func (p *Product) ReorderImages(ids []string) bool {
+	if len(ids) != len(p.Images) {
+		return false
+	}
+	byID := make(map[string]ProductImage, len(p.Images))
+	for _, img := range p.Images {
+		byID[img.ID] = img
+	}
+	ordered := make([]ProductImage, 0, len(ids))
+	for _, id := range ids {
+		img, ok := byID[id]
+		if !ok {
+			return false
+		}
+		delete(byID, id)
+		ordered = append(ordered, img)
+	}
+	p.Images = ordered
+	return true
+}
+
+// SyncPrimary memastikan ada tepat satu gambar utama dan field Image mengikuti gambar utama
+func (p *Product) SyncPrimary() {
+	primary := -1
+	for i := range p.Images {
+		if p.Images[i].Primary && primary < 0 {
+			primary = i
+		} else {
+			p.Images[i].Primary = false
+		}
+	}
+	if primary < 0 && len(p.Images) > 0 {
+		primary = 0
+		p.Images[0].Primary = true
+	}
+	if primary < 0 {
+		p.Image = ""
+		return
+	}
+	p.Image = p.Images[primary].URL
+}
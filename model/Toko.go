@@ -1,11 +1,41 @@
 package model
 
-import "go.mongodb.org/mongo-driver/bson/primitive"
+import (
+	"time"
+
+	"github.com/gocroot/helper/payment"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Status checkout
+const (
+	CheckoutPending = "pending"
+	CheckoutPaid    = "paid"
+	CheckoutShipped = "shipped" // dikirim owner lapak
+	CheckoutDone    = "done"    // diterima pembeli, pesanan selesai
+	CheckoutExpired = "expired"
+)
 
 type Checkout struct {
-	ID            primitive.ObjectID `bson:"_id,omitempty", json:"_id"`
-	Address       string             `bson:"address,omitempty", json:"address"`
-	Product       []Product          `bson:"product,omitempty", json:"product"`
-	PaymentMethod string             `bson:"paymentmethod,omitempty", json:"paymentmethod"`
-	TotalPrice    float64            `bson:"totalprice,omitempty", json:"totalprice"`
+	ID            primitive.ObjectID `bson:"_id,omitempty" json:"_id"`
+	PhoneNumber   string             `bson:"phonenumber,omitempty" json:"phonenumber,omitempty"`
+	NamaLapak     string             `bson:"namalapak,omitempty" json:"namalapak,omitempty"`
+	Address       string             `bson:"address,omitempty" json:"address"`
+	AddressID     primitive.ObjectID `bson:"addressid,omitempty" json:"addressid,omitempty"`
+	Shipping      *Address           `bson:"shipping,omitempty" json:"shipping,omitempty"` // snapshot alamat saat checkout dibuat
+	Product       []Product          `bson:"product,omitempty" json:"product"`
+	Items         []CartLine         `bson:"items,omitempty" json:"items,omitempty"`
+	PaymentMethod string             `bson:"paymentmethod,omitempty" json:"paymentmethod"`
+	Subtotal      float64            `bson:"subtotal,omitempty" json:"subtotal,omitempty"`
+	VoucherCode   string             `bson:"vouchercode,omitempty" json:"vouchercode,omitempty"`
+	Discount      float64            `bson:"discount,omitempty" json:"discount,omitempty"`
+	ShippingFee   float64            `bson:"shippingfee,omitempty" json:"shippingfee,omitempty"`
+	TotalPrice    float64            `bson:"totalprice,omitempty" json:"totalprice"`
+	Status        string             `bson:"status,omitempty" json:"status,omitempty"`
+	Payment       *payment.Invoice   `bson:"payment,omitempty" json:"payment,omitempty"` // tagihan dari payment gateway
+	ReceiptNumber string             `bson:"receiptnumber,omitempty" json:"receiptnumber,omitempty"`
+	CreatedAt     time.Time          `bson:"createdAt,omitempty" json:"createdAt,omitempty"`
+	ReservedUntil time.Time          `bson:"reserveduntil,omitempty" json:"reserveduntil,omitempty"`
+	ShippedAt     time.Time          `bson:"shippedat,omitempty" json:"shippedat,omitempty"`
+	DoneAt        time.Time          `bson:"doneat,omitempty" json:"doneat,omitempty"`
 }
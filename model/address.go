@@ -0,0 +1,40 @@
+package model
+
+import (
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Address alamat pengiriman di buku alamat user, province dan city harus ada di collection region database geo
+type Address struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"_id,omitempty"`
+	UserID      string             `bson:"user_id" json:"user_id,omitempty"`
+	Label       string             `bson:"label,omitempty" json:"label,omitempty"`
+	Penerima    string             `bson:"penerima" json:"penerima"`
+	PhoneNumber string             `bson:"phonenumber" json:"phonenumber"`
+	Street      string             `bson:"street" json:"street"`
+	Village     string             `bson:"village,omitempty" json:"village,omitempty"`
+	SubDistrict string             `bson:"sub_district,omitempty" json:"sub_district,omitempty"`
+	City        string             `bson:"city" json:"city"`
+	Province    string             `bson:"province" json:"province"`
+	PostalCode  string             `bson:"postal_code,omitempty" json:"postal_code,omitempty"`
+	Description string             `bson:"description,omitempty" json:"description,omitempty"`
+	Longitude   float64            `bson:"long,omitempty" json:"long,omitempty"`
+	Latitude    float64            `bson:"lat,omitempty" json:"lat,omitempty"`
+	Default     bool               `bson:"default" json:"default"`
+	CreatedAt   time.Time          `bson:"created_at,omitempty" json:"created_at,omitempty"`
+	UpdatedAt   time.Time          `bson:"updated_at,omitempty" json:"updated_at,omitempty"`
+}
+
+// String alamat dalam satu baris untuk ditampilkan di checkout dan kwitansi
+func (a Address) String() string {
+	var parts []string
+	for _, part := range []string{a.Street, a.Village, a.SubDistrict, a.City, a.Province, a.PostalCode} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
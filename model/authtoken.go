@@ -0,0 +1,19 @@
+package model
+
+// status verifikasi email akun form
+const (
+	EmailPending  = "pending"
+	EmailVerified = "verified"
+)
+
+// EmailRequest body untuk kirim ulang verifikasi dan lupa password, isi email atau nomor telepon
+type EmailRequest struct {
+	Email       string `json:"email,omitempty"`
+	PhoneNumber string `json:"phonenumber,omitempty"`
+}
+
+// TokenRequest body verifikasi email dan reset password
+type TokenRequest struct {
+	Token    string `json:"token"`
+	Password string `json:"password,omitempty"`
+}
@@ -2,6 +2,7 @@ package model
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
@@ -18,23 +19,14 @@ type CartItem struct {
 // Cart represents a shopping cart
 type Cart struct {
 	ID        primitive.ObjectID `json:"_id,omitempty" bson:"_id,omitempty"`
-	User      []User             `json:"user_id,omitempty" bson:"user_id,omitempty"`
+	UserID    string             `json:"user_id,omitempty" bson:"user_id,omitempty"`
 	Items     []CartItem         `json:"items,omitempty" bson:"items,omitempty"`
 	NamaToko  string             `json:"nama_toko,omitempty" bson:"nama_toko,omitempty"`
 	Category  string             `json:"category,omitempty" bson:"category,omitempty"`
 	CreatedAt time.Time          `json:"created_at,omitempty" bson:"created_at,omitempty"`
 	UpdatedAt time.Time          `json:"updated_at,omitempty" bson:"updated_at,omitempty"`
-	Alamat    []Alamat           `json:"alamat"`
-}
-
-type Alamat struct {
-	Street      string `json:"street,omitempty" bson:"street,omitempty"`
-	Province    string `json:"province,omitempty" bson:"province,omitempty"`
-	City        string `json:"city,omitempty" bson:"city,omitempty"`
-	Description string `json:"description,omitempty" bson:"description,omitempty"`
-	PostalCode  string `json:"postal_code,omitempty" bson:"postal_code,omitempty"`
-	CreatedAt   string `json:"createdAt,omitempty" bson:"createdAt,omitempty"`
-	UpdatedAt   string `json:"updatedAt,omitempty" bson:"updatedAt,omitempty"`
+	// RemindedAt waktu terakhir pengingat cart dikirim ke WhatsApp user
+	RemindedAt time.Time `json:"reminded_at,omitempty" bson:"reminded_at,omitempty"`
 }
 
 // AddItem adds an item to the cart
@@ -61,31 +53,99 @@ func (c *Cart) RemoveItem(productID primitive.ObjectID) {
 	}
 }
 
+// CartLine is a cart item priced with the unit price that is actually used
+type CartLine struct {
+	ProductID primitive.ObjectID `json:"product_id" bson:"product_id"`
+	Name      string             `json:"name" bson:"name"`
+	Image     string             `json:"image,omitempty" bson:"image,omitempty"`
+	NamaLapak string             `json:"namalapak,omitempty" bson:"namalapak,omitempty"`
+	Category  string             `json:"category,omitempty" bson:"category,omitempty"`
+	Quantity  int                `json:"quantity" bson:"quantity"`
+	UnitPrice int64              `json:"unit_price" bson:"unit_price"`
+	Subtotal  int64              `json:"subtotal" bson:"subtotal"`
+	// Unavailable produk sudah dihapus, baris tetap ditampilkan tanpa harga supaya user bisa menghapusnya dari cart
+	Unavailable bool `json:"unavailable,omitempty" bson:"unavailable,omitempty"`
+}
+
+// CartSummary is the cart response with merged lines and the cart total
+type CartSummary struct {
+	ID        primitive.ObjectID `json:"_id,omitempty" bson:"_id,omitempty"`
+	UserID    string             `json:"user_id,omitempty" bson:"user_id,omitempty"`
+	Items     []CartLine         `json:"items" bson:"items"`
+	Total     int64              `json:"total" bson:"total"`
+	UpdatedAt time.Time          `json:"updated_at,omitempty" bson:"updated_at,omitempty"`
+}
+
+// Available baris cart yang produknya masih ada
+func (s CartSummary) Available() []CartLine {
+	lines := []CartLine{}
+	for _, line := range s.Items {
+		if !line.Unavailable {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// UnitPrice returns DiscountPrice if available, else OriginalPrice
+func UnitPrice(product Product) int64 {
+	if product.DiscountPrice > 0 {
+		return product.DiscountPrice
+	}
+	return product.OriginalPrice
+}
+
 // TotalPrice calculates the total price of the cart by summing up prices of all items
 func (c *Cart) TotalPrice(productsCollection *mongo.Collection) (int64, error) {
-	var totalPrice int64
+	summary, err := c.Summary(productsCollection)
+	if err != nil {
+		return 0, err
+	}
+	return summary.Total, nil
+}
+
+// Summary prices every cart item from the products collection and sums up the cart total
+func (c *Cart) Summary(productsCollection *mongo.Collection) (CartSummary, error) {
+	return c.summarize(func(id primitive.ObjectID) (product Product, err error) {
+		err = productsCollection.FindOne(context.Background(), bson.M{"_id": id}).Decode(&product)
+		return
+	})
+}
+
+// summarize menghitung ringkasan cart dengan findProduct, produk yang tidak ditemukan ditandai Unavailable
+func (c *Cart) summarize(findProduct func(id primitive.ObjectID) (Product, error)) (summary CartSummary, err error) {
+	summary = CartSummary{
+		ID:        c.ID,
+		UserID:    c.UserID,
+		Items:     []CartLine{},
+		UpdatedAt: c.UpdatedAt,
+	}
 
-	// Loop through each cart item and calculate the total price
+	// Loop through each cart item and calculate the line subtotal
 	for _, cartItem := range c.Items {
-		// Fetch the product from the products collection using ProductID
-		var product Product
-		err := productsCollection.FindOne(context.Background(), bson.M{"_id": cartItem.ProductID}).Decode(&product)
-		if err != nil {
-			return 0, err // Return error if product is not found
+		product, ferr := findProduct(cartItem.ProductID)
+		if errors.Is(ferr, mongo.ErrNoDocuments) {
+			// produk yang sudah dihapus ditandai, tidak ikut dihitung di total
+			summary.Items = append(summary.Items, CartLine{ProductID: cartItem.ProductID, Quantity: cartItem.Quantity, Unavailable: true})
+			continue
 		}
-
-		// Determine which price to use (DiscountPrice if available, else OriginalPrice)
-		var price int64
-		if product.DiscountPrice > 0 {
-			price = product.DiscountPrice
-		} else {
-			price = product.OriginalPrice
+		if ferr != nil {
+			return summary, ferr
 		}
 
-		// Add the total price of the item (price * quantity)
-		totalPrice += price * int64(cartItem.Quantity)
+		price := UnitPrice(product)
+		line := CartLine{
+			ProductID: cartItem.ProductID,
+			Name:      product.Name,
+			Image:     product.Image,
+			NamaLapak: product.NamaLapak,
+			Category:  product.Category,
+			Quantity:  cartItem.Quantity,
+			UnitPrice: price,
+			Subtotal:  price * int64(cartItem.Quantity),
+		}
+		summary.Items = append(summary.Items, line)
+		summary.Total += line.Subtotal
 	}
-
-	// Return the calculated total price
-	return totalPrice, nil
+	return
 }
@@ -0,0 +1,63 @@
+package model
+
+import (
+	"errors"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestCartAddRemoveItem(t *testing.T) {
+	a, b := primitive.NewObjectID(), primitive.NewObjectID()
+	var cart Cart
+	cart.AddItem(CartItem{ProductID: a, Quantity: 1})
+	cart.AddItem(CartItem{ProductID: b, Quantity: 2})
+	cart.AddItem(CartItem{ProductID: a, Quantity: 3})
+	if len(cart.Items) != 2 || cart.Items[0].Quantity != 4 {
+		t.Fatalf("items = %+v", cart.Items)
+	}
+	cart.RemoveItem(a)
+	if len(cart.Items) != 1 || cart.Items[0].ProductID != b {
+		t.Fatalf("items after remove = %+v", cart.Items)
+	}
+}
+
+func TestCartSummaryDeletedLastItem(t *testing.T) {
+	kept, deleted := primitive.NewObjectID(), primitive.NewObjectID()
+	products := map[primitive.ObjectID]Product{
+		kept: {Name: "Kopi", OriginalPrice: 20000, DiscountPrice: 15000, NamaLapak: "lapak-a"},
+	}
+	find := func(id primitive.ObjectID) (Product, error) {
+		product, ok := products[id]
+		if !ok {
+			return Product{}, mongo.ErrNoDocuments
+		}
+		return product, nil
+	}
+	cart := Cart{Items: []CartItem{{ProductID: kept, Quantity: 2}, {ProductID: deleted, Quantity: 1}}}
+	summary, err := cart.summarize(find)
+	if err != nil {
+		t.Fatalf("summarize error = %v", err)
+	}
+	if len(summary.Items) != 2 || summary.Total != 30000 {
+		t.Fatalf("summary = %+v", summary)
+	}
+	available := summary.Available()
+	if len(available) != 1 || available[0].ProductID != kept || available[0].Subtotal != 30000 {
+		t.Errorf("available = %+v", available)
+	}
+	if line := summary.Items[1]; !line.Unavailable || line.ProductID != deleted || line.Subtotal != 0 {
+		t.Errorf("deleted line = %+v", line)
+	}
+}
+
+func TestCartSummaryLookupError(t *testing.T) {
+	cart := Cart{Items: []CartItem{{ProductID: primitive.NewObjectID(), Quantity: 1}}}
+	_, err := cart.summarize(func(primitive.ObjectID) (Product, error) {
+		return Product{}, errors.New("koneksi putus")
+	})
+	if err == nil {
+		t.Fatal("lookup error should be returned")
+	}
+}
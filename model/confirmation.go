@@ -0,0 +1,33 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Status konfirmasi pembayaran
+const (
+	ConfirmationPending  = "pending"
+	ConfirmationApproved = "approved"
+	ConfirmationRejected = "rejected"
+)
+
+// Confirmation bukti pembayaran yang diupload pembeli untuk satu checkout
+type Confirmation struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"_id,omitempty"`
+	CheckoutID  primitive.ObjectID `bson:"checkoutid" json:"checkoutid"`
+	PhoneNumber string             `bson:"phonenumber,omitempty" json:"phonenumber,omitempty"`
+	BuktiBayar  string             `bson:"buktibayar,omitempty" json:"buktibayar,omitempty"`
+	Status      string             `bson:"status,omitempty" json:"status,omitempty"`
+	Alasan      string             `bson:"alasan,omitempty" json:"alasan,omitempty"`
+	ApprovedBy  string             `bson:"approvedby,omitempty" json:"approvedby,omitempty"`
+	CreatedAt   time.Time          `bson:"createdAt,omitempty" json:"createdAt,omitempty"`
+	UpdatedAt   time.Time          `bson:"updatedAt,omitempty" json:"updatedAt,omitempty"`
+}
+
+type ConfirmationRequest struct {
+	ID      string `json:"_id"`
+	Approve bool   `json:"approve"`
+	Alasan  string `json:"alasan,omitempty"`
+}
@@ -0,0 +1,126 @@
+package model
+
+import (
+	"time"
+
+	"github.com/gocroot/helper/identity"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	IdentityChallengeCollection = "identitychallenge"
+	UserMergeCollection         = "usermerge"
+)
+
+// Identity identitas user, didefinisikan di helper/identity
+type Identity = identity.Identity
+
+// IdentityRequest body untuk menautkan, memverifikasi dan melepas identitas
+type IdentityRequest struct {
+	Type     string `json:"type"`
+	Subject  string `json:"subject,omitempty"`
+	IDToken  string `json:"id_token,omitempty"` // Google ID token untuk identitas google atau email yang diverifikasi Google
+	Password string `json:"password,omitempty"` // password akun lain pemilik email tersebut
+	Code     string `json:"code,omitempty"`
+	Merge    bool   `json:"merge,omitempty"` // gabungkan akun lain pemilik identitas ke akun ini
+}
+
+// IdentityChallenge verifikasi identitas yang belum selesai, _id gabungan nomor user, jenis dan subject
+type IdentityChallenge struct {
+	ID          string    `bson:"_id"`
+	PhoneNumber string    `bson:"phonenumber"`
+	Type        string    `bson:"type"`
+	Subject     string    `bson:"subject"`
+	Code        string    `bson:"code"` // hash kode WhatsApp, atau kode apa adanya untuk bio GitHub
+	Attempts    int       `bson:"attempts"`
+	ExpiresAt   time.Time `bson:"expires_at"`
+}
+
+// MergeRequest body admin untuk menggabungkan dua akun
+type MergeRequest struct {
+	Primary   string `json:"primary"`   // nomor akun yang dipertahankan
+	Duplicate string `json:"duplicate"` // nomor akun yang digabungkan lalu dihapus
+}
+
+// UserMerge catatan penggabungan akun, Duplicate menyimpan salinan akun yang dihapus
+type UserMerge struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"_id,omitempty"`
+	Actor     string             `bson:"actor" json:"actor"`
+	Primary   primitive.ObjectID `bson:"primary" json:"primary"`
+	Duplicate Userdomyikado      `bson:"duplicate" json:"duplicate"`
+	Projects  int64              `bson:"projects" json:"projects"` // jumlah project yang keanggotaannya dipindah
+	Moved     int64              `bson:"moved" json:"moved"`       // jumlah dokumen lain yang dipindah ke akun utama
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+}
+
+// LinkedIdentities identitas yang tersimpan ditambah identitas dari field lama phonenumber, email dan githubusername
+func (u Userdomyikado) LinkedIdentities() []Identity {
+	list := append([]Identity{}, u.Identities...)
+	legacy := []struct{ typ, subject string }{
+		{identity.Phone, u.PhoneNumber},
+		{identity.Email, u.Email},
+		{identity.GitHub, u.GithubUsername},
+	}
+	for _, l := range legacy {
+		if l.subject == "" {
+			continue
+		}
+		if subject, err := identity.Normalize(l.typ, l.subject); err == nil {
+			list, _ = identity.Add(list, Identity{Type: l.typ, Subject: subject})
+		}
+	}
+	return list
+}
+
+// MergeFrom melipat data akun duplikat ke akun ini. Nomor telepon akun ini tetap jadi id login,
+// poin dijumlahkan, identitas dan role digabung, dan field profil yang kosong diisi dari akun duplikat.
+func (u *Userdomyikado) MergeFrom(dup Userdomyikado) {
+	u.Identities = identity.Union(u.LinkedIdentities(), dup.LinkedIdentities())
+	u.Poin += dup.Poin
+	u.IsEditor = u.IsEditor || dup.IsEditor
+	u.IsManager = u.IsManager || dup.IsManager
+	for _, role := range dup.Roles {
+		found := false
+		for _, r := range u.Roles {
+			found = found || r == role
+		}
+		if !found {
+			u.Roles = append(u.Roles, role)
+		}
+	}
+	if u.JumlahAntrian < dup.JumlahAntrian {
+		u.JumlahAntrian = dup.JumlahAntrian
+	}
+	// email dan password satu pasang supaya login form akun duplikat tetap jalan
+	if u.Email == "" || (u.Password == "" && dup.Password != "" && u.Email == dup.Email) {
+		u.Email, u.Password, u.EmailVerification = dup.Email, dup.Password, dup.EmailVerification
+	}
+	fill := []struct {
+		dst *string
+		src string
+	}{
+		{&u.Name, dup.Name},
+		{&u.NIK, dup.NIK},
+		{&u.Pekerjaan, dup.Pekerjaan},
+		{&u.AlamatRumah, dup.AlamatRumah},
+		{&u.AlamatKantor, dup.AlamatKantor},
+		{&u.GithubUsername, dup.GithubUsername},
+		{&u.GitlabUsername, dup.GitlabUsername},
+		{&u.GitHostUsername, dup.GitHostUsername},
+		{&u.GoogleProfilePicture, dup.GoogleProfilePicture},
+		{&u.ProfilePicture, dup.ProfilePicture},
+		{&u.Bio, dup.Bio},
+		{&u.URLBio, dup.URLBio},
+		{&u.PATHBio, dup.PATHBio},
+		{&u.Team, dup.Team},
+		{&u.Scope, dup.Scope},
+		{&u.Section, dup.Section},
+		{&u.Chapter, dup.Chapter},
+		{&u.LinkedDevice, dup.LinkedDevice},
+	}
+	for _, f := range fill {
+		if *f.dst == "" {
+			*f.dst = f.src
+		}
+	}
+}
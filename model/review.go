@@ -0,0 +1,28 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Review ulasan dan rating produk dari pembeli yang checkout-nya sudah selesai (done), satu ulasan per user per produk
+type Review struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"_id,omitempty"`
+	ProductID  primitive.ObjectID `bson:"product_id" json:"product_id"`
+	NamaLapak  string             `bson:"namalapak" json:"namalapak"`
+	CheckoutID primitive.ObjectID `bson:"checkout_id" json:"checkout_id"`
+	UserID     string             `bson:"user_id" json:"user_id"`
+	Rating     int                `bson:"rating" json:"rating"`
+	Komentar   string             `bson:"komentar" json:"komentar"`
+	Reply      *ReviewReply       `bson:"reply,omitempty" json:"reply,omitempty"`
+	CreatedAt  time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt  time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+// ReviewReply balasan penjual untuk ulasan
+type ReviewReply struct {
+	Komentar  string    `bson:"komentar" json:"komentar"`
+	By        string    `bson:"by" json:"by"`
+	CreatedAt time.Time `bson:"created_at" json:"created_at"`
+}
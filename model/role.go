@@ -0,0 +1,35 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	RoleActionGrant  = "grant"
+	RoleActionRevoke = "revoke"
+)
+
+// RoleRequest body untuk memberi atau mencabut role user
+type RoleRequest struct {
+	PhoneNumber string `json:"phonenumber"`
+	Role        string `json:"role"`
+	Action      string `json:"action"` // grant atau revoke
+}
+
+// RoleAudit catatan setiap perubahan role user
+type RoleAudit struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"_id,omitempty"`
+	Actor     string             `bson:"actor" json:"actor"`   // nomor yang memberi atau mencabut
+	Target    string             `bson:"target" json:"target"` // nomor user yang role-nya berubah
+	Role      string             `bson:"role" json:"role"`
+	Action    string             `bson:"action" json:"action"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+}
+
+// UserRoles role efektif seorang user
+type UserRoles struct {
+	PhoneNumber string   `json:"phonenumber"`
+	Roles       []string `json:"roles"`
+}
@@ -0,0 +1,35 @@
+package model
+
+import "time"
+
+const (
+	TOTPCollection         = "totp"
+	MFAChallengeCollection = "mfachallenge"
+)
+
+// TOTPEnrollment pendaftaran authenticator milik user, _id adalah nomor telepon
+type TOTPEnrollment struct {
+	ID             string    `bson:"_id" json:"phonenumber"`
+	Secret         string    `bson:"secret" json:"-"` // terenkripsi dengan AESKEY
+	Enabled        bool      `bson:"enabled" json:"enabled"`
+	RecoveryHashes []string  `bson:"recovery_hashes,omitempty" json:"-"`
+	LastStep       int64     `bson:"last_step" json:"-"` // langkah waktu terakhir yang dipakai, untuk menolak kode yang diulang
+	CreatedAt      time.Time `bson:"created_at" json:"created_at"`
+	EnabledAt      time.Time `bson:"enabled_at,omitempty" json:"enabled_at,omitempty"`
+}
+
+// MFAChallenge tantangan login yang menunggu kode TOTP, _id adalah hash mfa_token
+type MFAChallenge struct {
+	ID          string    `bson:"_id"`
+	PhoneNumber string    `bson:"phonenumber"`
+	Alias       string    `bson:"alias"`
+	Attempts    int       `bson:"attempts"`
+	ExpiresAt   time.Time `bson:"expires_at"`
+}
+
+// TOTPRequest body endpoint TOTP, isi Code atau RecoveryCode
+type TOTPRequest struct {
+	MFAToken     string `json:"mfa_token,omitempty"`
+	Code         string `json:"code,omitempty"`
+	RecoveryCode string `json:"recovery_code,omitempty"`
+}
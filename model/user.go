@@ -8,7 +8,6 @@ import (
 	"github.com/gocroot/config"
 	"github.com/gocroot/helper/at"
 	"github.com/gocroot/helper/atdb"
-	"github.com/gocroot/helper/watoken"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -48,25 +47,16 @@ func FindUserByEmail(email string, collection *mongo.Collection) *mongo.SingleRe
 // Fungsi untuk mendapatkan data pengguna berdasarkan token yang diterima dalam request
 func GetDataUser(respw http.ResponseWriter, req *http.Request) {
 	// Decode token dari header
-	payload, err := watoken.Decode(config.PublicKeyWhatsAuth, at.GetLoginFromHeader(req))
+	payload, err := config.TokenKeyring.Decode(at.GetLoginFromHeader(req))
 	if err != nil {
-		var respn Response
-		respn.Status = "Error: Token Tidak Valid"
-		respn.Info = config.PublicKeyWhatsAuth
-		respn.Location = "Decode Token Error: " + at.GetLoginFromHeader(req)
-		respn.Response = err.Error()
-		at.WriteJSON(respw, http.StatusForbidden, respn)
+		at.WriteError(respw, at.NewError(at.CodeInvalidToken, "Token Tidak Valid", err.Error()))
 		return
 	}
 
 	// Ambil data pengguna dari database MongoDB berdasarkan phonenumber
 	docuser, err := atdb.GetOneDoc[User](config.Mongoconn, "user", primitive.M{"phonenumber": payload.Id})
 	if err != nil {
-		// Jika pengguna tidak ditemukan, kirim respons dengan informasi pengguna dari token
-		var newUser User
-		newUser.Phonenumber = payload.Id
-		newUser.Name = payload.Alias
-		at.WriteJSON(respw, http.StatusNotFound, newUser)
+		at.WriteError(respw, at.NewError(at.CodeNotFound, "Data user tidak di temukan", err.Error()))
 		return
 	}
 
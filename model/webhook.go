@@ -113,7 +113,10 @@ type Userdomyikado struct {
 	JumlahAntrian        int                `json:"jumlahantrian,omitempty" bson:"jumlahantrian,omitempty"`
 	IsEditor             bool               `json:"iseditor,omitempty" bson:"iseditor,omitempty"`
 	IsManager            bool               `json:"ismanager,omitempty" bson:"ismanager,omitempty"`
+	Roles                []string           `json:"roles,omitempty" bson:"roles,omitempty"`           // role tambahan dari admin, lihat helper/rbac
+	Identities           []Identity         `json:"identities,omitempty" bson:"identities,omitempty"` // identitas yang sudah ditautkan, lihat helper/identity
 	Password             string             `json:"password,omitempty" bson:"password,omitempty"`
+	EmailVerification    string             `json:"emailverification,omitempty" bson:"emailverification,omitempty"` // pending sampai email diverifikasi, kosong untuk akun lama
 }
 
 type Task struct {
@@ -0,0 +1,25 @@
+package model
+
+import (
+	"time"
+
+	"github.com/gocroot/helper/productevent"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Wishlist produk yang disimpan user, satu dokumen per user per produk
+type Wishlist struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"_id,omitempty"`
+	UserID     string             `bson:"user_id" json:"user_id"`
+	ProductID  primitive.ObjectID `bson:"product_id" json:"product_id"`
+	CreatedAt  time.Time          `bson:"created_at" json:"created_at"`
+	NotifiedAt time.Time          `bson:"notified_at,omitempty" json:"notified_at,omitempty"`
+	// Pending perubahan produk yang belum dikabarkan, dikirim cron notifikasi wishlist
+	Pending *productevent.Change `bson:"pending_change,omitempty" json:"-"`
+}
+
+// WishlistItem wishlist beserta data produknya untuk response
+type WishlistItem struct {
+	Wishlist `bson:",inline"`
+	Product  Product `bson:"product" json:"product"`
+}
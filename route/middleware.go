@@ -0,0 +1,38 @@
+package route
+
+import (
+	"net/http"
+
+	"github.com/gocroot/config"
+	"github.com/gocroot/controller"
+	"github.com/gocroot/helper/rbac"
+	"github.com/gocroot/helper/router"
+)
+
+// cors mengatur header CORS dan langsung menjawab preflight dari origin yang diizinkan
+func cors(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if config.SetAccessControlHeaders(w, r) {
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// env memastikan profile, keyring token dan secret sudah dimuat, pembacaan Mongo dibatasi di config.SetEnv.
+// Migrasi data lalu index unik dijalankan sekali pada request pertama setiap instance.
+func env(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		config.SetEnv()
+		controller.Migrate()
+		controller.EnsureIndexes()
+		next.ServeHTTP(w, r)
+	})
+}
+
+// auth middleware per route yang mewajibkan token login dengan permission rbac tertentu
+func auth(perm rbac.Permission) router.Middleware {
+	return func(next http.Handler) http.Handler {
+		return controller.Require(perm, next.ServeHTTP)
+	}
+}
@@ -3,231 +3,227 @@ package route
 import (
 	"net/http"
 
-	"github.com/gocroot/config"
 	"github.com/gocroot/controller"
-	"github.com/gocroot/helper/at"
+	"github.com/gocroot/helper/rbac"
+	"github.com/gocroot/helper/router"
 )
 
+// handler router aplikasi, disusun sekali saat package dimuat
+var handler = New()
+
+// URL entrypoint functions.HTTP, semua request diteruskan ke router
 func URL(w http.ResponseWriter, r *http.Request) {
-	if config.SetAccessControlHeaders(w, r) {
-		return // If it's a preflight request, return early.
-	}
-	config.SetEnv()
+	handler.ServeHTTP(w, r)
+}
+
+// New menyusun router beserta middleware dan seluruh route aplikasi.
+// Urutan middleware: request id, log, recovery, CORS lalu muat ulang env.
+func New() *router.Router {
+	rt := router.New()
+	rt.NotFound = http.HandlerFunc(controller.NotFound)
+	rt.MethodNotAllowed = http.HandlerFunc(controller.MethodNotAllowed)
+	rt.Use(router.RequestID, router.Logging, router.Recovery, cors, env)
+	register(rt)
+	return rt
+}
 
-	var method, path string = r.Method, r.URL.Path
-	switch {
+// register mendaftarkan semua endpoint, endpoint dengan auth(perm) hanya untuk user yang punya permission rbac
+func register(rt *router.Router) {
 	// Cart Routes
-	case method == "POST" && path == "/cart":
-		controller.CreateCart(w, r) // Create cart
-	case method == "GET" && at.URLParam(path, "/cart/:user_id"):
-		controller.GetCart(w, r) // Get cart by user_id
-	case method == "POST" && path == "/cart/item":
-		controller.AddItemToCart(w, r) // Add item to cart
-	case method == "PUT" && path == "/cart/item":
-		controller.UpdateItemInCart(w, r) // Update item in cart
-	case method == "DELETE" && path == "/cart/item":
-		controller.DeleteItemFromCart(w, r) // Delete item from cart
-
-	case method == "GET" && path == "/product":
-		controller.GetAllProducts(w, r) // Get all products
-	case method == "GET" && at.URLParam(path, "/product/:id"):
-		controller.GetProductByID(w, r) // Get product by ID
-	case method == "POST" && path == "/product":
-		controller.CreateProduct(w, r) // Create new product
-	case method == "PUT" && at.URLParam(path, "/product/:id"):
-		controller.UpdateProduct(w, r) // Update product
-	case method == "DELETE" && at.URLParam(path, "/product/:id"):
-		controller.DeleteProduct(w, r) // Delete product
+	rt.HandleFunc("POST", "/cart", controller.CreateCart)                // Create cart
+	rt.HandleFunc("GET", "/cart/reminder", controller.GetCartReminder)   //cron pengingat cart yang ditinggalkan
+	rt.HandleFunc("GET", "/cart/:user_id", controller.GetCart)           // Get cart by user_id
+	rt.HandleFunc("POST", "/cart/item", controller.AddItemToCart)        // Add item to cart
+	rt.HandleFunc("PUT", "/cart/item", controller.UpdateItemInCart)      // Update item in cart
+	rt.HandleFunc("DELETE", "/cart/item", controller.DeleteItemFromCart) // Delete item from cart
+
+	rt.HandleFunc("GET", "/wishlist", controller.GetWishlist)                  // Get user wishlist
+	rt.HandleFunc("POST", "/wishlist", controller.PostWishlist)                // Add product to wishlist
+	rt.HandleFunc("GET", "/wishlist/notify", controller.GetWishlistNotify)     //cron notifikasi wishlist
+	rt.HandleFunc("DELETE", "/wishlist/:productid", controller.DeleteWishlist) // Remove product from wishlist
+
+	rt.HandleFunc("GET", "/product", controller.GetAllProducts)                                           // Get all products
+	rt.HandleFunc("GET", "/product/categories", controller.GetProductCategories)                          // Get product categories
+	rt.HandleFunc("POST", "/review", controller.PostReview)                                               // Buyer review and rating
+	rt.HandleFunc("GET", "/review/product/:id", controller.GetProductReviews)                             // Product reviews
+	rt.HandleFunc("PUT", "/review/reply/:id", controller.PutReviewReply, auth(rbac.LapakManage))          // Seller reply to review
+	rt.HandleFunc("POST", "/product/media/:id", controller.PostProductMedia, auth(rbac.ProductWrite))     // Upload product images
+	rt.HandleFunc("PUT", "/product/media/:id", controller.PutProductMedia, auth(rbac.ProductWrite))       // Reorder images and set primary image
+	rt.HandleFunc("DELETE", "/product/media/:id", controller.DeleteProductMedia, auth(rbac.ProductWrite)) // Delete product image
+	rt.HandleFunc("GET", "/product/:id", controller.GetProductByID)                                       // Get product by ID
+	rt.HandleFunc("POST", "/product", controller.CreateProduct, auth(rbac.ProductWrite))                  // Create new product
+	rt.HandleFunc("PUT", "/product/:id", controller.UpdateProduct, auth(rbac.ProductWrite))               // Update product
+	rt.HandleFunc("DELETE", "/product/:id", controller.DeleteProduct, auth(rbac.ProductWrite))            // Delete product
 
 	// Existing routes
-	case method == "GET" && path == "/":
-		controller.GetHome(w, r)
+	rt.HandleFunc("GET", "/", controller.GetHome)
 	//chat bot inbox
-	case method == "POST" && at.URLParam(path, "/webhook/nomor/:nomorwa"):
-		controller.PostInboxNomor(w, r)
+	rt.HandleFunc("POST", "/webhook/nomor/:nomorwa", controller.PostInboxNomor)
 	//masking list nmor official
-	case method == "GET" && path == "/data/phone/all":
-		controller.GetBotList(w, r)
+	rt.HandleFunc("GET", "/data/phone/all", controller.GetBotList)
 	//akses data helpdesk layanan user
-	case method == "GET" && path == "/data/user/helpdesk/all":
-		controller.GetHelpdeskAll(w, r)
-	case method == "GET" && path == "/data/user/helpdesk/masuk":
-		controller.GetLatestHelpdeskMasuk(w, r)
-	case method == "GET" && path == "/data/user/helpdesk/selesai":
-		controller.GetLatestHelpdeskSelesai(w, r)
+	rt.HandleFunc("GET", "/data/user/helpdesk/all", controller.GetHelpdeskAll, auth(rbac.HelpdeskRead))
+	rt.HandleFunc("GET", "/data/user/helpdesk/masuk", controller.GetLatestHelpdeskMasuk, auth(rbac.HelpdeskRead))
+	rt.HandleFunc("GET", "/data/user/helpdesk/selesai", controller.GetLatestHelpdeskSelesai, auth(rbac.HelpdeskRead))
 	//pamong desa data from api
-	case method == "GET" && path == "/data/lms/user":
-		controller.GetDataUserFromApi(w, r)
+	rt.HandleFunc("GET", "/data/lms/user", controller.GetDataUserFromApi)
 	//simpan testimoni dari pamong desa lms api
-	case method == "POST" && path == "/data/lms/testi":
-		controller.PostTestimoni(w, r)
-		//get random 4 testi
-	case method == "GET" && path == "/data/lms/random/testi":
-		controller.GetRandomTesti4(w, r)
+	rt.HandleFunc("POST", "/data/lms/testi", controller.PostTestimoni)
+	//get random 4 testi
+	rt.HandleFunc("GET", "/data/lms/random/testi", controller.GetRandomTesti4)
 	//mendapatkan data sent item
-	case method == "GET" && at.URLParam(path, "/data/peserta/sent/:id"):
-		controller.GetSentItem(w, r)
+	rt.HandleFunc("GET", "/data/peserta/sent/:id", controller.GetSentItem)
 	//simpan feedback unsubs user
-	case method == "POST" && path == "/data/peserta/unsubscribe":
-		controller.PostUnsubscribe(w, r)
+	rt.HandleFunc("POST", "/data/peserta/unsubscribe", controller.PostUnsubscribe)
 	//generate token linked device
-	case method == "PUT" && path == "/data/user":
-		controller.PutTokenDataUser(w, r)
+	rt.HandleFunc("PUT", "/data/user", controller.PutTokenDataUser)
 	//Menambhakkan data nomor sender untuk broadcast
-	case method == "PUT" && path == "/data/sender":
-		controller.PutNomorBlast(w, r)
+	rt.HandleFunc("PUT", "/data/sender", controller.PutNomorBlast)
 	//mendapatkan data list nomor sender untuk broadcast
-	case method == "GET" && path == "/data/sender":
-		controller.GetDataSenders(w, r)
+	rt.HandleFunc("GET", "/data/sender", controller.GetDataSenders)
 	//mendapatkan data list nomor sender yang kena blokir dari broadcast
-	case method == "GET" && path == "/data/blokir":
-		controller.GetDataSendersTerblokir(w, r)
+	rt.HandleFunc("GET", "/data/blokir", controller.GetDataSendersTerblokir)
 	//mendapatkan data rekap pengiriman wa blast
-	case method == "GET" && path == "/data/rekap":
-		controller.GetRekapBlast(w, r)
+	rt.HandleFunc("GET", "/data/rekap", controller.GetRekapBlast)
 	//mendapatkan data faq
-	case method == "GET" && at.URLParam(path, "/data/faq/:id"):
-		controller.GetFAQ(w, r)
+	rt.HandleFunc("GET", "/data/faq/:id", controller.GetFAQ)
 	//legacy
-	case method == "PUT" && path == "/data/user/task/doing":
-		controller.PutTaskUser(w, r)
-	case method == "GET" && path == "/data/user/task/done":
-		controller.GetTaskDone(w, r)
-	case method == "POST" && path == "/data/user/task/done":
-		controller.PostTaskUser(w, r)
-	case method == "GET" && path == "/data/pushrepo/kemarin":
-		controller.GetYesterdayDistincWAGroup(w, r)
+	rt.HandleFunc("PUT", "/data/user/task/doing", controller.PutTaskUser)
+	rt.HandleFunc("GET", "/data/user/task/done", controller.GetTaskDone)
+	rt.HandleFunc("POST", "/data/user/task/done", controller.PostTaskUser)
+	rt.HandleFunc("GET", "/data/pushrepo/kemarin", controller.GetYesterdayDistincWAGroup)
 
 	//helpdesk
 	//mendapatkan data tiket
-	case method == "GET" && at.URLParam(path, "/data/tiket/closed/:id"):
-		controller.GetClosedTicket(w, r)
+	rt.HandleFunc("GET", "/data/tiket/closed/:id", controller.GetClosedTicket)
 	//simpan feedback tiket user
-	case method == "POST" && path == "/data/tiket/rate":
-		controller.PostMasukanTiket(w, r)
-		// order
-	case method == "POST" && at.URLParam(path, "/data/order/:namalapak"):
-		controller.HandleOrder(w, r)
+	rt.HandleFunc("POST", "/data/tiket/rate", controller.PostMasukanTiket)
+	// order
+	rt.HandleFunc("POST", "/data/order/:namalapak", controller.HandleOrder)
+	rt.HandleFunc("PUT", "/data/order/status", controller.PutStatusOrder, auth(rbac.LapakManage)) //ubah status pesanan oleh owner lapak
 	//user data
-	case method == "GET" && path == "/data/user":
-		controller.GetDataUser(w, r)
+	rt.HandleFunc("GET", "/data/user", controller.GetDataUser)
+	//role user, pemberian role dicatat di audit
+	rt.HandleFunc("GET", "/data/user/role", controller.GetUserRoles, auth(rbac.RoleRead))
+	rt.HandleFunc("PUT", "/data/user/role", controller.PutUserRole, auth(rbac.RoleGrant))
+	rt.HandleFunc("GET", "/data/user/role/audit", controller.GetRoleAudit, auth(rbac.RoleRead))
+	//penggabungan akun duplikat
+	rt.HandleFunc("POST", "/data/user/merge", controller.PostMergeUsers, auth(rbac.UserMerge))
+	rt.HandleFunc("GET", "/data/user/merge", controller.GetUserMerges, auth(rbac.UserMerge))
+	//kunci token login
+	rt.HandleFunc("GET", "/data/tokenkey", controller.GetTokenKeys, auth(rbac.KeyManage))
+	rt.HandleFunc("POST", "/data/tokenkey/rotate", controller.PostRotateTokenKey, auth(rbac.KeyManage))
+	rt.HandleFunc("DELETE", "/data/tokenkey", controller.DeleteTokenKey, auth(rbac.KeyManage))
+	//secret aplikasi
+	rt.HandleFunc("GET", "/data/secrets", controller.GetSecrets, auth(rbac.SecretManage)) //status secret tanpa nilainya
+	rt.HandleFunc("PUT", "/data/secrets", controller.PutSecret, auth(rbac.SecretManage))  //simpan secret terenkripsi di mongo
+	rt.HandleFunc("POST", "/data/secrets/reload", controller.PostReloadSecrets, auth(rbac.SecretManage))
 	//user pendaftaran
-	case method == "POST" && path == "/auth/register/users": //mendapatkan email gmail
-		controller.RegisterGmailAuth(w, r)
-	case method == "POST" && path == "/data/user":
-		controller.PostDataUser(w, r)
-	case method == "POST" && path == "/upload/profpic": //upload gambar profile
-		controller.UploadProfilePictureHandler(w, r)
-	case method == "POST" && path == "/data/user/bio":
-		controller.PostDataBioUser(w, r)
-	/* 	case method == "POST" && at.URLParam(path, "/data/user/wa/:nomorwa"):
-	controller.PostDataUserFromWA(w, r) */
+	rt.HandleFunc("POST", "/auth/register/users", controller.RegisterGmailAuth) //mendapatkan email gmail
+	rt.HandleFunc("POST", "/data/user", controller.PostDataUser)
+	rt.HandleFunc("POST", "/upload/profpic", controller.UploadProfilePictureHandler) //upload gambar profile
+	rt.HandleFunc("POST", "/data/user/bio", controller.PostDataBioUser)
+	// rt.HandleFunc("POST", "/data/user/wa/:nomorwa", controller.PostDataUserFromWA)
 	//data proyek
-	case method == "GET" && path == "/data/proyek":
-		controller.GetDataProject(w, r)
-	case method == "GET" && path == "/data/proyek/approved": //akses untuk manager
-		controller.GetEditorApprovedProject(w, r)
-	case method == "POST" && path == "/data/proyek":
-		controller.PostDataProject(w, r)
-	case method == "PUT" && path == "/data/metadatabuku":
-		controller.PutMetaDataProject(w, r)
-	case method == "PUT" && path == "/data/proyek/publishbuku": //publish buku isbn by manager
-		controller.PutPublishProject(w, r)
-	case method == "PUT" && path == "/data/proyek":
-		controller.PutDataProject(w, r)
-	case method == "DELETE" && path == "/data/proyek":
-		controller.DeleteDataProject(w, r)
-	case method == "GET" && path == "/data/proyek/anggota":
-		controller.GetDataMemberProject(w, r)
-	case method == "GET" && path == "/data/proyek/editor":
-		controller.GetDataEditorProject(w, r)
-	case method == "DELETE" && path == "/data/proyek/anggota":
-		controller.DeleteDataMemberProject(w, r)
-	case method == "POST" && path == "/data/proyek/anggota":
-		controller.PostDataMemberProject(w, r)
-	case method == "POST" && path == "/data/proyek/editor": //set editor oleh owner
-		controller.PostDataEditorProject(w, r)
-	case method == "PUT" && path == "/data/proyek/editor": //set approved oleh editor
-		controller.PUtApprovedEditorProject(w, r)
+	rt.HandleFunc("GET", "/data/proyek", controller.GetDataProject)
+	rt.HandleFunc("GET", "/data/proyek/approved", controller.GetEditorApprovedProject, auth(rbac.ProjectPublish)) //akses untuk manager
+	rt.HandleFunc("POST", "/data/proyek", controller.PostDataProject)
+	rt.HandleFunc("PUT", "/data/metadatabuku", controller.PutMetaDataProject)
+	rt.HandleFunc("PUT", "/data/proyek/publishbuku", controller.PutPublishProject, auth(rbac.ProjectPublish)) //publish buku isbn by manager
+	rt.HandleFunc("PUT", "/data/proyek", controller.PutDataProject)
+	rt.HandleFunc("DELETE", "/data/proyek", controller.DeleteDataProject)
+	rt.HandleFunc("GET", "/data/proyek/anggota", controller.GetDataMemberProject)
+	rt.HandleFunc("GET", "/data/proyek/editor", controller.GetDataEditorProject)
+	rt.HandleFunc("DELETE", "/data/proyek/anggota", controller.DeleteDataMemberProject)
+	rt.HandleFunc("POST", "/data/proyek/anggota", controller.PostDataMemberProject)
+	rt.HandleFunc("POST", "/data/proyek/editor", controller.PostDataEditorProject)                              //set editor oleh owner
+	rt.HandleFunc("PUT", "/data/proyek/editor", controller.PUtApprovedEditorProject, auth(rbac.ProjectApprove)) //set approved oleh editor
 	//upload cover,draft,pdf,sampul buku project
-	case method == "POST" && at.URLParam(path, "/upload/coverbuku/:projectid"):
-		controller.UploadCoverBukuWithParamFileHandler(w, r)
-	case method == "POST" && at.URLParam(path, "/upload/draftbuku/:projectid"):
-		controller.UploadDraftBukuWithParamFileHandler(w, r)
-	case method == "POST" && at.URLParam(path, "/upload/draftpdfbuku/:projectid"):
-		controller.UploadDraftBukuPDFWithParamFileHandler(w, r)
-	case method == "POST" && at.URLParam(path, "/upload/sampulpdfbuku/:projectid"):
-		controller.UploadSampulBukuPDFWithParamFileHandler(w, r)
-	case method == "POST" && at.URLParam(path, "/upload/spk/:projectid"):
-		controller.UploadSPKPDFWithParamFileHandler(w, r)
-	case method == "POST" && at.URLParam(path, "/upload/spi/:projectid"):
-		controller.UploadSPIPDFWithParamFileHandler(w, r)
-	case method == "GET" && at.URLParam(path, "/download/draft/:path"): //downoad file draft
-		controller.AksesFileRepoDraft(w, r)
-	case method == "POST" && path == "/data/proyek/katalog": //post blog katalog
-		controller.PostKatalogBuku(w, r)
-	case method == "GET" && at.URLParam(path, "/download/dokped/spk/:namaproject"): //base64 namaproject
-		controller.GetFileDraftSPK(w, r)
-	case method == "GET" && at.URLParam(path, "/download/dokped/spkt/:namaproject"): //base64 namaproject
-		controller.GetFileDraftSPKT(w, r)
-	case method == "GET" && at.URLParam(path, "/download/dokped/spi/:path"): //base64 path sampul
-		controller.GetFileDraftSPI(w, r)
-
-	case method == "POST" && path == "/data/proyek/menu":
-		controller.PostDataMenuProject(w, r)
-	case method == "POST" && path == "/approvebimbingan":
-		controller.ApproveBimbinganbyPoin(w, r)
-	case method == "DELETE" && path == "/data/proyek/menu":
-		controller.DeleteDataMenuProject(w, r)
-	case method == "POST" && path == "/notif/ux/postlaporan":
-		controller.PostLaporan(w, r)
-	case method == "POST" && path == "/notif/ux/postfeedback":
-		controller.PostFeedback(w, r)
-
-	case method == "POST" && path == "/notif/ux/postmeeting":
-		controller.PostMeeting(w, r)
-	case method == "POST" && at.URLParam(path, "/notif/ux/postpresensi/:id"):
-		controller.PostPresensi(w, r)
-	case method == "POST" && at.URLParam(path, "/notif/ux/posttasklists/:id"):
-		controller.PostTaskList(w, r)
-	case method == "POST" && at.URLParam(path, "/webhook/nomor/:nomorwa"):
-		controller.PostInboxNomor(w, r)
+	rt.HandleFunc("POST", "/upload/coverbuku/:projectid", controller.UploadCoverBukuWithParamFileHandler, auth(rbac.ProjectWrite))
+	rt.HandleFunc("POST", "/upload/draftbuku/:projectid", controller.UploadDraftBukuWithParamFileHandler, auth(rbac.ProjectWrite))
+	rt.HandleFunc("POST", "/upload/draftpdfbuku/:projectid", controller.UploadDraftBukuPDFWithParamFileHandler, auth(rbac.ProjectWrite))
+	rt.HandleFunc("POST", "/upload/sampulpdfbuku/:projectid", controller.UploadSampulBukuPDFWithParamFileHandler, auth(rbac.ProjectWrite))
+	rt.HandleFunc("POST", "/upload/spk/:projectid", controller.UploadSPKPDFWithParamFileHandler, auth(rbac.ProjectWrite))
+	rt.HandleFunc("POST", "/upload/spi/:projectid", controller.UploadSPIPDFWithParamFileHandler, auth(rbac.ProjectWrite))
+	rt.HandleFunc("GET", "/download/draft/:path", controller.AksesFileRepoDraft, auth(rbac.ProjectDraft))           //downoad file draft
+	rt.HandleFunc("POST", "/data/proyek/katalog", controller.PostKatalogBuku, auth(rbac.ProjectWrite))              //post blog katalog
+	rt.HandleFunc("GET", "/download/dokped/spk/:namaproject", controller.GetFileDraftSPK, auth(rbac.ProjectRead))   //base64 namaproject
+	rt.HandleFunc("GET", "/download/dokped/spkt/:namaproject", controller.GetFileDraftSPKT, auth(rbac.ProjectRead)) //base64 namaproject
+	rt.HandleFunc("GET", "/download/dokped/spi/:path", controller.GetFileDraftSPI, auth(rbac.ProjectRead))          //base64 path sampul
+
+	rt.HandleFunc("POST", "/data/proyek/menu", controller.PostDataMenuProject)
+	rt.HandleFunc("POST", "/approvebimbingan", controller.ApproveBimbinganbyPoin)
+	rt.HandleFunc("DELETE", "/data/proyek/menu", controller.DeleteDataMenuProject)
+	rt.HandleFunc("POST", "/notif/ux/postlaporan", controller.PostLaporan)
+	rt.HandleFunc("POST", "/notif/ux/postfeedback", controller.PostFeedback)
+
+	rt.HandleFunc("POST", "/notif/ux/postmeeting", controller.PostMeeting)
+	rt.HandleFunc("POST", "/notif/ux/postpresensi/:id", controller.PostPresensi)
+	rt.HandleFunc("POST", "/notif/ux/posttasklists/:id", controller.PostTaskList)
 	// LMS
-	case method == "GET" && path == "/lms/refresh/cookie":
-		controller.RefreshLMSCookie(w, r)
-	case method == "GET" && path == "/lms/count/user":
-		controller.GetCountDocUser(w, r)
+	rt.HandleFunc("GET", "/lms/refresh/cookie", controller.RefreshLMSCookie)
+	rt.HandleFunc("GET", "/lms/count/user", controller.GetCountDocUser)
 	// Google Auth
-	case method == "POST" && path == "/auth/users":
-		controller.Auth(w, r)
-	case method == "POST" && path == "/auth/login":
-		controller.GeneratePasswordHandler(w, r)
-	case method == "POST" && path == "/auth/regis":
-		controller.RegisterAkun(w, r)
-	case method == "POST" && path == "/auth/verify":
-		controller.VerifyPasswordHandler(w, r)
-	case method == "POST" && path == "/auth/resend":
-		controller.ResendPasswordHandler(w, r)
+	rt.HandleFunc("POST", "/auth/users", controller.Auth)
+	rt.HandleFunc("POST", "/auth/login", controller.GeneratePasswordHandler)
+	rt.HandleFunc("POST", "/auth/regis", controller.RegisterAkun)
+	rt.HandleFunc("POST", "/auth/verify", controller.VerifyPasswordHandler)
+	rt.HandleFunc("POST", "/auth/resend", controller.ResendPasswordHandler)
 
 	// auth form
-	case method == "POST" && path == "/login/form":
-		controller.LoginAkunForm(w, r)
+	rt.HandleFunc("POST", "/login/form", controller.LoginAkunForm)
+	rt.HandleFunc("POST", "/auth/email/verify", controller.PostVerifyEmail)
+	rt.HandleFunc("POST", "/auth/email/resend", controller.PostResendEmailVerification) //kirim ulang token verifikasi email
+	rt.HandleFunc("POST", "/auth/password/forgot", controller.PostForgotPassword)
+	rt.HandleFunc("POST", "/auth/password/reset", controller.PostResetPassword)
+	// sesi login
+	rt.HandleFunc("POST", "/auth/refresh", controller.PostRefreshToken)
+	rt.HandleFunc("POST", "/auth/logout", controller.PostLogout)
+	rt.HandleFunc("POST", "/auth/logout/all", controller.PostLogoutAll) //logout semua perangkat
+	rt.HandleFunc("GET", "/auth/sessions", controller.GetSessions)
+	rt.HandleFunc("POST", "/auth/revoke/user", controller.PostRevokeUserSessions, auth(rbac.SessionRevoke)) //cabut semua sesi user lain
+	// identitas login yang ditautkan ke akun
+	rt.HandleFunc("GET", "/auth/identity", controller.GetIdentities)
+	rt.HandleFunc("POST", "/auth/identity", controller.PostLinkIdentity)
+	rt.HandleFunc("POST", "/auth/identity/verify", controller.PostVerifyIdentity) //kode WhatsApp atau bio GitHub
+	rt.HandleFunc("DELETE", "/auth/identity", controller.DeleteIdentity)
+	// TOTP untuk akun manager dan editor
+	rt.HandleFunc("POST", "/auth/totp/enroll", controller.PostTOTPEnroll)
+	rt.HandleFunc("POST", "/auth/totp/confirm", controller.PostTOTPConfirm)
+	rt.HandleFunc("DELETE", "/auth/totp", controller.DeleteTOTP)
+	rt.HandleFunc("POST", "/auth/totp/recovery", controller.PostTOTPRecoveryCodes) //buat ulang kode pemulihan
+	rt.HandleFunc("POST", "/auth/totp/verify", controller.PostTOTPVerify)          //tukar mfa_token dengan token login
+
+	rt.HandleFunc("GET", "/address", controller.GetAddresses) //buku alamat user
+	rt.HandleFunc("POST", "/address", controller.PostAddress)
+	rt.HandleFunc("PUT", "/address/:id", controller.PutAddress)
+	rt.HandleFunc("DELETE", "/address/:id", controller.DeleteAddress)
+	rt.HandleFunc("POST", "/voucher", controller.PostVoucher, auth(rbac.LapakManage)) //owner lapak membuat voucher
+	rt.HandleFunc("GET", "/voucher/lapak/:namalapak", controller.GetVoucherLapak, auth(rbac.LapakManage))
+	rt.HandleFunc("PUT", "/voucher/:id", controller.PutVoucher, auth(rbac.LapakManage))        //owner lapak mengubah aturan voucher
+	rt.HandleFunc("DELETE", "/voucher/:id", controller.DeleteVoucher, auth(rbac.LapakManage))  //owner lapak menonaktifkan voucher
+	rt.HandleFunc("POST", "/voucher/apply", controller.PostApplyVoucher)                       //rincian potongan voucher untuk cart atau checkout
+	rt.HandleFunc("PUT", "/shipping/rate", controller.PutShippingRate, auth(rbac.LapakManage)) //owner lapak mengatur tarif ongkir
+	rt.HandleFunc("GET", "/shipping/rate/:namalapak", controller.GetShippingRate)
+	rt.HandleFunc("POST", "/shipping/quote", controller.PostShippingQuote)                                   //hitung ongkir ke alamat user
+	rt.HandleFunc("POST", "/checkout/payment/:checkoutid", controller.PostCheckoutPayment)                   //buat tagihan payment gateway
+	rt.HandleFunc("POST", "/payment/callback/:provider", controller.PostPaymentCallback)                     //callback payment gateway
+	rt.HandleFunc("GET", "/lapak/orders/:namalapak", controller.GetLapakOrders, auth(rbac.LapakReport))      //antrian pesanan lapak
+	rt.HandleFunc("GET", "/lapak/summary/:namalapak", controller.GetLapakSummary, auth(rbac.LapakReport))    //ringkasan penjualan lapak
+	rt.HandleFunc("POST", "/checkout/cart", controller.CheckoutCart)                                         //checkout isi cart milik user
+	rt.HandleFunc("GET", "/checkout/release", controller.ReleaseExpiredCheckout)                             //cron lepas stok checkout kedaluwarsa
+	rt.HandleFunc("POST", "/checkout/konfirmasi/:checkoutid", controller.PostKonfirmasiPembayaran)           //upload bukti bayar oleh pembeli
+	rt.HandleFunc("PUT", "/checkout/konfirmasi", controller.PutKonfirmasiPembayaran, auth(rbac.LapakManage)) //approve atau tolak bukti bayar oleh owner lapak
+	rt.HandleFunc("GET", "/checkout/receipt/:checkoutid", controller.GetReceipt, auth(rbac.CheckoutRead))    //download kwitansi pdf
 
-	// checkout
-	case method == "POST" && path == "/checkout/product":
-		controller.Createcheckout(w, r)
+	//status pengiriman checkout
+	rt.HandleFunc("PUT", "/checkout/shipped/:checkoutid", controller.PutCheckoutShipped, auth(rbac.LapakManage)) //owner lapak menandai pesanan dikirim
+	rt.HandleFunc("PUT", "/checkout/done/:checkoutid", controller.PutCheckoutDone)                               //pembeli menandai pesanan diterima
 
 	//GEO
 	//definisiin endpoint
 	// Roads
-	case method == "POST" && path == "/geo/roads":
-		controller.GetRoads(w, r)
+	rt.HandleFunc("POST", "/geo/roads", controller.GetRoads)
 	// Region
-	case method == "POST" && path == "/geo/region":
-		controller.GetRegion(w, r)
-
-	// Google Auth
-	default:
-		controller.NotFound(w, r)
-	}
+	rt.HandleFunc("POST", "/geo/region", controller.GetRegion)
 }